@@ -0,0 +1,896 @@
+// api
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/morpheusxaut/lootsheeter/models"
+)
+
+type apiContextKey int
+
+const (
+	apiPlayerContextKey apiContextKey = iota
+)
+
+const (
+	apiTokenPrefix = "ls_"
+
+	// Last use is only written once per interval so authenticated reads do not each cause a write
+	apiTokenLastUsedInterval = time.Minute
+)
+
+func GenerateAPIToken() (string, error) {
+	token := make([]byte, 32)
+
+	_, err := rand.Read(token)
+	if err != nil {
+		return "", err
+	}
+
+	return apiTokenPrefix + hex.EncodeToString(token), nil
+}
+
+func HashAPIToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(hash[:])
+}
+
+func APIPlayer(r *http.Request) *models.Player {
+	if r == nil {
+		return nil
+	}
+
+	player, ok := r.Context().Value(apiPlayerContextKey).(*models.Player)
+	if !ok {
+		return nil
+	}
+
+	return player
+}
+
+func APIAuthenticated(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authorization := r.Header.Get("Authorization")
+		if len(authorization) < 7 || !strings.EqualFold(authorization[:7], "bearer ") {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			SendAPIError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Missing API token")
+			return
+		}
+
+		token, err := database.LoadAPITokenFromHash(HashAPIToken(strings.TrimSpace(authorization[7:])))
+		if err == sql.ErrNoRows {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			SendAPIError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Invalid API token")
+			return
+		} else if err != nil {
+			logger.Errorf("Failed to load API token in APIAuthenticated: [%v]", err)
+
+			SendAPIError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to verify API token")
+			return
+		}
+
+		player, err := database.LoadPlayer(token.PlayerID)
+		if err != nil {
+			logger.Errorf("Failed to load player #%d for API token #%d in APIAuthenticated: [%v]", token.PlayerID, token.ID, err)
+
+			SendAPIError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Invalid API token")
+			return
+		}
+
+		if !player.Active {
+			logger.Warnf("Received API request for departed player #%d in APIAuthenticated...", player.ID)
+
+			SendAPIError(w, http.StatusForbidden, ErrorCodeForbidden, "Player is no longer a member of the corporation")
+			return
+		}
+
+		now := time.Now().UTC()
+
+		if now.Sub(token.LastUsed) >= apiTokenLastUsedInterval {
+			token.LastUsed = now
+
+			_, err = database.SaveAPIToken(token)
+			if err != nil {
+				logger.Warnf("Failed to update last use of API token #%d in APIAuthenticated: [%v]", token.ID, err)
+			}
+		}
+
+		handler(w, r.WithContext(context.WithValue(r.Context(), apiPlayerContextKey, player)))
+	}
+}
+
+func SendAPIResponse(w http.ResponseWriter, status int, data interface{}) {
+	SendJSONResponseWithStatus(w, status, map[string]interface{}{"data": data})
+}
+
+func SendAPIError(w http.ResponseWriter, status int, code ErrorCode, message string) {
+	SendJSONResponseWithStatus(w, status, map[string]interface{}{"error": ErrorBody{Code: code, Message: message}})
+}
+
+func decodeAPIRequest(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(v)
+	if err != nil {
+		return fmt.Errorf("Invalid request body: [%v]", err)
+	}
+
+	return nil
+}
+
+func apiLoadFleet(w http.ResponseWriter, r *http.Request) (*models.Fleet, bool) {
+	fleetID, err := strconv.ParseInt(mux.Vars(r)["fleetid"], 10, 64)
+	if err != nil {
+		SendAPIError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Invalid fleet ID")
+		return nil, false
+	}
+
+	fleet, err := database.LoadFleet(fleetID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			logger.Errorf("Failed to load fleet #%d in API: [%v]", fleetID, err)
+		}
+
+		status, code := ErrorStatus(err)
+		SendAPIError(w, status, code, "Failed to load fleet")
+		return nil, false
+	}
+
+	if fleet.Corporation.ID != APIPlayer(r).Corp.ID {
+		SendAPIError(w, http.StatusNotFound, ErrorCodeNotFound, "Fleet not found")
+		return nil, false
+	}
+
+	return fleet, true
+}
+
+func apiLoadReport(w http.ResponseWriter, r *http.Request) (*models.Report, bool) {
+	reportID, err := strconv.ParseInt(mux.Vars(r)["reportid"], 10, 64)
+	if err != nil {
+		SendAPIError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Invalid report ID")
+		return nil, false
+	}
+
+	report, err := database.LoadReport(reportID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			logger.Errorf("Failed to load report #%d in API: [%v]", reportID, err)
+		}
+
+		status, code := ErrorStatus(err)
+		SendAPIError(w, status, code, "Failed to load report")
+		return nil, false
+	}
+
+	if report.Corporation.ID != APIPlayer(r).Corp.ID {
+		SendAPIError(w, http.StatusNotFound, ErrorCodeNotFound, "Report not found")
+		return nil, false
+	}
+
+	return report, true
+}
+
+func APIMeGetHandler(w http.ResponseWriter, r *http.Request) {
+	SendAPIResponse(w, http.StatusOK, NewPlayerResponse(APIPlayer(r)))
+}
+
+func APIFleetsGetHandler(w http.ResponseWriter, r *http.Request) {
+	fleets, err := database.LoadAllFleets(APIPlayer(r).Corp.ID)
+	if err != nil {
+		logger.Errorf("Failed to load all fleets in APIFleetsGetHandler: [%v]", err)
+
+		SendAPIError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to load fleets")
+		return
+	}
+
+	showAll, _ := strconv.ParseBool(r.URL.Query().Get("all"))
+
+	result := make([]*models.Fleet, 0)
+
+	for _, fleet := range fleets {
+		if showAll || !fleet.IsFleetFinished() {
+			result = append(result, fleet)
+		}
+	}
+
+	SendAPIResponse(w, http.StatusOK, NewFleetResponses(result))
+}
+
+func APIFleetGetHandler(w http.ResponseWriter, r *http.Request) {
+	fleet, ok := apiLoadFleet(w, r)
+	if !ok {
+		return
+	}
+
+	SendAPIResponse(w, http.StatusOK, NewFleetResponse(fleet))
+}
+
+func APIFleetMembersGetHandler(w http.ResponseWriter, r *http.Request) {
+	fleet, ok := apiLoadFleet(w, r)
+	if !ok {
+		return
+	}
+
+	SendAPIResponse(w, http.StatusOK, NewFleetMemberResponses(fleet))
+}
+
+type APIFleetMemberRequest struct {
+	Player          string                    `json:"player"`
+	Role            *models.FleetRole         `json:"role"`
+	Ship            *string                   `json:"ship"`
+	SiteModifier    *int                      `json:"siteModifier"`
+	PaymentModifier *float64                  `json:"paymentModifier"`
+	PayoutComplete  *bool                     `json:"payoutComplete"`
+	Present         *bool                     `json:"present"`
+	Intervals       *[]APIFleetMemberInterval `json:"intervals"`
+}
+
+type APIFleetMemberInterval struct {
+	Join  time.Time  `json:"join"`
+	Leave *time.Time `json:"leave"`
+}
+
+func APIFleetMembersPostHandler(w http.ResponseWriter, r *http.Request) {
+	var request APIFleetMemberRequest
+
+	err := decodeAPIRequest(r, &request)
+	if err != nil {
+		SendAPIError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	fleetID, _ := strconv.ParseInt(mux.Vars(r)["fleetid"], 10, 64)
+
+	fleetLocks.Lock(fleetID)
+	defer fleetLocks.Unlock(fleetID)
+
+	fleet, ok := apiLoadFleet(w, r)
+	if !ok {
+		return
+	}
+
+	if !IsFleetCommander(r, fleet) && !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
+		SendAPIError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask or fleet role")
+		return
+	}
+
+	if fleet.IsFleetFinished() {
+		SendAPIError(w, http.StatusConflict, ErrorCodeConflict, "Cannot add members to a finished fleet")
+		return
+	}
+
+	if request.Role == nil || !fleet.Corporation.HasFleetRole(*request.Role) {
+		SendAPIError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Invalid fleet role")
+		return
+	}
+
+	if *request.Role == models.FleetRoleFleetCommander && len(fleet.FleetCommanders()) > 0 {
+		SendAPIError(w, http.StatusConflict, ErrorCodeConflict, "Cannot add two fleet commanders to the same fleet!")
+		return
+	}
+
+	player, err := database.LoadPlayerFromName(request.Player)
+	if err != nil || player.Corp == nil || player.Corp.ID != fleet.Corporation.ID {
+		SendAPIError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, fmt.Sprintf("Unknown player %q", request.Player))
+		return
+	}
+
+	if fleet.HasMember(player.Name) {
+		SendAPIError(w, http.StatusConflict, ErrorCodeConflict, fmt.Sprintf("Player %q is already a member of this fleet", player.Name))
+		return
+	}
+
+	ship := ""
+	if request.Ship != nil {
+		ship = *request.Ship
+	}
+
+	before := NewFleetMemberResponses(fleet)
+
+	member := models.NewFleetMember(-1, fleet.ID, player, *request.Role, ship, 0, 1, 0, false, -1, time.Now(), time.Time{})
+
+	fleet.AddMember(member)
+
+	fleet, err = database.SaveFleet(fleet)
+	if err != nil {
+		logger.Errorf("Failed to save fleet in APIFleetMembersPostHandler: [%v]", err)
+
+		SendAPIError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to save fleet")
+		return
+	}
+
+	RecordAudit(r, fleet.ID, -1, "addmembers", before, NewFleetMemberResponses(fleet))
+
+	SendAPIResponse(w, http.StatusCreated, NewFleetMemberResponse(fleet.Members[player.Name], fleet.Corporation))
+}
+
+func APIFleetMemberPutHandler(w http.ResponseWriter, r *http.Request) {
+	var request APIFleetMemberRequest
+
+	err := decodeAPIRequest(r, &request)
+	if err != nil {
+		SendAPIError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	memberID, err := strconv.ParseInt(mux.Vars(r)["memberid"], 10, 64)
+	if err != nil {
+		SendAPIError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Invalid member ID")
+		return
+	}
+
+	fleetID, _ := strconv.ParseInt(mux.Vars(r)["fleetid"], 10, 64)
+
+	fleetLocks.Lock(fleetID)
+	defer fleetLocks.Unlock(fleetID)
+
+	fleet, ok := apiLoadFleet(w, r)
+	if !ok {
+		return
+	}
+
+	if !IsFleetCommander(r, fleet) && !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
+		SendAPIError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask or fleet role")
+		return
+	}
+
+	member, err := database.LoadFleetMember(fleet.ID, memberID)
+	if err != nil {
+		status, code := ErrorStatus(err)
+		SendAPIError(w, status, code, "Failed to load fleet member")
+		return
+	}
+
+	before := NewFleetMemberResponse(member, fleet.Corporation)
+
+	if request.Role != nil {
+		if !fleet.Corporation.HasFleetRole(*request.Role) {
+			SendAPIError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Invalid fleet role")
+			return
+		}
+
+		if member.Role == models.FleetRoleFleetCommander && *request.Role != models.FleetRoleFleetCommander && len(fleet.FleetCommanders()) <= 1 {
+			SendAPIError(w, http.StatusConflict, ErrorCodeConflict, "Cannot remove the fleet commander without replacement from the member list!")
+			return
+		}
+
+		member.Role = *request.Role
+	}
+
+	if request.Ship != nil {
+		member.Ship = *request.Ship
+	}
+
+	if request.SiteModifier != nil {
+		if *request.SiteModifier > 0 {
+			SendAPIError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Site modifier cannot be positive")
+			return
+		}
+
+		member.SiteModifier = *request.SiteModifier
+	}
+
+	if request.PaymentModifier != nil {
+		if *request.PaymentModifier < 0 {
+			SendAPIError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Payment modifier cannot be negative")
+			return
+		}
+
+		member.PaymentModifier = *request.PaymentModifier
+	}
+
+	if request.PayoutComplete != nil {
+		member.PayoutComplete = *request.PayoutComplete
+	}
+
+	if request.Intervals != nil {
+		var intervals []*models.FleetMemberInterval
+
+		for _, interval := range *request.Intervals {
+			var leave time.Time
+			if interval.Leave != nil {
+				leave = *interval.Leave
+			}
+
+			intervals = append(intervals, models.NewFleetMemberInterval(-1, interval.Join, leave))
+		}
+
+		err = member.SetIntervals(intervals)
+		if err != nil {
+			SendAPIError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+			return
+		}
+	}
+
+	if request.Present != nil {
+		if *request.Present {
+			member.Join(time.Now())
+		} else {
+			member.Leave(time.Now())
+		}
+	}
+
+	fleet.UpdateMember(member)
+	fleet.AllPayoutsComplete()
+
+	fleet, err = database.SaveFleet(fleet)
+	if err != nil {
+		logger.Errorf("Failed to save fleet in APIFleetMemberPutHandler: [%v]", err)
+
+		SendAPIError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to save fleet")
+		return
+	}
+
+	RecordAudit(r, fleet.ID, -1, "editmember", before, NewFleetMemberResponse(fleet.Members[member.Name], fleet.Corporation))
+
+	SendAPIResponse(w, http.StatusOK, NewFleetMemberResponse(fleet.Members[member.Name], fleet.Corporation))
+}
+
+func APIFleetMemberDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	memberID, err := strconv.ParseInt(mux.Vars(r)["memberid"], 10, 64)
+	if err != nil {
+		SendAPIError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Invalid member ID")
+		return
+	}
+
+	fleetID, _ := strconv.ParseInt(mux.Vars(r)["fleetid"], 10, 64)
+
+	fleetLocks.Lock(fleetID)
+	defer fleetLocks.Unlock(fleetID)
+
+	fleet, ok := apiLoadFleet(w, r)
+	if !ok {
+		return
+	}
+
+	if !IsFleetCommander(r, fleet) && !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
+		SendAPIError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask or fleet role")
+		return
+	}
+
+	member, err := database.LoadFleetMember(fleet.ID, memberID)
+	if err != nil {
+		status, code := ErrorStatus(err)
+		SendAPIError(w, status, code, "Failed to load fleet member")
+		return
+	}
+
+	if member.Role == models.FleetRoleFleetCommander && len(fleet.FleetCommanders()) <= 1 {
+		SendAPIError(w, http.StatusConflict, ErrorCodeConflict, "Cannot remove the fleet commander from the member list!")
+		return
+	}
+
+	err = database.DeleteFleetMember(fleet.ID, memberID)
+	if err != nil {
+		logger.Errorf("Failed to delete fleet member in APIFleetMemberDeleteHandler: [%v]", err)
+
+		SendAPIError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to delete fleet member")
+		return
+	}
+
+	RecordAudit(r, fleet.ID, -1, "removemember", NewFleetMemberResponse(member, fleet.Corporation), nil)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func APIFleetLootPastesGetHandler(w http.ResponseWriter, r *http.Request) {
+	fleet, ok := apiLoadFleet(w, r)
+	if !ok {
+		return
+	}
+
+	lootPastes, err := database.LoadAllLootPastes(fleet.ID)
+	if err != nil {
+		logger.Errorf("Failed to load loot pastes in APIFleetLootPastesGetHandler: [%v]", err)
+
+		SendAPIError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to load loot pastes")
+		return
+	}
+
+	SendAPIResponse(w, http.StatusOK, NewLootPasteResponses(lootPastes))
+}
+
+type APILootPasteRequest struct {
+	Type      string `json:"type"`
+	Paste     string `json:"paste"`
+	PriceMode string `json:"priceMode"`
+	SiteID    int64  `json:"siteID"`
+}
+
+func APIFleetLootPastesPostHandler(w http.ResponseWriter, r *http.Request) {
+	var request APILootPasteRequest
+
+	err := decodeAPIRequest(r, &request)
+	if err != nil {
+		SendAPIError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	var pasteType models.LootPasteType
+
+	switch strings.ToLower(request.Type) {
+	case "profit":
+		pasteType = models.LootPasteTypeProfit
+	case "loss":
+		pasteType = models.LootPasteTypeLoss
+	default:
+		SendAPIError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Invalid loot paste type, expected profit or loss")
+		return
+	}
+
+	if len(strings.TrimSpace(request.Paste)) == 0 {
+		SendAPIError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Loot paste cannot be empty")
+		return
+	}
+
+	priceMode, err := ParseRequestPriceMode(request.PriceMode)
+	if err != nil {
+		SendAPIError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	fleetID, _ := strconv.ParseInt(mux.Vars(r)["fleetid"], 10, 64)
+
+	fleetLocks.Lock(fleetID)
+	defer fleetLocks.Unlock(fleetID)
+
+	fleet, ok := apiLoadFleet(w, r)
+	if !ok {
+		return
+	}
+
+	if !IsFleetCommander(r, fleet) && !HasFleetRole(r, fleet, 8) && !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
+		SendAPIError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask or fleet role")
+		return
+	}
+
+	if fleet.IsFleetFinished() {
+		SendAPIError(w, http.StatusConflict, ErrorCodeConflict, "Cannot add loot pastes to a finished fleet")
+		return
+	}
+
+	if request.SiteID > 0 && fleet.Site(request.SiteID) == nil {
+		SendAPIError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Site does not belong to this fleet")
+		return
+	}
+
+	lootPaste := models.NewLootPaste(-1, fleet.ID, APIPlayer(r).ID, request.Paste, 0, pasteType)
+
+	if request.SiteID > 0 {
+		lootPaste.SiteID = request.SiteID
+	}
+
+	err = AppraiseLootPaste(lootPaste, priceMode)
+	if err != nil {
+		SendAPIError(w, http.StatusUnprocessableEntity, ErrorCodeUnprocessable, err.Error())
+		return
+	}
+
+	lootPaste, err = database.SaveLootPaste(lootPaste)
+	if err != nil {
+		logger.Errorf("Failed to save loot paste in APIFleetLootPastesPostHandler: [%v]", err)
+
+		SendAPIError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to save loot paste")
+		return
+	}
+
+	RecordAudit(r, fleet.ID, -1, "add"+strings.ToLower(request.Type), nil, NewLootPasteResponse(lootPaste))
+
+	SendAPIResponse(w, http.StatusCreated, NewLootPasteResponse(lootPaste))
+}
+
+func APIFleetSitesGetHandler(w http.ResponseWriter, r *http.Request) {
+	fleet, ok := apiLoadFleet(w, r)
+	if !ok {
+		return
+	}
+
+	SendAPIResponse(w, http.StatusOK, NewFleetSiteResponses(fleet.Sites))
+}
+
+type APIFleetSiteRequest struct {
+	Name       *string    `json:"name"`
+	Finished   *time.Time `json:"finished"`
+	Escalation *bool      `json:"escalation"`
+	Members    *[]int64   `json:"members"`
+	Voided     *bool      `json:"voided"`
+}
+
+func APIFleetSitesPostHandler(w http.ResponseWriter, r *http.Request) {
+	var request APIFleetSiteRequest
+
+	err := decodeAPIRequest(r, &request)
+	if err != nil {
+		SendAPIError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	fleetID, _ := strconv.ParseInt(mux.Vars(r)["fleetid"], 10, 64)
+
+	fleetLocks.Lock(fleetID)
+	defer fleetLocks.Unlock(fleetID)
+
+	fleet, ok := apiLoadFleet(w, r)
+	if !ok {
+		return
+	}
+
+	if !IsFleetCommander(r, fleet) && !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
+		SendAPIError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask or fleet role")
+		return
+	}
+
+	if fleet.IsFleetFinished() {
+		SendAPIError(w, http.StatusConflict, ErrorCodeConflict, "Cannot log sites for a finished fleet")
+		return
+	}
+
+	finished := time.Now()
+	if request.Finished != nil {
+		finished = *request.Finished
+	}
+
+	site := models.NewFleetSite(-1, fleet.ID, "", finished, false, APIPlayer(r).ID, fleet.MembersPresentAt(finished))
+
+	if !applyAPIFleetSiteRequest(w, fleet, site, &request) {
+		return
+	}
+
+	site, err = database.SaveFleetSite(site)
+	if err != nil {
+		logger.Errorf("Failed to save fleet site in APIFleetSitesPostHandler: [%v]", err)
+
+		SendAPIError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to save fleet site")
+		return
+	}
+
+	RecordAudit(r, fleet.ID, -1, "ticksitesfinished", nil, NewFleetSiteResponse(site))
+
+	SendAPIResponse(w, http.StatusCreated, NewFleetSiteResponse(site))
+}
+
+func APIFleetSitePutHandler(w http.ResponseWriter, r *http.Request) {
+	var request APIFleetSiteRequest
+
+	err := decodeAPIRequest(r, &request)
+	if err != nil {
+		SendAPIError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	siteID, err := strconv.ParseInt(mux.Vars(r)["siteid"], 10, 64)
+	if err != nil {
+		SendAPIError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Invalid site ID")
+		return
+	}
+
+	fleetID, _ := strconv.ParseInt(mux.Vars(r)["fleetid"], 10, 64)
+
+	fleetLocks.Lock(fleetID)
+	defer fleetLocks.Unlock(fleetID)
+
+	fleet, ok := apiLoadFleet(w, r)
+	if !ok {
+		return
+	}
+
+	if !IsFleetCommander(r, fleet) && !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
+		SendAPIError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask or fleet role")
+		return
+	}
+
+	if fleet.IsFleetFinished() {
+		SendAPIError(w, http.StatusConflict, ErrorCodeConflict, "Cannot change sites of a finished fleet")
+		return
+	}
+
+	site, err := database.LoadFleetSite(siteID)
+	if err != nil {
+		status, code := ErrorStatus(err)
+		SendAPIError(w, status, code, "Failed to load fleet site")
+		return
+	}
+
+	if site.FleetID != fleet.ID {
+		SendAPIError(w, http.StatusNotFound, ErrorCodeNotFound, "Site does not belong to this fleet")
+		return
+	}
+
+	before := NewFleetSiteResponse(site)
+
+	if request.Finished != nil {
+		site.Finished = *request.Finished
+	}
+
+	if request.Voided != nil {
+		site.Voided = *request.Voided
+	}
+
+	if !applyAPIFleetSiteRequest(w, fleet, site, &request) {
+		return
+	}
+
+	site, err = database.SaveFleetSite(site)
+	if err != nil {
+		logger.Errorf("Failed to save fleet site in APIFleetSitePutHandler: [%v]", err)
+
+		SendAPIError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to save fleet site")
+		return
+	}
+
+	RecordAudit(r, fleet.ID, -1, "editsite", before, NewFleetSiteResponse(site))
+
+	SendAPIResponse(w, http.StatusOK, NewFleetSiteResponse(site))
+}
+
+func applyAPIFleetSiteRequest(w http.ResponseWriter, fleet *models.Fleet, site *models.FleetSite, request *APIFleetSiteRequest) bool {
+	if request.Name != nil {
+		site.Name = strings.TrimSpace(*request.Name)
+	}
+
+	if request.Escalation != nil {
+		site.Escalation = *request.Escalation
+	}
+
+	if request.Members != nil {
+		members, err := ValidateSiteMembers(fleet, *request.Members)
+		if err != nil {
+			SendAPIError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+			return false
+		}
+
+		site.MemberIDs = members
+	}
+
+	return true
+}
+
+func APIFleetPayoutsGetHandler(w http.ResponseWriter, r *http.Request) {
+	fleet, ok := apiLoadFleet(w, r)
+	if !ok {
+		return
+	}
+
+	strategy := fleet.EffectivePayoutStrategy()
+
+	corporationPayout, payouts := fleet.PreviewPayouts(strategy)
+
+	if fleet.IsFleetFinished() {
+		corporationPayout = fleet.CorporationPayout
+
+		for name, member := range fleet.Members {
+			payouts[name] = member.Payout
+		}
+	}
+
+	SendAPIResponse(w, http.StatusOK, &PayoutPreviewResponse{
+		Strategy:          strategy.String(),
+		Final:             fleet.IsFleetFinished(),
+		CorporationPayout: corporationPayout,
+		Payouts:           payouts,
+	})
+}
+
+func APIReportsGetHandler(w http.ResponseWriter, r *http.Request) {
+	reports, err := database.LoadAllReports(APIPlayer(r).Corp.ID)
+	if err != nil {
+		logger.Errorf("Failed to load all reports in APIReportsGetHandler: [%v]", err)
+
+		SendAPIError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to load reports")
+		return
+	}
+
+	showAll, _ := strconv.ParseBool(r.URL.Query().Get("all"))
+
+	result := make([]*models.Report, 0)
+
+	for _, report := range reports {
+		if showAll || !report.PayoutComplete {
+			result = append(result, report)
+		}
+	}
+
+	SendAPIResponse(w, http.StatusOK, NewReportResponses(result))
+}
+
+func APIReportGetHandler(w http.ResponseWriter, r *http.Request) {
+	report, ok := apiLoadReport(w, r)
+	if !ok {
+		return
+	}
+
+	SendAPIResponse(w, http.StatusOK, NewReportResponse(report))
+}
+
+func APIReportPayoutsGetHandler(w http.ResponseWriter, r *http.Request) {
+	report, ok := apiLoadReport(w, r)
+	if !ok {
+		return
+	}
+
+	SendAPIResponse(w, http.StatusOK, NewReportPayoutResponses(report))
+}
+
+type APIReportPayoutRequest struct {
+	PayoutComplete bool `json:"payoutComplete"`
+}
+
+func APIReportPayoutPutHandler(w http.ResponseWriter, r *http.Request) {
+	var request APIReportPayoutRequest
+
+	err := decodeAPIRequest(r, &request)
+	if err != nil {
+		SendAPIError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	payoutID, err := strconv.ParseInt(mux.Vars(r)["payoutid"], 10, 64)
+	if err != nil {
+		SendAPIError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Invalid payout ID")
+		return
+	}
+
+	reportID, _ := strconv.ParseInt(mux.Vars(r)["reportid"], 10, 64)
+
+	reportLocks.Lock(reportID)
+	defer reportLocks.Unlock(reportID)
+
+	report, ok := apiLoadReport(w, r)
+	if !ok {
+		return
+	}
+
+	if !IsReportCreator(r, report) && !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
+		SendAPIError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask")
+		return
+	}
+
+	var reportPayout *models.ReportPayout
+
+	for _, payout := range report.Payouts {
+		if payout.ID == payoutID {
+			reportPayout = payout
+			break
+		}
+	}
+
+	if reportPayout == nil {
+		SendAPIError(w, http.StatusNotFound, ErrorCodeNotFound, "Failed to load report payout")
+		return
+	}
+
+	before := NewReportPayoutResponse(reportPayout)
+
+	reportPayout.PayoutComplete = request.PayoutComplete
+
+	report, err = database.SaveReport(report)
+	if err != nil {
+		logger.Errorf("Failed to save report in APIReportPayoutPutHandler: [%v]", err)
+
+		SendAPIError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to save report")
+		return
+	}
+
+	command := "playerpaid"
+	if !request.PayoutComplete {
+		command = "playerunpaid"
+	}
+
+	RecordAudit(r, -1, report.ID, command, before, NewReportPayoutResponse(report.Payouts[reportPayout.Player.Name]))
+
+	SendAPIResponse(w, http.StatusOK, NewReportPayoutResponse(report.Payouts[reportPayout.Player.Name]))
+}
@@ -0,0 +1,72 @@
+// api_test
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/morpheusxaut/lootsheeter/models"
+)
+
+func TestAPIAuthenticatedLastUsed(t *testing.T) {
+	db := openTestDatabase(t)
+
+	_, players := createTestPlayers(t, db, "Alice")
+
+	raw, err := GenerateAPIToken()
+	if err != nil {
+		t.Fatalf("Failed to generate API token: [%v]", err)
+	}
+
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name     string
+		lastUsed time.Time
+		updated  bool
+	}{
+		{"never used", time.Time{}, true},
+		{"used recently", now.Add(-30 * time.Second), false},
+		{"used before interval", now.Add(-2 * time.Minute), true},
+	}
+
+	token, err := db.SaveAPIToken(models.NewAPIToken(-1, players[0].ID, "Test", HashAPIToken(raw), now.Add(-time.Hour), time.Time{}))
+	if err != nil {
+		t.Fatalf("Failed to save API token: [%v]", err)
+	}
+
+	handler := APIAuthenticated(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	for _, test := range tests {
+		token.LastUsed = test.lastUsed
+
+		token, err = db.SaveAPIToken(token)
+		if err != nil {
+			t.Fatalf("%s: Failed to save API token: [%v]", test.name, err)
+		}
+
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/fleets", nil)
+		r.Header.Set("Authorization", "Bearer "+raw)
+		w := httptest.NewRecorder()
+
+		handler(w, r)
+
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("%s: Expected status %d, got %d: %s", test.name, http.StatusNoContent, w.Code, w.Body.String())
+		}
+
+		loaded, err := db.LoadAPITokenFromHash(HashAPIToken(raw))
+		if err != nil {
+			t.Fatalf("%s: Failed to load API token: [%v]", test.name, err)
+		}
+
+		updated := !loaded.LastUsed.Equal(test.lastUsed)
+		if updated != test.updated {
+			t.Errorf("%s: Expected last use updated to be %v, got %v (%v)", test.name, test.updated, updated, loaded.LastUsed)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+// appraisal
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/morpheusxaut/lootsheeter/models"
+)
+
+type PasteLine struct {
+	Name     string
+	Quantity int64
+}
+
+var (
+	cargoScanRegex      = regexp.MustCompile(`^(\d[\d,.']*)\s+(.+)$`)
+	quantitySuffixRegex = regexp.MustCompile(`^(.+?)\s+x\s?(\d[\d,.']*)$`)
+)
+
+func ParsePaste(raw string) ([]PasteLine, error) {
+	var lines []PasteLine
+
+	index := make(map[string]int)
+
+	for _, row := range strings.Split(strings.Replace(raw, "\r\n", "\n", -1), "\n") {
+		row = strings.TrimSpace(row)
+		if len(row) == 0 {
+			continue
+		}
+
+		name, quantity, err := parsePasteRow(row)
+		if err != nil {
+			return lines, err
+		}
+
+		if quantity <= 0 {
+			continue
+		}
+
+		key := strings.ToLower(name)
+
+		if i, ok := index[key]; ok {
+			lines[i].Quantity += quantity
+			continue
+		}
+
+		index[key] = len(lines)
+		lines = append(lines, PasteLine{Name: name, Quantity: quantity})
+	}
+
+	if len(lines) == 0 {
+		return lines, fmt.Errorf("Paste did not contain any items")
+	}
+
+	return lines, nil
+}
+
+func parsePasteRow(row string) (string, int64, error) {
+	if strings.Contains(row, "\t") {
+		columns := strings.Split(row, "\t")
+
+		name := cleanItemName(columns[0])
+		if len(name) == 0 {
+			return "", 0, fmt.Errorf("Invalid paste row, missing item name: %q", row)
+		}
+
+		if len(columns) < 2 || len(strings.TrimSpace(columns[1])) == 0 {
+			return name, 1, nil
+		}
+
+		quantity, err := parseQuantity(columns[1])
+		if err != nil {
+			return "", 0, fmt.Errorf("Invalid quantity in paste row %q: [%v]", row, err)
+		}
+
+		return name, quantity, nil
+	}
+
+	if match := quantitySuffixRegex.FindStringSubmatch(row); match != nil {
+		quantity, err := parseQuantity(match[2])
+		if err != nil {
+			return "", 0, fmt.Errorf("Invalid quantity in paste row %q: [%v]", row, err)
+		}
+
+		return cleanItemName(match[1]), quantity, nil
+	}
+
+	if match := cargoScanRegex.FindStringSubmatch(row); match != nil {
+		quantity, err := parseQuantity(match[1])
+		if err != nil {
+			return "", 0, fmt.Errorf("Invalid quantity in paste row %q: [%v]", row, err)
+		}
+
+		return cleanItemName(match[2]), quantity, nil
+	}
+
+	return cleanItemName(row), 1, nil
+}
+
+func cleanItemName(name string) string {
+	return strings.TrimSpace(strings.TrimRight(strings.TrimSpace(name), "*"))
+}
+
+func parseQuantity(raw string) (int64, error) {
+	cleaned := strings.NewReplacer(",", "", ".", "", "'", "", " ", "", " ", "").Replace(strings.TrimSpace(raw))
+
+	return strconv.ParseInt(cleaned, 10, 64)
+}
+
+func ParseRequestPriceMode(raw string) (models.PriceMode, error) {
+	if len(raw) == 0 {
+		raw = config.PriceMode
+	}
+
+	return models.ParsePriceMode(raw)
+}
+
+func AppraisePaste(raw string, mode models.PriceMode) (*models.Appraisal, error) {
+	appraisal := models.NewAppraisal(mode)
+
+	lines, err := ParsePaste(raw)
+	if err != nil {
+		return appraisal, err
+	}
+
+	for _, line := range lines {
+		itemType, err := database.LoadItemTypeFromName(line.Name)
+		if err == sql.ErrNoRows {
+			appraisal.AddUnknown(line.Name)
+			continue
+		} else if err != nil {
+			return appraisal, err
+		}
+
+		appraisal.AddItem(itemType, line.Quantity)
+	}
+
+	if len(appraisal.Unknown) > 0 {
+		return appraisal, fmt.Errorf("Failed to appraise paste, no price available for: %s", strings.Join(appraisal.Unknown, ", "))
+	}
+
+	return appraisal, nil
+}
+
+func AppraiseLootPaste(paste *models.LootPaste, mode models.PriceMode) error {
+	if paste.PasteType == models.LootPasteTypeLoss && strings.Contains(strings.ToLower(paste.RawPaste), "zkillboard") {
+		var loss float64
+
+		for _, row := range strings.Split(strings.Replace(paste.RawPaste, "\r\n", "\n", -1), "\n") {
+			row = strings.TrimSpace(row)
+			if len(row) == 0 {
+				continue
+			}
+
+			l, err := GetZKillboardValue(row)
+			if err != nil {
+				return err
+			}
+
+			loss += l
+		}
+
+		paste.SetAppraisal(models.NewAppraisal(mode))
+		paste.Value = loss
+
+		return nil
+	}
+
+	appraisal, err := AppraisePaste(paste.RawPaste, mode)
+	if err != nil {
+		return err
+	}
+
+	paste.SetAppraisal(appraisal)
+
+	return nil
+}
+
+func (db *Database) ImportPrices(r io.Reader) (int, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 6
+	reader.TrimLeadingSpace = true
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	updated := time.Now().UTC()
+	imported := 0
+	row := 0
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+
+		row++
+
+		typeID, err := strconv.ParseInt(record[0], 10, 64)
+		if err != nil {
+			if row == 1 {
+				continue
+			}
+
+			tx.Rollback()
+			return 0, fmt.Errorf("Invalid type ID %q: [%v]", record[0], err)
+		}
+
+		var values [3]float64
+
+		for i, raw := range record[3:] {
+			values[i], err = strconv.ParseFloat(raw, 64)
+			if err != nil {
+				tx.Rollback()
+				return 0, fmt.Errorf("Invalid number %q for type ID %d: [%v]", raw, typeID, err)
+			}
+		}
+
+		itemType := models.NewItemType(typeID, record[1], record[2], values[0], values[1], values[2], updated)
+
+		err = db.saveItemType(tx, itemType)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+
+		imported++
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return imported, nil
+}
+
+func RunPricesCommand(db *Database, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("Missing prices command, expected import <file> or status")
+	}
+
+	current, err := db.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	if current < LatestSchemaVersion() {
+		return fmt.Errorf("Database schema is at version %d, run migrate before managing prices", current)
+	}
+
+	switch args[0] {
+	case "import":
+		if len(args) < 2 {
+			return fmt.Errorf("Missing file for prices import")
+		}
+
+		file, err := os.Open(args[1])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		imported, err := db.ImportPrices(file)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Imported prices for %d item types\n", imported)
+
+		return nil
+	case "status":
+		count, oldest, err := db.ItemPriceStatus()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Item types with prices: %d\n", count)
+		if count > 0 {
+			fmt.Printf("Oldest price update:    %s\n", oldest.Format("2006-01-02 15:04:05"))
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("Unknown prices command %q, expected import <file> or status", args[0])
+	}
+}
@@ -0,0 +1,217 @@
+// audit
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/morpheusxaut/lootsheeter/models"
+)
+
+const (
+	auditLogLimit = 500
+)
+
+func RecordAudit(r *http.Request, fleetID int64, reportID int64, command string, before interface{}, after interface{}) {
+	var playerID int64
+	actor := "unknown"
+
+	player := session.GetPlayerFromRequest(r)
+	if player != nil {
+		playerID = player.ID
+		actor = player.Name
+	}
+
+	saveAuditEntry(database, models.NewAuditEntry(-1, fleetID, reportID, playerID, actor, command, encodeAuditValue(before), encodeAuditValue(after), r.RemoteAddr, time.Now().UTC()))
+}
+
+func RecordSystemAudit(store Store, actor string, fleetID int64, reportID int64, command string, before interface{}, after interface{}) {
+	saveAuditEntry(store, models.NewAuditEntry(-1, fleetID, reportID, -1, actor, command, encodeAuditValue(before), encodeAuditValue(after), "", time.Now().UTC()))
+}
+
+func saveAuditEntry(store Store, entry *models.AuditEntry) {
+	_, err := store.SaveAuditEntry(entry)
+	if err != nil {
+		logger.Errorf("Failed to save audit entry for command %q on fleet #%d and report #%d: [%v]", entry.Command, entry.FleetID, entry.ReportID, err)
+	}
+}
+
+func encodeAuditValue(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		logger.Errorf("Failed to encode audit value: [%v]", err)
+
+		return ""
+	}
+
+	return string(encoded)
+}
+
+func ParseAuditFilter(r *http.Request) *models.AuditFilter {
+	filter := &models.AuditFilter{
+		FleetID:  -1,
+		ReportID: -1,
+		Actor:    strings.TrimSpace(r.FormValue("actor")),
+		Command:  strings.TrimSpace(r.FormValue("command")),
+		Limit:    auditLogLimit,
+	}
+
+	from, err := time.Parse("2006-01-02", r.FormValue("from"))
+	if err == nil {
+		filter.From = from
+	}
+
+	to, err := time.Parse("2006-01-02", r.FormValue("to"))
+	if err == nil {
+		filter.To = to.AddDate(0, 0, 1)
+	}
+
+	return filter
+}
+
+func FleetAuditGetHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	fleetID, err := strconv.ParseInt(vars["fleetid"], 10, 64)
+	if err != nil {
+		logger.Errorf("Failed to parse fleet ID %q in FleetAuditGetHandler: [%v]", vars["fleetid"], err)
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	loggedIn := session.IsLoggedIn(w, r)
+
+	if !loggedIn {
+		session.SetLoginRedirect(w, r, fmt.Sprintf("/fleet/%d/audit", fleetID))
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	data := make(map[string]interface{})
+
+	data["PageTitle"] = fmt.Sprintf("Audit Log Fleet #%d", fleetID)
+	data["PageType"] = 3
+	data["LoggedIn"] = loggedIn
+
+	fleet, err := database.LoadFleet(fleetID)
+	if err != nil {
+		logger.Errorf("Failed to load details for fleet #%d in FleetAuditGetHandler: [%v]", fleetID, err)
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	corporationID := session.GetCorpID(r)
+
+	if fleet.Corporation.ID != corporationID {
+		http.Redirect(w, r, "/fleets", http.StatusSeeOther)
+		return
+	}
+
+	if !IsFleetCommander(r, fleet) && !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
+		logger.Warnf("Received request to FleetAuditGetHandler without proper access...")
+
+		http.Redirect(w, r, fmt.Sprintf("/fleet/%d", fleetID), http.StatusSeeOther)
+		return
+	}
+
+	filter := ParseAuditFilter(r)
+	filter.FleetID = fleet.ID
+
+	entries, err := database.LoadAuditEntries(filter)
+	if err != nil {
+		logger.Errorf("Failed to load audit entries for fleet #%d in FleetAuditGetHandler: [%v]", fleetID, err)
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data["AuditTitle"] = fmt.Sprintf("Fleet #%d - %s", fleet.ID, fleet.Name)
+	data["AuditBackLink"] = fmt.Sprintf("/fleet/%d", fleet.ID)
+	data["AuditLink"] = fmt.Sprintf("/fleet/%d/audit", fleet.ID)
+	data["AuditEntries"] = entries
+	data["AuditFilter"] = r.Form
+
+	err = templates.Funcs(TemplateFunctions(r)).ExecuteTemplate(w, "auditlog", data)
+	if err != nil {
+		logger.Errorf("Failed to execute template in FleetAuditGetHandler: [%v]", err)
+	}
+}
+
+func ReportAuditGetHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	reportID, err := strconv.ParseInt(vars["reportid"], 10, 64)
+	if err != nil {
+		logger.Errorf("Failed to parse report ID %q in ReportAuditGetHandler: [%v]", vars["reportid"], err)
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	loggedIn := session.IsLoggedIn(w, r)
+
+	if !loggedIn {
+		session.SetLoginRedirect(w, r, fmt.Sprintf("/report/%d/audit", reportID))
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	data := make(map[string]interface{})
+
+	data["PageTitle"] = fmt.Sprintf("Audit Log Report #%d", reportID)
+	data["PageType"] = 4
+	data["LoggedIn"] = loggedIn
+
+	report, err := database.LoadReport(reportID)
+	if err != nil {
+		logger.Errorf("Failed to load details for report #%d in ReportAuditGetHandler: [%v]", reportID, err)
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	corporationID := session.GetCorpID(r)
+
+	if report.Corporation.ID != corporationID {
+		http.Redirect(w, r, "/reports", http.StatusSeeOther)
+		return
+	}
+
+	if !IsReportCreator(r, report) && !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
+		logger.Warnf("Received request to ReportAuditGetHandler without proper access...")
+
+		http.Redirect(w, r, fmt.Sprintf("/report/%d", reportID), http.StatusSeeOther)
+		return
+	}
+
+	filter := ParseAuditFilter(r)
+	filter.ReportID = report.ID
+
+	entries, err := database.LoadAuditEntries(filter)
+	if err != nil {
+		logger.Errorf("Failed to load audit entries for report #%d in ReportAuditGetHandler: [%v]", reportID, err)
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data["AuditTitle"] = fmt.Sprintf("Report #%d", report.ID)
+	data["AuditBackLink"] = fmt.Sprintf("/report/%d", report.ID)
+	data["AuditLink"] = fmt.Sprintf("/report/%d/audit", report.ID)
+	data["AuditEntries"] = entries
+	data["AuditFilter"] = r.Form
+
+	err = templates.Funcs(TemplateFunctions(r)).ExecuteTemplate(w, "auditlog", data)
+	if err != nil {
+		logger.Errorf("Failed to execute template in ReportAuditGetHandler: [%v]", err)
+	}
+}
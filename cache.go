@@ -0,0 +1,204 @@
+// cache
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+type Cache struct {
+	lock    sync.RWMutex
+	entries map[int64]*cacheEntry
+	ttl     time.Duration
+	size    int
+	clone   func(interface{}) interface{}
+}
+
+type cacheEntry struct {
+	value    interface{}
+	expires  time.Time
+	lastUsed time.Time
+}
+
+func NewCache(ttl time.Duration, size int, clone func(interface{}) interface{}) *Cache {
+	cache := &Cache{
+		entries: make(map[int64]*cacheEntry),
+		ttl:     ttl,
+		size:    size,
+		clone:   clone,
+	}
+
+	return cache
+}
+
+func (c *Cache) Get(id int64) (interface{}, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entry, ok := c.entries[id]
+	if !ok {
+		return nil, false
+	}
+
+	if c.isExpired(entry) {
+		delete(c.entries, id)
+		return nil, false
+	}
+
+	entry.lastUsed = time.Now()
+
+	return c.clone(entry.value), true
+}
+
+func (c *Cache) Find(match func(interface{}) bool) (interface{}, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for id, entry := range c.entries {
+		if c.isExpired(entry) {
+			delete(c.entries, id)
+			continue
+		}
+
+		if match(entry.value) {
+			entry.lastUsed = time.Now()
+
+			return c.clone(entry.value), true
+		}
+	}
+
+	return nil, false
+}
+
+func (c *Cache) Set(id int64, value interface{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := time.Now()
+
+	entry := &cacheEntry{
+		value:    c.clone(value),
+		lastUsed: now,
+	}
+
+	if c.ttl > 0 {
+		entry.expires = now.Add(c.ttl)
+	}
+
+	c.entries[id] = entry
+
+	c.evict()
+}
+
+func (c *Cache) Delete(id int64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.entries, id)
+}
+
+func (c *Cache) DeleteWhere(match func(interface{}) bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for id, entry := range c.entries {
+		if match(entry.value) {
+			delete(c.entries, id)
+		}
+	}
+}
+
+func (c *Cache) Purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.entries = make(map[int64]*cacheEntry)
+}
+
+func (c *Cache) Len() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return len(c.entries)
+}
+
+func (c *Cache) isExpired(entry *cacheEntry) bool {
+	return !entry.expires.IsZero() && time.Now().After(entry.expires)
+}
+
+func (c *Cache) evict() {
+	if c.size <= 0 {
+		return
+	}
+
+	for id, entry := range c.entries {
+		if c.isExpired(entry) {
+			delete(c.entries, id)
+		}
+	}
+
+	for len(c.entries) > c.size {
+		var oldestID int64
+		var oldest *cacheEntry
+
+		for id, entry := range c.entries {
+			if oldest == nil || entry.lastUsed.Before(oldest.lastUsed) {
+				oldestID = id
+				oldest = entry
+			}
+		}
+
+		delete(c.entries, oldestID)
+	}
+}
+
+type KeyedMutex struct {
+	lock  sync.Mutex
+	locks map[int64]*keyedLock
+}
+
+type keyedLock struct {
+	sync.Mutex
+	waiting int
+}
+
+func NewKeyedMutex() *KeyedMutex {
+	mutex := &KeyedMutex{
+		locks: make(map[int64]*keyedLock),
+	}
+
+	return mutex
+}
+
+func (m *KeyedMutex) Lock(id int64) {
+	m.lock.Lock()
+
+	l, ok := m.locks[id]
+	if !ok {
+		l = &keyedLock{}
+		m.locks[id] = l
+	}
+
+	l.waiting++
+
+	m.lock.Unlock()
+
+	l.Lock()
+}
+
+func (m *KeyedMutex) Unlock(id int64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	l, ok := m.locks[id]
+	if !ok {
+		return
+	}
+
+	l.waiting--
+	if l.waiting == 0 {
+		delete(m.locks, id)
+	}
+
+	l.Unlock()
+}
@@ -0,0 +1,262 @@
+// parser
+package composition
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+type Format int
+
+const (
+	FormatUnknown Format = iota
+	FormatFleetComposition
+	FormatWatchlist
+	FormatNameList
+)
+
+func (format Format) String() string {
+	switch format {
+	case FormatFleetComposition:
+		return "fleet composition"
+	case FormatWatchlist:
+		return "watchlist"
+	case FormatNameList:
+		return "name list"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	minNameLength = 3
+	maxNameLength = 37
+)
+
+var (
+	nameRegex        = regexp.MustCompile(`^[\pL\pN][\pL\pN '.-]*$`)
+	spaceColumnRegex = regexp.MustCompile(`\s{2,}`)
+	bossSuffixRegex  = regexp.MustCompile(`(?i)\s*\(boss\)$`)
+)
+
+type Row struct {
+	Line     int
+	Name     string
+	Ship     string
+	System   string
+	Position string
+	Boss     bool
+	Format   Format
+}
+
+type Warning struct {
+	Line    int
+	Row     string
+	Message string
+}
+
+func (warning *Warning) String() string {
+	if len(warning.Row) == 0 {
+		return fmt.Sprintf("Line %d: %s", warning.Line, warning.Message)
+	}
+
+	return fmt.Sprintf("Line %d: %s: %q", warning.Line, warning.Message, warning.Row)
+}
+
+type Result struct {
+	Rows     []*Row
+	Warnings []*Warning
+}
+
+func (result *Result) warn(line int, row string, format string, args ...interface{}) {
+	result.Warnings = append(result.Warnings, &Warning{Line: line, Row: row, Message: fmt.Sprintf(format, args...)})
+}
+
+// layout maps the columns of a tabular paste, -1 marks a column missing from the paste
+type layout struct {
+	format   Format
+	name     int
+	system   int
+	ship     int
+	position int
+}
+
+var (
+	// Column order of the fleet composition window as copied by the client without a header
+	compositionLayout = layout{format: FormatFleetComposition, name: 0, system: 1, ship: 2, position: 4}
+	watchlistLayout   = layout{format: FormatWatchlist, name: 0, system: -1, ship: 1, position: -1}
+	nameListLayout    = layout{format: FormatNameList, name: 0, system: -1, ship: -1, position: -1}
+)
+
+var headerColumns = map[string]string{
+	"name":           "name",
+	"pilot":          "name",
+	"character":      "name",
+	"member":         "name",
+	"solar system":   "system",
+	"system":         "system",
+	"location":       "system",
+	"ship type":      "ship",
+	"ship":           "ship",
+	"position":       "position",
+	"fleet position": "position",
+	"role":           "position",
+}
+
+// Parse reads a pasted fleet composition, fleet watchlist or list of names, one character per line.
+// Rows that cannot be read are reported as warnings and skipped instead of failing the whole paste.
+func Parse(raw string) *Result {
+	result := &Result{}
+
+	raw = strings.TrimPrefix(raw, "\ufeff")
+	raw = strings.Replace(raw, "\r\n", "\n", -1)
+	raw = strings.Replace(raw, "\r", "\n", -1)
+
+	var header *layout
+
+	seen := make(map[string]int)
+
+	for i, row := range strings.Split(raw, "\n") {
+		line := i + 1
+
+		// Leading tabs are kept as they mark empty columns
+		columns := splitColumns(row)
+
+		row = strings.TrimSpace(row)
+		if len(row) == 0 {
+			continue
+		}
+
+		if parsed, ok := parseHeader(columns); ok {
+			header = parsed
+			continue
+		}
+
+		current := header
+		if current == nil {
+			current = guessLayout(len(columns))
+		}
+
+		parsed, err := parseRow(current, columns)
+		if err != nil {
+			result.warn(line, row, "%v", err)
+			continue
+		}
+
+		parsed.Line = line
+
+		key := strings.ToLower(parsed.Name)
+		if first, ok := seen[key]; ok {
+			result.warn(line, row, "Character %q already listed on line %d", parsed.Name, first)
+			continue
+		}
+
+		seen[key] = line
+		result.Rows = append(result.Rows, parsed)
+	}
+
+	return result
+}
+
+func splitColumns(row string) []string {
+	var columns []string
+
+	if strings.Contains(row, "\t") {
+		columns = strings.Split(strings.Trim(row, " "), "\t")
+	} else {
+		// Tabs are turned into runs of spaces when a paste went through chat or a text editor first
+		columns = spaceColumnRegex.Split(strings.TrimSpace(row), -1)
+	}
+
+	for i := range columns {
+		columns[i] = strings.TrimSpace(columns[i])
+	}
+
+	for len(columns) > 1 && len(columns[len(columns)-1]) == 0 {
+		columns = columns[:len(columns)-1]
+	}
+
+	return columns
+}
+
+func parseHeader(columns []string) (*layout, bool) {
+	if len(columns) < 2 {
+		return nil, false
+	}
+
+	header := &layout{format: FormatFleetComposition, name: -1, system: -1, ship: -1, position: -1}
+
+	for i, column := range columns {
+		switch headerColumns[strings.ToLower(column)] {
+		case "name":
+			header.name = i
+		case "system":
+			header.system = i
+		case "ship":
+			header.ship = i
+		case "position":
+			header.position = i
+		}
+	}
+
+	if header.name < 0 || (header.system < 0 && header.ship < 0 && header.position < 0) {
+		return nil, false
+	}
+
+	if header.system < 0 && header.position < 0 {
+		header.format = FormatWatchlist
+	}
+
+	return header, true
+}
+
+func guessLayout(columns int) *layout {
+	switch {
+	case columns == 1:
+		return &nameListLayout
+	case columns <= 3:
+		return &watchlistLayout
+	default:
+		return &compositionLayout
+	}
+}
+
+func parseRow(current *layout, columns []string) (*Row, error) {
+	row := &Row{
+		Name:     column(columns, current.name),
+		Ship:     column(columns, current.ship),
+		System:   column(columns, current.system),
+		Position: column(columns, current.position),
+		Format:   current.format,
+	}
+
+	if bossSuffixRegex.MatchString(row.Name) {
+		row.Name = bossSuffixRegex.ReplaceAllString(row.Name, "")
+		row.Boss = true
+	}
+
+	if strings.Contains(strings.ToLower(row.Position), "(boss)") {
+		row.Boss = true
+	}
+
+	if len(row.Name) == 0 {
+		return nil, fmt.Errorf("Missing character name")
+	}
+
+	length := utf8.RuneCountInString(row.Name)
+	if length < minNameLength || length > maxNameLength || !nameRegex.MatchString(row.Name) {
+		return nil, fmt.Errorf("Invalid character name %q", row.Name)
+	}
+
+	return row, nil
+}
+
+func column(columns []string, index int) string {
+	if index < 0 || index >= len(columns) {
+		return ""
+	}
+
+	return columns[index]
+}
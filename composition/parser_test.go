@@ -0,0 +1,137 @@
+// parser_test
+package composition
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		file     string
+		rows     []Row
+		warnings []Warning
+	}{
+		{
+			file: "composition_crlf.txt",
+			rows: []Row{
+				{Line: 1, Name: "Alice Adama", Ship: "Tengu", System: "J123456", Position: "Fleet Commander (Boss)", Boss: true, Format: FormatFleetComposition},
+				{Line: 2, Name: "Bob Bobsen", Ship: "Loki", System: "J123456", Position: "Squad Member", Format: FormatFleetComposition},
+				{Line: 5, Name: "Carol Cain", Ship: "Proteus", System: "J123457", Position: "Squad Commander", Format: FormatFleetComposition},
+			},
+			warnings: []Warning{
+				{Line: 3, Row: "x\tJ123456\tNoctis\tSalvage Ship\tSquad Member\t0 - 0 - 0\tWing 1 / Squad 2", Message: `Invalid character name "x"`},
+				{Line: 6, Row: "alice adama\tJ123456\tTengu\tStrategic Cruiser\tSquad Member\t0 - 0 - 0\tWing 1 / Squad 2", Message: `Character "alice adama" already listed on line 1`},
+			},
+		},
+		{
+			file: "composition_lf.txt",
+			rows: []Row{
+				{Line: 2, Name: "Alice Adama", Ship: "Tengu", System: "J123456", Position: "Fleet Commander (Boss)", Boss: true, Format: FormatFleetComposition},
+				{Line: 3, Name: "Dave Davis", Ship: "Hurricane", System: "J123456", Position: "Squad Member", Format: FormatFleetComposition},
+			},
+			warnings: []Warning{
+				{Line: 4, Row: "J123456\tNoctis\tSalvage Ship\tSquad Member\t0 - 0 - 0\tWing 1 / Squad 2", Message: "Missing character name"},
+			},
+		},
+		{
+			file: "watchlist.txt",
+			rows: []Row{
+				{Line: 1, Name: "Alice Adama", Ship: "Tengu", Boss: true, Format: FormatWatchlist},
+				{Line: 2, Name: "Bob Bobsen", Ship: "Loki", Format: FormatWatchlist},
+				{Line: 3, Name: "Eve Evans", Format: FormatNameList},
+			},
+			warnings: []Warning{
+				{Line: 4, Row: "Frank#1\tNoctis", Message: `Invalid character name "Frank#1"`},
+			},
+		},
+		{
+			file: "namelist.txt",
+			rows: []Row{
+				{Line: 1, Name: "Alice Adama", Format: FormatNameList},
+				{Line: 2, Name: "Frank O'Neil", Format: FormatNameList},
+				{Line: 5, Name: "BOB BOBSEN", Format: FormatNameList},
+			},
+			warnings: []Warning{
+				{Line: 3, Row: "Ev", Message: `Invalid character name "Ev"`},
+				{Line: 6, Row: "Bob Bobsen", Message: `Character "Bob Bobsen" already listed on line 5`},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		content, err := ioutil.ReadFile(filepath.Join("testdata", test.file))
+		if err != nil {
+			t.Fatalf("Failed to read %s: [%v]", test.file, err)
+		}
+
+		result := Parse(string(content))
+
+		rows := make([]Row, 0, len(result.Rows))
+		for _, row := range result.Rows {
+			rows = append(rows, *row)
+		}
+
+		warnings := make([]Warning, 0, len(result.Warnings))
+		for _, warning := range result.Warnings {
+			warnings = append(warnings, *warning)
+		}
+
+		if !reflect.DeepEqual(rows, test.rows) {
+			t.Errorf("%s: Expected rows %+v, got %+v", test.file, test.rows, rows)
+		}
+
+		if !reflect.DeepEqual(warnings, test.warnings) {
+			t.Errorf("%s: Expected warnings %+v, got %+v", test.file, test.warnings, warnings)
+		}
+	}
+}
+
+func TestGuessLayout(t *testing.T) {
+	tests := []struct {
+		columns int
+		format  Format
+	}{
+		{1, FormatNameList},
+		{2, FormatWatchlist},
+		{3, FormatWatchlist},
+		{4, FormatFleetComposition},
+		{7, FormatFleetComposition},
+	}
+
+	for _, test := range tests {
+		format := guessLayout(test.columns).format
+		if format != test.format {
+			t.Errorf("Expected %d columns to be read as %s, got %s", test.columns, test.format, format)
+		}
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	tests := []struct {
+		columns []string
+		ok      bool
+		layout  layout
+	}{
+		{[]string{"Name", "Solar System", "Ship Type", "Ship Class", "Position"}, true, layout{format: FormatFleetComposition, name: 0, system: 1, ship: 2, position: 4}},
+		{[]string{"Pilot", "Ship"}, true, layout{format: FormatWatchlist, name: 0, system: -1, ship: 1, position: -1}},
+		{[]string{"Ship", "Character", "Location"}, true, layout{format: FormatFleetComposition, name: 1, system: 2, ship: 0, position: -1}},
+		{[]string{"Name"}, false, layout{}},
+		{[]string{"Name", "Skills"}, false, layout{}},
+		{[]string{"Alice Adama", "Tengu"}, false, layout{}},
+	}
+
+	for _, test := range tests {
+		header, ok := parseHeader(test.columns)
+		if ok != test.ok {
+			t.Errorf("Expected header %q to be detected %v, got %v", test.columns, test.ok, ok)
+			continue
+		}
+
+		if ok && *header != test.layout {
+			t.Errorf("Expected header %q to map to %+v, got %+v", test.columns, test.layout, *header)
+		}
+	}
+}
@@ -14,76 +14,176 @@ import (
 )
 
 var (
-	database *Database
+	database    Store
+	fleetLocks  = NewKeyedMutex()
+	reportLocks = NewKeyedMutex()
+
+	fleetTotalsColumns = fmt.Sprintf("(SELECT COALESCE(SUM(value), 0) FROM lootpastes WHERE lootpastes.fleet_id = fleets.id AND paste_type = %d AND voided = 'N') AS profit, "+
+		"(SELECT COALESCE(SUM(value), 0) FROM lootpastes WHERE lootpastes.fleet_id = fleets.id AND paste_type = %d AND voided = 'N') AS losses, "+
+		"(SELECT COUNT(*) FROM fleetsites WHERE fleetsites.fleet_id = fleets.id AND voided = 'N') AS sites_finished", models.LootPasteTypeProfit, models.LootPasteTypeLoss)
 )
 
 type Database struct {
 	db           *sql.DB
-	corporations map[int64]*models.Corporation
-	players      map[int64]*models.Player
-	fleetMembers map[int64]*models.FleetMember
-	fleets       map[int64]*models.Fleet
-	reports      map[int64]*models.Report
+	driver       string
+	corporations *Cache
+	players      *Cache
+	fleetMembers *Cache
+	fleets       *Cache
+	reports      *Cache
 }
 
-func NewDatabase(d *sql.DB) *Database {
+func NewDatabase(driver string, d *sql.DB) *Database {
+	ttl := time.Duration(config.CacheTTL) * time.Second
+	size := config.CacheSize
+
 	database := &Database{
 		db:           d,
-		corporations: make(map[int64]*models.Corporation),
-		players:      make(map[int64]*models.Player),
-		fleetMembers: make(map[int64]*models.FleetMember),
-		fleets:       make(map[int64]*models.Fleet),
-		reports:      make(map[int64]*models.Report),
+		driver:       driver,
+		corporations: NewCache(ttl, size, func(v interface{}) interface{} { return v.(*models.Corporation).Copy() }),
+		players:      NewCache(ttl, size, func(v interface{}) interface{} { return v.(*models.Player).Copy() }),
+		fleetMembers: NewCache(ttl, size, func(v interface{}) interface{} { return v.(*models.FleetMember).Copy() }),
+		fleets:       NewCache(ttl, size, func(v interface{}) interface{} { return v.(*models.Fleet).Copy() }),
+		reports:      NewCache(ttl, size, func(v interface{}) interface{} { return v.(*models.Report).Copy() }),
 	}
 
 	return database
 }
 
 func InitialiseDatabase() {
-	logger.Infof("Trying to connect to MySQL database at %q...", net.JoinHostPort(config.MySqlHost, strconv.Itoa(config.MySqlPort)))
-
-	db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s)/%s?charset=utf8&parseTime=true", config.MySqlUser, config.MySqlPassword, net.JoinHostPort(config.MySqlHost, strconv.Itoa(config.MySqlPort)), config.MySqlDatabase))
+	db, err := ConnectDatabase()
 	if err != nil {
 		logger.Fatalf("Failed to connect to database: [%v]", err)
 		return
 	}
 
-	database = NewDatabase(db)
+	err = db.CheckSchemaVersion()
+	if err != nil {
+		logger.Fatalf("Failed to verify database schema: [%v]", err)
+		return
+	}
+
+	if config.AutoMigrate {
+		err = db.MigrateUp()
+		if err != nil {
+			logger.Fatalf("Failed to migrate database schema: [%v]", err)
+			return
+		}
+	} else {
+		version, err := db.SchemaVersion()
+		if err != nil {
+			logger.Fatalf("Failed to query database schema version: [%v]", err)
+			return
+		}
+
+		if version < LatestSchemaVersion() {
+			logger.Fatalf("Database schema version %d is outdated (latest is %d), run \"lootsheeter migrate\" or enable automatic migrations", version, LatestSchemaVersion())
+			return
+		}
+	}
+
+	database = db
+
+	logger.Infof("Database schema is up to date, initialisation completed!")
+}
+
+func ConnectDatabase() (*Database, error) {
+	var db *Database
+	var err error
+
+	switch strings.ToLower(config.DatabaseType) {
+	case "sqlite":
+		db, err = OpenSQLiteDatabase(config.SQLitePath)
+	case "mysql":
+		db, err = OpenMySQLDatabase(config.MySqlUser, config.MySqlPassword, config.MySqlHost, config.MySqlPort, config.MySqlDatabase)
+	default:
+		err = fmt.Errorf("Unknown database type %q", config.DatabaseType)
+	}
+	if err != nil {
+		return nil, err
+	}
 
 	logger.Infof("Successfully connected to database, trying to ping...")
 
-	err = database.db.Ping()
+	err = db.Ping()
 	if err != nil {
-		logger.Fatalf("Failed to ping database: [%v]", err)
-		return
+		return nil, err
+	}
+
+	logger.Infof("Successfully pinged database!")
+
+	return db, nil
+}
+
+func OpenMySQLDatabase(user string, password string, host string, port int, name string) (*Database, error) {
+	logger.Infof("Trying to connect to MySQL database at %q...", net.JoinHostPort(host, strconv.Itoa(port)))
+
+	db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s)/%s?charset=utf8&parseTime=true", user, password, net.JoinHostPort(host, strconv.Itoa(port)), name))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewDatabase("mysql", db), nil
+}
+
+func (db *Database) Ping() error {
+	return db.db.Ping()
+}
+
+func (db *Database) Close() error {
+	return db.db.Close()
+}
+
+type querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func rowExists(q querier, query string, args ...interface{}) (bool, error) {
+	var count int
+
+	err := q.QueryRow(query, args...).Scan(&count)
+	if err != nil {
+		return false, err
 	}
 
-	logger.Infof("Successfully pinged database, initialisation completed!")
+	return count > 0, nil
 }
 
 func (db *Database) LoadCorporation(id int64) (*models.Corporation, error) {
 	logger.Tracef("Querying database for corporation with cid = %d...", id)
 
-	corp, ok := db.corporations[id]
+	cached, ok := db.corporations.Get(id)
 	if ok {
 		logger.Tracef("Corporation with cid = %d found in cache, returning...", id)
-		return corp, nil
+		return cached.(*models.Corporation), nil
 	}
 
-	row := db.db.QueryRow("SELECT id, corporation_id, name, ticker, corporation_cut, api_keyid, api_keycode FROM corporations WHERE id = ?", id)
+	row := db.db.QueryRow("SELECT id, corporation_id, name, ticker, corporation_cut, api_keyid, api_keycode, payout_strategy FROM corporations WHERE id = ?", id)
 
 	var cid, corporationID, corporationAPIKeyID int64
 	var corporationName, corporationTicker, corporationAPIKeyCode string
 	var corporationCut float64
+	var corporationPayoutStrategy int
 
-	err := row.Scan(&cid, &corporationID, &corporationName, &corporationTicker, &corporationCut, &corporationAPIKeyID, &corporationAPIKeyCode)
+	err := row.Scan(&cid, &corporationID, &corporationName, &corporationTicker, &corporationCut, &corporationAPIKeyID, &corporationAPIKeyCode, &corporationPayoutStrategy)
 	if err != nil {
 		return &models.Corporation{}, err
 	}
 
-	corp = models.NewCorporation(cid, corporationID, corporationName, corporationTicker, corporationCut, corporationAPIKeyID, corporationAPIKeyCode)
+	corp := models.NewCorporation(cid, corporationID, corporationName, corporationTicker, corporationCut, corporationAPIKeyID, corporationAPIKeyCode, models.PayoutStrategyType(corporationPayoutStrategy))
+
+	err = db.loadCorporationFleetRoles(corp)
+	if err != nil {
+		return &models.Corporation{}, err
+	}
 
-	db.corporations[id] = corp
+	db.corporations.Set(corp.ID, corp)
 
 	return corp, nil
 }
@@ -91,27 +191,32 @@ func (db *Database) LoadCorporation(id int64) (*models.Corporation, error) {
 func (db *Database) LoadCorporationFromName(name string) (*models.Corporation, error) {
 	logger.Tracef("Querying database for corporation with name = %q...", name)
 
-	for _, corp := range db.corporations {
-		if strings.EqualFold(name, corp.Name) {
-			logger.Tracef("Corporation with name %q found in cache, returning...", name)
-			return corp, nil
-		}
+	cached, ok := db.corporations.Find(func(v interface{}) bool { return strings.EqualFold(name, v.(*models.Corporation).Name) })
+	if ok {
+		logger.Tracef("Corporation with name %q found in cache, returning...", name)
+		return cached.(*models.Corporation), nil
 	}
 
-	row := db.db.QueryRow("SELECT id, corporation_id, name, ticker, corporation_cut, api_keyid, api_keycode FROM corporations WHERE name LIKE ?", name)
+	row := db.db.QueryRow("SELECT id, corporation_id, name, ticker, corporation_cut, api_keyid, api_keycode, payout_strategy FROM corporations WHERE name LIKE ?", name)
 
 	var cid, corporationID, corporationAPIKeyID int64
 	var corporationName, corporationTicker, corporationAPIKeyCode string
 	var corporationCut float64
+	var corporationPayoutStrategy int
 
-	err := row.Scan(&cid, &corporationID, &corporationName, &corporationTicker, &corporationCut, &corporationAPIKeyID, &corporationAPIKeyCode)
+	err := row.Scan(&cid, &corporationID, &corporationName, &corporationTicker, &corporationCut, &corporationAPIKeyID, &corporationAPIKeyCode, &corporationPayoutStrategy)
 	if err != nil {
 		return &models.Corporation{}, err
 	}
 
-	corp := models.NewCorporation(cid, corporationID, corporationName, corporationTicker, corporationCut, corporationAPIKeyID, corporationAPIKeyCode)
+	corp := models.NewCorporation(cid, corporationID, corporationName, corporationTicker, corporationCut, corporationAPIKeyID, corporationAPIKeyCode, models.PayoutStrategyType(corporationPayoutStrategy))
+
+	err = db.loadCorporationFleetRoles(corp)
+	if err != nil {
+		return &models.Corporation{}, err
+	}
 
-	db.corporations[corp.ID] = corp
+	db.corporations.Set(corp.ID, corp)
 
 	return corp, nil
 }
@@ -121,7 +226,7 @@ func (db *Database) LoadAllCorporations() ([]*models.Corporation, error) {
 
 	var corporations []*models.Corporation
 
-	rows, err := db.db.Query("SELECT id, corporation_id, name, ticker, corporation_cut, api_keyid, api_keycode FROM corporations ORDER BY name")
+	rows, err := db.db.Query("SELECT id, corporation_id, name, ticker, corporation_cut, api_keyid, api_keycode, payout_strategy FROM corporations ORDER BY name")
 	if err != nil {
 		return corporations, err
 	}
@@ -130,15 +235,21 @@ func (db *Database) LoadAllCorporations() ([]*models.Corporation, error) {
 		var cid, corporationID, corporationAPIKeyID int64
 		var corporationName, corporationTicker, corporationAPIKeyCode string
 		var corporationCut float64
+		var corporationPayoutStrategy int
 
-		err := rows.Scan(&cid, &corporationID, &corporationName, &corporationTicker, &corporationCut, &corporationAPIKeyID, &corporationAPIKeyCode)
+		err := rows.Scan(&cid, &corporationID, &corporationName, &corporationTicker, &corporationCut, &corporationAPIKeyID, &corporationAPIKeyCode, &corporationPayoutStrategy)
 		if err != nil {
 			return corporations, err
 		}
 
-		corp := models.NewCorporation(cid, corporationID, corporationName, corporationTicker, corporationCut, corporationAPIKeyID, corporationAPIKeyCode)
+		corp := models.NewCorporation(cid, corporationID, corporationName, corporationTicker, corporationCut, corporationAPIKeyID, corporationAPIKeyCode, models.PayoutStrategyType(corporationPayoutStrategy))
+
+		err = db.loadCorporationFleetRoles(corp)
+		if err != nil {
+			return corporations, err
+		}
 
-		db.corporations[corp.ID] = corp
+		db.corporations.Set(corp.ID, corp)
 
 		corporations = append(corporations, corp)
 	}
@@ -151,7 +262,7 @@ func (db *Database) SaveCorporation(corporation *models.Corporation) (*models.Co
 
 	_, err := db.LoadCorporation(corporation.ID)
 	if err == sql.ErrNoRows {
-		result, err := db.db.Exec("INSERT INTO corporations(corporation_id, name, ticker, api_keyid, api_keycode) VALUES (?, ?, ?, ?, ?)", corporation.CorporationID, corporation.Name, corporation.Ticker, corporation.APIID, corporation.APICode)
+		result, err := db.db.Exec("INSERT INTO corporations(corporation_id, name, ticker, corporation_cut, api_keyid, api_keycode, payout_strategy) VALUES (?, ?, ?, ?, ?, ?, ?)", corporation.CorporationID, corporation.Name, corporation.Ticker, corporation.CorporationCut, corporation.APIID, corporation.APICode, corporation.PayoutStrategy)
 		if err != nil {
 			return corporation, err
 		}
@@ -163,7 +274,7 @@ func (db *Database) SaveCorporation(corporation *models.Corporation) (*models.Co
 
 		corporation.ID = id
 	} else if err == nil {
-		_, err := db.db.Exec("UPDATE corporations SET corporation_id=?, name=?, ticker=?, api_keyid=?, api_keycode=? WHERE id=?", corporation.CorporationID, corporation.Name, corporation.Ticker, corporation.ID, corporation.APIID, corporation.APICode)
+		_, err := db.db.Exec("UPDATE corporations SET corporation_id=?, name=?, ticker=?, corporation_cut=?, api_keyid=?, api_keycode=?, payout_strategy=? WHERE id=?", corporation.CorporationID, corporation.Name, corporation.Ticker, corporation.CorporationCut, corporation.APIID, corporation.APICode, corporation.PayoutStrategy, corporation.ID)
 		if err != nil {
 			return corporation, err
 		}
@@ -171,7 +282,8 @@ func (db *Database) SaveCorporation(corporation *models.Corporation) (*models.Co
 		return corporation, err
 	}
 
-	db.corporations[corporation.ID] = corporation
+	db.corporations.Set(corporation.ID, corporation)
+	db.invalidateCorporation(corporation.ID)
 
 	return corporation, nil
 }
@@ -179,31 +291,20 @@ func (db *Database) SaveCorporation(corporation *models.Corporation) (*models.Co
 func (db *Database) LoadPlayer(id int64) (*models.Player, error) {
 	logger.Tracef("Querying database for player with pid = %d...", id)
 
-	player, ok := db.players[id]
+	cached, ok := db.players.Get(id)
 	if ok {
 		logger.Tracef("Player with pid = %d found in cache, returning...", id)
-		return player, nil
+		return cached.(*models.Player), nil
 	}
 
-	row := db.db.QueryRow("SELECT id, player_id, name, corporation_id, accessmask FROM players WHERE id = ?", id)
-
-	var pid, playerID, cid int64
-	var playerAccessMask int
-	var playerName string
-
-	err := row.Scan(&pid, &playerID, &playerName, &cid, &playerAccessMask)
-	if err != nil {
-		return &models.Player{}, err
-	}
+	row := db.db.QueryRow("SELECT id, player_id, name, corporation_id, accessmask, active FROM players WHERE id = ?", id)
 
-	corp, err := db.LoadCorporation(cid)
+	player, err := db.scanPlayer(row)
 	if err != nil {
 		return &models.Player{}, err
 	}
 
-	player = models.NewPlayer(pid, playerID, playerName, corp, models.AccessMask(playerAccessMask))
-
-	db.players[id] = player
+	db.players.Set(player.ID, player)
 
 	return player, nil
 }
@@ -211,112 +312,119 @@ func (db *Database) LoadPlayer(id int64) (*models.Player, error) {
 func (db *Database) LoadPlayerFromName(name string) (*models.Player, error) {
 	logger.Tracef("Querying database for player with player_name = %q...", name)
 
-	for _, player := range db.players {
-		if strings.EqualFold(name, player.Name) {
-			logger.Tracef("Player with name %q found in cache, returning...", name)
-			return player, nil
-		}
+	cached, ok := db.players.Find(func(v interface{}) bool { return strings.EqualFold(name, v.(*models.Player).Name) })
+	if ok {
+		logger.Tracef("Player with name %q found in cache, returning...", name)
+		return cached.(*models.Player), nil
 	}
 
-	row := db.db.QueryRow("SELECT id, player_id, name, corporation_id, accessmask FROM players WHERE name LIKE ?", name)
-
-	var pid, playerID, cid int64
-	var playerAccessMask int
-	var playerName string
-
-	err := row.Scan(&pid, &playerID, &playerName, &cid, &playerAccessMask)
-	if err != nil {
-		return &models.Player{}, err
-	}
+	row := db.db.QueryRow("SELECT id, player_id, name, corporation_id, accessmask, active FROM players WHERE name LIKE ?", name)
 
-	corp, err := db.LoadCorporation(cid)
+	player, err := db.scanPlayer(row)
 	if err != nil {
 		return &models.Player{}, err
 	}
 
-	player := models.NewPlayer(pid, playerID, playerName, corp, models.AccessMask(playerAccessMask))
-
-	db.players[player.ID] = player
+	db.players.Set(player.ID, player)
 
 	return player, nil
 }
 
-func (db *Database) LoadAllPlayers(corporationID int64) ([]*models.Player, error) {
-	logger.Tracef("Querying database for all players for corporation #%d...", corporationID)
+func (db *Database) LoadPlayerFromPlayerID(playerID int64) (*models.Player, error) {
+	logger.Tracef("Querying database for player with player_id = %d...", playerID)
 
-	var players []*models.Player
+	cached, ok := db.players.Find(func(v interface{}) bool { return v.(*models.Player).PlayerID == playerID })
+	if ok {
+		logger.Tracef("Player with player_id = %d found in cache, returning...", playerID)
+		return cached.(*models.Player), nil
+	}
 
-	rows, err := db.db.Query("SELECT id, player_id, name, corporation_id, accessmask FROM players WHERE corporation_id = ? ORDER BY name", corporationID)
+	row := db.db.QueryRow("SELECT id, player_id, name, corporation_id, accessmask, active FROM players WHERE player_id = ?", playerID)
+
+	player, err := db.scanPlayer(row)
 	if err != nil {
-		return players, err
+		return &models.Player{}, err
 	}
 
-	for rows.Next() {
-		var pid, playerID, cid int64
-		var playerAccessMask int
-		var playerName string
-
-		err := rows.Scan(&pid, &playerID, &playerName, &cid, &playerAccessMask)
-		if err != nil {
-			return players, err
-		}
+	db.players.Set(player.ID, player)
 
-		corp, err := db.LoadCorporation(cid)
-		if err != nil {
-			return players, err
-		}
+	return player, nil
+}
 
-		player := models.NewPlayer(pid, playerID, playerName, corp, models.AccessMask(playerAccessMask))
+func (db *Database) LoadAllPlayers(corporationID int64) ([]*models.Player, error) {
+	logger.Tracef("Querying database for all players for corporation #%d...", corporationID)
 
-		db.players[player.ID] = player
+	return db.loadPlayers("SELECT id, player_id, name, corporation_id, accessmask, active FROM players WHERE corporation_id = ? ORDER BY name", corporationID)
+}
 
-		players = append(players, player)
-	}
+func (db *Database) LoadActivePlayers(corporationID int64) ([]*models.Player, error) {
+	logger.Tracef("Querying database for active players for corporation #%d...", corporationID)
 
-	return players, nil
+	return db.loadPlayers("SELECT id, player_id, name, corporation_id, accessmask, active FROM players WHERE corporation_id = ? AND active = 'Y' ORDER BY name", corporationID)
 }
 
 func (db *Database) LoadAvailablePlayers(fleedID int64, corporationID int64) ([]*models.Player, error) {
 	logger.Tracef("Querying database for available players with cid = %d...", corporationID)
 
+	return db.loadPlayers("SELECT id, player_id, name, corporation_id, accessmask, active FROM players WHERE corporation_id = ? AND active = 'Y' AND id NOT IN (SELECT player_id FROM fleetmembers WHERE fleet_id = ?) ORDER BY name", corporationID, fleedID)
+}
+
+func (db *Database) loadPlayers(query string, args ...interface{}) ([]*models.Player, error) {
 	var players []*models.Player
 
-	rows, err := db.db.Query("SELECT id, player_id, name, corporation_id, accessmask FROM players WHERE corporation_id = ? AND id NOT IN (SELECT player_id FROM fleetmembers WHERE fleet_id = ?) ORDER BY name", corporationID, fleedID)
+	rows, err := db.db.Query(query, args...)
 	if err != nil {
 		return players, err
 	}
 
-	for rows.Next() {
-		var pid, playerID, cid int64
-		var playerAccessMask int
-		var playerName string
+	defer rows.Close()
 
-		err := rows.Scan(&pid, &playerID, &playerName, &cid, &playerAccessMask)
+	for rows.Next() {
+		player, err := db.scanPlayer(rows)
 		if err != nil {
 			return players, err
 		}
 
-		corp, err := db.LoadCorporation(cid)
-		if err != nil {
-			return players, err
-		}
+		db.players.Set(player.ID, player)
+
+		players = append(players, player)
+	}
 
-		player := models.NewPlayer(pid, playerID, playerName, corp, models.AccessMask(playerAccessMask))
+	return players, rows.Err()
+}
 
-		db.players[player.ID] = player
+func (db *Database) scanPlayer(row scanner) (*models.Player, error) {
+	var pid, playerID, cid int64
+	var playerAccessMask int
+	var playerName, playerActiveEnum string
 
-		players = append(players, player)
+	err := row.Scan(&pid, &playerID, &playerName, &cid, &playerAccessMask, &playerActiveEnum)
+	if err != nil {
+		return &models.Player{}, err
+	}
+
+	corp, err := db.LoadCorporation(cid)
+	if err != nil {
+		return &models.Player{}, err
 	}
 
-	return players, nil
+	return models.NewPlayer(pid, playerID, playerName, corp, models.AccessMask(playerAccessMask), strings.EqualFold(playerActiveEnum, "Y")), nil
 }
 
 func (db *Database) SavePlayer(player *models.Player) (*models.Player, error) {
 	logger.Tracef("Saving player #%d to database...", player.ID)
 
+	var playerActiveEnum string
+
+	if player.Active {
+		playerActiveEnum = "Y"
+	} else {
+		playerActiveEnum = "N"
+	}
+
 	_, err := db.LoadPlayer(player.ID)
 	if err == sql.ErrNoRows {
-		result, err := db.db.Exec("INSERT INTO players(player_id, name, corporation_id, accessmask) VALUES (?, ?, ?, ?)", player.PlayerID, player.Name, player.Corp.ID, player.AccessMask)
+		result, err := db.db.Exec("INSERT INTO players(player_id, name, corporation_id, accessmask, active) VALUES (?, ?, ?, ?, ?)", player.PlayerID, player.Name, player.Corp.ID, player.AccessMask, playerActiveEnum)
 		if err != nil {
 			return player, err
 		}
@@ -328,7 +436,7 @@ func (db *Database) SavePlayer(player *models.Player) (*models.Player, error) {
 
 		player.ID = id
 	} else if err == nil {
-		_, err := db.db.Exec("UPDATE players SET player_id=?, name=?, corporation_id=?, accessmask=? WHERE id=?", player.PlayerID, player.Name, player.Corp.ID, player.AccessMask, player.ID)
+		_, err := db.db.Exec("UPDATE players SET player_id=?, name=?, corporation_id=?, accessmask=?, active=? WHERE id=?", player.PlayerID, player.Name, player.Corp.ID, player.AccessMask, playerActiveEnum, player.ID)
 		if err != nil {
 			return player, err
 		}
@@ -336,7 +444,8 @@ func (db *Database) SavePlayer(player *models.Player) (*models.Player, error) {
 		return player, err
 	}
 
-	db.players[player.ID] = player
+	db.players.Set(player.ID, player)
+	db.invalidatePlayer(player.ID)
 
 	return player, nil
 }
@@ -344,10 +453,10 @@ func (db *Database) SavePlayer(player *models.Player) (*models.Player, error) {
 func (db *Database) LoadFleetMember(fleetID int64, id int64) (*models.FleetMember, error) {
 	logger.Tracef("Querying database for fleet member with fid = %d and pid = %d...", fleetID, id)
 
-	fleetMember, ok := db.fleetMembers[id]
-	if ok {
+	cached, ok := db.fleetMembers.Get(id)
+	if ok && cached.(*models.FleetMember).FleetID == fleetID {
 		logger.Tracef("FleetMember with fid = %d and pid = %d found in cache, returning...", fleetID, id)
-		return fleetMember, nil
+		return cached.(*models.FleetMember), nil
 	}
 
 	row := db.db.QueryRow("SELECT id, fleet_id, player_id, role, ship, site_modifier, payment_modifier, payout, payout_complete, report_id FROM fleetmembers WHERE fleet_id = ? AND id = ?", fleetID, id)
@@ -381,13 +490,17 @@ func (db *Database) LoadFleetMember(fleetID int64, id int64) (*models.FleetMembe
 		return &models.FleetMember{}, err
 	}
 
-	fleetMember = models.NewFleetMember(fmid, fid, player, models.FleetRole(fleetmemberRole), fleetMemberShip, fleetmemberSiteModifier, fleetmemberPaymentModifier, fleetmemberPayout, fleetmemberPayoutComplete, rid)
+	fleetMember := models.NewFleetMember(fmid, fid, player, models.FleetRole(fleetmemberRole), fleetMemberShip, fleetmemberSiteModifier, fleetmemberPaymentModifier, fleetmemberPayout, fleetmemberPayoutComplete, rid, time.Time{}, time.Time{})
 
-	db.fleetMembers[fleetMember.ID] = fleetMember
-	if _, ok := db.fleets[fleetMember.FleetID]; ok {
-		db.fleets[fleetMember.FleetID].UpdateMember(fleetMember)
+	intervals, err := db.loadFleetMemberIntervals(fid)
+	if err != nil {
+		return &models.FleetMember{}, err
 	}
 
+	fleetMember.RestoreIntervals(intervals[fleetMember.ID])
+
+	db.fleetMembers.Set(fleetMember.ID, fleetMember)
+
 	return fleetMember, nil
 }
 
@@ -396,6 +509,11 @@ func (db *Database) LoadAllFleetMembers(fleetID int64) ([]*models.FleetMember, e
 
 	var fleetMembers []*models.FleetMember
 
+	intervals, err := db.loadFleetMemberIntervals(fleetID)
+	if err != nil {
+		return fleetMembers, err
+	}
+
 	rows, err := db.db.Query("SELECT f.id, fleet_id, f.player_id, role, ship, site_modifier, payment_modifier, payout, payout_complete, report_id FROM fleetmembers AS f INNER JOIN players AS p ON f.player_id = p.id WHERE fleet_id = ? ORDER BY p.Name", fleetID)
 	if err != nil {
 		return fleetMembers, err
@@ -431,12 +549,11 @@ func (db *Database) LoadAllFleetMembers(fleetID int64) ([]*models.FleetMember, e
 			return fleetMembers, err
 		}
 
-		fleetMember := models.NewFleetMember(fmid, fid, player, models.FleetRole(fleetmemberRole), fleetMemberShip, fleetmemberSiteModifier, fleetmemberPaymentModifier, fleetmemberPayout, fleetmemberPayoutComplete, rid)
+		fleetMember := models.NewFleetMember(fmid, fid, player, models.FleetRole(fleetmemberRole), fleetMemberShip, fleetmemberSiteModifier, fleetmemberPaymentModifier, fleetmemberPayout, fleetmemberPayoutComplete, rid, time.Time{}, time.Time{})
 
-		db.fleetMembers[fleetMember.ID] = fleetMember
-		if _, ok := db.fleets[fleetMember.FleetID]; ok {
-			db.fleets[fleetMember.FleetID].UpdateMember(fleetMember)
-		}
+		fleetMember.RestoreIntervals(intervals[fleetMember.ID])
+
+		db.fleetMembers.Set(fleetMember.ID, fleetMember)
 
 		fleetMembers = append(fleetMembers, fleetMember)
 	}
@@ -484,13 +601,17 @@ func (db *Database) LoadAllFleetMembersForReportPlayer(reportID int64, playerID
 			return fleetMembers, err
 		}
 
-		fleetMember := models.NewFleetMember(fmid, fid, player, models.FleetRole(fleetmemberRole), fleetMemberShip, fleetmemberSiteModifier, fleetmemberPaymentModifier, fleetmemberPayout, fleetmemberPayoutComplete, rid)
+		fleetMember := models.NewFleetMember(fmid, fid, player, models.FleetRole(fleetmemberRole), fleetMemberShip, fleetmemberSiteModifier, fleetmemberPaymentModifier, fleetmemberPayout, fleetmemberPayoutComplete, rid, time.Time{}, time.Time{})
 
-		db.fleetMembers[fleetMember.ID] = fleetMember
-		if _, ok := db.fleets[fleetMember.FleetID]; ok {
-			db.fleets[fleetMember.FleetID].UpdateMember(fleetMember)
+		intervals, err := db.loadFleetMemberIntervals(fid)
+		if err != nil {
+			return fleetMembers, err
 		}
 
+		fleetMember.RestoreIntervals(intervals[fleetMember.ID])
+
+		db.fleetMembers.Set(fleetMember.ID, fleetMember)
+
 		fleetMembers = append(fleetMembers, fleetMember)
 	}
 
@@ -500,6 +621,18 @@ func (db *Database) LoadAllFleetMembersForReportPlayer(reportID int64, playerID
 func (db *Database) SaveFleetMember(fleetID int64, member *models.FleetMember) (*models.FleetMember, error) {
 	logger.Tracef("Saving fleet member #%d to database...", member.ID)
 
+	member, err := db.saveFleetMember(db.db, fleetID, member)
+	if err != nil {
+		return member, err
+	}
+
+	db.fleetMembers.Set(member.ID, member)
+	db.invalidateFleet(member.FleetID)
+
+	return member, nil
+}
+
+func (db *Database) saveFleetMember(q querier, fleetID int64, member *models.FleetMember) (*models.FleetMember, error) {
 	var fleetmemberReportID sql.NullInt64
 
 	if member.ReportID > 0 {
@@ -515,9 +648,13 @@ func (db *Database) SaveFleetMember(fleetID int64, member *models.FleetMember) (
 		fleetmemberPayoutCompleteEnum = "N"
 	}
 
-	_, err := db.LoadFleetMember(fleetID, member.ID)
-	if err == sql.ErrNoRows {
-		result, err := db.db.Exec("INSERT INTO fleetmembers(fleet_id, player_id, role, ship, site_modifier, payment_modifier, payout, payout_complete, report_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)", fleetID, member.Player.ID, member.Role, member.Ship, member.SiteModifier, member.PaymentModifier, member.Payout, fleetmemberPayoutCompleteEnum, fleetmemberReportID)
+	exists, err := rowExists(q, "SELECT COUNT(*) FROM fleetmembers WHERE fleet_id = ? AND id = ?", fleetID, member.ID)
+	if err != nil {
+		return member, err
+	}
+
+	if !exists {
+		result, err := q.Exec("INSERT INTO fleetmembers(fleet_id, player_id, role, ship, site_modifier, payment_modifier, payout, payout_complete, report_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)", fleetID, member.Player.ID, member.Role, member.Ship, member.SiteModifier, member.PaymentModifier, member.Payout, fleetmemberPayoutCompleteEnum, fleetmemberReportID)
 		if err != nil {
 			return member, err
 		}
@@ -528,32 +665,82 @@ func (db *Database) SaveFleetMember(fleetID int64, member *models.FleetMember) (
 		}
 
 		member.ID = id
-	} else if err == nil {
-		_, err := db.db.Exec("UPDATE fleetmembers SET fleet_id=?, player_id=?, role=?, ship=?, site_modifier=?, payment_modifier=?, payout=?, payout_complete=?, report_id=? WHERE id=?", fleetID, member.Player.ID, member.Role, member.Ship, member.SiteModifier, member.PaymentModifier, member.Payout, fleetmemberPayoutCompleteEnum, fleetmemberReportID, member.ID)
+	} else {
+		_, err := q.Exec("UPDATE fleetmembers SET fleet_id=?, player_id=?, role=?, ship=?, site_modifier=?, payment_modifier=?, payout=?, payout_complete=?, report_id=? WHERE id=?", fleetID, member.Player.ID, member.Role, member.Ship, member.SiteModifier, member.PaymentModifier, member.Payout, fleetmemberPayoutCompleteEnum, fleetmemberReportID, member.ID)
 		if err != nil {
 			return member, err
 		}
-	} else {
+	}
+
+	member.FleetID = fleetID
+
+	// Intervals are replaced as a whole, they are only ever edited together with their member
+	_, err = q.Exec("DELETE FROM fleetmemberintervals WHERE fleetmember_id = ?", member.ID)
+	if err != nil {
 		return member, err
 	}
 
-	db.fleetMembers[member.ID] = member
-	if _, ok := db.fleets[member.FleetID]; ok {
-		db.fleets[member.FleetID].UpdateMember(member)
+	for _, interval := range member.Intervals {
+		var intervalLeave *time.Time
+
+		if !interval.Leave.IsZero() {
+			intervalLeave = &interval.Leave
+		}
+
+		result, err := q.Exec("INSERT INTO fleetmemberintervals(fleetmember_id, join_time, leave_time) VALUES (?, ?, ?)", member.ID, interval.Join, intervalLeave)
+		if err != nil {
+			return member, err
+		}
+
+		interval.ID, err = result.LastInsertId()
+		if err != nil {
+			return member, err
+		}
 	}
 
 	return member, nil
 }
 
+func (db *Database) loadFleetMemberIntervals(fleetID int64) (map[int64][]*models.FleetMemberInterval, error) {
+	intervals := make(map[int64][]*models.FleetMemberInterval)
+
+	rows, err := db.db.Query("SELECT i.id, i.fleetmember_id, i.join_time, i.leave_time FROM fleetmemberintervals AS i INNER JOIN fleetmembers AS m ON m.id = i.fleetmember_id WHERE m.fleet_id = ? ORDER BY i.join_time, i.id", fleetID)
+	if err != nil {
+		return intervals, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var iid, fmid int64
+		var intervalJoin time.Time
+		var intervalLeave *time.Time
+
+		err = rows.Scan(&iid, &fmid, &intervalJoin, &intervalLeave)
+		if err != nil {
+			return intervals, err
+		}
+
+		if intervalLeave == nil {
+			intervalLeave = &time.Time{}
+		}
+
+		intervals[fmid] = append(intervals[fmid], models.NewFleetMemberInterval(iid, intervalJoin, *intervalLeave))
+	}
+
+	return intervals, rows.Err()
+}
+
 func (db *Database) DeleteFleetMember(fleetID int64, memberID int64) error {
-	logger.Tracef("Deleting member #%d from fleet #%d from database...")
+	logger.Tracef("Deleting member #%d from fleet #%d from database...", memberID, fleetID)
 
 	_, err := db.db.Exec("DELETE FROM fleetmembers WHERE fleet_id = ? AND id = ?", fleetID, memberID)
 	if err != nil {
 		return err
 	}
 
-	delete(db.fleetMembers, memberID)
+	db.fleetMembers.Delete(memberID)
+	db.invalidateFleet(fleetID)
 
 	return nil
 }
@@ -561,23 +748,23 @@ func (db *Database) DeleteFleetMember(fleetID int64, memberID int64) error {
 func (db *Database) LoadFleet(id int64) (*models.Fleet, error) {
 	logger.Tracef("Querying database for fleet with fid = %d...", id)
 
-	fleet, ok := db.fleets[id]
+	cached, ok := db.fleets.Get(id)
 	if ok {
 		logger.Tracef("Fleet with fid = %d found in cache, returning...", id)
-		return fleet, nil
+		return cached.(*models.Fleet), nil
 	}
 
-	row := db.db.QueryRow("SELECT id, corporation_id, name, system, system_nickname, profit, losses, sites_finished, starttime, endtime, corporation_payout, payout_complete, notes, report_id FROM fleets WHERE id = ?", id)
+	row := db.db.QueryRow("SELECT id, corporation_id, name, system, system_nickname, "+fleetTotalsColumns+", starttime, endtime, corporation_payout, payout_complete, notes, report_id, payout_strategy FROM fleets WHERE id = ?", id)
 
 	var fid, cid, rid int64
 	var sqlRid sql.NullInt64
 	var fleetName, fleetSystem, fleetSystemNickname, fleetPayoutCompleteEnumString, fleetNotes string
 	var fleetProfit, fleetLosses, fleetCorporationPayout float64
-	var fleetSitesFinished int
+	var fleetSitesFinished, fleetPayoutStrategy int
 	var fleetStart, fleetEnd *time.Time
 	var fleetPayoutComplete bool
 
-	err := row.Scan(&fid, &cid, &fleetName, &fleetSystem, &fleetSystemNickname, &fleetProfit, &fleetLosses, &fleetSitesFinished, &fleetStart, &fleetEnd, &fleetCorporationPayout, &fleetPayoutCompleteEnumString, &fleetNotes, &sqlRid)
+	err := row.Scan(&fid, &cid, &fleetName, &fleetSystem, &fleetSystemNickname, &fleetProfit, &fleetLosses, &fleetSitesFinished, &fleetStart, &fleetEnd, &fleetCorporationPayout, &fleetPayoutCompleteEnumString, &fleetNotes, &sqlRid, &fleetPayoutStrategy)
 	if err != nil {
 		return &models.Fleet{}, err
 	}
@@ -608,7 +795,12 @@ func (db *Database) LoadFleet(id int64) (*models.Fleet, error) {
 		return &models.Fleet{}, err
 	}
 
-	fleet = models.NewFleet(fid, corporation, fleetName, fleetSystem, fleetSystemNickname, fleetProfit, fleetLosses, fleetSitesFinished, *fleetStart, *fleetEnd, fleetCorporationPayout, fleetPayoutComplete, fleetNotes, rid)
+	fleetSites, err := db.LoadAllFleetSites(fid)
+	if err != nil {
+		return &models.Fleet{}, err
+	}
+
+	fleet := models.NewFleet(fid, corporation, fleetName, fleetSystem, fleetSystemNickname, fleetProfit, fleetLosses, fleetSitesFinished, *fleetStart, *fleetEnd, fleetCorporationPayout, fleetPayoutComplete, fleetNotes, rid, models.PayoutStrategyType(fleetPayoutStrategy))
 
 	for _, member := range fleetMembers {
 		err = fleet.AddMember(member)
@@ -617,7 +809,9 @@ func (db *Database) LoadFleet(id int64) (*models.Fleet, error) {
 		}
 	}
 
-	db.fleets[fleet.ID] = fleet
+	fleet.Sites = fleetSites
+
+	db.fleets.Set(fleet.ID, fleet)
 
 	return fleet, nil
 }
@@ -627,7 +821,7 @@ func (db *Database) LoadAllFleets(corporationID int64) ([]*models.Fleet, error)
 
 	var fleets []*models.Fleet
 
-	rows, err := db.db.Query("SELECT id, corporation_id, name, system, system_nickname, profit, losses, sites_finished, starttime, endtime, corporation_payout, payout_complete, notes, report_id FROM fleets WHERE corporation_id = ?", corporationID)
+	rows, err := db.db.Query("SELECT id, corporation_id, name, system, system_nickname, "+fleetTotalsColumns+", starttime, endtime, corporation_payout, payout_complete, notes, report_id, payout_strategy FROM fleets WHERE corporation_id = ?", corporationID)
 	if err != nil {
 		return fleets, err
 	}
@@ -637,11 +831,11 @@ func (db *Database) LoadAllFleets(corporationID int64) ([]*models.Fleet, error)
 		var sqlRid sql.NullInt64
 		var fleetName, fleetSystem, fleetSystemNickname, fleetPayoutCompleteEnumString, fleetNotes string
 		var fleetProfit, fleetLosses, fleetCorporationPayout float64
-		var fleetSitesFinished int
+		var fleetSitesFinished, fleetPayoutStrategy int
 		var fleetStart, fleetEnd *time.Time
 		var fleetPayoutComplete bool
 
-		err := rows.Scan(&fid, &cid, &fleetName, &fleetSystem, &fleetSystemNickname, &fleetProfit, &fleetLosses, &fleetSitesFinished, &fleetStart, &fleetEnd, &fleetCorporationPayout, &fleetPayoutCompleteEnumString, &fleetNotes, &sqlRid)
+		err := rows.Scan(&fid, &cid, &fleetName, &fleetSystem, &fleetSystemNickname, &fleetProfit, &fleetLosses, &fleetSitesFinished, &fleetStart, &fleetEnd, &fleetCorporationPayout, &fleetPayoutCompleteEnumString, &fleetNotes, &sqlRid, &fleetPayoutStrategy)
 		if err != nil {
 			return fleets, err
 		}
@@ -672,7 +866,12 @@ func (db *Database) LoadAllFleets(corporationID int64) ([]*models.Fleet, error)
 			return fleets, err
 		}
 
-		fleet := models.NewFleet(fid, corporation, fleetName, fleetSystem, fleetSystemNickname, fleetProfit, fleetLosses, fleetSitesFinished, *fleetStart, *fleetEnd, fleetCorporationPayout, fleetPayoutComplete, fleetNotes, rid)
+		fleetSites, err := db.LoadAllFleetSites(fid)
+		if err != nil {
+			return fleets, err
+		}
+
+		fleet := models.NewFleet(fid, corporation, fleetName, fleetSystem, fleetSystemNickname, fleetProfit, fleetLosses, fleetSitesFinished, *fleetStart, *fleetEnd, fleetCorporationPayout, fleetPayoutComplete, fleetNotes, rid, models.PayoutStrategyType(fleetPayoutStrategy))
 
 		for _, member := range fleetMembers {
 			err = fleet.AddMember(member)
@@ -681,7 +880,9 @@ func (db *Database) LoadAllFleets(corporationID int64) ([]*models.Fleet, error)
 			}
 		}
 
-		db.fleets[fleet.ID] = fleet
+		fleet.Sites = fleetSites
+
+		db.fleets.Set(fleet.ID, fleet)
 
 		fleets = append(fleets, fleet)
 	}
@@ -694,7 +895,7 @@ func (db *Database) LoadAllFleetsForReport(reportID int64) ([]*models.Fleet, err
 
 	var fleets []*models.Fleet
 
-	rows, err := db.db.Query("SELECT id, corporation_id, name, system, system_nickname, profit, losses, sites_finished, starttime, endtime, corporation_payout, payout_complete, notes, report_id FROM fleets WHERE report_id = ?", reportID)
+	rows, err := db.db.Query("SELECT id, corporation_id, name, system, system_nickname, "+fleetTotalsColumns+", starttime, endtime, corporation_payout, payout_complete, notes, report_id, payout_strategy FROM fleets WHERE report_id = ?", reportID)
 	if err != nil {
 		return fleets, err
 	}
@@ -704,11 +905,11 @@ func (db *Database) LoadAllFleetsForReport(reportID int64) ([]*models.Fleet, err
 		var sqlRid sql.NullInt64
 		var fleetName, fleetSystem, fleetSystemNickname, fleetPayoutCompleteEnumString, fleetNotes string
 		var fleetProfit, fleetLosses, fleetCorporationPayout float64
-		var fleetSitesFinished int
+		var fleetSitesFinished, fleetPayoutStrategy int
 		var fleetStart, fleetEnd *time.Time
 		var fleetPayoutComplete bool
 
-		err := rows.Scan(&fid, &cid, &fleetName, &fleetSystem, &fleetSystemNickname, &fleetProfit, &fleetLosses, &fleetSitesFinished, &fleetStart, &fleetEnd, &fleetCorporationPayout, &fleetPayoutCompleteEnumString, &fleetNotes, &sqlRid)
+		err := rows.Scan(&fid, &cid, &fleetName, &fleetSystem, &fleetSystemNickname, &fleetProfit, &fleetLosses, &fleetSitesFinished, &fleetStart, &fleetEnd, &fleetCorporationPayout, &fleetPayoutCompleteEnumString, &fleetNotes, &sqlRid, &fleetPayoutStrategy)
 		if err != nil {
 			return fleets, err
 		}
@@ -739,7 +940,12 @@ func (db *Database) LoadAllFleetsForReport(reportID int64) ([]*models.Fleet, err
 			return fleets, err
 		}
 
-		fleet := models.NewFleet(fid, corporation, fleetName, fleetSystem, fleetSystemNickname, fleetProfit, fleetLosses, fleetSitesFinished, *fleetStart, *fleetEnd, fleetCorporationPayout, fleetPayoutComplete, fleetNotes, rid)
+		fleetSites, err := db.LoadAllFleetSites(fid)
+		if err != nil {
+			return fleets, err
+		}
+
+		fleet := models.NewFleet(fid, corporation, fleetName, fleetSystem, fleetSystemNickname, fleetProfit, fleetLosses, fleetSitesFinished, *fleetStart, *fleetEnd, fleetCorporationPayout, fleetPayoutComplete, fleetNotes, rid, models.PayoutStrategyType(fleetPayoutStrategy))
 
 		for _, member := range fleetMembers {
 			err = fleet.AddMember(member)
@@ -748,7 +954,9 @@ func (db *Database) LoadAllFleetsForReport(reportID int64) ([]*models.Fleet, err
 			}
 		}
 
-		db.fleets[fleet.ID] = fleet
+		fleet.Sites = fleetSites
+
+		db.fleets.Set(fleet.ID, fleet)
 
 		fleets = append(fleets, fleet)
 	}
@@ -761,7 +969,7 @@ func (db *Database) LoadAllFleetsWithoutReports(corporationID int64) ([]*models.
 
 	var fleets []*models.Fleet
 
-	rows, err := db.db.Query("SELECT id, corporation_id, name, system, system_nickname, profit, losses, sites_finished, starttime, endtime, corporation_payout, payout_complete, notes, report_id FROM fleets WHERE corporation_id = ? AND report_id IS NULL AND endtime IS NOT NULL", corporationID)
+	rows, err := db.db.Query("SELECT id, corporation_id, name, system, system_nickname, "+fleetTotalsColumns+", starttime, endtime, corporation_payout, payout_complete, notes, report_id, payout_strategy FROM fleets WHERE corporation_id = ? AND report_id IS NULL AND endtime IS NOT NULL", corporationID)
 	if err != nil {
 		return fleets, err
 	}
@@ -771,11 +979,11 @@ func (db *Database) LoadAllFleetsWithoutReports(corporationID int64) ([]*models.
 		var sqlRid sql.NullInt64
 		var fleetName, fleetSystem, fleetSystemNickname, fleetPayoutCompleteEnumString, fleetNotes string
 		var fleetProfit, fleetLosses, fleetCorporationPayout float64
-		var fleetSitesFinished int
+		var fleetSitesFinished, fleetPayoutStrategy int
 		var fleetStart, fleetEnd *time.Time
 		var fleetPayoutComplete bool
 
-		err := rows.Scan(&fid, &cid, &fleetName, &fleetSystem, &fleetSystemNickname, &fleetProfit, &fleetLosses, &fleetSitesFinished, &fleetStart, &fleetEnd, &fleetCorporationPayout, &fleetPayoutCompleteEnumString, &fleetNotes, &sqlRid)
+		err := rows.Scan(&fid, &cid, &fleetName, &fleetSystem, &fleetSystemNickname, &fleetProfit, &fleetLosses, &fleetSitesFinished, &fleetStart, &fleetEnd, &fleetCorporationPayout, &fleetPayoutCompleteEnumString, &fleetNotes, &sqlRid, &fleetPayoutStrategy)
 		if err != nil {
 			return fleets, err
 		}
@@ -806,7 +1014,12 @@ func (db *Database) LoadAllFleetsWithoutReports(corporationID int64) ([]*models.
 			return fleets, err
 		}
 
-		fleet := models.NewFleet(fid, corporation, fleetName, fleetSystem, fleetSystemNickname, fleetProfit, fleetLosses, fleetSitesFinished, *fleetStart, *fleetEnd, fleetCorporationPayout, fleetPayoutComplete, fleetNotes, rid)
+		fleetSites, err := db.LoadAllFleetSites(fid)
+		if err != nil {
+			return fleets, err
+		}
+
+		fleet := models.NewFleet(fid, corporation, fleetName, fleetSystem, fleetSystemNickname, fleetProfit, fleetLosses, fleetSitesFinished, *fleetStart, *fleetEnd, fleetCorporationPayout, fleetPayoutComplete, fleetNotes, rid, models.PayoutStrategyType(fleetPayoutStrategy))
 
 		for _, member := range fleetMembers {
 			err = fleet.AddMember(member)
@@ -815,7 +1028,9 @@ func (db *Database) LoadAllFleetsWithoutReports(corporationID int64) ([]*models.
 			}
 		}
 
-		db.fleets[fleet.ID] = fleet
+		fleet.Sites = fleetSites
+
+		db.fleets.Set(fleet.ID, fleet)
 
 		fleets = append(fleets, fleet)
 	}
@@ -826,6 +1041,30 @@ func (db *Database) LoadAllFleetsWithoutReports(corporationID int64) ([]*models.
 func (db *Database) SaveFleet(fleet *models.Fleet) (*models.Fleet, error) {
 	logger.Tracef("Saving fleet #%d to database...", fleet.ID)
 
+	tx, err := db.db.Begin()
+	if err != nil {
+		return fleet, err
+	}
+
+	fleet, err = db.saveFleet(tx, fleet)
+	if err != nil {
+		tx.Rollback()
+		db.evictFleet(fleet)
+		return fleet, fmt.Errorf("Failed to save fleet, rolled back all changes: [%v]", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		db.evictFleet(fleet)
+		return fleet, err
+	}
+
+	db.cacheFleet(fleet)
+
+	return fleet, nil
+}
+
+func (db *Database) saveFleet(q querier, fleet *models.Fleet) (*models.Fleet, error) {
 	var fleetPayoutCompleteEnumString string
 
 	if fleet.PayoutComplete {
@@ -848,9 +1087,13 @@ func (db *Database) SaveFleet(fleet *models.Fleet) (*models.Fleet, error) {
 		fleetEndTime = nil
 	}
 
-	_, err := db.LoadFleet(fleet.ID)
-	if err == sql.ErrNoRows {
-		result, err := db.db.Exec("INSERT INTO fleets(name, corporation_id, system, system_nickname, profit, losses, sites_finished, starttime, endtime, corporation_payout, payout_complete, notes, report_id) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", fleet.Name, fleet.Corporation.ID, fleet.System, fleet.SystemNickname, fleet.Profit, fleet.Losses, fleet.SitesFinished, fleet.StartTime, fleetEndTime, fleet.CorporationPayout, fleetPayoutCompleteEnumString, fleet.Notes, fleetReportID)
+	exists, err := rowExists(q, "SELECT COUNT(*) FROM fleets WHERE id = ?", fleet.ID)
+	if err != nil {
+		return fleet, err
+	}
+
+	if !exists {
+		result, err := q.Exec("INSERT INTO fleets(name, corporation_id, system, system_nickname, starttime, endtime, corporation_payout, payout_complete, notes, report_id, payout_strategy) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", fleet.Name, fleet.Corporation.ID, fleet.System, fleet.SystemNickname, fleet.StartTime, fleetEndTime, fleet.CorporationPayout, fleetPayoutCompleteEnumString, fleet.Notes, fleetReportID, fleet.PayoutStrategy)
 		if err != nil {
 			return fleet, err
 		}
@@ -861,40 +1104,52 @@ func (db *Database) SaveFleet(fleet *models.Fleet) (*models.Fleet, error) {
 		}
 
 		fleet.ID = id
-	} else if err == nil {
-		_, err := db.db.Exec("UPDATE fleets SET name=?, corporation_id=?, system=?, system_nickname=?, profit=?, losses=?, sites_finished=?, starttime=?, endtime=?, corporation_payout=?, payout_complete=?, notes=?, report_id=? WHERE id=?", fleet.Name, fleet.Corporation.ID, fleet.System, fleet.SystemNickname, fleet.Profit, fleet.Losses, fleet.SitesFinished, fleet.StartTime, fleetEndTime, fleet.CorporationPayout, fleetPayoutCompleteEnumString, fleet.Notes, fleetReportID, fleet.ID)
+	} else {
+		_, err := q.Exec("UPDATE fleets SET name=?, corporation_id=?, system=?, system_nickname=?, starttime=?, endtime=?, corporation_payout=?, payout_complete=?, notes=?, report_id=?, payout_strategy=? WHERE id=?", fleet.Name, fleet.Corporation.ID, fleet.System, fleet.SystemNickname, fleet.StartTime, fleetEndTime, fleet.CorporationPayout, fleetPayoutCompleteEnumString, fleet.Notes, fleetReportID, fleet.PayoutStrategy, fleet.ID)
 		if err != nil {
 			return fleet, err
 		}
-	} else {
-		return fleet, err
 	}
 
 	for _, member := range fleet.Members {
-		m, err := db.SaveFleetMember(fleet.ID, member)
+		_, err := db.saveFleetMember(q, fleet.ID, member)
 		if err != nil {
 			return fleet, err
 		}
+	}
+
+	return fleet, nil
+}
 
-		member = m
+func (db *Database) cacheFleet(fleet *models.Fleet) {
+	for _, member := range fleet.Members {
+		db.fleetMembers.Set(member.ID, member)
 	}
 
-	db.fleets[fleet.ID] = fleet
+	db.invalidateFleet(fleet.ID)
+	db.fleets.Set(fleet.ID, fleet)
+}
 
-	return fleet, nil
+func (db *Database) evictFleet(fleet *models.Fleet) {
+	for _, member := range fleet.Members {
+		db.fleetMembers.Delete(member.ID)
+	}
+
+	db.invalidateFleet(fleet.ID)
 }
 
 func (db *Database) LoadReportPayout(reportPayoutID int64) (*models.ReportPayout, error) {
 	logger.Tracef("Querying database for report payout with rpid = %d...", reportPayoutID)
 
-	row := db.db.QueryRow("SELECT id, report_id, player_id, payout, payout_complete FROM reportpayouts WHERE id = ?", reportPayoutID)
+	row := db.db.QueryRow("SELECT id, report_id, player_id, payout, payout_complete, payer_id FROM reportpayouts WHERE id = ?", reportPayoutID)
 
 	var rpid, rid, pid int64
+	var payerID sql.NullInt64
 	var recordPayoutPayout float64
 	var recordPayoutPayoutCompleteEnumString string
 	var recordPayoutPayoutComplete bool
 
-	err := row.Scan(&rpid, &rid, &pid, &recordPayoutPayout, &recordPayoutPayoutCompleteEnumString)
+	err := row.Scan(&rpid, &rid, &pid, &recordPayoutPayout, &recordPayoutPayoutCompleteEnumString, &payerID)
 	if err != nil {
 		return &models.ReportPayout{}, err
 	}
@@ -905,12 +1160,21 @@ func (db *Database) LoadReportPayout(reportPayoutID int64) (*models.ReportPayout
 		recordPayoutPayoutComplete = false
 	}
 
-	player, err := database.LoadPlayer(pid)
+	player, err := db.LoadPlayer(pid)
 	if err != nil {
 		return &models.ReportPayout{}, err
 	}
 
-	reportPayout := models.NewReportPayout(rpid, rid, player, recordPayoutPayout, recordPayoutPayoutComplete)
+	var payer *models.Player
+
+	if payerID.Valid {
+		payer, err = db.LoadPlayer(payerID.Int64)
+		if err != nil {
+			return &models.ReportPayout{}, err
+		}
+	}
+
+	reportPayout := models.NewReportPayout(rpid, rid, player, recordPayoutPayout, recordPayoutPayoutComplete, payer)
 
 	return reportPayout, nil
 }
@@ -920,18 +1184,19 @@ func (db *Database) LoadAllReportPayouts(reportID int64) ([]*models.ReportPayout
 
 	var reportPayouts []*models.ReportPayout
 
-	rows, err := db.db.Query("SELECT id, report_id, player_id, payout, payout_complete FROM reportpayouts WHERE report_id = ?", reportID)
+	rows, err := db.db.Query("SELECT id, report_id, player_id, payout, payout_complete, payer_id FROM reportpayouts WHERE report_id = ?", reportID)
 	if err != nil {
 		return reportPayouts, err
 	}
 
 	for rows.Next() {
 		var rpid, rid, pid int64
+		var payerID sql.NullInt64
 		var recordPayoutPayout float64
 		var recordPayoutPayoutCompleteEnumString string
 		var recordPayoutPayoutComplete bool
 
-		err := rows.Scan(&rpid, &rid, &pid, &recordPayoutPayout, &recordPayoutPayoutCompleteEnumString)
+		err := rows.Scan(&rpid, &rid, &pid, &recordPayoutPayout, &recordPayoutPayoutCompleteEnumString, &payerID)
 		if err != nil {
 			return reportPayouts, err
 		}
@@ -942,12 +1207,21 @@ func (db *Database) LoadAllReportPayouts(reportID int64) ([]*models.ReportPayout
 			recordPayoutPayoutComplete = false
 		}
 
-		player, err := database.LoadPlayer(pid)
+		player, err := db.LoadPlayer(pid)
 		if err != nil {
 			return reportPayouts, err
 		}
 
-		reportPayout := models.NewReportPayout(rpid, rid, player, recordPayoutPayout, recordPayoutPayoutComplete)
+		var payer *models.Player
+
+		if payerID.Valid {
+			payer, err = db.LoadPlayer(payerID.Int64)
+			if err != nil {
+				return reportPayouts, err
+			}
+		}
+
+		reportPayout := models.NewReportPayout(rpid, rid, player, recordPayoutPayout, recordPayoutPayoutComplete, payer)
 
 		reportPayouts = append(reportPayouts, reportPayout)
 	}
@@ -958,17 +1232,77 @@ func (db *Database) LoadAllReportPayouts(reportID int64) ([]*models.ReportPayout
 func (db *Database) SaveReportPayout(reportPayout *models.ReportPayout) (*models.ReportPayout, error) {
 	logger.Tracef("Saving report payout #%d to database...", reportPayout.ID)
 
-	var recordPayoutCompleteEnumString string
-
+	reportPayout, err := db.saveReportPayout(db.db, reportPayout)
+	if err != nil {
+		return reportPayout, err
+	}
+
+	db.reports.Delete(reportPayout.ReportID)
+
+	return reportPayout, nil
+}
+
+// CompleteReportPayout only marks the payout and the matching fleet members as paid, leaving the rest of the report untouched, and records the transfer that paid it in the same transaction
+func (db *Database) CompleteReportPayout(reportPayout *models.ReportPayout, transfer *models.WalletTransfer) error {
+	logger.Tracef("Completing report payout #%d with wallet transfer %d in database...", reportPayout.ID, transfer.RefID)
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE reportpayouts SET payout_complete = 'Y' WHERE id = ?", reportPayout.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE fleetmembers SET payout_complete = 'Y' WHERE report_id = ? AND player_id = ?", reportPayout.ReportID, reportPayout.Player.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = db.saveWalletTransfer(tx, transfer)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	db.fleetMembers.DeleteWhere(func(v interface{}) bool { return v.(*models.FleetMember).ReportID == reportPayout.ReportID })
+	db.fleets.DeleteWhere(func(v interface{}) bool { return v.(*models.Fleet).ReportID == reportPayout.ReportID })
+	db.reports.Delete(reportPayout.ReportID)
+
+	return nil
+}
+
+func (db *Database) saveReportPayout(q querier, reportPayout *models.ReportPayout) (*models.ReportPayout, error) {
+	var recordPayoutCompleteEnumString string
+
 	if reportPayout.PayoutComplete {
 		recordPayoutCompleteEnumString = "Y"
 	} else {
 		recordPayoutCompleteEnumString = "N"
 	}
 
-	_, err := db.LoadReportPayout(reportPayout.ID)
-	if err == sql.ErrNoRows {
-		result, err := db.db.Exec("INSERT INTO reportpayouts(report_id, player_id, payout, payout_complete) VALUES (?, ?, ?, ?)", reportPayout.ReportID, reportPayout.Player.ID, reportPayout.Payout, recordPayoutCompleteEnumString)
+	var payerID sql.NullInt64
+
+	if reportPayout.HasPayer() {
+		payerID = nullableID(reportPayout.Payer.ID)
+	}
+
+	exists, err := rowExists(q, "SELECT COUNT(*) FROM reportpayouts WHERE id = ?", reportPayout.ID)
+	if err != nil {
+		return reportPayout, err
+	}
+
+	if !exists {
+		result, err := q.Exec("INSERT INTO reportpayouts(report_id, player_id, payout, payout_complete, payer_id) VALUES (?, ?, ?, ?, ?)", reportPayout.ReportID, reportPayout.Player.ID, reportPayout.Payout, recordPayoutCompleteEnumString, payerID)
 		if err != nil {
 			return reportPayout, err
 		}
@@ -979,13 +1313,11 @@ func (db *Database) SaveReportPayout(reportPayout *models.ReportPayout) (*models
 		}
 
 		reportPayout.ID = id
-	} else if err == nil {
-		_, err := db.db.Exec("UPDATE reportpayouts SET payout = ?, payout_complete = ? WHERE id = ?", reportPayout.Payout, recordPayoutCompleteEnumString, reportPayout.ID)
+	} else {
+		_, err := q.Exec("UPDATE reportpayouts SET payout = ?, payout_complete = ?, payer_id = ? WHERE id = ?", reportPayout.Payout, recordPayoutCompleteEnumString, payerID, reportPayout.ID)
 		if err != nil {
 			return reportPayout, err
 		}
-	} else {
-		return reportPayout, err
 	}
 
 	return reportPayout, nil
@@ -994,10 +1326,10 @@ func (db *Database) SaveReportPayout(reportPayout *models.ReportPayout) (*models
 func (db *Database) LoadReport(id int64) (*models.Report, error) {
 	logger.Tracef("Querying database for report with rid = %d...", id)
 
-	report, ok := db.reports[id]
+	cached, ok := db.reports.Get(id)
 	if ok {
 		logger.Tracef("Report with rid = %d found in cache, returning...", id)
-		return report, nil
+		return cached.(*models.Report), nil
 	}
 
 	row := db.db.QueryRow("SELECT id, corporation_id, creator, total_payout, starttime, endtime, payout_complete FROM reports WHERE id=?", id)
@@ -1005,7 +1337,8 @@ func (db *Database) LoadReport(id int64) (*models.Report, error) {
 	var rid, cid, pid int64
 	var recordTotalPayout float64
 	var recordPayoutCompleteEnumString string
-	var recordStartTime, recordEndTime time.Time
+	var recordStartTime time.Time
+	var recordEndTime *time.Time
 	var recordPayoutComplete bool
 
 	err := row.Scan(&rid, &cid, &pid, &recordTotalPayout, &recordStartTime, &recordEndTime, &recordPayoutCompleteEnumString)
@@ -1013,23 +1346,27 @@ func (db *Database) LoadReport(id int64) (*models.Report, error) {
 		return &models.Report{}, err
 	}
 
+	if recordEndTime == nil {
+		recordEndTime = &time.Time{}
+	}
+
 	if strings.EqualFold(recordPayoutCompleteEnumString, "y") {
 		recordPayoutComplete = true
 	} else {
 		recordPayoutComplete = false
 	}
 
-	fleets, err := database.LoadAllFleetsForReport(rid)
+	fleets, err := db.LoadAllFleetsForReport(rid)
 	if err != nil {
 		return &models.Report{}, err
 	}
 
-	corporation, err := database.LoadCorporation(cid)
+	corporation, err := db.LoadCorporation(cid)
 	if err != nil {
 		return &models.Report{}, err
 	}
 
-	player, err := database.LoadPlayer(pid)
+	player, err := db.LoadPlayer(pid)
 	if err != nil {
 		return &models.Report{}, err
 	}
@@ -1039,13 +1376,13 @@ func (db *Database) LoadReport(id int64) (*models.Report, error) {
 		return &models.Report{}, err
 	}
 
-	report = models.NewReport(rid, recordTotalPayout, recordStartTime, recordEndTime, recordPayoutComplete, corporation, player, fleets)
+	report := models.NewReport(rid, recordTotalPayout, recordStartTime, *recordEndTime, recordPayoutComplete, corporation, player, fleets)
 
 	for _, payout := range reportPayouts {
 		report.Payouts[payout.Player.Name] = payout
 	}
 
-	db.reports[report.ID] = report
+	db.reports.Set(report.ID, report)
 
 	return report, nil
 }
@@ -1064,7 +1401,8 @@ func (db *Database) LoadAllReports(corporationID int64) ([]*models.Report, error
 		var rid, cid, pid int64
 		var recordTotalPayout float64
 		var recordPayoutCompleteEnumString string
-		var recordStartTime, recordEndTime time.Time
+		var recordStartTime time.Time
+		var recordEndTime *time.Time
 		var recordPayoutComplete bool
 
 		err := rows.Scan(&rid, &cid, &pid, &recordTotalPayout, &recordStartTime, &recordEndTime, &recordPayoutCompleteEnumString)
@@ -1072,23 +1410,27 @@ func (db *Database) LoadAllReports(corporationID int64) ([]*models.Report, error
 			return reports, err
 		}
 
+		if recordEndTime == nil {
+			recordEndTime = &time.Time{}
+		}
+
 		if strings.EqualFold(recordPayoutCompleteEnumString, "y") {
 			recordPayoutComplete = true
 		} else {
 			recordPayoutComplete = false
 		}
 
-		fleets, err := database.LoadAllFleetsForReport(rid)
+		fleets, err := db.LoadAllFleetsForReport(rid)
 		if err != nil {
 			return reports, err
 		}
 
-		corporation, err := database.LoadCorporation(cid)
+		corporation, err := db.LoadCorporation(cid)
 		if err != nil {
 			return reports, err
 		}
 
-		player, err := database.LoadPlayer(pid)
+		player, err := db.LoadPlayer(pid)
 		if err != nil {
 			return reports, err
 		}
@@ -1098,13 +1440,13 @@ func (db *Database) LoadAllReports(corporationID int64) ([]*models.Report, error
 			return reports, err
 		}
 
-		report := models.NewReport(rid, recordTotalPayout, recordStartTime, recordEndTime, recordPayoutComplete, corporation, player, fleets)
+		report := models.NewReport(rid, recordTotalPayout, recordStartTime, *recordEndTime, recordPayoutComplete, corporation, player, fleets)
 
 		for _, payout := range reportPayouts {
 			report.Payouts[payout.Player.Name] = payout
 		}
 
-		db.reports[report.ID] = report
+		db.reports.Set(report.ID, report)
 
 		reports = append(reports, report)
 	}
@@ -1115,6 +1457,38 @@ func (db *Database) LoadAllReports(corporationID int64) ([]*models.Report, error
 func (db *Database) SaveReport(report *models.Report) (*models.Report, error) {
 	logger.Tracef("Saving report #%d to database...", report.ID)
 
+	tx, err := db.db.Begin()
+	if err != nil {
+		return report, err
+	}
+
+	reportID := report.ID
+
+	report, err = db.saveReport(tx, report)
+	if err != nil {
+		tx.Rollback()
+		db.evictReport(report)
+		report.ID = reportID
+		return report, fmt.Errorf("Failed to save report, rolled back all changes: [%v]", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		db.evictReport(report)
+		report.ID = reportID
+		return report, err
+	}
+
+	for _, fleet := range report.Fleets {
+		db.cacheFleet(fleet)
+	}
+
+	db.reports.Set(report.ID, report)
+
+	return report, nil
+}
+
+func (db *Database) saveReport(q querier, report *models.Report) (*models.Report, error) {
 	var reportPayoutCompleteEnum string
 
 	if report.PayoutComplete {
@@ -1123,9 +1497,20 @@ func (db *Database) SaveReport(report *models.Report) (*models.Report, error) {
 		reportPayoutCompleteEnum = "N"
 	}
 
-	_, err := db.LoadReport(report.ID)
-	if err == sql.ErrNoRows {
-		result, err := db.db.Exec("INSERT INTO reports(corporation_id, creator, total_payout, starttime, endtime, payout_complete) VALUES (?, ?, ?, ?, ?, ?)", report.Corporation.ID, report.Creator.ID, report.TotalPayout, report.StartRange, report.EndRange, reportPayoutCompleteEnum)
+	var reportEndTime *time.Time
+	if !report.EndRange.IsZero() {
+		reportEndTime = &report.EndRange
+	} else {
+		reportEndTime = nil
+	}
+
+	exists, err := rowExists(q, "SELECT COUNT(*) FROM reports WHERE id = ?", report.ID)
+	if err != nil {
+		return report, err
+	}
+
+	if !exists {
+		result, err := q.Exec("INSERT INTO reports(corporation_id, creator, total_payout, starttime, endtime, payout_complete) VALUES (?, ?, ?, ?, ?, ?)", report.Corporation.ID, report.Creator.ID, report.TotalPayout, report.StartRange, reportEndTime, reportPayoutCompleteEnum)
 		if err != nil {
 			return report, err
 		}
@@ -1144,51 +1529,114 @@ func (db *Database) SaveReport(report *models.Report) (*models.Report, error) {
 				member.ReportID = report.ID
 			}
 
-			f, err := database.SaveFleet(fleet)
+			_, err := db.saveFleet(q, fleet)
 			if err != nil {
 				return report, err
 			}
+		}
+	} else {
+		_, err := q.Exec("UPDATE reports SET corporation_id=?, creator=?, total_payout=?, starttime=?, endtime=?, payout_complete=? WHERE id = ?", report.Corporation.ID, report.Creator.ID, report.TotalPayout, report.StartRange, reportEndTime, reportPayoutCompleteEnum, report.ID)
+		if err != nil {
+			return report, err
+		}
+	}
+
+	for _, reportPayout := range report.Payouts {
+		reportPayout.ReportID = report.ID
 
-			fleet = f
+		_, err := db.saveReportPayout(q, reportPayout)
+		if err != nil {
+			return report, err
 		}
-	} else if err == nil {
-		_, err := db.db.Exec("UPDATE reports SET corporation_id=?, creator=?, total_payout=?, starttime=?, endtime=?, payout_complete=? WHERE id = ?", report.Corporation.ID, report.Creator.ID, report.TotalPayout, report.StartRange, report.EndRange, reportPayoutCompleteEnum, report.ID)
+	}
+
+	for _, reportPayout := range report.Payouts {
+		if !reportPayout.PayoutComplete && !report.PayoutComplete {
+			continue
+		}
+
+		_, err := q.Exec("UPDATE fleetmembers SET payout_complete = 'Y' WHERE report_id = ? AND player_id = ?", report.ID, reportPayout.Player.ID)
 		if err != nil {
 			return report, err
 		}
 
-		for _, reportPayout := range report.Payouts {
-			reportPayout, err = db.SaveReportPayout(reportPayout)
-			if err != nil {
-				return report, err
+		for _, fleet := range report.Fleets {
+			member, ok := fleet.Members[reportPayout.Player.Name]
+			if ok {
+				member.PayoutComplete = true
 			}
 		}
+	}
 
-		if report.PayoutComplete {
-			for _, reportPayout := range report.Payouts {
-				fleetMembers, err := db.LoadAllFleetMembersForReportPlayer(reportPayout.ReportID, reportPayout.Player.ID)
-				if err != nil {
-					return report, err
-				}
+	return report, nil
+}
 
-				for _, member := range fleetMembers {
-					member.PayoutComplete = true
+func (db *Database) invalidateCorporation(corporationID int64) {
+	db.players.DeleteWhere(func(v interface{}) bool {
+		player := v.(*models.Player)
+		return player.Corp != nil && player.Corp.ID == corporationID
+	})
+	db.fleetMembers.DeleteWhere(func(v interface{}) bool {
+		member := v.(*models.FleetMember)
+		return member.Player != nil && member.Corp != nil && member.Corp.ID == corporationID
+	})
+	db.fleets.DeleteWhere(func(v interface{}) bool {
+		return v.(*models.Fleet).Corporation.ID == corporationID
+	})
+	db.reports.DeleteWhere(func(v interface{}) bool {
+		return v.(*models.Report).Corporation.ID == corporationID
+	})
+}
 
-					_, err := db.SaveFleetMember(member.FleetID, member)
-					if err != nil {
-						return report, err
-					}
-				}
+func (db *Database) invalidatePlayer(playerID int64) {
+	db.fleetMembers.DeleteWhere(func(v interface{}) bool {
+		member := v.(*models.FleetMember)
+		return member.Player != nil && member.Player.ID == playerID
+	})
+	db.fleets.DeleteWhere(func(v interface{}) bool {
+		for _, member := range v.(*models.Fleet).Members {
+			if member.Player != nil && member.Player.ID == playerID {
+				return true
 			}
+		}
 
+		return false
+	})
+	db.reports.DeleteWhere(func(v interface{}) bool {
+		report := v.(*models.Report)
+		if report.Creator != nil && report.Creator.ID == playerID {
+			return true
+		}
+
+		for _, payout := range report.Payouts {
+			if payout.Player != nil && payout.Player.ID == playerID {
+				return true
+			}
 		}
-	} else {
-		return report, err
-	}
 
-	db.reports[report.ID] = report
+		return false
+	})
+}
 
-	return report, nil
+func (db *Database) invalidateFleet(fleetID int64) {
+	db.fleets.Delete(fleetID)
+	db.reports.DeleteWhere(func(v interface{}) bool {
+		for _, fleet := range v.(*models.Report).Fleets {
+			if fleet.ID == fleetID {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+func (db *Database) evictReport(report *models.Report) {
+	for _, fleet := range report.Fleets {
+		db.evictFleet(fleet)
+	}
+
+	db.reports.Delete(report.ID)
 }
 
 func (db *Database) QueryShipRole(ship string) (models.FleetRole, error) {
@@ -1206,55 +1654,1261 @@ func (db *Database) QueryShipRole(ship string) (models.FleetRole, error) {
 	return models.FleetRole(fleetMemberRole), nil
 }
 
-func (db *Database) LoadLootPaste(id int64) (*models.LootPaste, error) {
-	logger.Tracef("Querying database for loot paste with id = %d...", id)
+func (db *Database) LoadShipRole(id int64) (*models.ShipRole, error) {
+	logger.Tracef("Querying database for ship role with id = %d...", id)
 
-	row := db.db.QueryRow("SELECT id, fleet_id, pasted_by, raw_paste, value, paste_type FROM lootpastes WHERE id = ?", id)
+	row := db.db.QueryRow("SELECT id, ship, fleet_role FROM fleetroles WHERE id = ?", id)
 
-	var lid, lootPasteFleetID, lootPastePastedBy int64
-	var lootPasteRawPaste string
-	var lootPasteValue float64
-	var lootPastePasteType int
+	var sid int64
+	var shipRoleShip string
+	var shipRoleRole int
 
-	err := row.Scan(&lid, &lootPasteFleetID, &lootPastePastedBy, &lootPasteRawPaste, &lootPasteValue, &lootPastePasteType)
+	err := row.Scan(&sid, &shipRoleShip, &shipRoleRole)
 	if err != nil {
-		return &models.LootPaste{}, err
+		return &models.ShipRole{}, err
 	}
 
-	return models.NewLootPaste(lid, lootPasteFleetID, lootPastePastedBy, lootPasteRawPaste, lootPasteValue, models.LootPasteType(lootPastePasteType)), nil
+	return models.NewShipRole(sid, shipRoleShip, models.FleetRole(shipRoleRole)), nil
 }
 
-func (db *Database) SaveLootPaste(paste *models.LootPaste) (*models.LootPaste, error) {
-	logger.Tracef("Saving loot paste #%d to database...", paste.ID)
+func (db *Database) LoadAllShipRoles() ([]*models.ShipRole, error) {
+	logger.Tracef("Querying database for all ship roles...")
+
+	shipRoles := make([]*models.ShipRole, 0)
+
+	rows, err := db.db.Query("SELECT id, ship, fleet_role FROM fleetroles ORDER BY ship")
+	if err != nil {
+		return shipRoles, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var sid int64
+		var shipRoleShip string
+		var shipRoleRole int
+
+		err := rows.Scan(&sid, &shipRoleShip, &shipRoleRole)
+		if err != nil {
+			return shipRoles, err
+		}
+
+		shipRoles = append(shipRoles, models.NewShipRole(sid, shipRoleShip, models.FleetRole(shipRoleRole)))
+	}
+
+	return shipRoles, rows.Err()
+}
+
+func (db *Database) SaveShipRole(shipRole *models.ShipRole) (*models.ShipRole, error) {
+	logger.Tracef("Saving ship role for ship %q to database...", shipRole.Ship)
+
+	return db.saveShipRole(db.db, shipRole)
+}
+
+func (db *Database) SaveShipRoles(shipRoles []*models.ShipRole) error {
+	logger.Tracef("Saving %d ship roles to database...", len(shipRoles))
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, shipRole := range shipRoles {
+		_, err = db.saveShipRole(tx, shipRole)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("Failed to save ship roles, rolled back all changes: [%v]", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (db *Database) saveShipRole(q querier, shipRole *models.ShipRole) (*models.ShipRole, error) {
+	var sid int64
+	var err error
+
+	if shipRole.ID > 0 {
+		err = q.QueryRow("SELECT id FROM fleetroles WHERE id = ?", shipRole.ID).Scan(&sid)
+	} else {
+		err = q.QueryRow("SELECT id FROM fleetroles WHERE ship = ?", shipRole.Ship).Scan(&sid)
+	}
 
-	_, err := db.LoadLootPaste(paste.ID)
 	if err == sql.ErrNoRows {
-		result, err := db.db.Exec("INSERT INTO lootpastes(fleet_id, pasted_by, raw_paste, value, paste_type) VALUES(?, ?, ?, ?, ?)", paste.FleetID, paste.PastedBy, paste.RawPaste, paste.Value, paste.PasteType)
+		result, err := q.Exec("INSERT INTO fleetroles(ship, fleet_role) VALUES (?, ?)", shipRole.Ship, shipRole.Role)
 		if err != nil {
-			return paste, err
+			return shipRole, err
 		}
 
 		id, err := result.LastInsertId()
 		if err != nil {
-			return paste, err
+			return shipRole, err
 		}
 
-		paste.ID = id
+		shipRole.ID = id
 	} else if err == nil {
-		_, err := db.db.Exec("UPDATE lootpastes SET fleet_id=?, pasted_by=?, raw_paste=?, value=?, paste_type=? WHERE id=?", paste.FleetID, paste.PastedBy, paste.RawPaste, paste.Value, paste.PasteType, paste.ID)
+		_, err := q.Exec("UPDATE fleetroles SET ship=?, fleet_role=? WHERE id=?", shipRole.Ship, shipRole.Role, sid)
+		if err != nil {
+			return shipRole, err
+		}
+
+		shipRole.ID = sid
+	} else {
+		return shipRole, err
+	}
+
+	return shipRole, nil
+}
+
+func (db *Database) DeleteShipRole(id int64) error {
+	logger.Tracef("Deleting ship role #%d from database...", id)
+
+	_, err := db.db.Exec("DELETE FROM fleetroles WHERE id = ?", id)
+
+	return err
+}
+
+func (db *Database) LoadAllFleetRoleDefinitions(corporationID int64) ([]*models.FleetRoleDefinition, error) {
+	logger.Tracef("Querying database for all fleet role definitions for corporation #%d...", corporationID)
+
+	definitions := make([]*models.FleetRoleDefinition, 0)
+
+	rows, err := db.db.Query("SELECT id, corporation_id, role, name, payment_rate, label_type FROM corporationroles WHERE corporation_id = ? ORDER BY role", corporationID)
+	if err != nil {
+		return definitions, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var did, cid int64
+		var definitionRole int
+		var definitionName, definitionLabelType string
+		var definitionPaymentRate float64
+
+		err := rows.Scan(&did, &cid, &definitionRole, &definitionName, &definitionPaymentRate, &definitionLabelType)
+		if err != nil {
+			return definitions, err
+		}
+
+		definitions = append(definitions, models.NewFleetRoleDefinition(did, cid, models.FleetRole(definitionRole), definitionName, definitionPaymentRate, definitionLabelType))
+	}
+
+	return definitions, rows.Err()
+}
+
+func (db *Database) loadCorporationFleetRoles(corp *models.Corporation) error {
+	definitions, err := db.LoadAllFleetRoleDefinitions(corp.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, definition := range definitions {
+		corp.Roles[definition.Role] = definition
+	}
+
+	return nil
+}
+
+func (db *Database) SaveFleetRoleDefinition(definition *models.FleetRoleDefinition) (*models.FleetRoleDefinition, error) {
+	logger.Tracef("Saving fleet role %d for corporation #%d to database...", definition.Role, definition.CorporationID)
+
+	exists, err := rowExists(db.db, "SELECT COUNT(*) FROM corporationroles WHERE corporation_id = ? AND role = ?", definition.CorporationID, definition.Role)
+	if err != nil {
+		return definition, err
+	}
+
+	if !exists {
+		result, err := db.db.Exec("INSERT INTO corporationroles(corporation_id, role, name, payment_rate, label_type) VALUES (?, ?, ?, ?, ?)", definition.CorporationID, definition.Role, definition.Name, definition.PaymentRate, definition.LabelType)
+		if err != nil {
+			return definition, err
+		}
+
+		id, err := result.LastInsertId()
 		if err != nil {
-			return paste, err
+			return definition, err
 		}
+
+		definition.ID = id
 	} else {
-		return paste, nil
+		_, err := db.db.Exec("UPDATE corporationroles SET name=?, payment_rate=?, label_type=? WHERE corporation_id=? AND role=?", definition.Name, definition.PaymentRate, definition.LabelType, definition.CorporationID, definition.Role)
+		if err != nil {
+			return definition, err
+		}
+	}
+
+	db.corporations.Delete(definition.CorporationID)
+	db.invalidateCorporation(definition.CorporationID)
+
+	return definition, nil
+}
+
+func (db *Database) DeleteFleetRoleDefinition(corporationID int64, role models.FleetRole) error {
+	logger.Tracef("Deleting fleet role %d for corporation #%d from database...", role, corporationID)
+
+	_, err := db.db.Exec("DELETE FROM corporationroles WHERE corporation_id = ? AND role = ?", corporationID, role)
+	if err != nil {
+		return err
+	}
+
+	db.corporations.Delete(corporationID)
+	db.invalidateCorporation(corporationID)
+
+	return nil
+}
+
+func (db *Database) IsFleetRoleInUse(corporationID int64, role models.FleetRole) (bool, error) {
+	logger.Tracef("Querying database for usage of fleet role %d for corporation #%d...", role, corporationID)
+
+	return rowExists(db.db, "SELECT COUNT(*) FROM fleetmembers INNER JOIN fleets ON fleets.id = fleetmembers.fleet_id WHERE fleets.corporation_id = ? AND fleetmembers.role = ?", corporationID, role)
+}
+
+func (db *Database) LoadLootPaste(id int64) (*models.LootPaste, error) {
+	logger.Tracef("Querying database for loot paste with id = %d...", id)
+
+	row := db.db.QueryRow("SELECT id, fleet_id, pasted_by, raw_paste, value, paste_type, voided, site_id FROM lootpastes WHERE id = ?", id)
+
+	var lid, lootPasteFleetID, lootPastePastedBy int64
+	var lootPasteRawPaste, lootPasteVoidedEnumString string
+	var lootPasteValue float64
+	var lootPastePasteType int
+	var sqlSiteID sql.NullInt64
+
+	err := row.Scan(&lid, &lootPasteFleetID, &lootPastePastedBy, &lootPasteRawPaste, &lootPasteValue, &lootPastePasteType, &lootPasteVoidedEnumString, &sqlSiteID)
+	if err != nil {
+		return &models.LootPaste{}, err
+	}
+
+	paste := models.NewLootPaste(lid, lootPasteFleetID, lootPastePastedBy, lootPasteRawPaste, lootPasteValue, models.LootPasteType(lootPastePasteType))
+	paste.Voided = strings.EqualFold(lootPasteVoidedEnumString, "y")
+
+	if sqlSiteID.Valid {
+		paste.SiteID = sqlSiteID.Int64
+	}
+
+	paste.Items, err = db.LoadAllLootPasteItems(paste.ID)
+	if err != nil {
+		return paste, err
 	}
 
 	return paste, nil
 }
 
-func (db *Database) RemovePlayerFromCache(id int64) {
-	_, ok := db.players[id]
-	if ok {
-		delete(db.players, id)
+func (db *Database) LoadAllLootPastes(fleetID int64) ([]*models.LootPaste, error) {
+	logger.Tracef("Querying database for all loot pastes with fleet_id = %d...", fleetID)
+
+	var pastes []*models.LootPaste
+
+	rows, err := db.db.Query("SELECT id, fleet_id, pasted_by, raw_paste, value, paste_type, voided, site_id FROM lootpastes WHERE fleet_id = ? ORDER BY id", fleetID)
+	if err != nil {
+		return pastes, err
 	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var lid, lootPasteFleetID, lootPastePastedBy int64
+		var lootPasteRawPaste, lootPasteVoidedEnumString string
+		var lootPasteValue float64
+		var lootPastePasteType int
+		var sqlSiteID sql.NullInt64
+
+		err := rows.Scan(&lid, &lootPasteFleetID, &lootPastePastedBy, &lootPasteRawPaste, &lootPasteValue, &lootPastePasteType, &lootPasteVoidedEnumString, &sqlSiteID)
+		if err != nil {
+			return pastes, err
+		}
+
+		paste := models.NewLootPaste(lid, lootPasteFleetID, lootPastePastedBy, lootPasteRawPaste, lootPasteValue, models.LootPasteType(lootPastePasteType))
+		paste.Voided = strings.EqualFold(lootPasteVoidedEnumString, "y")
+
+		if sqlSiteID.Valid {
+			paste.SiteID = sqlSiteID.Int64
+		}
+
+		paste.Items, err = db.LoadAllLootPasteItems(paste.ID)
+		if err != nil {
+			return pastes, err
+		}
+
+		pastes = append(pastes, paste)
+	}
+
+	return pastes, rows.Err()
+}
+
+func (db *Database) LoadAllLootPasteItems(lootPasteID int64) ([]*models.LootPasteItem, error) {
+	logger.Tracef("Querying database for all items of loot paste #%d...", lootPasteID)
+
+	items := make([]*models.LootPasteItem, 0)
+
+	rows, err := db.db.Query("SELECT id, lootpaste_id, type_id, name, group_name, quantity, unit_price, total, volume FROM lootpasteitems WHERE lootpaste_id = ? ORDER BY total DESC", lootPasteID)
+	if err != nil {
+		return items, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var iid, lootPasteItemPasteID, lootPasteItemTypeID, lootPasteItemQuantity int64
+		var lootPasteItemName, lootPasteItemGroupName string
+		var lootPasteItemUnitPrice, lootPasteItemTotal, lootPasteItemVolume float64
+
+		err := rows.Scan(&iid, &lootPasteItemPasteID, &lootPasteItemTypeID, &lootPasteItemName, &lootPasteItemGroupName, &lootPasteItemQuantity, &lootPasteItemUnitPrice, &lootPasteItemTotal, &lootPasteItemVolume)
+		if err != nil {
+			return items, err
+		}
+
+		items = append(items, models.NewLootPasteItem(iid, lootPasteItemPasteID, lootPasteItemTypeID, lootPasteItemName, lootPasteItemGroupName, lootPasteItemQuantity, lootPasteItemUnitPrice, lootPasteItemTotal, lootPasteItemVolume))
+	}
+
+	return items, rows.Err()
+}
+
+func (db *Database) SaveLootPaste(paste *models.LootPaste) (*models.LootPaste, error) {
+	logger.Tracef("Saving loot paste #%d to database...", paste.ID)
+
+	id := paste.ID
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return paste, err
+	}
+
+	err = db.saveLootPaste(tx, paste)
+	if err == nil {
+		err = tx.Commit()
+	} else {
+		tx.Rollback()
+	}
+	if err != nil {
+		paste.ID = id
+		return paste, fmt.Errorf("Failed to save loot paste, rolled back all changes: [%v]", err)
+	}
+
+	db.invalidateFleet(paste.FleetID)
+
+	return paste, nil
+}
+
+func (db *Database) saveLootPaste(q querier, paste *models.LootPaste) error {
+	exists, err := rowExists(q, "SELECT COUNT(*) FROM lootpastes WHERE id = ?", paste.ID)
+	if err != nil {
+		return err
+	}
+
+	var lootPasteVoidedEnumString string
+
+	if paste.Voided {
+		lootPasteVoidedEnumString = "Y"
+	} else {
+		lootPasteVoidedEnumString = "N"
+	}
+
+	var lootPasteSiteID sql.NullInt64
+
+	if paste.SiteID > 0 {
+		lootPasteSiteID.Int64 = paste.SiteID
+		lootPasteSiteID.Valid = true
+	}
+
+	if exists {
+		_, err := q.Exec("UPDATE lootpastes SET fleet_id=?, pasted_by=?, raw_paste=?, value=?, paste_type=?, voided=?, site_id=? WHERE id=?", paste.FleetID, paste.PastedBy, paste.RawPaste, paste.Value, paste.PasteType, lootPasteVoidedEnumString, lootPasteSiteID, paste.ID)
+		if err != nil {
+			return err
+		}
+	} else {
+		result, err := q.Exec("INSERT INTO lootpastes(fleet_id, pasted_by, raw_paste, value, paste_type, voided, site_id) VALUES(?, ?, ?, ?, ?, ?, ?)", paste.FleetID, paste.PastedBy, paste.RawPaste, paste.Value, paste.PasteType, lootPasteVoidedEnumString, lootPasteSiteID)
+		if err != nil {
+			return err
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		paste.ID = id
+	}
+
+	_, err = q.Exec("DELETE FROM lootpasteitems WHERE lootpaste_id = ?", paste.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range paste.Items {
+		result, err := q.Exec("INSERT INTO lootpasteitems(lootpaste_id, type_id, name, group_name, quantity, unit_price, total, volume) VALUES(?, ?, ?, ?, ?, ?, ?, ?)", paste.ID, item.TypeID, item.Name, item.GroupName, item.Quantity, item.UnitPrice, item.Total, item.Volume)
+		if err != nil {
+			return err
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		item.ID = id
+		item.LootPasteID = paste.ID
+	}
+
+	return nil
+}
+
+func (db *Database) LoadFleetSite(id int64) (*models.FleetSite, error) {
+	logger.Tracef("Querying database for fleet site with id = %d...", id)
+
+	row := db.db.QueryRow("SELECT id, fleet_id, name, finished, escalation, voided, ticked_by FROM fleetsites WHERE id = ?", id)
+
+	site, err := scanFleetSite(row)
+	if err != nil {
+		return &models.FleetSite{}, err
+	}
+
+	site.MemberIDs, err = db.loadFleetSiteMembers(site.ID)
+	if err != nil {
+		return site, err
+	}
+
+	return site, nil
+}
+
+func (db *Database) LoadAllFleetSites(fleetID int64) ([]*models.FleetSite, error) {
+	logger.Tracef("Querying database for all sites with fleet_id = %d...", fleetID)
+
+	sites := make([]*models.FleetSite, 0)
+
+	rows, err := db.db.Query("SELECT id, fleet_id, name, finished, escalation, voided, ticked_by FROM fleetsites WHERE fleet_id = ? ORDER BY finished, id", fleetID)
+	if err != nil {
+		return sites, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		site, err := scanFleetSite(rows)
+		if err != nil {
+			return sites, err
+		}
+
+		sites = append(sites, site)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return sites, err
+	}
+
+	for _, site := range sites {
+		site.MemberIDs, err = db.loadFleetSiteMembers(site.ID)
+		if err != nil {
+			return sites, err
+		}
+	}
+
+	return sites, nil
+}
+
+func scanFleetSite(row scanner) (*models.FleetSite, error) {
+	var sid, fid int64
+	var siteName, siteEscalationEnumString, siteVoidedEnumString string
+	var siteFinished time.Time
+	var sqlTickedBy sql.NullInt64
+
+	err := row.Scan(&sid, &fid, &siteName, &siteFinished, &siteEscalationEnumString, &siteVoidedEnumString, &sqlTickedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	tickedBy := int64(-1)
+	if sqlTickedBy.Valid {
+		tickedBy = sqlTickedBy.Int64
+	}
+
+	site := models.NewFleetSite(sid, fid, siteName, siteFinished, strings.EqualFold(siteEscalationEnumString, "y"), tickedBy, nil)
+	site.Voided = strings.EqualFold(siteVoidedEnumString, "y")
+
+	return site, nil
+}
+
+func (db *Database) loadFleetSiteMembers(siteID int64) ([]int64, error) {
+	members := make([]int64, 0)
+
+	rows, err := db.db.Query("SELECT fleetmember_id FROM fleetsitemembers WHERE fleetsite_id = ? ORDER BY fleetmember_id", siteID)
+	if err != nil {
+		return members, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var fmid int64
+
+		err = rows.Scan(&fmid)
+		if err != nil {
+			return members, err
+		}
+
+		members = append(members, fmid)
+	}
+
+	return members, rows.Err()
+}
+
+func (db *Database) SaveFleetSite(site *models.FleetSite) (*models.FleetSite, error) {
+	logger.Tracef("Saving fleet site #%d to database...", site.ID)
+
+	id := site.ID
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return site, err
+	}
+
+	err = db.saveFleetSite(tx, site)
+	if err == nil {
+		err = tx.Commit()
+	} else {
+		tx.Rollback()
+	}
+	if err != nil {
+		site.ID = id
+		return site, fmt.Errorf("Failed to save fleet site, rolled back all changes: [%v]", err)
+	}
+
+	db.invalidateFleet(site.FleetID)
+
+	return site, nil
+}
+
+func (db *Database) saveFleetSite(q querier, site *models.FleetSite) error {
+	exists, err := rowExists(q, "SELECT COUNT(*) FROM fleetsites WHERE id = ?", site.ID)
+	if err != nil {
+		return err
+	}
+
+	var siteEscalationEnumString, siteVoidedEnumString string
+
+	if site.Escalation {
+		siteEscalationEnumString = "Y"
+	} else {
+		siteEscalationEnumString = "N"
+	}
+
+	if site.Voided {
+		siteVoidedEnumString = "Y"
+	} else {
+		siteVoidedEnumString = "N"
+	}
+
+	var siteTickedBy sql.NullInt64
+
+	if site.TickedBy > 0 {
+		siteTickedBy.Int64 = site.TickedBy
+		siteTickedBy.Valid = true
+	}
+
+	if exists {
+		_, err := q.Exec("UPDATE fleetsites SET fleet_id=?, name=?, finished=?, escalation=?, voided=?, ticked_by=? WHERE id=?", site.FleetID, site.Name, site.Finished, siteEscalationEnumString, siteVoidedEnumString, siteTickedBy, site.ID)
+		if err != nil {
+			return err
+		}
+	} else {
+		result, err := q.Exec("INSERT INTO fleetsites(fleet_id, name, finished, escalation, voided, ticked_by) VALUES(?, ?, ?, ?, ?, ?)", site.FleetID, site.Name, site.Finished, siteEscalationEnumString, siteVoidedEnumString, siteTickedBy)
+		if err != nil {
+			return err
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		site.ID = id
+	}
+
+	_, err = q.Exec("DELETE FROM fleetsitemembers WHERE fleetsite_id = ?", site.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, memberID := range site.MemberIDs {
+		_, err := q.Exec("INSERT INTO fleetsitemembers(fleetsite_id, fleetmember_id) VALUES(?, ?)", site.ID, memberID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (db *Database) LoadItemTypeFromName(name string) (*models.ItemType, error) {
+	logger.Tracef("Querying database for item type with name %q...", name)
+
+	row := db.db.QueryRow("SELECT t.id, t.name, t.group_name, t.volume, p.buy, p.sell, p.updated FROM itemtypes AS t INNER JOIN itemprices AS p ON p.type_id = t.id WHERE t.name = ?", name)
+
+	var tid int64
+	var itemTypeName, itemTypeGroupName string
+	var itemTypeVolume, itemPriceBuy, itemPriceSell float64
+	var itemPriceUpdated time.Time
+
+	err := row.Scan(&tid, &itemTypeName, &itemTypeGroupName, &itemTypeVolume, &itemPriceBuy, &itemPriceSell, &itemPriceUpdated)
+	if err != nil {
+		return &models.ItemType{}, err
+	}
+
+	return models.NewItemType(tid, itemTypeName, itemTypeGroupName, itemTypeVolume, itemPriceBuy, itemPriceSell, itemPriceUpdated), nil
+}
+
+func (db *Database) SaveItemType(itemType *models.ItemType) (*models.ItemType, error) {
+	logger.Tracef("Saving item type #%d to database...", itemType.ID)
+
+	err := db.saveItemType(db.db, itemType)
+	if err != nil {
+		return itemType, err
+	}
+
+	return itemType, nil
+}
+
+func (db *Database) saveItemType(q querier, itemType *models.ItemType) error {
+	exists, err := rowExists(q, "SELECT COUNT(*) FROM itemtypes WHERE id = ?", itemType.ID)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		_, err = q.Exec("UPDATE itemtypes SET name=?, group_name=?, volume=? WHERE id=?", itemType.Name, itemType.GroupName, itemType.Volume, itemType.ID)
+	} else {
+		_, err = q.Exec("INSERT INTO itemtypes(id, name, group_name, volume) VALUES(?, ?, ?, ?)", itemType.ID, itemType.Name, itemType.GroupName, itemType.Volume)
+	}
+	if err != nil {
+		return err
+	}
+
+	exists, err = rowExists(q, "SELECT COUNT(*) FROM itemprices WHERE type_id = ?", itemType.ID)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		_, err = q.Exec("UPDATE itemprices SET buy=?, sell=?, updated=? WHERE type_id=?", itemType.BuyPrice, itemType.SellPrice, itemType.PriceUpdated, itemType.ID)
+	} else {
+		_, err = q.Exec("INSERT INTO itemprices(type_id, buy, sell, updated) VALUES(?, ?, ?, ?)", itemType.ID, itemType.BuyPrice, itemType.SellPrice, itemType.PriceUpdated)
+	}
+
+	return err
+}
+
+func (db *Database) ItemPriceStatus() (int, time.Time, error) {
+	var count int
+
+	err := db.db.QueryRow("SELECT COUNT(*) FROM itemprices").Scan(&count)
+	if err != nil || count == 0 {
+		return count, time.Time{}, err
+	}
+
+	var oldest time.Time
+
+	err = db.db.QueryRow("SELECT updated FROM itemprices ORDER BY updated ASC LIMIT 1").Scan(&oldest)
+	if err != nil {
+		return count, time.Time{}, err
+	}
+
+	return count, oldest, nil
+}
+
+func (db *Database) LoadAPITokenFromHash(hash string) (*models.APIToken, error) {
+	logger.Tracef("Querying database for API token by hash...")
+
+	row := db.db.QueryRow("SELECT id, player_id, name, token_hash, created, last_used FROM apitokens WHERE token_hash = ?", hash)
+
+	var tid, apiTokenPlayerID int64
+	var apiTokenName, apiTokenHash string
+	var apiTokenCreated time.Time
+	var apiTokenLastUsed *time.Time
+
+	err := row.Scan(&tid, &apiTokenPlayerID, &apiTokenName, &apiTokenHash, &apiTokenCreated, &apiTokenLastUsed)
+	if err != nil {
+		return &models.APIToken{}, err
+	}
+
+	if apiTokenLastUsed == nil {
+		apiTokenLastUsed = &time.Time{}
+	}
+
+	return models.NewAPIToken(tid, apiTokenPlayerID, apiTokenName, apiTokenHash, apiTokenCreated, *apiTokenLastUsed), nil
+}
+
+func (db *Database) LoadAllAPITokens(playerID int64) ([]*models.APIToken, error) {
+	logger.Tracef("Querying database for all API tokens of player #%d...", playerID)
+
+	tokens := make([]*models.APIToken, 0)
+
+	rows, err := db.db.Query("SELECT id, player_id, name, token_hash, created, last_used FROM apitokens WHERE player_id = ? ORDER BY created", playerID)
+	if err != nil {
+		return tokens, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var tid, apiTokenPlayerID int64
+		var apiTokenName, apiTokenHash string
+		var apiTokenCreated time.Time
+		var apiTokenLastUsed *time.Time
+
+		err := rows.Scan(&tid, &apiTokenPlayerID, &apiTokenName, &apiTokenHash, &apiTokenCreated, &apiTokenLastUsed)
+		if err != nil {
+			return tokens, err
+		}
+
+		if apiTokenLastUsed == nil {
+			apiTokenLastUsed = &time.Time{}
+		}
+
+		tokens = append(tokens, models.NewAPIToken(tid, apiTokenPlayerID, apiTokenName, apiTokenHash, apiTokenCreated, *apiTokenLastUsed))
+	}
+
+	return tokens, rows.Err()
+}
+
+func (db *Database) SaveAPIToken(token *models.APIToken) (*models.APIToken, error) {
+	logger.Tracef("Saving API token #%d to database...", token.ID)
+
+	var apiTokenLastUsed *time.Time
+	if token.HasBeenUsed() {
+		apiTokenLastUsed = &token.LastUsed
+	}
+
+	exists, err := rowExists(db.db, "SELECT COUNT(*) FROM apitokens WHERE id = ?", token.ID)
+	if err != nil {
+		return token, err
+	}
+
+	if !exists {
+		result, err := db.db.Exec("INSERT INTO apitokens(player_id, name, token_hash, created, last_used) VALUES (?, ?, ?, ?, ?)", token.PlayerID, token.Name, token.TokenHash, token.Created, apiTokenLastUsed)
+		if err != nil {
+			return token, err
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return token, err
+		}
+
+		token.ID = id
+	} else {
+		_, err := db.db.Exec("UPDATE apitokens SET name=?, last_used=? WHERE id=?", token.Name, apiTokenLastUsed, token.ID)
+		if err != nil {
+			return token, err
+		}
+	}
+
+	return token, nil
+}
+
+func (db *Database) DeleteAPIToken(playerID int64, tokenID int64) error {
+	logger.Tracef("Deleting API token #%d of player #%d from database...", tokenID, playerID)
+
+	result, err := db.db.Exec("DELETE FROM apitokens WHERE player_id = ? AND id = ?", playerID, tokenID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (db *Database) LoadESIToken(playerID int64) (*models.ESIToken, error) {
+	logger.Tracef("Querying database for ESI token of player #%d...", playerID)
+
+	row := db.db.QueryRow("SELECT id, player_id, character_id, scopes, access_token, refresh_token, expiry, valid, updated FROM esitokens WHERE player_id = ?", playerID)
+
+	return scanESIToken(row)
+}
+
+func (db *Database) LoadCorporationESITokens(corporationID int64) ([]*models.ESIToken, error) {
+	logger.Tracef("Querying database for ESI tokens of corporation #%d...", corporationID)
+
+	tokens := make([]*models.ESIToken, 0)
+
+	// Tokens of players with higher access are preferred when acting on behalf of the corporation
+	rows, err := db.db.Query("SELECT t.id, t.player_id, t.character_id, t.scopes, t.access_token, t.refresh_token, t.expiry, t.valid, t.updated FROM esitokens AS t INNER JOIN players AS p ON p.id = t.player_id WHERE p.corporation_id = ? AND p.active = 'Y' AND t.valid = 'Y' ORDER BY p.accessmask DESC, t.updated DESC", corporationID)
+	if err != nil {
+		return tokens, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		token, err := scanESIToken(rows)
+		if err != nil {
+			return tokens, err
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+func (db *Database) SaveESIToken(token *models.ESIToken) (*models.ESIToken, error) {
+	logger.Tracef("Saving ESI token of player #%d to database...", token.PlayerID)
+
+	esiTokenAccess, err := EncryptSecret(token.AccessToken)
+	if err != nil {
+		return token, err
+	}
+
+	esiTokenRefresh, err := EncryptSecret(token.RefreshToken)
+	if err != nil {
+		return token, err
+	}
+
+	var esiTokenValidEnum string
+
+	if token.Valid {
+		esiTokenValidEnum = "Y"
+	} else {
+		esiTokenValidEnum = "N"
+	}
+
+	token.Updated = time.Now().UTC()
+
+	exists, err := rowExists(db.db, "SELECT COUNT(*) FROM esitokens WHERE player_id = ?", token.PlayerID)
+	if err != nil {
+		return token, err
+	}
+
+	if !exists {
+		result, err := db.db.Exec("INSERT INTO esitokens(player_id, character_id, scopes, access_token, refresh_token, expiry, valid, updated) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", token.PlayerID, token.CharacterID, strings.Join(token.Scopes, " "), esiTokenAccess, esiTokenRefresh, token.Expiry, esiTokenValidEnum, token.Updated)
+		if err != nil {
+			return token, err
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return token, err
+		}
+
+		token.ID = id
+	} else {
+		_, err := db.db.Exec("UPDATE esitokens SET character_id=?, scopes=?, access_token=?, refresh_token=?, expiry=?, valid=?, updated=? WHERE player_id=?", token.CharacterID, strings.Join(token.Scopes, " "), esiTokenAccess, esiTokenRefresh, token.Expiry, esiTokenValidEnum, token.Updated, token.PlayerID)
+		if err != nil {
+			return token, err
+		}
+	}
+
+	return token, nil
+}
+
+func (db *Database) DeleteESIToken(playerID int64) error {
+	logger.Tracef("Deleting ESI token of player #%d from database...", playerID)
+
+	result, err := db.db.Exec("DELETE FROM esitokens WHERE player_id = ?", playerID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func scanESIToken(row scanner) (*models.ESIToken, error) {
+	var tid, esiTokenPlayerID, esiTokenCharacterID int64
+	var esiTokenScopes, esiTokenAccess, esiTokenRefresh, esiTokenValidEnum string
+	var esiTokenExpiry *time.Time
+	var esiTokenUpdated time.Time
+
+	err := row.Scan(&tid, &esiTokenPlayerID, &esiTokenCharacterID, &esiTokenScopes, &esiTokenAccess, &esiTokenRefresh, &esiTokenExpiry, &esiTokenValidEnum, &esiTokenUpdated)
+	if err != nil {
+		return &models.ESIToken{}, err
+	}
+
+	if esiTokenExpiry == nil {
+		esiTokenExpiry = &time.Time{}
+	}
+
+	access, err := DecryptSecret(esiTokenAccess)
+	if err != nil {
+		return &models.ESIToken{}, err
+	}
+
+	refresh, err := DecryptSecret(esiTokenRefresh)
+	if err != nil {
+		return &models.ESIToken{}, err
+	}
+
+	return models.NewESIToken(tid, esiTokenPlayerID, esiTokenCharacterID, strings.Fields(esiTokenScopes), access, refresh, *esiTokenExpiry, strings.EqualFold(esiTokenValidEnum, "Y"), esiTokenUpdated), nil
+}
+
+func (db *Database) LoadFleetLink(fleetID int64) (*models.FleetLink, error) {
+	logger.Tracef("Querying database for link of fleet #%d...", fleetID)
+
+	row := db.db.QueryRow("SELECT id, fleet_id, esi_fleet_id, player_id, members, last_sync, last_error, created FROM fleetlinks WHERE fleet_id = ?", fleetID)
+
+	return scanFleetLink(row)
+}
+
+func (db *Database) LoadAllFleetLinks() ([]*models.FleetLink, error) {
+	logger.Tracef("Querying database for all fleet links...")
+
+	links := make([]*models.FleetLink, 0)
+
+	rows, err := db.db.Query("SELECT id, fleet_id, esi_fleet_id, player_id, members, last_sync, last_error, created FROM fleetlinks ORDER BY id")
+	if err != nil {
+		return links, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		link, err := scanFleetLink(rows)
+		if err != nil {
+			return links, err
+		}
+
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
+}
+
+func (db *Database) SaveFleetLink(link *models.FleetLink) (*models.FleetLink, error) {
+	logger.Tracef("Saving link of fleet #%d to database...", link.FleetID)
+
+	members := make([]string, len(link.CharacterIDs))
+	for i, id := range link.CharacterIDs {
+		members[i] = strconv.FormatInt(id, 10)
+	}
+
+	var fleetLinkLastSync *time.Time
+
+	if !link.LastSync.IsZero() {
+		fleetLinkLastSync = &link.LastSync
+	}
+
+	exists, err := rowExists(db.db, "SELECT COUNT(*) FROM fleetlinks WHERE fleet_id = ?", link.FleetID)
+	if err != nil {
+		return link, err
+	}
+
+	if !exists {
+		if link.Created.IsZero() {
+			link.Created = time.Now().UTC()
+		}
+
+		result, err := db.db.Exec("INSERT INTO fleetlinks(fleet_id, esi_fleet_id, player_id, members, last_sync, last_error, created) VALUES (?, ?, ?, ?, ?, ?, ?)", link.FleetID, link.ESIFleetID, link.PlayerID, strings.Join(members, " "), fleetLinkLastSync, link.LastError, link.Created)
+		if err != nil {
+			return link, err
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return link, err
+		}
+
+		link.ID = id
+	} else {
+		_, err := db.db.Exec("UPDATE fleetlinks SET esi_fleet_id=?, player_id=?, members=?, last_sync=?, last_error=? WHERE fleet_id=?", link.ESIFleetID, link.PlayerID, strings.Join(members, " "), fleetLinkLastSync, link.LastError, link.FleetID)
+		if err != nil {
+			return link, err
+		}
+	}
+
+	return link, nil
+}
+
+func (db *Database) DeleteFleetLink(fleetID int64) error {
+	logger.Tracef("Deleting link of fleet #%d from database...", fleetID)
+
+	result, err := db.db.Exec("DELETE FROM fleetlinks WHERE fleet_id = ?", fleetID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func scanFleetLink(row scanner) (*models.FleetLink, error) {
+	var lid, fid, fleetLinkESIFleetID, fleetLinkPlayerID int64
+	var fleetLinkMembers, fleetLinkLastError string
+	var fleetLinkLastSync *time.Time
+	var fleetLinkCreated time.Time
+
+	err := row.Scan(&lid, &fid, &fleetLinkESIFleetID, &fleetLinkPlayerID, &fleetLinkMembers, &fleetLinkLastSync, &fleetLinkLastError, &fleetLinkCreated)
+	if err != nil {
+		return &models.FleetLink{}, err
+	}
+
+	if fleetLinkLastSync == nil {
+		fleetLinkLastSync = &time.Time{}
+	}
+
+	var characterIDs []int64
+
+	for _, field := range strings.Fields(fleetLinkMembers) {
+		id, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return &models.FleetLink{}, err
+		}
+
+		characterIDs = append(characterIDs, id)
+	}
+
+	return models.NewFleetLink(lid, fid, fleetLinkESIFleetID, fleetLinkPlayerID, characterIDs, *fleetLinkLastSync, fleetLinkLastError, fleetLinkCreated), nil
+}
+
+func (db *Database) LoadAuditEntries(filter *models.AuditFilter) ([]*models.AuditEntry, error) {
+	logger.Tracef("Querying database for audit entries with fid = %d and rid = %d...", filter.FleetID, filter.ReportID)
+
+	entries := make([]*models.AuditEntry, 0)
+
+	var conditions []string
+	var args []interface{}
+
+	if filter.FleetID > 0 {
+		conditions = append(conditions, "fleet_id = ?")
+		args = append(args, filter.FleetID)
+	}
+	if filter.ReportID > 0 {
+		conditions = append(conditions, "report_id = ?")
+		args = append(args, filter.ReportID)
+	}
+	if len(filter.Actor) > 0 {
+		conditions = append(conditions, "LOWER(actor) LIKE ?")
+		args = append(args, "%"+strings.ToLower(filter.Actor)+"%")
+	}
+	if len(filter.Command) > 0 {
+		conditions = append(conditions, "LOWER(command) = ?")
+		args = append(args, strings.ToLower(filter.Command))
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "created >= ?")
+		args = append(args, filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "created < ?")
+		args = append(args, filter.To.UTC())
+	}
+
+	query := "SELECT id, fleet_id, report_id, player_id, actor, command, before_value, after_value, remote_address, created FROM auditlog"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created DESC, id DESC"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+
+	rows, err := db.db.Query(query, args...)
+	if err != nil {
+		return entries, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var aid int64
+		var fid, rid, pid sql.NullInt64
+		var auditActor, auditCommand, auditBefore, auditAfter, auditRemoteAddress string
+		var auditCreated time.Time
+
+		err := rows.Scan(&aid, &fid, &rid, &pid, &auditActor, &auditCommand, &auditBefore, &auditAfter, &auditRemoteAddress, &auditCreated)
+		if err != nil {
+			return entries, err
+		}
+
+		entries = append(entries, models.NewAuditEntry(aid, fid.Int64, rid.Int64, pid.Int64, auditActor, auditCommand, auditBefore, auditAfter, auditRemoteAddress, auditCreated))
+	}
+
+	return entries, rows.Err()
+}
+
+func (db *Database) SaveAuditEntry(entry *models.AuditEntry) (*models.AuditEntry, error) {
+	logger.Tracef("Saving audit entry for command %q to database...", entry.Command)
+
+	if entry.ID > 0 {
+		return entry, fmt.Errorf("Audit entry #%d already exists, audit entries cannot be modified", entry.ID)
+	}
+
+	result, err := db.db.Exec("INSERT INTO auditlog(fleet_id, report_id, player_id, actor, command, before_value, after_value, remote_address, created) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		nullableID(entry.FleetID), nullableID(entry.ReportID), nullableID(entry.PlayerID), entry.Actor, entry.Command, entry.Before, entry.After, entry.RemoteAddress, entry.Created.UTC())
+	if err != nil {
+		return entry, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return entry, err
+	}
+
+	entry.ID = id
+
+	return entry, nil
+}
+
+func (db *Database) LoadWalletTransfer(corporationID int64, id int64) (*models.WalletTransfer, error) {
+	logger.Tracef("Querying database for wallet transfer with wid = %d...", id)
+
+	row := db.db.QueryRow("SELECT id, corporation_id, ref_id, date, recipient_id, recipient_name, amount, reason, report_id, reportpayout_id, expected_amount, status, resolved FROM wallettransfers WHERE corporation_id = ? AND id = ?", corporationID, id)
+
+	return scanWalletTransfer(row)
+}
+
+func (db *Database) LoadAllWalletTransfers(corporationID int64, limit int) ([]*models.WalletTransfer, error) {
+	logger.Tracef("Querying database for wallet transfers of corporation #%d...", corporationID)
+
+	transfers := make([]*models.WalletTransfer, 0)
+
+	query := "SELECT id, corporation_id, ref_id, date, recipient_id, recipient_name, amount, reason, report_id, reportpayout_id, expected_amount, status, resolved FROM wallettransfers WHERE corporation_id = ? ORDER BY date DESC, id DESC"
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+
+	rows, err := db.db.Query(query, corporationID)
+	if err != nil {
+		return transfers, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		transfer, err := scanWalletTransfer(rows)
+		if err != nil {
+			return transfers, err
+		}
+
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers, rows.Err()
+}
+
+func (db *Database) LoadUnresolvedWalletTransfers(corporationID int64) ([]*models.WalletTransfer, error) {
+	logger.Tracef("Querying database for unresolved wallet transfers of corporation #%d...", corporationID)
+
+	transfers := make([]*models.WalletTransfer, 0)
+
+	rows, err := db.db.Query("SELECT id, corporation_id, ref_id, date, recipient_id, recipient_name, amount, reason, report_id, reportpayout_id, expected_amount, status, resolved FROM wallettransfers WHERE corporation_id = ? AND status <> ? AND resolved = 'N' ORDER BY date DESC, id DESC", corporationID, models.WalletTransferStatusMatched)
+	if err != nil {
+		return transfers, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		transfer, err := scanWalletTransfer(rows)
+		if err != nil {
+			return transfers, err
+		}
+
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers, rows.Err()
+}
+
+func (db *Database) IsWalletTransferImported(corporationID int64, refID int64) (bool, error) {
+	return rowExists(db.db, "SELECT COUNT(*) FROM wallettransfers WHERE corporation_id = ? AND ref_id = ?", corporationID, refID)
+}
+
+func (db *Database) SaveWalletTransfer(transfer *models.WalletTransfer) (*models.WalletTransfer, error) {
+	logger.Tracef("Saving wallet transfer #%d to database...", transfer.ID)
+
+	return db.saveWalletTransfer(db.db, transfer)
+}
+
+func (db *Database) saveWalletTransfer(q querier, transfer *models.WalletTransfer) (*models.WalletTransfer, error) {
+	var walletTransferResolvedEnum string
+
+	if transfer.Resolved {
+		walletTransferResolvedEnum = "Y"
+	} else {
+		walletTransferResolvedEnum = "N"
+	}
+
+	exists, err := rowExists(q, "SELECT COUNT(*) FROM wallettransfers WHERE id = ?", transfer.ID)
+	if err != nil {
+		return transfer, err
+	}
+
+	if !exists {
+		result, err := q.Exec("INSERT INTO wallettransfers(corporation_id, ref_id, date, recipient_id, recipient_name, amount, reason, report_id, reportpayout_id, expected_amount, status, resolved) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", transfer.CorporationID, transfer.RefID, transfer.Date, transfer.RecipientID, transfer.RecipientName, transfer.Amount, transfer.Reason, nullableID(transfer.ReportID), nullableID(transfer.ReportPayoutID), transfer.ExpectedAmount, transfer.Status, walletTransferResolvedEnum)
+		if err != nil {
+			return transfer, err
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return transfer, err
+		}
+
+		transfer.ID = id
+	} else {
+		_, err := q.Exec("UPDATE wallettransfers SET report_id=?, reportpayout_id=?, expected_amount=?, status=?, resolved=? WHERE id=?", nullableID(transfer.ReportID), nullableID(transfer.ReportPayoutID), transfer.ExpectedAmount, transfer.Status, walletTransferResolvedEnum, transfer.ID)
+		if err != nil {
+			return transfer, err
+		}
+	}
+
+	return transfer, nil
+}
+
+func scanWalletTransfer(row scanner) (*models.WalletTransfer, error) {
+	var wid, cid, walletTransferRefID, walletTransferRecipientID int64
+	var rid, rpid sql.NullInt64
+	var walletTransferRecipientName, walletTransferReason, walletTransferResolvedEnum string
+	var walletTransferAmount, walletTransferExpectedAmount float64
+	var walletTransferDate time.Time
+	var walletTransferStatus int
+
+	err := row.Scan(&wid, &cid, &walletTransferRefID, &walletTransferDate, &walletTransferRecipientID, &walletTransferRecipientName, &walletTransferAmount, &walletTransferReason, &rid, &rpid, &walletTransferExpectedAmount, &walletTransferStatus, &walletTransferResolvedEnum)
+	if err != nil {
+		return &models.WalletTransfer{}, err
+	}
+
+	reportID := int64(-1)
+	if rid.Valid {
+		reportID = rid.Int64
+	}
+
+	reportPayoutID := int64(-1)
+	if rpid.Valid {
+		reportPayoutID = rpid.Int64
+	}
+
+	return models.NewWalletTransfer(wid, cid, walletTransferRefID, walletTransferDate, walletTransferRecipientID, walletTransferRecipientName, walletTransferAmount, walletTransferReason, reportID, reportPayoutID, walletTransferExpectedAmount, models.WalletTransferStatus(walletTransferStatus), strings.EqualFold(walletTransferResolvedEnum, "y")), nil
+}
+
+func nullableID(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id > 0}
+}
+
+func (db *Database) RemovePlayerFromCache(id int64) {
+	db.players.Delete(id)
 }
@@ -0,0 +1,270 @@
+// client
+package esi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultBaseURL = "https://esi.evetech.net/latest"
+
+	// Requests are refused once fewer errors than this remain in the current ESI error window
+	errorLimitThreshold = 10
+	cacheSize           = 4096
+	namesPerRequest     = 1000
+)
+
+var (
+	ErrErrorLimited = errors.New("ESI error limit reached, refusing requests until the error window resets")
+)
+
+type Error struct {
+	StatusCode int
+	Method     string
+	Path       string
+	Message    string
+}
+
+func (err *Error) Error() string {
+	return fmt.Sprintf("ESI returned status %d for %s %s: %s", err.StatusCode, err.Method, err.Path, err.Message)
+}
+
+type cacheEntry struct {
+	etag    string
+	expires time.Time
+	pages   int
+	body    []byte
+}
+
+// Credentials authorise a request on behalf of a character
+type Credentials struct {
+	CharacterID int64
+	AccessToken string
+}
+
+type Client struct {
+	BaseURL   string
+	UserAgent string
+	HTTP      *http.Client
+
+	lock             sync.Mutex
+	cache            map[string]*cacheEntry
+	errorLimitRemain int
+	errorLimitReset  time.Time
+	now              func() time.Time
+}
+
+func NewClient(baseURL string, userAgent string) *Client {
+	client := &Client{
+		BaseURL:          strings.TrimRight(baseURL, "/"),
+		UserAgent:        userAgent,
+		HTTP:             &http.Client{Timeout: 30 * time.Second},
+		cache:            make(map[string]*cacheEntry),
+		errorLimitRemain: -1,
+		now:              time.Now,
+	}
+
+	return client
+}
+
+func (client *Client) ErrorLimit() (int, time.Time) {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+
+	return client.errorLimitRemain, client.errorLimitReset
+}
+
+func (client *Client) get(path string, credentials *Credentials, v interface{}) error {
+	_, err := client.getPage(path, credentials, v)
+	return err
+}
+
+// getPage performs a GET request and returns the number of pages ESI reported for the resource
+func (client *Client) getPage(path string, credentials *Credentials, v interface{}) (int, error) {
+	key := path
+	token := ""
+
+	// Authenticated responses are cached per character, so no bearer token is kept and a refreshed token still revalidates them
+	if credentials != nil {
+		key = fmt.Sprintf("%d %s", credentials.CharacterID, path)
+		token = credentials.AccessToken
+	}
+
+	client.lock.Lock()
+	entry, cached := client.cache[key]
+	if cached && client.now().Before(entry.expires) {
+		body, pages := entry.body, entry.pages
+		client.lock.Unlock()
+
+		return pages, json.Unmarshal(body, v)
+	}
+	client.lock.Unlock()
+
+	req, err := http.NewRequest("GET", client.BaseURL+path, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	if cached && len(entry.etag) > 0 {
+		req.Header.Set("If-None-Match", entry.etag)
+	}
+
+	resp, body, err := client.do(req, token)
+	if err != nil {
+		return 0, err
+	}
+
+	client.lock.Lock()
+	defer client.lock.Unlock()
+
+	if resp.StatusCode == http.StatusNotModified && cached {
+		entry.expires = client.expires(resp)
+		body = entry.body
+		if pages, err := strconv.Atoi(resp.Header.Get("X-Pages")); err == nil && pages > 0 {
+			entry.pages = pages
+		}
+
+		return entry.pages, json.Unmarshal(body, v)
+	}
+
+	pages, err := strconv.Atoi(resp.Header.Get("X-Pages"))
+	if err != nil || pages < 1 {
+		pages = 1
+	}
+
+	client.store(key, &cacheEntry{
+		etag:    resp.Header.Get("ETag"),
+		expires: client.expires(resp),
+		pages:   pages,
+		body:    body,
+	})
+
+	return pages, json.Unmarshal(body, v)
+}
+
+func (client *Client) post(path string, payload interface{}, v interface{}) error {
+	content, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", client.BaseURL+path, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	_, body, err := client.do(req, "")
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(body, v)
+}
+
+func (client *Client) do(req *http.Request, token string) (*http.Response, []byte, error) {
+	client.lock.Lock()
+	limited := client.errorLimitRemain >= 0 && client.errorLimitRemain < errorLimitThreshold && client.now().Before(client.errorLimitReset)
+	client.lock.Unlock()
+
+	if limited {
+		return nil, nil, ErrErrorLimited
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	if len(client.UserAgent) > 0 {
+		req.Header.Set("User-Agent", client.UserAgent)
+	}
+
+	if len(token) > 0 {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+
+	resp, err := client.HTTP.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client.updateErrorLimit(resp)
+
+	if resp.StatusCode >= 400 {
+		var content struct {
+			Error string `json:"error"`
+		}
+
+		message := strings.TrimSpace(string(body))
+		if json.Unmarshal(body, &content) == nil && len(content.Error) > 0 {
+			message = content.Error
+		}
+
+		return resp, nil, &Error{StatusCode: resp.StatusCode, Method: req.Method, Path: req.URL.Path, Message: message}
+	}
+
+	return resp, body, nil
+}
+
+func (client *Client) updateErrorLimit(resp *http.Response) {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+
+	// 420 is ESI's answer once the error limit has been exhausted
+	if resp.StatusCode == 420 {
+		client.errorLimitRemain = 0
+	}
+
+	if remain, err := strconv.Atoi(resp.Header.Get("X-ESI-Error-Limit-Remain")); err == nil {
+		client.errorLimitRemain = remain
+	}
+
+	if reset, err := strconv.Atoi(resp.Header.Get("X-ESI-Error-Limit-Reset")); err == nil {
+		client.errorLimitReset = client.now().Add(time.Duration(reset) * time.Second)
+	} else if resp.StatusCode == 420 {
+		client.errorLimitReset = client.now().Add(time.Minute)
+	}
+}
+
+func (client *Client) expires(resp *http.Response) time.Time {
+	expires, err := http.ParseTime(resp.Header.Get("Expires"))
+	if err != nil {
+		return client.now()
+	}
+
+	return expires
+}
+
+func (client *Client) store(key string, entry *cacheEntry) {
+	if len(client.cache) >= cacheSize {
+		now := client.now()
+
+		// Entries without an ETag cannot be revalidated and are dropped first
+		for k, e := range client.cache {
+			if now.After(e.expires) && len(e.etag) == 0 {
+				delete(client.cache, k)
+			}
+		}
+
+		if len(client.cache) >= cacheSize {
+			client.cache = make(map[string]*cacheEntry)
+		}
+	}
+
+	client.cache[key] = entry
+}
@@ -0,0 +1,236 @@
+// client_test
+package esi
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) (*Client, *time.Time) {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	client := NewClient(server.URL, "lootsheeter test")
+	client.now = func() time.Time {
+		return now
+	}
+
+	return client, &now
+}
+
+func TestClientRevalidatesWithETag(t *testing.T) {
+	requests := 0
+
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		if requests > 1 {
+			t.Errorf("Expected request #%d to revalidate the cached ETag, got If-None-Match %q", requests, r.Header.Get("If-None-Match"))
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, `{"name": "Test Corporation", "ticker": "TEST"}`)
+	})
+
+	for i := 0; i < 2; i++ {
+		corporation, err := client.Corporation(98000001)
+		if err != nil {
+			t.Fatalf("Failed to request corporation: [%v]", err)
+		}
+
+		if corporation.Name != "Test Corporation" || corporation.Ticker != "TEST" {
+			t.Errorf("Expected cached corporation to be returned, got %+v", corporation)
+		}
+	}
+
+	if requests != 2 {
+		t.Errorf("Expected 2 requests, got %d", requests)
+	}
+}
+
+func TestClientRevalidatesAfterTokenRefresh(t *testing.T) {
+	requests := 0
+
+	client, now := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		if requests > 1 && r.Header.Get("If-None-Match") != `"v1"` {
+			t.Errorf("Expected request #%d with a refreshed token to revalidate the cached ETag, got If-None-Match %q", requests, r.Header.Get("If-None-Match"))
+		}
+
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, `[90000001, 90000002]`)
+	})
+
+	for i, token := range []string{"first", "refreshed"} {
+		*now = now.Add(time.Duration(i) * time.Hour)
+
+		members, err := client.CorporationMembers(98000001, &Credentials{CharacterID: 90000001, AccessToken: token})
+		if err != nil {
+			t.Fatalf("Failed to request corporation members with token %q: [%v]", token, err)
+		}
+
+		if len(members) != 2 {
+			t.Errorf("Expected 2 corporation members with token %q, got %v", token, members)
+		}
+	}
+
+	for key := range client.cache {
+		if strings.Contains(key, "first") || strings.Contains(key, "refreshed") {
+			t.Errorf("Expected cache keys to not contain access tokens, got %q", key)
+		}
+	}
+}
+
+func TestClientCachesUntilExpires(t *testing.T) {
+	requests := 0
+
+	client, now := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		w.Header().Set("Expires", time.Date(2026, 10, 1, 12, 5, 0, 0, time.UTC).Format(http.TimeFormat))
+		fmt.Fprintf(w, `{"name": "Alice Adama", "corporation_id": %d}`, 98000000+requests)
+	})
+
+	tests := []struct {
+		now           time.Time
+		corporationID int64
+		requests      int
+	}{
+		{time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC), 98000001, 1},
+		{time.Date(2026, 10, 1, 12, 4, 59, 0, time.UTC), 98000001, 1},
+		{time.Date(2026, 10, 1, 12, 5, 1, 0, time.UTC), 98000002, 2},
+	}
+
+	for _, test := range tests {
+		*now = test.now
+
+		character, err := client.Character(90000001)
+		if err != nil {
+			t.Fatalf("Failed to request character at %v: [%v]", test.now, err)
+		}
+
+		if character.CorporationID != test.corporationID || requests != test.requests {
+			t.Errorf("Expected corporation %d after %d requests at %v, got %d after %d", test.corporationID, test.requests, test.now, character.CorporationID, requests)
+		}
+	}
+}
+
+func TestClientBacksOffAtErrorLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		remain  string
+		reset   string
+		backoff time.Duration
+	}{
+		{"below threshold", http.StatusInternalServerError, "5", "30", 30 * time.Second},
+		{"exhausted", 420, "", "", time.Minute},
+	}
+
+	for _, test := range tests {
+		requests := 0
+
+		client, now := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			requests++
+
+			if requests > 1 {
+				w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+				fmt.Fprint(w, `{"name": "Test Corporation"}`)
+				return
+			}
+
+			if len(test.remain) > 0 {
+				w.Header().Set("X-ESI-Error-Limit-Remain", test.remain)
+				w.Header().Set("X-ESI-Error-Limit-Reset", test.reset)
+			}
+
+			w.WriteHeader(test.status)
+			fmt.Fprint(w, `{"error": "Something went wrong"}`)
+		})
+
+		start := *now
+
+		_, err := client.Corporation(98000001)
+		if esiErr, ok := err.(*Error); !ok || esiErr.StatusCode != test.status || esiErr.Message != "Something went wrong" {
+			t.Errorf("%s: Expected ESI error with status %d, got [%v]", test.name, test.status, err)
+		}
+
+		*now = start.Add(test.backoff - time.Second)
+
+		_, err = client.Corporation(98000001)
+		if err != ErrErrorLimited || requests != 1 {
+			t.Errorf("%s: Expected request to be refused without reaching ESI, got [%v] after %d requests", test.name, err, requests)
+		}
+
+		*now = start.Add(test.backoff + time.Second)
+
+		_, err = client.Corporation(98000001)
+		if err != nil || requests != 2 {
+			t.Errorf("%s: Expected request to go through after the error window reset, got [%v] after %d requests", test.name, err, requests)
+		}
+
+		remain, _ := client.ErrorLimit()
+		if remain != 100 {
+			t.Errorf("%s: Expected error limit to be updated to 100, got %d", test.name, remain)
+		}
+	}
+}
+
+func TestCorporationWalletJournalReadsAllPages(t *testing.T) {
+	const pages = 3
+
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/corporations/98000001/wallets/1/journal/" {
+			t.Errorf("Unexpected request for %s", r.URL.Path)
+		}
+
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("Expected bearer token, got %q", r.Header.Get("Authorization"))
+		}
+
+		page, err := strconv.Atoi(r.URL.Query().Get("page"))
+		if err != nil || page < 1 || page > pages {
+			http.Error(w, `{"error": "Invalid page"}`, http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("X-Pages", strconv.Itoa(pages))
+		fmt.Fprintf(w, `[{"id": %d, "ref_type": "player_donation", "amount": -1000000}, {"id": %d, "ref_type": "player_donation", "amount": -2000000}]`, page*10+1, page*10+2)
+	})
+
+	entries, err := client.CorporationWalletJournal(98000001, 1, &Credentials{CharacterID: 90000001, AccessToken: "token"})
+	if err != nil {
+		t.Fatalf("Failed to request wallet journal: [%v]", err)
+	}
+
+	if len(entries) != pages*2 {
+		t.Fatalf("Expected %d entries from %d pages, got %d", pages*2, pages, len(entries))
+	}
+
+	for i, entry := range entries {
+		id := int64((i/2+1)*10 + i%2 + 1)
+		if entry.ID != id {
+			t.Errorf("Expected entry #%d to have ID %d, got %d", i, id, entry.ID)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// endpoints
+package esi
+
+import (
+	"fmt"
+)
+
+func (client *Client) CharacterAffiliations(characterIDs []int64) ([]CharacterAffiliation, error) {
+	var affiliations []CharacterAffiliation
+
+	err := client.post("/characters/affiliation/", characterIDs, &affiliations)
+	if err != nil {
+		return nil, err
+	}
+
+	return affiliations, nil
+}
+
+func (client *Client) Character(characterID int64) (*Character, error) {
+	var character Character
+
+	err := client.get(fmt.Sprintf("/characters/%d/", characterID), nil, &character)
+	if err != nil {
+		return nil, err
+	}
+
+	return &character, nil
+}
+
+// CharacterFleet returns the fleet the authorised character is currently in and requires a token with the esi-fleets.read_fleet.v1 scope
+func (client *Client) CharacterFleet(credentials *Credentials) (*CharacterFleet, error) {
+	var fleet CharacterFleet
+
+	err := client.get(fmt.Sprintf("/characters/%d/fleet/", credentials.CharacterID), credentials, &fleet)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fleet, nil
+}
+
+func (client *Client) Corporation(corporationID int64) (*Corporation, error) {
+	var corporation Corporation
+
+	err := client.get(fmt.Sprintf("/corporations/%d/", corporationID), nil, &corporation)
+	if err != nil {
+		return nil, err
+	}
+
+	return &corporation, nil
+}
+
+// CorporationMembers requires a token with the esi-corporations.read_corporation_membership.v1 scope
+func (client *Client) CorporationMembers(corporationID int64, credentials *Credentials) ([]int64, error) {
+	var members []int64
+
+	err := client.get(fmt.Sprintf("/corporations/%d/members/", corporationID), credentials, &members)
+	if err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+// CorporationWalletJournal returns every page of a wallet division and requires a token with the esi-wallet.read_corporation_wallets.v1 scope
+func (client *Client) CorporationWalletJournal(corporationID int64, division int, credentials *Credentials) ([]WalletJournalEntry, error) {
+	var entries []WalletJournalEntry
+
+	for page, pages := 1, 1; page <= pages; page++ {
+		var batch []WalletJournalEntry
+
+		var err error
+
+		pages, err = client.getPage(fmt.Sprintf("/corporations/%d/wallets/%d/journal/?page=%d", corporationID, division, page), credentials, &batch)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, batch...)
+	}
+
+	return entries, nil
+}
+
+// FleetMembers can only be requested by the fleet boss and requires a token with the esi-fleets.read_fleet.v1 scope
+func (client *Client) FleetMembers(fleetID int64, credentials *Credentials) ([]FleetMember, error) {
+	var members []FleetMember
+
+	err := client.get(fmt.Sprintf("/fleets/%d/members/", fleetID), credentials, &members)
+	if err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+func (client *Client) Names(ids []int64) ([]Name, error) {
+	var names []Name
+
+	for start := 0; start < len(ids); start += namesPerRequest {
+		end := start + namesPerRequest
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		var batch []Name
+
+		err := client.post("/universe/names/", ids[start:end], &batch)
+		if err != nil {
+			return names, err
+		}
+
+		names = append(names, batch...)
+	}
+
+	return names, nil
+}
@@ -0,0 +1,73 @@
+// models
+package esi
+
+import (
+	"time"
+)
+
+type CharacterAffiliation struct {
+	CharacterID   int64 `json:"character_id"`
+	CorporationID int64 `json:"corporation_id"`
+	AllianceID    int64 `json:"alliance_id"`
+	FactionID     int64 `json:"faction_id"`
+}
+
+type Character struct {
+	Name          string `json:"name"`
+	CorporationID int64  `json:"corporation_id"`
+	AllianceID    int64  `json:"alliance_id"`
+	FactionID     int64  `json:"faction_id"`
+}
+
+type Corporation struct {
+	Name        string  `json:"name"`
+	Ticker      string  `json:"ticker"`
+	CEOID       int64   `json:"ceo_id"`
+	AllianceID  int64   `json:"alliance_id"`
+	FactionID   int64   `json:"faction_id"`
+	HomeStation int64   `json:"home_station_id"`
+	Description string  `json:"description"`
+	URL         string  `json:"url"`
+	TaxRate     float64 `json:"tax_rate"`
+	MemberCount int64   `json:"member_count"`
+	Shares      int64   `json:"shares"`
+}
+
+type Name struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+}
+
+type WalletJournalEntry struct {
+	ID            int64     `json:"id"`
+	Date          time.Time `json:"date"`
+	RefType       string    `json:"ref_type"`
+	FirstPartyID  int64     `json:"first_party_id"`
+	SecondPartyID int64     `json:"second_party_id"`
+	Amount        float64   `json:"amount"`
+	Balance       float64   `json:"balance"`
+	Reason        string    `json:"reason"`
+	Description   string    `json:"description"`
+}
+
+type CharacterFleet struct {
+	FleetID     int64  `json:"fleet_id"`
+	FleetBossID int64  `json:"fleet_boss_id"`
+	Role        string `json:"role"`
+	SquadID     int64  `json:"squad_id"`
+	WingID      int64  `json:"wing_id"`
+}
+
+type FleetMember struct {
+	CharacterID    int64     `json:"character_id"`
+	JoinTime       time.Time `json:"join_time"`
+	Role           string    `json:"role"`
+	RoleName       string    `json:"role_name"`
+	ShipTypeID     int64     `json:"ship_type_id"`
+	SolarSystemID  int64     `json:"solar_system_id"`
+	SquadID        int64     `json:"squad_id"`
+	StationID      int64     `json:"station_id"`
+	TakesFleetWarp bool      `json:"takes_fleet_warp"`
+	WingID         int64     `json:"wing_id"`
+}
@@ -0,0 +1,116 @@
+// eve
+package main
+
+import (
+	"fmt"
+
+	"github.com/morpheusxaut/lootsheeter/esi"
+	"github.com/morpheusxaut/lootsheeter/models"
+)
+
+const (
+	esiUserAgent = "lootsheeter (https://github.com/morpheusxaut/lootsheeter)"
+)
+
+var (
+	esiClient = esi.NewClient(esi.DefaultBaseURL, esiUserAgent)
+)
+
+func InitialiseESI(store Store) {
+	logger.Debugf("Using ESI at %q...", config.ESIURL)
+
+	esiClient = esi.NewClient(config.ESIURL, esiUserAgent)
+
+	err := InitialiseTokenCipher(config.SSOTokenKey)
+	if err != nil {
+		logger.Fatalf("Failed to initialise ESI token encryption: [%v]", err)
+		return
+	}
+
+	if !IsTokenStorageEnabled() {
+		logger.Warnf("No -ssotokenkey set, ESI tokens will not be stored and background jobs cannot access ESI...")
+	}
+
+	tokens := NewStoredESITokenSource(store)
+
+	memberTrackingFetcher = NewESIMemberTrackingFetcher(tokens)
+	walletJournalFetcher = NewESIWalletJournalFetcher(tokens)
+	inGameFleetFetcher = NewESIInGameFleetFetcher(tokens)
+}
+
+func ESICredentials(token *models.ESIToken) *esi.Credentials {
+	credentials := &esi.Credentials{
+		CharacterID: token.CharacterID,
+		AccessToken: token.AccessToken,
+	}
+
+	return credentials
+}
+
+func FetchCharacterAffiliation(v models.SSOVerification) (models.CharacterAffiliation, error) {
+	affiliations, err := esiClient.CharacterAffiliations([]int64{v.CharacterID})
+	if err != nil {
+		return models.CharacterAffiliation{}, err
+	}
+
+	if len(affiliations) == 0 {
+		return models.CharacterAffiliation{}, fmt.Errorf("ESI returned no affiliation for character #%d", v.CharacterID)
+	}
+
+	affiliation := affiliations[0]
+
+	ids := []int64{affiliation.CharacterID, affiliation.CorporationID}
+	if affiliation.AllianceID > 0 {
+		ids = append(ids, affiliation.AllianceID)
+	}
+	if affiliation.FactionID > 0 {
+		ids = append(ids, affiliation.FactionID)
+	}
+
+	names, err := esiClient.Names(ids)
+	if err != nil {
+		return models.CharacterAffiliation{}, err
+	}
+
+	resolved := make(map[int64]string)
+	for _, name := range names {
+		resolved[name.ID] = name.Name
+	}
+
+	a := models.CharacterAffiliation{
+		CharacterID:     affiliation.CharacterID,
+		CharacterName:   resolved[affiliation.CharacterID],
+		CorporationID:   affiliation.CorporationID,
+		CorporationName: resolved[affiliation.CorporationID],
+		AllianceID:      affiliation.AllianceID,
+		AllianceName:    resolved[affiliation.AllianceID],
+		FactionID:       affiliation.FactionID,
+		FactionName:     resolved[affiliation.FactionID],
+	}
+
+	return a, nil
+}
+
+func FetchCorporationSheet(a models.CharacterAffiliation) (models.CorporationSheet, error) {
+	corporation, err := esiClient.Corporation(a.CorporationID)
+	if err != nil {
+		return models.CorporationSheet{}, err
+	}
+
+	sh := models.CorporationSheet{
+		CorporationID:   a.CorporationID,
+		CorporationName: corporation.Name,
+		Ticker:          corporation.Ticker,
+		CEOID:           corporation.CEOID,
+		StationID:       corporation.HomeStation,
+		Description:     corporation.Description,
+		Homepage:        corporation.URL,
+		AllianceID:      corporation.AllianceID,
+		FactionID:       corporation.FactionID,
+		TaxRate:         corporation.TaxRate,
+		MemberCount:     corporation.MemberCount,
+		Shares:          corporation.Shares,
+	}
+
+	return sh, nil
+}
@@ -0,0 +1,315 @@
+// export
+package main
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/morpheusxaut/lootsheeter/models"
+)
+
+type ExportSheet struct {
+	Name   string
+	Header []string
+	Rows   [][]interface{}
+}
+
+func NewExportSheet(name string, header ...string) *ExportSheet {
+	sheet := &ExportSheet{
+		Name:   name,
+		Header: header,
+	}
+
+	return sheet
+}
+
+func (sheet *ExportSheet) AddRow(values ...interface{}) {
+	sheet.Rows = append(sheet.Rows, values)
+}
+
+func ReportPayoutsSheet(report *models.Report) *ExportSheet {
+	sheet := NewExportSheet("Payouts", "Player", "Character ID", "Payout (ISK)", "Paid", "Fleets")
+
+	var names []string
+	for name := range report.Payouts {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		payout := report.Payouts[name]
+
+		var fleets []string
+		for _, fleet := range report.PayoutFleets(name) {
+			fleets = append(fleets, fmt.Sprintf("#%d %s", fleet.ID, fleet.Name))
+		}
+
+		sheet.AddRow(payout.Player.Name, payout.Player.PlayerID, payout.Payout, payout.PayoutComplete, strings.Join(fleets, "; "))
+	}
+
+	return sheet
+}
+
+func ReportFleetMembersSheet(report *models.Report) *ExportSheet {
+	sheet := NewExportSheet("Fleet Members", "Fleet ID", "Fleet", "Player", "Character ID", "Role", "Ship", "Sites Finished", "Payment Modifier", "Payout (ISK)", "Paid")
+
+	for _, fleet := range report.Fleets {
+		var names []string
+		for name := range fleet.Members {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			member := fleet.Members[name]
+
+			sites, _ := fleet.GetMemberSitesFinished(name)
+
+			sheet.AddRow(fleet.ID, fleet.Name, member.Name, member.PlayerID, report.Corporation.FleetRole(member.Role).Name, member.Ship, sites, member.PaymentModifier, member.Payout, member.PayoutComplete)
+		}
+	}
+
+	return sheet
+}
+
+func WriteCSV(w io.Writer, sheet *ExportSheet) error {
+	writer := csv.NewWriter(w)
+
+	header := make([]string, len(sheet.Header))
+	for i, name := range sheet.Header {
+		header[i] = escapeCSVFormula(name)
+	}
+
+	err := writer.Write(header)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range sheet.Rows {
+		record := make([]string, len(row))
+
+		for i, value := range row {
+			record[i] = formatExportValue(value)
+		}
+
+		err = writer.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+func formatExportValue(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	case bool:
+		if v {
+			return "Yes"
+		}
+
+		return "No"
+	}
+
+	return escapeCSVFormula(fmt.Sprint(value))
+}
+
+// escapeCSVFormula keeps spreadsheet applications from evaluating player controlled text such as fleet names as a formula
+func escapeCSVFormula(value string) string {
+	if len(value) > 0 && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
+
+type xlsxFile struct {
+	Name    string
+	Content string
+}
+
+func WriteXLSX(w io.Writer, sheets []*ExportSheet) error {
+	archive := zip.NewWriter(w)
+
+	// Cells are written as inline strings, so the workbook needs neither a shared string table nor styles
+
+	var contentTypes, workbookSheets, workbookRels strings.Builder
+
+	for i, sheet := range sheets {
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+		fmt.Fprintf(&workbookSheets, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escapeXML(sheet.Name), i+1, i+1)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+
+	files := []xlsxFile{
+		{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` + contentTypes.String() + `</Types>`},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>` + workbookSheets.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` + workbookRels.String() + `</Relationships>`},
+	}
+
+	for i, sheet := range sheets {
+		files = append(files, xlsxFile{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), xlsxWorksheet(sheet)})
+	}
+
+	for _, file := range files {
+		writer, err := archive.Create(file.Name)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.WriteString(writer, file.Content)
+		if err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+func xlsxWorksheet(sheet *ExportSheet) string {
+	var data strings.Builder
+
+	data.WriteString(xml.Header)
+	data.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	header := make([]interface{}, len(sheet.Header))
+	for i, name := range sheet.Header {
+		header[i] = name
+	}
+
+	rows := append([][]interface{}{header}, sheet.Rows...)
+
+	for i, row := range rows {
+		fmt.Fprintf(&data, `<row r="%d">`, i+1)
+
+		for j, value := range row {
+			reference := fmt.Sprintf("%s%d", xlsxColumn(j), i+1)
+
+			switch v := value.(type) {
+			case int, int64:
+				fmt.Fprintf(&data, `<c r="%s"><v>%d</v></c>`, reference, v)
+			case float64:
+				fmt.Fprintf(&data, `<c r="%s"><v>%s</v></c>`, reference, strconv.FormatFloat(v, 'f', -1, 64))
+			case bool:
+				flag := 0
+				if v {
+					flag = 1
+				}
+
+				fmt.Fprintf(&data, `<c r="%s" t="b"><v>%d</v></c>`, reference, flag)
+			default:
+				fmt.Fprintf(&data, `<c r="%s" t="inlineStr"><is><t>%s</t></is></c>`, reference, escapeXML(fmt.Sprint(v)))
+			}
+		}
+
+		data.WriteString(`</row>`)
+	}
+
+	data.WriteString(`</sheetData></worksheet>`)
+
+	return data.String()
+}
+
+func xlsxColumn(index int) string {
+	column := ""
+
+	for index >= 0 {
+		column = string(rune('A'+index%26)) + column
+		index = index/26 - 1
+	}
+
+	return column
+}
+
+func escapeXML(value string) string {
+	var escaped strings.Builder
+
+	xml.EscapeText(&escaped, []byte(value))
+
+	return escaped.String()
+}
+
+func ReportExportGetHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	reportID, err := strconv.ParseInt(vars["reportid"], 10, 64)
+	if err != nil {
+		logger.Errorf("Failed to parse report ID %q in ReportExportGetHandler: [%v]", vars["reportid"], err)
+
+		http.Error(w, "Failed to parse report ID", http.StatusBadRequest)
+		return
+	}
+
+	loggedIn := session.IsLoggedIn(w, r)
+
+	if !loggedIn {
+		session.SetLoginRedirect(w, r, fmt.Sprintf("/report/%d", reportID))
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	report, err := database.LoadReport(reportID)
+	if err != nil {
+		logger.Errorf("Failed to load details for report #%d in ReportExportGetHandler: [%v]", reportID, err)
+
+		status, _ := ErrorStatus(err)
+		http.Error(w, "Failed to load report", status)
+		return
+	}
+
+	corporationID := session.GetCorpID(r)
+
+	if report.Corporation.ID != corporationID {
+		http.Error(w, "Report not found", http.StatusNotFound)
+		return
+	}
+
+	if !IsReportCreator(r, report) && !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
+		logger.Warnf("Received request to ReportExportGetHandler without proper access...")
+
+		http.Redirect(w, r, fmt.Sprintf("/report/%d", reportID), http.StatusSeeOther)
+		return
+	}
+
+	fileName := fmt.Sprintf("report-%d", report.ID)
+
+	switch strings.ToLower(r.FormValue("format")) {
+	case "", "csv":
+		sheet := ReportPayoutsSheet(report)
+		if strings.EqualFold(r.FormValue("sheet"), "members") {
+			sheet = ReportFleetMembersSheet(report)
+			fileName += "-members"
+		}
+
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName+".csv"))
+
+		err = WriteCSV(w, sheet)
+	case "xlsx":
+		w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName+".xlsx"))
+
+		err = WriteXLSX(w, []*ExportSheet{ReportPayoutsSheet(report), ReportFleetMembersSheet(report)})
+	default:
+		http.Error(w, fmt.Sprintf("Unknown export format %q", r.FormValue("format")), http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		logger.Errorf("Failed to write export for report #%d in ReportExportGetHandler: [%v]", reportID, err)
+	}
+}
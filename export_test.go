@@ -0,0 +1,30 @@
+// export_test
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteCSVEscapesFormulas(t *testing.T) {
+	sheet := NewExportSheet("Payouts", "Player", "Fleet", "Payout (ISK)")
+	sheet.AddRow("Alice", "=HYPERLINK(\"http://example.com\")", -1500000.0)
+	sheet.AddRow("@Bob", "+1 Fleet", 2500000.0)
+	sheet.AddRow("Carol", "-Home", 0.0)
+
+	var buffer bytes.Buffer
+
+	err := WriteCSV(&buffer, sheet)
+	if err != nil {
+		t.Fatalf("Failed to write CSV: [%v]", err)
+	}
+
+	expected := "Player,Fleet,Payout (ISK)\n" +
+		"Alice,\"'=HYPERLINK(\"\"http://example.com\"\")\",-1500000.00\n" +
+		"'@Bob,'+1 Fleet,2500000.00\n" +
+		"Carol,'-Home,0.00\n"
+
+	if buffer.String() != expected {
+		t.Errorf("Expected CSV %q, got %q", expected, buffer.String())
+	}
+}
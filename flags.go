@@ -5,22 +5,42 @@ import (
 	"encoding/json"
 	"flag"
 	"os"
+
+	"github.com/morpheusxaut/lootsheeter/esi"
 )
 
 type Config struct {
-	DebugLevel              int
-	DebugTemplates          bool
-	HTTPPort                int
-	HTTPHost                string
-	MySqlUser               string
-	MySqlPassword           string
-	MySqlDatabase           string
-	MySqlHost               string
-	MySqlPort               int
-	SSOClientID             string
-	SSOClientSecret         string
-	SSOCallbackURL          string
-	SchedulerMemberTracking bool
+	DebugLevel                      int
+	DebugTemplates                  bool
+	HTTPPort                        int
+	HTTPHost                        string
+	DatabaseType                    string
+	SQLitePath                      string
+	AutoMigrate                     bool
+	MySqlUser                       string
+	MySqlPassword                   string
+	MySqlDatabase                   string
+	MySqlHost                       string
+	MySqlPort                       int
+	CacheTTL                        int
+	CacheSize                       int
+	PriceMode                       string
+	SSOClientID                     string
+	SSOClientSecret                 string
+	SSOCallbackURL                  string
+	SchedulerMemberTracking         bool
+	SchedulerMemberTrackingSchedule string
+	SchedulerWalletJournal          bool
+	SchedulerWalletJournalSchedule  string
+	SchedulerFleetSync              bool
+	SchedulerFleetSyncSchedule      string
+	ShutdownTimeout                 int
+	ESIURL                          string
+	SSOURL                          string
+	SSOTokenKey                     string
+	SSOScopesMemberTracking         string
+	SSOScopesWallet                 string
+	SSOScopesFleet                  string
 }
 
 var (
@@ -32,49 +52,84 @@ func ParseConfigFlags() (*Config, error) {
 	debugTemplatesFlag := flag.Bool("debugtemplates", false, "Toggles a complete rebuild for all templates on each request")
 	httpPortFlag := flag.Int("port", 3000, "Port for the webserver to bind to")
 	httpHostFlag := flag.String("host", "0.0.0.0", "Hostname for the webserver to bind to")
+	databaseTypeFlag := flag.String("dbtype", "mysql", "Database backend to use (mysql, sqlite)")
+	sqlitePathFlag := flag.String("sqlitepath", "lootsheeter.db", "Path of the SQLite database file, created if it does not exist")
+	autoMigrateFlag := flag.Bool("automigrate", true, "Applies pending database schema migrations on startup")
 	mysqlUserFlag := flag.String("mysqluser", "", "Username for authenticating to the MySQL server")
 	mysqlPasswordFlag := flag.String("mysqlpassword", "", "Password for authenticating to the MySQL server")
 	mysqlDatabaseFlag := flag.String("mysqldatabase", "", "Database to use with the MySQL server")
 	mysqlHostFlag := flag.String("mysqlhost", "localhost", "Hostname of the MySQL server")
 	mysqlPortFlag := flag.Int("mysqlport", 3306, "Port of the MySQL server")
+	cacheTTLFlag := flag.Int("cachettl", 300, "Seconds a cached database entry stays valid, 0 disables expiry")
+	cacheSizeFlag := flag.Int("cachesize", 1000, "Maximum number of cached entries per type, 0 disables the limit")
+	priceModeFlag := flag.String("pricemode", "buy", "Default price used to appraise loot pastes (buy, sell, split)")
 	ssoClientIDFlag := flag.String("ssoid", "", "EVE Online Application Client ID")
 	ssoClientSecretFlag := flag.String("ssosecret", "", "EVE Online Application Client Secret")
 	ssoCallbackURLFlag := flag.String("ssocallback", "", "EVE Online Application Callback URL")
-	schedulerMemberTrackingFlag := flag.Bool("membertracking", false, "Enables automatic member list updates via the EVE API (requires corp API key)")
+	schedulerMemberTrackingFlag := flag.Bool("membertracking", false, "Enables automatic member list updates via ESI (requires an officer ESI token)")
+	schedulerWalletJournalFlag := flag.Bool("walletjournal", false, "Enables automatic payout verification from the corporation wallet journal via ESI (requires an officer ESI token)")
+	schedulerMemberTrackingScheduleFlag := flag.String("membertrackingschedule", "@every 4h", "Schedule of the member import, either a cron expression (minute hour day month weekday, UTC) or @every <interval>")
+	schedulerWalletJournalScheduleFlag := flag.String("walletjournalschedule", "@hourly", "Schedule of the wallet journal import, either a cron expression (minute hour day month weekday, UTC) or @every <interval>")
+	schedulerFleetSyncFlag := flag.Bool("fleetsync", true, "Enables syncing members of linked in-game fleets via ESI (requires the fleet boss to authorise in-game fleets)")
+	schedulerFleetSyncScheduleFlag := flag.String("fleetsyncschedule", "@every 1m", "Schedule of the in-game fleet sync, either a cron expression (minute hour day month weekday, UTC) or @every <interval>")
+	shutdownTimeoutFlag := flag.Int("shutdowntimeout", 30, "Seconds to wait for running requests and jobs to finish on shutdown")
+	esiURLFlag := flag.String("esiurl", esi.DefaultBaseURL, "Base URL of the EVE Swagger Interface (ESI)")
+	ssoURLFlag := flag.String("ssourl", "https://login.eveonline.com", "Base URL of the EVE Online SSO")
+	ssoTokenKeyFlag := flag.String("ssotokenkey", "", "Secret used to encrypt stored ESI tokens, storing tokens is disabled if empty")
+	ssoScopesMemberTrackingFlag := flag.String("ssoscopesmembertracking", "esi-corporations.read_corporation_membership.v1", "Space separated ESI scopes requested for member tracking")
+	ssoScopesWalletFlag := flag.String("ssoscopeswallet", "esi-wallet.read_corporation_wallets.v1", "Space separated ESI scopes requested for wallet journal imports")
+	ssoScopesFleetFlag := flag.String("ssoscopesfleet", "esi-fleets.read_fleet.v1", "Space separated ESI scopes requested for in-game fleet imports")
 	configFileFlag := flag.String("config", "", "Config file to parse commandline parameters from")
 
 	flag.Parse()
 
-	var conf *Config
+	conf := &Config{
+		DebugLevel:                      *debugLevelFlag,
+		DebugTemplates:                  *debugTemplatesFlag,
+		HTTPPort:                        *httpPortFlag,
+		HTTPHost:                        *httpHostFlag,
+		DatabaseType:                    *databaseTypeFlag,
+		SQLitePath:                      *sqlitePathFlag,
+		AutoMigrate:                     *autoMigrateFlag,
+		MySqlUser:                       *mysqlUserFlag,
+		MySqlPassword:                   *mysqlPasswordFlag,
+		MySqlDatabase:                   *mysqlDatabaseFlag,
+		MySqlHost:                       *mysqlHostFlag,
+		MySqlPort:                       *mysqlPortFlag,
+		CacheTTL:                        *cacheTTLFlag,
+		CacheSize:                       *cacheSizeFlag,
+		PriceMode:                       *priceModeFlag,
+		SSOClientID:                     *ssoClientIDFlag,
+		SSOClientSecret:                 *ssoClientSecretFlag,
+		SSOCallbackURL:                  *ssoCallbackURLFlag,
+		SchedulerMemberTracking:         *schedulerMemberTrackingFlag,
+		SchedulerWalletJournal:          *schedulerWalletJournalFlag,
+		SchedulerMemberTrackingSchedule: *schedulerMemberTrackingScheduleFlag,
+		SchedulerWalletJournalSchedule:  *schedulerWalletJournalScheduleFlag,
+		SchedulerFleetSync:              *schedulerFleetSyncFlag,
+		SchedulerFleetSyncSchedule:      *schedulerFleetSyncScheduleFlag,
+		ShutdownTimeout:                 *shutdownTimeoutFlag,
+		ESIURL:                          *esiURLFlag,
+		SSOURL:                          *ssoURLFlag,
+		SSOTokenKey:                     *ssoTokenKeyFlag,
+		SSOScopesMemberTracking:         *ssoScopesMemberTrackingFlag,
+		SSOScopesWallet:                 *ssoScopesWalletFlag,
+		SSOScopesFleet:                  *ssoScopesFleetFlag,
+	}
 
 	if len(*configFileFlag) > 0 {
 		configFile, err := os.Open(*configFileFlag)
 		if err != nil {
 			return &Config{}, err
 		}
+		defer configFile.Close()
 
 		decoder := json.NewDecoder(configFile)
 
-		err = decoder.Decode(&conf)
+		err = decoder.Decode(conf)
 		if err != nil {
 			return &Config{}, err
 		}
-	} else {
-		conf = &Config{
-			DebugLevel:              *debugLevelFlag,
-			DebugTemplates:          *debugTemplatesFlag,
-			HTTPPort:                *httpPortFlag,
-			HTTPHost:                *httpHostFlag,
-			MySqlUser:               *mysqlUserFlag,
-			MySqlPassword:           *mysqlPasswordFlag,
-			MySqlDatabase:           *mysqlDatabaseFlag,
-			MySqlHost:               *mysqlHostFlag,
-			MySqlPort:               *mysqlPortFlag,
-			SSOClientID:             *ssoClientIDFlag,
-			SSOClientSecret:         *ssoClientSecretFlag,
-			SSOCallbackURL:          *ssoCallbackURLFlag,
-			SchedulerMemberTracking: *schedulerMemberTrackingFlag,
-		}
 	}
 
 	return conf, nil
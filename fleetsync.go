@@ -0,0 +1,579 @@
+// fleetsync
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/morpheusxaut/lootsheeter/esi"
+	"github.com/morpheusxaut/lootsheeter/models"
+)
+
+const (
+	fleetSyncActor = "fleetsync"
+)
+
+var (
+	inGameFleetFetcher InGameFleetFetcher = NewESIInGameFleetFetcher(noESITokenSource{})
+
+	ErrNotInFleet    = errors.New("Character is not in the linked in-game fleet")
+	ErrNotFleetBoss  = errors.New("Only the fleet boss can read the members of an in-game fleet")
+	ErrFleetFinished = errors.New("Fleet is already finished")
+)
+
+type InGameFleetFetcher interface {
+	FetchCharacterFleet(playerID int64) (*models.InGameFleet, error)
+	FetchInGameFleet(playerID int64, esiFleetID int64) (*models.InGameFleet, error)
+}
+
+type ESIInGameFleetFetcher struct {
+	Tokens PlayerESITokenSource
+}
+
+func NewESIInGameFleetFetcher(tokens PlayerESITokenSource) *ESIInGameFleetFetcher {
+	fetcher := &ESIInGameFleetFetcher{
+		Tokens: tokens,
+	}
+
+	return fetcher
+}
+
+// FetchCharacterFleet returns the in-game fleet the player is currently in without its members
+func (fetcher *ESIInGameFleetFetcher) FetchCharacterFleet(playerID int64) (*models.InGameFleet, error) {
+	token, err := fetcher.Tokens.PlayerToken(playerID, SSOFeatureFleet)
+	if err != nil {
+		return &models.InGameFleet{}, err
+	}
+
+	characterFleet, err := esiClient.CharacterFleet(ESICredentials(token))
+	if esiErr, ok := err.(*esi.Error); ok && esiErr.StatusCode == http.StatusNotFound {
+		return &models.InGameFleet{}, ErrNotInFleet
+	} else if err != nil {
+		return &models.InGameFleet{}, err
+	}
+
+	inGameFleet := &models.InGameFleet{
+		ESIFleetID: characterFleet.FleetID,
+		BossID:     characterFleet.FleetBossID,
+	}
+
+	if characterFleet.FleetBossID != token.CharacterID {
+		return inGameFleet, ErrNotFleetBoss
+	}
+
+	return inGameFleet, nil
+}
+
+func (fetcher *ESIInGameFleetFetcher) FetchInGameFleet(playerID int64, esiFleetID int64) (*models.InGameFleet, error) {
+	inGameFleet, err := fetcher.FetchCharacterFleet(playerID)
+	if err == ErrNotFleetBoss && inGameFleet.ESIFleetID != esiFleetID {
+		return &models.InGameFleet{}, ErrNotInFleet
+	} else if err != nil {
+		return inGameFleet, err
+	}
+
+	if inGameFleet.ESIFleetID != esiFleetID {
+		return &models.InGameFleet{}, ErrNotInFleet
+	}
+
+	token, err := fetcher.Tokens.PlayerToken(playerID, SSOFeatureFleet)
+	if err != nil {
+		return inGameFleet, err
+	}
+
+	members, err := esiClient.FleetMembers(esiFleetID, ESICredentials(token))
+	if err != nil {
+		return inGameFleet, err
+	}
+
+	var ids []int64
+	for _, member := range members {
+		ids = append(ids, member.CharacterID, member.ShipTypeID)
+	}
+
+	names, err := esiClient.Names(uniqueIDs(ids))
+	if err != nil {
+		return inGameFleet, err
+	}
+
+	resolved := make(map[int64]string)
+	for _, name := range names {
+		resolved[name.ID] = name.Name
+	}
+
+	for _, member := range members {
+		inGameFleet.Members = append(inGameFleet.Members, models.InGameFleetMember{
+			CharacterID: member.CharacterID,
+			Name:        resolved[member.CharacterID],
+			Ship:        resolved[member.ShipTypeID],
+			JoinTime:    member.JoinTime,
+		})
+	}
+
+	return inGameFleet, nil
+}
+
+type FleetSyncResult struct {
+	Joined      int      `json:"joined"`
+	Rejoined    int      `json:"rejoined"`
+	Left        int      `json:"left"`
+	ShipChanged int      `json:"shipChanged"`
+	BossChanged bool     `json:"bossChanged"`
+	Unknown     []string `json:"unknown"`
+}
+
+func (result *FleetSyncResult) HasChanges() bool {
+	return result.Joined > 0 || result.Rejoined > 0 || result.Left > 0 || result.ShipChanged > 0 || result.BossChanged
+}
+
+func (result *FleetSyncResult) String() string {
+	return fmt.Sprintf("%d joined, %d rejoined, %d left, %d changed ships, %d unknown", result.Joined, result.Rejoined, result.Left, result.ShipChanged, len(result.Unknown))
+}
+
+type FleetSyncer struct {
+	store   Store
+	fetcher InGameFleetFetcher
+}
+
+func NewFleetSyncer(store Store, fetcher InGameFleetFetcher) *FleetSyncer {
+	syncer := &FleetSyncer{
+		store:   store,
+		fetcher: fetcher,
+	}
+
+	return syncer
+}
+
+func (syncer *FleetSyncer) SyncAll() error {
+	links, err := syncer.store.LoadAllFleetLinks()
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+
+	// A failing fleet is logged and skipped so the others are still synced
+	for _, link := range links {
+		result, err := syncer.Sync(link)
+		if err == ErrFleetFinished {
+			logger.Infof("Removed link of finished fleet #%d...", link.FleetID)
+			continue
+		} else if err != nil {
+			logger.Warnf("Failed to sync members of fleet #%d: [%v]", link.FleetID, err)
+
+			failed = append(failed, fmt.Sprintf("#%d: %v", link.FleetID, err))
+			continue
+		}
+
+		if result.HasChanges() {
+			logger.Infof("Synced members of fleet #%d: %s", link.FleetID, result)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("Failed to sync members for %d fleets: %s", len(failed), strings.Join(failed, "; "))
+	}
+
+	return nil
+}
+
+func (syncer *FleetSyncer) Sync(link *models.FleetLink) (*FleetSyncResult, error) {
+	fleetLocks.Lock(link.FleetID)
+	defer fleetLocks.Unlock(link.FleetID)
+
+	result := &FleetSyncResult{}
+
+	fleet, err := syncer.store.LoadFleet(link.FleetID)
+	if err != nil {
+		return result, err
+	}
+
+	if fleet.IsFleetFinished() {
+		err = syncer.store.DeleteFleetLink(fleet.ID)
+		if err != nil {
+			return result, err
+		}
+
+		return result, ErrFleetFinished
+	}
+
+	// Changes are applied to a copy so a failed sync does not leave the cached fleet half updated
+	fleet = fleet.Copy()
+
+	inGameFleet, err := syncer.fetch(link)
+	if err != nil {
+		link.LastError = err.Error()
+
+		_, saveErr := syncer.store.SaveFleetLink(link)
+		if saveErr != nil {
+			logger.Errorf("Failed to save link of fleet #%d: [%v]", link.FleetID, saveErr)
+		}
+
+		return result, err
+	}
+
+	before := NewFleetMemberResponses(fleet)
+
+	byCharacter := make(map[int64]*models.FleetMember)
+	for _, member := range fleet.Members {
+		byCharacter[member.Player.PlayerID] = member
+	}
+
+	// The fleet boss is the fleet commander, so a new boss takes over the role from the previous one
+	bossKnown := syncer.isKnownCharacter(inGameFleet.BossID)
+	if bossKnown {
+		for _, commander := range fleet.FleetCommanders() {
+			if commander.Player.PlayerID == inGameFleet.BossID {
+				continue
+			}
+
+			role, err := syncer.fleetRole(commander.Ship, false)
+			if err != nil {
+				return result, err
+			}
+
+			commander.Role = role
+			result.BossChanged = true
+		}
+	}
+
+	var present []int64
+
+	now := time.Now().UTC()
+
+	for _, inGameMember := range inGameFleet.Members {
+		present = append(present, inGameMember.CharacterID)
+
+		isBoss := bossKnown && inGameMember.CharacterID == inGameFleet.BossID
+
+		member, ok := byCharacter[inGameMember.CharacterID]
+		if !ok {
+			player, err := syncer.store.LoadPlayerFromPlayerID(inGameMember.CharacterID)
+			if err == sql.ErrNoRows {
+				result.Unknown = append(result.Unknown, inGameMember.Name)
+				continue
+			} else if err != nil {
+				return result, err
+			}
+
+			role, err := syncer.fleetRole(inGameMember.Ship, isBoss)
+			if err != nil {
+				return result, err
+			}
+
+			err = fleet.AddMember(models.NewFleetMember(-1, fleet.ID, player, role, inGameMember.Ship, 0, 1, 0, false, -1, inGameMember.JoinTime, time.Time{}))
+			if err != nil {
+				return result, err
+			}
+
+			result.Joined++
+			continue
+		}
+
+		if !member.IsPresent() {
+			// ESI reports when the current stint began, which is only trustworthy if it is after the member left
+			rejoined := inGameMember.JoinTime
+			if !rejoined.After(member.LeaveTime) {
+				rejoined = now
+			}
+
+			member.Join(rejoined)
+			result.Rejoined++
+		}
+
+		if isBoss && member.Role != models.FleetRoleFleetCommander {
+			member.Role = models.FleetRoleFleetCommander
+			result.BossChanged = true
+		}
+
+		if strings.EqualFold(member.Ship, inGameMember.Ship) {
+			continue
+		}
+
+		// Roles set by hand are kept, only roles matching the previous ship are updated
+		if !isBoss {
+			previous, err := syncer.fleetRole(member.Ship, false)
+			if err != nil {
+				return result, err
+			}
+
+			if member.Role == previous {
+				member.Role, err = syncer.fleetRole(inGameMember.Ship, false)
+				if err != nil {
+					return result, err
+				}
+			}
+		}
+
+		member.Ship = inGameMember.Ship
+		result.ShipChanged++
+	}
+
+	// Only members seen in the in-game fleet before are marked as left, members added by hand stay untouched
+	for _, characterID := range link.CharacterIDs {
+		member, ok := byCharacter[characterID]
+		if !ok || containsID(present, characterID) {
+			continue
+		}
+
+		if member.Leave(now) {
+			result.Left++
+		}
+	}
+
+	if result.HasChanges() {
+		fleet, err = syncer.store.SaveFleet(fleet)
+		if err != nil {
+			return result, err
+		}
+
+		RecordSystemAudit(syncer.store, fleetSyncActor, fleet.ID, -1, "syncmembers", before, NewFleetMemberResponses(fleet))
+	}
+
+	sort.Slice(present, func(i, j int) bool { return present[i] < present[j] })
+
+	link.CharacterIDs = present
+	link.LastSync = now
+	link.LastError = ""
+
+	_, err = syncer.store.SaveFleetLink(link)
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// fetch reads the in-game fleet with the token of the linking player, falling back to the token of the current boss after the boss role was passed on
+func (syncer *FleetSyncer) fetch(link *models.FleetLink) (*models.InGameFleet, error) {
+	inGameFleet, err := syncer.fetcher.FetchInGameFleet(link.PlayerID, link.ESIFleetID)
+	if err != ErrNotFleetBoss {
+		return inGameFleet, err
+	}
+
+	player, loadErr := syncer.store.LoadPlayerFromPlayerID(inGameFleet.BossID)
+	if loadErr != nil || player.ID == link.PlayerID {
+		return inGameFleet, err
+	}
+
+	inGameFleet, err = syncer.fetcher.FetchInGameFleet(player.ID, link.ESIFleetID)
+	if err != nil {
+		return inGameFleet, fmt.Errorf("Fleet boss %s needs to authorise in-game fleets on the ESI Access page: [%v]", player.Name, err)
+	}
+
+	link.PlayerID = player.ID
+
+	return inGameFleet, nil
+}
+
+func (syncer *FleetSyncer) isKnownCharacter(characterID int64) bool {
+	_, err := syncer.store.LoadPlayerFromPlayerID(characterID)
+
+	return err == nil
+}
+
+func (syncer *FleetSyncer) fleetRole(ship string, boss bool) (models.FleetRole, error) {
+	if boss {
+		return models.FleetRoleFleetCommander, nil
+	}
+
+	role, err := syncer.store.QueryShipRole(ship)
+	if err == sql.ErrNoRows {
+		return models.FleetRoleNone, nil
+	} else if err != nil {
+		return models.FleetRoleUnknown, err
+	}
+
+	return role, nil
+}
+
+func FleetLinkPostHandler(w http.ResponseWriter, r *http.Request) {
+	response := make(map[string]interface{})
+
+	vars := mux.Vars(r)
+	fleetID, err := strconv.ParseInt(vars["fleetid"], 10, 64)
+	if err != nil {
+		logger.Errorf("Failed to parse fleet ID %q in FleetLinkPostHandler: [%v]", vars["fleetid"], err)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Failed to parse fleet ID")
+		return
+	}
+
+	loggedIn := session.IsLoggedIn(w, r)
+
+	if !loggedIn {
+		SendJSONError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Not logged in")
+		return
+	}
+
+	fleet, err := database.LoadFleet(fleetID)
+	if err != nil {
+		logger.Errorf("Failed to load fleet in FleetLinkPostHandler: [%v]", err)
+
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to load fleet")
+		return
+	}
+
+	if fleet.Corporation.ID != session.GetCorpID(r) {
+		SendJSONError(w, http.StatusNotFound, ErrorCodeNotFound, "Fleet not found")
+		return
+	}
+
+	if !IsFleetCommander(r, fleet) && !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
+		logger.Warnf("Received request to FleetLinkPostHandler without proper access...")
+
+		SendJSONError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask or fleet role")
+		return
+	}
+
+	if fleet.IsFleetFinished() {
+		SendJSONError(w, http.StatusConflict, ErrorCodeConflict, "Cannot link a finished fleet")
+		return
+	}
+
+	playerID := session.GetPlayerID(r)
+
+	inGameFleet, err := inGameFleetFetcher.FetchCharacterFleet(playerID)
+	if err == ErrNoESIToken {
+		SendJSONError(w, http.StatusConflict, ErrorCodeConflict, "Authorise in-game fleets on the ESI Access page first")
+		return
+	} else if err == ErrNotInFleet {
+		SendJSONError(w, http.StatusConflict, ErrorCodeConflict, "You are not in an in-game fleet")
+		return
+	} else if err == ErrNotFleetBoss {
+		SendJSONError(w, http.StatusConflict, ErrorCodeConflict, "Only the fleet boss can link an in-game fleet")
+		return
+	} else if err != nil {
+		logger.Errorf("Failed to fetch in-game fleet in FleetLinkPostHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusBadGateway, ErrorCodeInternal, "Failed to fetch in-game fleet")
+		return
+	}
+
+	link, err := database.LoadFleetLink(fleet.ID)
+	if err == sql.ErrNoRows {
+		link = models.NewFleetLink(-1, fleet.ID, inGameFleet.ESIFleetID, playerID, nil, time.Time{}, "", time.Now().UTC())
+	} else if err != nil {
+		logger.Errorf("Failed to load fleet link in FleetLinkPostHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to load fleet link")
+		return
+	}
+
+	// Linking a different in-game fleet starts over, members of the old one are not marked as left
+	if link.ESIFleetID != inGameFleet.ESIFleetID {
+		link.CharacterIDs = nil
+	}
+
+	link.ESIFleetID = inGameFleet.ESIFleetID
+	link.PlayerID = playerID
+
+	link, err = database.SaveFleetLink(link)
+	if err != nil {
+		logger.Errorf("Failed to save fleet link in FleetLinkPostHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to save fleet link")
+		return
+	}
+
+	RecordAudit(r, fleet.ID, -1, "linkfleet", nil, NewFleetLinkResponse(link))
+
+	result, err := NewFleetSyncer(database, inGameFleetFetcher).Sync(link)
+	if err != nil {
+		logger.Errorf("Failed to sync fleet members in FleetLinkPostHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusBadGateway, ErrorCodeInternal, fmt.Sprintf("Linked in-game fleet but failed to sync members: %v", err))
+		return
+	}
+
+	response["result"] = "success"
+	response["error"] = nil
+	response["link"] = NewFleetLinkResponse(link)
+	response["sync"] = result
+
+	SendJSONResponse(w, response)
+}
+
+func FleetLinkDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	response := make(map[string]interface{})
+
+	vars := mux.Vars(r)
+	fleetID, err := strconv.ParseInt(vars["fleetid"], 10, 64)
+	if err != nil {
+		logger.Errorf("Failed to parse fleet ID %q in FleetLinkDeleteHandler: [%v]", vars["fleetid"], err)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Failed to parse fleet ID")
+		return
+	}
+
+	loggedIn := session.IsLoggedIn(w, r)
+
+	if !loggedIn {
+		SendJSONError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Not logged in")
+		return
+	}
+
+	fleet, err := database.LoadFleet(fleetID)
+	if err != nil {
+		logger.Errorf("Failed to load fleet in FleetLinkDeleteHandler: [%v]", err)
+
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to load fleet")
+		return
+	}
+
+	if fleet.Corporation.ID != session.GetCorpID(r) {
+		SendJSONError(w, http.StatusNotFound, ErrorCodeNotFound, "Fleet not found")
+		return
+	}
+
+	if !IsFleetCommander(r, fleet) && !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
+		logger.Warnf("Received request to FleetLinkDeleteHandler without proper access...")
+
+		SendJSONError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask or fleet role")
+		return
+	}
+
+	link, err := database.LoadFleetLink(fleet.ID)
+	if err != nil {
+		logger.Errorf("Failed to load fleet link in FleetLinkDeleteHandler: [%v]", err)
+
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to load fleet link")
+		return
+	}
+
+	err = database.DeleteFleetLink(fleet.ID)
+	if err != nil {
+		logger.Errorf("Failed to delete fleet link in FleetLinkDeleteHandler: [%v]", err)
+
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to delete fleet link")
+		return
+	}
+
+	RecordAudit(r, fleet.ID, -1, "unlinkfleet", NewFleetLinkResponse(link), nil)
+
+	response["result"] = "success"
+	response["error"] = nil
+
+	SendJSONResponse(w, response)
+}
+
+func containsID(ids []int64, id int64) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+
+	return false
+}
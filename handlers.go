@@ -2,6 +2,7 @@
 package main
 
 import (
+	"database/sql"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -49,9 +50,7 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 
 	session.SetSSOState(w, r, state)
 
-	data["SSOState"] = state
-	data["SSOClientID"] = config.SSOClientID
-	data["SSOCallbackURL"] = config.SSOCallbackURL
+	data["SSOLoginURL"] = SSOAuthorizeURL(state, nil)
 
 	templates.Funcs(TemplateFunctions(r)).ExecuteTemplate(w, "login", data)
 }
@@ -115,6 +114,25 @@ func LoginSSOHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Tokens are only kept when the player consented to additional scopes, a plain login does not need them
+	if len(strings.Fields(v.Scopes)) > 0 {
+		player, err := database.LoadPlayerFromPlayerID(v.CharacterID)
+		if err != nil {
+			logger.Errorf("Received error while loading player in LoginSSOHandler: [%v]", err)
+
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		_, err = SaveSSOToken(database, player, t, v)
+		if err != nil {
+			logger.Errorf("Received error while saving ESI token in LoginSSOHandler: [%v]", err)
+
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
 	http.Redirect(w, r, session.GetLoginRedirect(r), http.StatusSeeOther)
 }
 
@@ -192,9 +210,9 @@ func FleetCreateHandler(w http.ResponseWriter, r *http.Request) {
 
 	corporationID := session.GetCorpID(r)
 
-	players, err := database.LoadAllPlayers(corporationID)
+	players, err := database.LoadActivePlayers(corporationID)
 	if err != nil {
-		logger.Errorf("Failed to load all players in FleetCreateHandler: [%v]", err)
+		logger.Errorf("Failed to load active players in FleetCreateHandler: [%v]", err)
 
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -252,7 +270,7 @@ func FleetCreateFormHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	fleet := models.NewFleet(-1, corporation, fleetName, fleetSystem, fleetSystemNickname, 0, 0, 0, time.Now(), time.Time{}, 0, false, "", -1)
+	fleet := models.NewFleet(-1, corporation, fleetName, fleetSystem, fleetSystemNickname, 0, 0, 0, time.Now(), time.Time{}, 0, false, "", -1, 0)
 
 	player, err := database.LoadPlayer(fleetCommanderID)
 	if err != nil {
@@ -270,7 +288,7 @@ func FleetCreateFormHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	commander := models.NewFleetMember(fleetCommanderID, fleet.ID, player, models.FleetRoleFleetCommander, "", 0, 1, 0, false, -1)
+	commander := models.NewFleetMember(-1, fleet.ID, player, models.FleetRoleFleetCommander, "", 0, 1, 0, false, -1, fleet.StartTime, time.Time{})
 
 	fleet.AddMember(commander)
 
@@ -324,40 +342,76 @@ func FleetGetHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !fleet.PayoutComplete {
-		payoutComplete := true
+	data["Fleet"] = fleet
 
-		for _, member := range fleet.Members {
-			if !member.PayoutComplete {
-				payoutComplete = false
-				break
-			}
-		}
+	availablePlayers, err := database.LoadAvailablePlayers(fleetID, fleet.Corporation.ID)
+	if err != nil {
+		logger.Errorf("Failed to load available players for fleet #%d in FleetGetHandler: [%v]", fleetID, err)
 
-		if payoutComplete {
-			fleet.PayoutComplete = true
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
-			fleet, err = database.SaveFleet(fleet)
-			if err != nil {
-				logger.Errorf("Failed to update fleet #%d in FleetGetHandler: [%v]", fleetID, err)
+	data["AvailablePlayers"] = availablePlayers
 
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
-		}
+	fleetLink, err := database.LoadFleetLink(fleetID)
+	if err == nil {
+		data["FleetLink"] = fleetLink
+	} else if err != sql.ErrNoRows {
+		logger.Errorf("Failed to load link for fleet #%d in FleetGetHandler: [%v]", fleetID, err)
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 
-	data["Fleet"] = fleet
+	data["PriceMode"] = config.PriceMode
+	data["PayoutStrategies"] = models.PayoutStrategyTypes()
 
-	availablePlayers, err := database.LoadAvailablePlayers(fleetID, fleet.Corporation.ID)
+	lootPastes, err := database.LoadAllLootPastes(fleetID)
 	if err != nil {
-		logger.Errorf("Failed to load available players for fleet #%d in FleetGetHandler: [%v]", fleetID, err)
+		logger.Errorf("Failed to load loot pastes for fleet #%d in FleetGetHandler: [%v]", fleetID, err)
 
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	data["AvailablePlayers"] = availablePlayers
+	pastedBy := make(map[int64]string)
+
+	for _, paste := range lootPastes {
+		if _, ok := pastedBy[paste.PastedBy]; ok {
+			continue
+		}
+
+		player, err := database.LoadPlayer(paste.PastedBy)
+		if err != nil {
+			logger.Errorf("Failed to load player #%d for loot paste #%d in FleetGetHandler: [%v]", paste.PastedBy, paste.ID, err)
+
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		pastedBy[paste.PastedBy] = player.Name
+	}
+
+	siteLoot := make(map[int64]float64)
+
+	for _, paste := range lootPastes {
+		if paste.Voided || paste.SiteID <= 0 {
+			continue
+		}
+
+		if paste.PasteType == models.LootPasteTypeLoss {
+			siteLoot[paste.SiteID] -= paste.Value
+		} else {
+			siteLoot[paste.SiteID] += paste.Value
+		}
+	}
+
+	data["LootPastes"] = lootPastes
+	data["LootPastedBy"] = pastedBy
+	data["SiteLoot"] = siteLoot
+	data["ProfitGroups"] = models.GroupLootPasteItems(lootPastes, models.LootPasteTypeProfit)
+	data["LossGroups"] = models.GroupLootPasteItems(lootPastes, models.LootPasteTypeLoss)
 
 	err = templates.Funcs(TemplateFunctions(r)).ExecuteTemplate(w, "fleetdetails", data)
 	if err != nil {
@@ -371,15 +425,14 @@ func FleetPutHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		logger.Errorf("Failed to parse fleet ID %q in FleetPutHandler: [%v]", vars["fleetid"], err)
 
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
 		return
 	}
 
 	loggedIn := session.IsLoggedIn(w, r)
 
 	if !loggedIn {
-		session.SetLoginRedirect(w, r, fmt.Sprintf("/fleet/%d", fleetID))
-		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		SendJSONError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Not logged in")
 		return
 	}
 
@@ -387,7 +440,7 @@ func FleetPutHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		logger.Errorf("Failed to parse form in FleetPutHandler: [%v]", err)
 
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -395,22 +448,26 @@ func FleetPutHandler(w http.ResponseWriter, r *http.Request) {
 	if len(command) == 0 {
 		logger.Errorf("Received empty command in FleetPutHandler...")
 
-		http.Error(w, "Received empty command", http.StatusBadRequest)
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Received empty command")
 		return
 	}
 
+	fleetLocks.Lock(fleetID)
+	defer fleetLocks.Unlock(fleetID)
+
 	fleet, err := database.LoadFleet(fleetID)
 	if err != nil {
 		logger.Errorf("Failed to load fleet in FleetPutHandler: [%v]", err)
 
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to load fleet")
 		return
 	}
 
 	corporationID := session.GetCorpID(r)
 
 	if fleet.Corporation.ID != corporationID {
-		http.Redirect(w, r, "/fleets", http.StatusSeeOther)
+		SendJSONError(w, http.StatusNotFound, ErrorCodeNotFound, "Fleet not found")
 		return
 	}
 
@@ -427,6 +484,12 @@ func FleetPutHandler(w http.ResponseWriter, r *http.Request) {
 	case "addloss":
 		FleetPutAddLossHandler(w, r, fleet)
 		break
+	case "setpayoutstrategy":
+		FleetPutSetPayoutStrategyHandler(w, r, fleet)
+		break
+	case "previewpayouts":
+		FleetPutPreviewPayoutsHandler(w, r, fleet)
+		break
 	case "calculatepayouts":
 		FleetPutCalculatePayoutsHandler(w, r, fleet)
 		break
@@ -434,11 +497,7 @@ func FleetPutHandler(w http.ResponseWriter, r *http.Request) {
 		FleetPutFinishFleetHandler(w, r, fleet)
 		break
 	default:
-		response := make(map[string]interface{})
-		response["result"] = "error"
-		response["error"] = "Invalid command"
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidCommand, "Invalid command")
 	}
 }
 
@@ -448,29 +507,52 @@ func FleetPutTickSitesFinishedHandler(w http.ResponseWriter, r *http.Request, fl
 	if !IsFleetCommander(r, fleet) && !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
 		logger.Warnf("Received request to FleetPutTickSitesFinishedHandler without proper access...")
 
-		response["result"] = "error"
-		response["error"] = "Unauthorised access: cannot perform this operation with your current access mask or fleet role"
+		SendJSONError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask or fleet role")
+		return
+	}
 
-		SendJSONResponse(w, response)
+	if fleet.IsFleetFinished() {
+		logger.Warnf("Received request to FleetPutTickSitesFinishedHandler for finished fleet #%d...", fleet.ID)
+
+		SendJSONError(w, http.StatusConflict, ErrorCodeConflict, "Cannot tick sites of a finished fleet")
 		return
 	}
 
-	fleet.SitesFinished++
+	player := session.GetPlayerFromRequest(r)
+	if player == nil {
+		logger.Errorf("Failed to get player from request in FleetPutTickSitesFinishedHandler...")
 
-	fleet, err := database.SaveFleet(fleet)
+		SendJSONError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Failed to load player, cannot tick site")
+		return
+	}
+
+	finished := time.Now()
+
+	site := models.NewFleetSite(-1, fleet.ID, strings.TrimSpace(r.FormValue("tickSiteName")), finished, strings.EqualFold(r.FormValue("tickSiteEscalation"), "true"), player.ID, fleet.MembersPresentAt(finished))
+
+	site, err := database.SaveFleetSite(site)
 	if err != nil {
-		logger.Errorf("Failed to save fleet in FleetPutTickSitesFinishedHandler: [%v]", err)
+		logger.Errorf("Failed to save fleet site in FleetPutTickSitesFinishedHandler: [%v]", err)
 
-		response["result"] = "error"
-		response["error"] = err.Error()
+		SendJSONError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to save fleet site")
+		return
+	}
 
-		SendJSONResponse(w, response)
+	fleet, err = database.LoadFleet(fleet.ID)
+	if err != nil {
+		logger.Errorf("Failed to reload fleet in FleetPutTickSitesFinishedHandler: [%v]", err)
+
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to reload fleet")
 		return
 	}
 
+	RecordAudit(r, fleet.ID, -1, "ticksitesfinished", nil, NewFleetSiteResponse(site))
+
 	response["result"] = "success"
 	response["error"] = nil
-	response["fleet"] = fleet
+	response["fleet"] = NewFleetResponse(fleet)
+	response["site"] = NewFleetSiteResponse(site)
 
 	SendJSONResponse(w, response)
 }
@@ -481,21 +563,17 @@ func FleetPutEditDetailsHandler(w http.ResponseWriter, r *http.Request, fleet *m
 	if !IsFleetCommander(r, fleet) && !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
 		logger.Warnf("Received request to FleetPutEditDetailsHandler without proper access...")
 
-		response["result"] = "error"
-		response["error"] = "Unauthorised access: cannot perform this operation with your current access mask or fleet role"
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask or fleet role")
 		return
 	}
 
+	before := NewFleetResponse(fleet)
+
 	startTime, err := time.Parse("2006-01-02 15:04:05 +0000 UTC", r.FormValue("fleetDetailsStartTimeEdit"))
 	if err != nil {
 		logger.Errorf("Failed to parse startTime in FleetPutEditDetailsHandler: [%v]", err)
 
-		response["result"] = "error"
-		response["error"] = err.Error()
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -508,35 +586,18 @@ func FleetPutEditDetailsHandler(w http.ResponseWriter, r *http.Request, fleet *m
 		if err != nil {
 			logger.Errorf("Failed to parse endTime in FleetPutEditDetailsHandler: [%v]", err)
 
-			response["result"] = "error"
-			response["error"] = err.Error()
-
-			SendJSONResponse(w, response)
+			SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
 			return
 		}
 
 		endTime = e
 	}
 
-	sitesFinished, err := strconv.ParseInt(r.FormValue("fleetDetailsSitesFinishedEdit"), 10, 64)
-	if err != nil {
-		logger.Errorf("Failed to parse sitesFinished in FleetPutEditDetailsHandler: [%v]", err)
-
-		response["result"] = "error"
-		response["error"] = err.Error()
-
-		SendJSONResponse(w, response)
-		return
-	}
-
 	payoutComplete, err := strconv.ParseBool(r.FormValue("fleetDetailsPayoutCompleteEdit"))
 	if err != nil {
 		logger.Errorf("Failed to parse payoutComplete in FleetPutEditDetailsHandler: [%v]", err)
 
-		response["result"] = "error"
-		response["error"] = err.Error()
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -544,7 +605,6 @@ func FleetPutEditDetailsHandler(w http.ResponseWriter, r *http.Request, fleet *m
 
 	fleet.StartTime = startTime
 	fleet.EndTime = endTime
-	fleet.SitesFinished = int(sitesFinished)
 	fleet.PayoutComplete = payoutComplete
 	fleet.Notes = notes
 
@@ -552,16 +612,15 @@ func FleetPutEditDetailsHandler(w http.ResponseWriter, r *http.Request, fleet *m
 	if err != nil {
 		logger.Errorf("Failed to save fleet in FleetPutEditDetailsHandler: [%v]", err)
 
-		response["result"] = "error"
-		response["error"] = err.Error()
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to save fleet")
 		return
 	}
 
+	RecordAudit(r, fleet.ID, -1, "editdetails", before, NewFleetResponse(fleet))
+
 	response["result"] = "success"
 	response["error"] = nil
-	response["fleet"] = fleet
+	response["fleet"] = NewFleetResponse(fleet)
 
 	SendJSONResponse(w, response)
 }
@@ -572,21 +631,17 @@ func FleetPutAddProfitHandler(w http.ResponseWriter, r *http.Request, fleet *mod
 	if !IsFleetCommander(r, fleet) && !HasFleetRole(r, fleet, 8) && !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
 		logger.Warnf("Received request to FleetPutAddProfitHandler without proper access...")
 
-		response["result"] = "error"
-		response["error"] = "Unauthorised access: cannot perform this operation with your current access mask or fleet role"
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask or fleet role")
 		return
 	}
 
+	before := NewFleetResponse(fleet)
+
 	rawProfit := r.FormValue("addProfitRaw")
 	if len(rawProfit) == 0 {
 		logger.Errorf("Content of rawProfit in FleetPutAddProfitHandler was empty...")
 
-		response["result"] = "error"
-		response["error"] = fmt.Sprintf("Content of rawProfit was empty")
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, fmt.Sprintf("Content of rawProfit was empty"))
 		return
 	}
 
@@ -594,91 +649,59 @@ func FleetPutAddProfitHandler(w http.ResponseWriter, r *http.Request, fleet *mod
 	if player == nil {
 		logger.Errorf("Failed to get player from request in FleetPutAddProfitHandler...")
 
-		response["result"] = "error"
-		response["error"] = "Failed to load player, cannot submit loot paste"
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Failed to load player, cannot submit loot paste")
 		return
 	}
 
-	lootPaste := models.NewLootPaste(-1, fleet.ID, player.ID, rawProfit, 0, models.LootPasteTypeProfit)
-
-	lootPaste, err := database.SaveLootPaste(lootPaste)
+	priceMode, err := ParseRequestPriceMode(r.FormValue("addProfitPriceMode"))
 	if err != nil {
-		logger.Errorf("Failed to save loot paste in FleetPutAddProfitHandler: [%v]", err)
+		logger.Errorf("Failed to parse price mode in FleetPutAddProfitHandler: [%v]", err)
 
-		response["result"] = "error"
-		response["error"] = err.Error()
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
 		return
 	}
 
-	var profit float64
-
-	profit = 0
-
-	if strings.Contains(strings.ToLower(rawProfit), "evepraisal") {
-		rowSplit := strings.Split(rawProfit, "\r\n")
-
-		for _, row := range rowSplit {
-			p, err := GetEvepraisalValue(row)
-			if err != nil {
-				logger.Errorf("Failed to parse evepraisal row in FleetPutAddProfitHandler: [%v]", err)
-
-				response["result"] = "error"
-				response["error"] = err.Error()
-
-				SendJSONResponse(w, response)
-				return
-			}
+	siteID, err := ParseRequestSiteID(fleet, r.FormValue("addProfitSiteID"))
+	if err != nil {
+		logger.Errorf("Failed to parse site ID in FleetPutAddProfitHandler: [%v]", err)
 
-			profit += p
-		}
-	} else {
-		p, err := GetPasteValue(rawProfit)
-		if err != nil {
-			logger.Errorf("Failed to parse paste in FleetPutAddProfitHandler: [%v]", err)
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
 
-			response["result"] = "error"
-			response["error"] = err.Error()
+	lootPaste := models.NewLootPaste(-1, fleet.ID, player.ID, rawProfit, 0, models.LootPasteTypeProfit)
+	lootPaste.SiteID = siteID
 
-			SendJSONResponse(w, response)
-			return
-		}
+	err = AppraiseLootPaste(lootPaste, priceMode)
+	if err != nil {
+		logger.Errorf("Failed to appraise paste in FleetPutAddProfitHandler: [%v]", err)
 
-		profit = p
+		SendJSONError(w, http.StatusUnprocessableEntity, ErrorCodeUnprocessable, err.Error())
+		return
 	}
 
-	lootPaste.Value = profit
-
 	lootPaste, err = database.SaveLootPaste(lootPaste)
 	if err != nil {
-		logger.Errorf("Failed to update loot paste in FleetPutAddProfitHandler: [%v]", err)
-
-		response["result"] = "error"
-		response["error"] = err.Error()
+		logger.Errorf("Failed to save loot paste in FleetPutAddProfitHandler: [%v]", err)
 
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to save loot paste")
 		return
 	}
 
-	fleet.AddProfit(profit)
-
-	fleet, err = database.SaveFleet(fleet)
+	fleet, err = database.LoadFleet(fleet.ID)
 	if err != nil {
-		logger.Errorf("Failed to save fleet in FleetPutAddProfitHandler: [%v]", err)
-
-		response["result"] = "error"
-		response["error"] = err.Error()
+		logger.Errorf("Failed to reload fleet in FleetPutAddProfitHandler: [%v]", err)
 
-		SendJSONResponse(w, response)
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to reload fleet")
 		return
 	}
 
+	RecordAudit(r, fleet.ID, -1, "addprofit", before, NewFleetResponse(fleet))
+
 	response["result"] = "success"
 	response["error"] = nil
-	response["fleet"] = fleet
+	response["fleet"] = NewFleetResponse(fleet)
 
 	SendJSONResponse(w, response)
 }
@@ -689,21 +712,17 @@ func FleetPutAddLossHandler(w http.ResponseWriter, r *http.Request, fleet *model
 	if !IsFleetCommander(r, fleet) && !HasFleetRole(r, fleet, 8) && !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
 		logger.Warnf("Received request to FleetPutAddLossHandler without proper access...")
 
-		response["result"] = "error"
-		response["error"] = "Unauthorised access: cannot perform this operation with your current access mask or fleet role"
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask or fleet role")
 		return
 	}
 
+	before := NewFleetResponse(fleet)
+
 	rawLoss := r.FormValue("addLossRaw")
 	if len(rawLoss) == 0 {
 		logger.Errorf("Content of rawLoss in FleetPutAddLossHandler was empty...")
 
-		response["result"] = "error"
-		response["error"] = fmt.Sprintf("Content of rawLoss was empty")
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, fmt.Sprintf("Content of rawLoss was empty"))
 		return
 	}
 
@@ -711,108 +730,151 @@ func FleetPutAddLossHandler(w http.ResponseWriter, r *http.Request, fleet *model
 	if player == nil {
 		logger.Errorf("Failed to get player from request in FleetPutAddLossHandler...")
 
-		response["result"] = "error"
-		response["error"] = "Failed to load player, cannot submit loot paste"
+		SendJSONError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Failed to load player, cannot submit loot paste")
+		return
+	}
+
+	priceMode, err := ParseRequestPriceMode(r.FormValue("addLossPriceMode"))
+	if err != nil {
+		logger.Errorf("Failed to parse price mode in FleetPutAddLossHandler: [%v]", err)
 
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	siteID, err := ParseRequestSiteID(fleet, r.FormValue("addLossSiteID"))
+	if err != nil {
+		logger.Errorf("Failed to parse site ID in FleetPutAddLossHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
 		return
 	}
 
 	lootPaste := models.NewLootPaste(-1, fleet.ID, player.ID, rawLoss, 0, models.LootPasteTypeLoss)
+	lootPaste.SiteID = siteID
+
+	err = AppraiseLootPaste(lootPaste, priceMode)
+	if err != nil {
+		logger.Errorf("Failed to appraise paste in FleetPutAddLossHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusUnprocessableEntity, ErrorCodeUnprocessable, err.Error())
+		return
+	}
 
-	lootPaste, err := database.SaveLootPaste(lootPaste)
+	lootPaste, err = database.SaveLootPaste(lootPaste)
 	if err != nil {
 		logger.Errorf("Failed to save loot paste in FleetPutAddLossHandler: [%v]", err)
 
-		response["result"] = "error"
-		response["error"] = err.Error()
+		SendJSONError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to save loot paste")
+		return
+	}
+
+	fleet, err = database.LoadFleet(fleet.ID)
+	if err != nil {
+		logger.Errorf("Failed to reload fleet in FleetPutAddLossHandler: [%v]", err)
 
-		SendJSONResponse(w, response)
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to reload fleet")
 		return
 	}
 
-	var loss float64
+	RecordAudit(r, fleet.ID, -1, "addloss", before, NewFleetResponse(fleet))
 
-	loss = 0
+	response["result"] = "success"
+	response["error"] = nil
+	response["fleet"] = NewFleetResponse(fleet)
 
-	if strings.Contains(strings.ToLower(rawLoss), "evepraisal") {
-		rowSplit := strings.Split(rawLoss, "\r\n")
+	SendJSONResponse(w, response)
+}
 
-		for _, row := range rowSplit {
-			l, err := GetEvepraisalValue(row)
-			if err != nil {
-				logger.Errorf("Failed to parse evepraisal row in FleetPutAddLossHandler: [%v]", err)
+func FleetPutSetPayoutStrategyHandler(w http.ResponseWriter, r *http.Request, fleet *models.Fleet) {
+	response := make(map[string]interface{})
 
-				response["result"] = "error"
-				response["error"] = err.Error()
+	if !HasHigherAccessMask(r, models.AccessMaskDirector) {
+		logger.Warnf("Received request to FleetPutSetPayoutStrategyHandler without proper access...")
 
-				SendJSONResponse(w, response)
-				return
-			}
+		SendJSONError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask or fleet role")
+		return
+	}
 
-			loss += l
-		}
-	} else if strings.Contains(strings.ToLower(rawLoss), "zkillboard") {
-		rowSplit := strings.Split(rawLoss, "\r\n")
+	before := NewFleetResponse(fleet)
 
-		for _, row := range rowSplit {
-			l, err := GetZKillboardValue(row)
-			if err != nil {
-				logger.Errorf("Failed to parse zKillboard row in FleetPutAddLossHandler: [%v]", err)
+	if fleet.IsFleetFinished() {
+		logger.Warnf("Received request to FleetPutSetPayoutStrategyHandler for finished fleet #%d...", fleet.ID)
 
-				response["result"] = "error"
-				response["error"] = err.Error()
+		SendJSONError(w, http.StatusConflict, ErrorCodeConflict, "Cannot change the payout strategy of a finished fleet")
+		return
+	}
 
-				SendJSONResponse(w, response)
-				return
-			}
+	strategy, err := strconv.ParseInt(r.FormValue("fleetPayoutStrategy"), 10, 64)
+	if err != nil {
+		logger.Errorf("Failed to parse payout strategy in FleetPutSetPayoutStrategyHandler: [%v]", err)
 
-			loss += l
-		}
-	} else {
-		l, err := GetPasteValue(rawLoss)
-		if err != nil {
-			logger.Errorf("Failed to parse paste in FleetPutAddLossHandler: [%v]", err)
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
 
-			response["result"] = "error"
-			response["error"] = err.Error()
+	strategyType := models.PayoutStrategyType(strategy)
 
-			SendJSONResponse(w, response)
-			return
-		}
+	if strategyType != 0 && !strategyType.IsValid() {
+		logger.Errorf("Received invalid payout strategy %d in FleetPutSetPayoutStrategyHandler...", strategy)
 
-		loss = l
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Invalid payout strategy")
+		return
 	}
 
-	lootPaste.Value = loss
+	fleet.PayoutStrategy = strategyType
 
-	lootPaste, err = database.SaveLootPaste(lootPaste)
+	fleet, err = database.SaveFleet(fleet)
 	if err != nil {
-		logger.Errorf("Failed to update loot paste in FleetPutAddLossHandler: [%v]", err)
-
-		response["result"] = "error"
-		response["error"] = err.Error()
+		logger.Errorf("Failed to save fleet in FleetPutSetPayoutStrategyHandler: [%v]", err)
 
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to save fleet")
 		return
 	}
 
-	fleet.AddLoss(loss)
+	RecordAudit(r, fleet.ID, -1, "setpayoutstrategy", before, NewFleetResponse(fleet))
 
-	fleet, err = database.SaveFleet(fleet)
-	if err != nil {
-		logger.Errorf("Failed to save fleet in FleetPutAddLossHandler: [%v]", err)
+	response["result"] = "success"
+	response["error"] = nil
+	response["fleet"] = NewFleetResponse(fleet)
 
-		response["result"] = "error"
-		response["error"] = err.Error()
+	SendJSONResponse(w, response)
+}
 
-		SendJSONResponse(w, response)
+func FleetPutPreviewPayoutsHandler(w http.ResponseWriter, r *http.Request, fleet *models.Fleet) {
+	response := make(map[string]interface{})
+
+	if !IsFleetCommander(r, fleet) && !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
+		logger.Warnf("Received request to FleetPutPreviewPayoutsHandler without proper access...")
+
+		SendJSONError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask or fleet role")
 		return
 	}
 
+	strategyType := fleet.EffectivePayoutStrategy()
+
+	if len(r.FormValue("fleetPayoutStrategy")) > 0 {
+		strategy, err := strconv.ParseInt(r.FormValue("fleetPayoutStrategy"), 10, 64)
+		if err != nil {
+			logger.Errorf("Failed to parse payout strategy in FleetPutPreviewPayoutsHandler: [%v]", err)
+
+			SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+			return
+		}
+
+		if models.PayoutStrategyType(strategy).IsValid() {
+			strategyType = models.PayoutStrategyType(strategy)
+		}
+	}
+
+	corporationPayout, payouts := fleet.PreviewPayouts(strategyType)
+
 	response["result"] = "success"
 	response["error"] = nil
-	response["fleet"] = fleet
+	response["strategy"] = strategyType.String()
+	response["corporationPayout"] = corporationPayout
+	response["payouts"] = NewMemberPayoutResponses(fleet, payouts)
 
 	SendJSONResponse(w, response)
 }
@@ -823,29 +885,27 @@ func FleetPutCalculatePayoutsHandler(w http.ResponseWriter, r *http.Request, fle
 	if !IsFleetCommander(r, fleet) && !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
 		logger.Warnf("Received request to FleetPutCalculatePayoutsHandler without proper access...")
 
-		response["result"] = "error"
-		response["error"] = "Unauthorised access: cannot perform this operation with your current access mask or fleet role"
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask or fleet role")
 		return
 	}
 
+	before := NewFleetResponse(fleet)
+
 	fleet.CalculatePayouts()
 
 	fleet, err := database.SaveFleet(fleet)
 	if err != nil {
 		logger.Errorf("Failed to save fleet in FleetPutCalculatePayoutsHandler: [%v]", err)
 
-		response["result"] = "error"
-		response["error"] = err.Error()
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to save fleet")
 		return
 	}
 
+	RecordAudit(r, fleet.ID, -1, "calculatepayouts", before, NewFleetResponse(fleet))
+
 	response["result"] = "success"
 	response["error"] = nil
-	response["fleet"] = fleet
+	response["fleet"] = NewFleetResponse(fleet)
 
 	SendJSONResponse(w, response)
 }
@@ -856,29 +916,32 @@ func FleetPutFinishFleetHandler(w http.ResponseWriter, r *http.Request, fleet *m
 	if !IsFleetCommander(r, fleet) && !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
 		logger.Warnf("Received request to FleetPutFinishFleetHandler without proper access...")
 
-		response["result"] = "error"
-		response["error"] = "Unauthorised access: cannot perform this operation with your current access mask or fleet role"
+		SendJSONError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask or fleet role")
+		return
+	}
 
-		SendJSONResponse(w, response)
+	if names := fleet.MembersWithoutRole(); len(names) > 0 {
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, fmt.Sprintf("Pick a fleet role for %s before finishing the fleet", strings.Join(names, ", ")))
 		return
 	}
 
+	before := NewFleetResponse(fleet)
+
 	fleet.FinishFleet()
 
 	fleet, err := database.SaveFleet(fleet)
 	if err != nil {
 		logger.Errorf("Failed to save fleet in FleetPutFinishFleetHandler: [%v]", err)
 
-		response["result"] = "error"
-		response["error"] = err.Error()
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to save fleet")
 		return
 	}
 
+	RecordAudit(r, fleet.ID, -1, "finishfleet", before, NewFleetResponse(fleet))
+
 	response["result"] = "success"
 	response["error"] = nil
-	response["fleet"] = fleet
+	response["fleet"] = NewFleetResponse(fleet)
 
 	SendJSONResponse(w, response)
 }
@@ -891,35 +954,34 @@ func FleetMembersGetHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		logger.Errorf("Failed to parse fleet ID %q in FleetMembersGetHandler: [%v]", vars["fleetid"], err)
 
-		response["result"] = "error"
-		response["error"] = "Failed to parse fleet ID"
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Failed to parse fleet ID")
 		return
 	}
 
 	loggedIn := session.IsLoggedIn(w, r)
 
 	if !loggedIn {
-		session.SetLoginRedirect(w, r, fmt.Sprintf("/fleet/%d", fleetID))
-		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		SendJSONError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Not logged in")
 		return
 	}
 
-	fleetMembers, err := database.LoadAllFleetMembers(fleetID)
+	fleet, err := database.LoadFleet(fleetID)
 	if err != nil {
-		logger.Errorf("Failed to load all fleet members for fleet #%d in FleetMembersGetHandler: [%v]", fleetID, err)
+		logger.Errorf("Failed to load fleet #%d in FleetMembersGetHandler: [%v]", fleetID, err)
 
-		response["result"] = "error"
-		response["error"] = err.Error()
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to load fleet")
+		return
+	}
 
-		SendJSONResponse(w, response)
+	if fleet.Corporation.ID != session.GetCorpID(r) {
+		SendJSONError(w, http.StatusNotFound, ErrorCodeNotFound, "Fleet not found")
 		return
 	}
 
 	response["result"] = "success"
 	response["error"] = nil
-	response["fleetMembers"] = fleetMembers
+	response["fleetMembers"] = NewFleetMemberResponses(fleet)
 
 	SendJSONResponse(w, response)
 	return
@@ -934,78 +996,92 @@ func FleetMembersPostHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		logger.Errorf("Failed to parse fleet ID %q in FleetMembersPostHandler: [%v]", vars["fleetid"], err)
 
-		response["result"] = "error"
-		response["error"] = "Failed to parse fleet ID"
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Failed to parse fleet ID")
 		return
 	}
 
 	loggedIn := session.IsLoggedIn(w, r)
 
 	if !loggedIn {
-		session.SetLoginRedirect(w, r, fmt.Sprintf("/fleet/%d", fleetID))
-		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		SendJSONError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Not logged in")
 		return
 	}
 
+	fleetLocks.Lock(fleetID)
+	defer fleetLocks.Unlock(fleetID)
+
 	fleet, err := database.LoadFleet(fleetID)
 	if err != nil {
 		logger.Errorf("Failed to load fleet in FleetMembersPostHandler: [%v]", err)
 
-		response["result"] = "error"
-		response["error"] = err.Error()
-
-		SendJSONResponse(w, response)
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to load fleet")
 		return
 	}
 
 	corporationID := session.GetCorpID(r)
 
 	if fleet.Corporation.ID != corporationID {
-		http.Redirect(w, r, "/fleets", http.StatusSeeOther)
+		SendJSONError(w, http.StatusNotFound, ErrorCodeNotFound, "Fleet not found")
 		return
 	}
 
 	if !IsFleetCommander(r, fleet) && !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
 		logger.Warnf("Received request to FleetMembersPostHandler without proper access...")
 
-		response["result"] = "error"
-		response["error"] = "Unauthorised access: cannot perform this operation with your current access mask or fleet role"
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask or fleet role")
 		return
 	}
 
+	before := NewFleetMemberResponses(fleet)
+
 	fleetCommanders := fleet.FleetCommanders()
 
 	err = r.ParseForm()
 	if err != nil {
 		logger.Errorf("Failed to parse form in FleetPutHandler: [%v]", err)
 
-		response["result"] = "error"
-		response["error"] = err.Error()
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
 		return
 	}
 
 	fleetComposition := r.FormValue("addMemberFleetComposition")
 	if len(fleetComposition) > 0 {
-		fleetCompositionRows := strings.Split(fleetComposition, "\r\n")
-
-		members, err := ParseFleetCompositionRows(fleet.ID, fleetCompositionRows)
+		members, unmappedShips, warnings, err := ParseFleetComposition(fleet.ID, fleetComposition)
 		if err != nil {
-			logger.Errorf("Failed to parse fleet composition rows in FleetMembersPostHandler: [%v]", err)
+			logger.Errorf("Failed to parse fleet composition in FleetMembersPostHandler: [%v]", err)
+
+			SendJSONError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to parse fleet composition")
+			return
+		}
 
-			response["result"] = "error"
-			response["error"] = err.Error()
+		if len(members) == 0 {
+			logger.Warnf("Fleet composition in FleetMembersPostHandler did not contain any known players...")
 
-			SendJSONResponse(w, response)
+			message := "Fleet composition did not contain any known players"
+			if len(warnings) > 0 {
+				message = fmt.Sprintf("%s: %s", message, strings.Join(warnings, "; "))
+			}
+
+			SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, message)
 			return
 		}
 
+		response["unmappedShips"] = unmappedShips
+		response["warnings"] = warnings
+
+		pasted := make(map[string]bool)
+		now := time.Now()
+
 		for _, member := range members {
+			pasted[member.Name] = true
+
+			// Members pasted again are back in fleet if they had left in the meantime
+			if existing, ok := fleet.Members[member.Name]; ok {
+				existing.Join(now)
+				continue
+			}
+
 			if member.Role == models.FleetRoleFleetCommander && len(fleetCommanders) > 0 {
 				secondCommander := true
 
@@ -1026,15 +1102,20 @@ func FleetMembersPostHandler(w http.ResponseWriter, r *http.Request) {
 
 			fleet.AddMember(member)
 		}
+
+		if r.FormValue("addMemberCompositionLeave") == "true" {
+			for name, member := range fleet.Members {
+				if !pasted[name] {
+					member.Leave(now)
+				}
+			}
+		}
 	} else {
 		memberID, err := strconv.ParseInt(r.FormValue("addMemberSelectMember"), 10, 64)
 		if err != nil {
 			logger.Errorf("Failed to parse memberID in FleetMembersPostHandler: [%v]", err)
 
-			response["result"] = "error"
-			response["error"] = err.Error()
-
-			SendJSONResponse(w, response)
+			SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
 			return
 		}
 
@@ -1042,20 +1123,21 @@ func FleetMembersPostHandler(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			logger.Errorf("Failed to parse fleetRole in FleetMembersPostHandler: [%v]", err)
 
-			response["result"] = "error"
-			response["error"] = err.Error()
+			SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+			return
+		}
 
-			SendJSONResponse(w, response)
+		if !fleet.Corporation.HasFleetRole(models.FleetRole(fleetRole)) {
+			logger.Errorf("Received invalid fleet role %d in FleetMembersPostHandler...", fleetRole)
+
+			SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Invalid fleet role")
 			return
 		}
 
 		if models.FleetRole(fleetRole) == models.FleetRoleFleetCommander && len(fleetCommanders) > 0 {
 			logger.Errorf("Tried to add second fleet commander to fleet in FleetMembersPostHandler...")
 
-			response["result"] = "error"
-			response["error"] = "Cannot add two fleet commanders to the same fleet!"
-
-			SendJSONResponse(w, response)
+			SendJSONError(w, http.StatusConflict, ErrorCodeConflict, "Cannot add two fleet commanders to the same fleet!")
 			return
 		}
 
@@ -1065,14 +1147,12 @@ func FleetMembersPostHandler(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			logger.Errorf("Failed to load player in FleetMembersPostHandler: [%v]", err)
 
-			response["result"] = "error"
-			response["error"] = err.Error()
-
-			SendJSONResponse(w, response)
+			status, code := ErrorStatus(err)
+			SendJSONError(w, status, code, "Failed to load player")
 			return
 		}
 
-		fleetMember := models.NewFleetMember(-1, fleet.ID, player, models.FleetRole(fleetRole), ship, 0, 1, 0, false, -1)
+		fleetMember := models.NewFleetMember(-1, fleet.ID, player, models.FleetRole(fleetRole), ship, 0, 1, 0, false, -1, time.Now(), time.Time{})
 
 		fleet.AddMember(fleetMember)
 	}
@@ -1081,24 +1161,20 @@ func FleetMembersPostHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		logger.Errorf("Failed to save fleet in FleetMembersPostHandler: [%v]", err)
 
-		response["result"] = "error"
-		response["error"] = err.Error()
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to save fleet")
 		return
 	}
 
-	if len(errors) > 0 {
-		response["result"] = "error"
-		response["error"] = strings.Join(errors, ";")
+	RecordAudit(r, fleet.ID, -1, "addmembers", before, NewFleetMemberResponses(fleet))
 
-		SendJSONResponse(w, response)
+	if len(errors) > 0 {
+		SendJSONError(w, http.StatusConflict, ErrorCodeConflict, strings.Join(errors, ";"))
 		return
 	}
 
 	response["result"] = "success"
 	response["error"] = nil
-	response["fleet"] = fleet
+	response["fleet"] = NewFleetResponse(fleet)
 
 	SendJSONResponse(w, response)
 }
@@ -1111,10 +1187,7 @@ func FleetMembersPutHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		logger.Errorf("Failed to parse fleet ID %q in FleetMembersPutHandler: [%v]", vars["fleetid"], err)
 
-		response["result"] = "error"
-		response["error"] = "Failed to parse fleet ID"
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Failed to parse fleet ID")
 		return
 	}
 
@@ -1122,46 +1195,40 @@ func FleetMembersPutHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		logger.Errorf("Failed to parse member ID %q in FleetMembersPutHandler: [%v]", vars["fleetid"], err)
 
-		response["result"] = "error"
-		response["error"] = "Failed to parse member ID"
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Failed to parse member ID")
 		return
 	}
 
 	loggedIn := session.IsLoggedIn(w, r)
 
 	if !loggedIn {
-		session.SetLoginRedirect(w, r, fmt.Sprintf("/fleet/%d", fleetID))
-		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		SendJSONError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Not logged in")
 		return
 	}
 
+	fleetLocks.Lock(fleetID)
+	defer fleetLocks.Unlock(fleetID)
+
 	fleet, err := database.LoadFleet(fleetID)
 	if err != nil {
 		logger.Errorf("Failed to load fleet in FleetMembersPutHandler: [%v]", err)
 
-		response["result"] = "error"
-		response["error"] = err.Error()
-
-		SendJSONResponse(w, response)
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to load fleet")
 		return
 	}
 
 	corporationID := session.GetCorpID(r)
 
 	if fleet.Corporation.ID != corporationID {
-		http.Redirect(w, r, "/fleets", http.StatusSeeOther)
+		SendJSONError(w, http.StatusNotFound, ErrorCodeNotFound, "Fleet not found")
 		return
 	}
 
 	if !IsFleetCommander(r, fleet) && !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
 		logger.Warnf("Received request to FleetMembersPutHandler without proper access...")
 
-		response["result"] = "error"
-		response["error"] = "Unauthorised access: cannot perform this operation with your current access mask or fleet role"
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask or fleet role")
 		return
 	}
 
@@ -1169,10 +1236,7 @@ func FleetMembersPutHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		logger.Errorf("Failed to parse form in FleetMembersPutHandler: [%v]", err)
 
-		response["result"] = "error"
-		response["error"] = err.Error()
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -1180,10 +1244,14 @@ func FleetMembersPutHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		logger.Errorf("Failed to parse fleetRole in FleetMembersPutHandler: [%v]", err)
 
-		response["result"] = "error"
-		response["error"] = err.Error()
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
 
-		SendJSONResponse(w, response)
+	if !fleet.Corporation.HasFleetRole(models.FleetRole(fleetRole)) {
+		logger.Errorf("Received invalid fleet role %d in FleetMembersPutHandler...", fleetRole)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Invalid fleet role")
 		return
 	}
 
@@ -1191,10 +1259,12 @@ func FleetMembersPutHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		logger.Errorf("Failed to parse siteModifier in FleetMembersPutHandler: [%v]", err)
 
-		response["result"] = "error"
-		response["error"] = err.Error()
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
 
-		SendJSONResponse(w, response)
+	if siteModifier > 0 {
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Site modifier cannot be positive")
 		return
 	}
 
@@ -1202,10 +1272,7 @@ func FleetMembersPutHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		logger.Errorf("Failed to parse paymentModifier in FleetMembersPutHandler: [%v]", err)
 
-		response["result"] = "error"
-		response["error"] = err.Error()
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -1213,10 +1280,7 @@ func FleetMembersPutHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		logger.Errorf("Failed to parse payoutComplete in FleetMembersPutHandler: [%v]", err)
 
-		response["result"] = "error"
-		response["error"] = err.Error()
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -1224,10 +1288,8 @@ func FleetMembersPutHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		logger.Errorf("Failed to load fleet member in FleetMembersPutHandler: [%v]", err)
 
-		response["result"] = "error"
-		response["error"] = err.Error()
-
-		SendJSONResponse(w, response)
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to load fleet member")
 		return
 	}
 
@@ -1236,34 +1298,50 @@ func FleetMembersPutHandler(w http.ResponseWriter, r *http.Request) {
 	if fleetMember.Role == models.FleetRoleFleetCommander && models.FleetRole(fleetRole) != models.FleetRoleFleetCommander && len(fleetCommanders) <= 1 {
 		logger.Errorf("Tried to remove fleet commander without replacement in FleetMembersPutHandler...")
 
-		response["result"] = "error"
-		response["error"] = "Cannot remove the fleet commander without replacement from the member list!"
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusConflict, ErrorCodeConflict, "Cannot remove the fleet commander without replacement from the member list!")
 		return
 	}
 
+	before := NewFleetMemberResponse(fleetMember, fleet.Corporation)
+
 	fleetMember.Role = models.FleetRole(fleetRole)
 	fleetMember.SiteModifier = int(siteModifier)
 	fleetMember.PaymentModifier = paymentModifier
 	fleetMember.PayoutComplete = payoutComplete
 
+	present := r.FormValue("fleetMemberPresentEdit")
+	if len(present) > 0 {
+		isPresent, err := strconv.ParseBool(present)
+		if err != nil {
+			logger.Errorf("Failed to parse present in FleetMembersPutHandler: [%v]", err)
+
+			SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+			return
+		}
+
+		if isPresent {
+			fleetMember.Join(time.Now())
+		} else {
+			fleetMember.Leave(time.Now())
+		}
+	}
+
 	fleet.Members[fleetMember.Name] = fleetMember
+	fleet.AllPayoutsComplete()
 
 	fleet, err = database.SaveFleet(fleet)
 	if err != nil {
 		logger.Errorf("Failed to save fleet in FleetMembersPutHandler: [%v]", err)
 
-		response["result"] = "error"
-		response["error"] = err.Error()
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to save fleet")
 		return
 	}
 
+	RecordAudit(r, fleet.ID, -1, "editmember", before, NewFleetMemberResponse(fleet.Members[fleetMember.Name], fleet.Corporation))
+
 	response["result"] = "success"
 	response["error"] = nil
-	response["fleet"] = fleet
+	response["fleet"] = NewFleetResponse(fleet)
 
 	SendJSONResponse(w, response)
 }
@@ -1276,10 +1354,7 @@ func FleetMembersDeleteHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		logger.Errorf("Failed to parse fleet ID %q in FleetMembersDeleteHandler: [%v]", vars["fleetid"], err)
 
-		response["result"] = "error"
-		response["error"] = "Failed to parse fleet ID"
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Failed to parse fleet ID")
 		return
 	}
 
@@ -1287,46 +1362,40 @@ func FleetMembersDeleteHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		logger.Errorf("Failed to parse member ID %q in FleetMembersDeleteHandler: [%v]", vars["fleetid"], err)
 
-		response["result"] = "error"
-		response["error"] = "Failed to parse member ID"
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Failed to parse member ID")
 		return
 	}
 
 	loggedIn := session.IsLoggedIn(w, r)
 
 	if !loggedIn {
-		session.SetLoginRedirect(w, r, fmt.Sprintf("/fleet/%d", fleetID))
-		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		SendJSONError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Not logged in")
 		return
 	}
 
+	fleetLocks.Lock(fleetID)
+	defer fleetLocks.Unlock(fleetID)
+
 	fleet, err := database.LoadFleet(fleetID)
 	if err != nil {
 		logger.Errorf("Failed to load fleet in FleetMembersDeleteHandler: [%v]", err)
 
-		response["result"] = "error"
-		response["error"] = err.Error()
-
-		SendJSONResponse(w, response)
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to load fleet")
 		return
 	}
 
 	corporationID := session.GetCorpID(r)
 
 	if fleet.Corporation.ID != corporationID {
-		http.Redirect(w, r, "/fleets", http.StatusSeeOther)
+		SendJSONError(w, http.StatusNotFound, ErrorCodeNotFound, "Fleet not found")
 		return
 	}
 
 	if !IsFleetCommander(r, fleet) && !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
 		logger.Warnf("Received request to FleetMembersDeleteHandler without proper access...")
 
-		response["result"] = "error"
-		response["error"] = "Unauthorised access: cannot perform this operation with your current access mask or fleet role"
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask or fleet role")
 		return
 	}
 
@@ -1334,10 +1403,8 @@ func FleetMembersDeleteHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		logger.Errorf("Failed to load fleet member in FleetMembersDeleteHandler: [%v]", err)
 
-		response["result"] = "error"
-		response["error"] = err.Error()
-
-		SendJSONResponse(w, response)
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to load fleet member")
 		return
 	}
 
@@ -1348,10 +1415,7 @@ func FleetMembersDeleteHandler(w http.ResponseWriter, r *http.Request) {
 	if len(fleetCommanders) == 0 {
 		logger.Errorf("Tried to remove fleet commander in FleetMembersDeleteHandler...")
 
-		response["result"] = "error"
-		response["error"] = "Cannot remove the fleet commander from the member list!"
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusConflict, ErrorCodeConflict, "Cannot remove the fleet commander from the member list!")
 		return
 	}
 
@@ -1359,16 +1423,245 @@ func FleetMembersDeleteHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		logger.Errorf("Failed to remove fleet member in FleetMembersDeleteHandler: [%v]", err)
 
-		response["result"] = "error"
-		response["error"] = err.Error()
+		SendJSONError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to remove fleet member")
+		return
+	}
 
-		SendJSONResponse(w, response)
+	RecordAudit(r, fleet.ID, -1, "removemember", NewFleetMemberResponse(member, fleet.Corporation), nil)
+
+	response["result"] = "success"
+	response["error"] = nil
+	response["fleet"] = NewFleetResponse(fleet)
+
+	SendJSONResponse(w, response)
+}
+
+func FleetLootPastesGetHandler(w http.ResponseWriter, r *http.Request) {
+	response := make(map[string]interface{})
+
+	vars := mux.Vars(r)
+	fleetID, err := strconv.ParseInt(vars["fleetid"], 10, 64)
+	if err != nil {
+		logger.Errorf("Failed to parse fleet ID %q in FleetLootPastesGetHandler: [%v]", vars["fleetid"], err)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Failed to parse fleet ID")
+		return
+	}
+
+	loggedIn := session.IsLoggedIn(w, r)
+
+	if !loggedIn {
+		SendJSONError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Not logged in")
+		return
+	}
+
+	fleet, err := database.LoadFleet(fleetID)
+	if err != nil {
+		logger.Errorf("Failed to load fleet in FleetLootPastesGetHandler: [%v]", err)
+
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to load fleet")
+		return
+	}
+
+	corporationID := session.GetCorpID(r)
+
+	if fleet.Corporation.ID != corporationID {
+		SendJSONError(w, http.StatusNotFound, ErrorCodeNotFound, "Fleet not found")
+		return
+	}
+
+	lootPastes, err := database.LoadAllLootPastes(fleetID)
+	if err != nil {
+		logger.Errorf("Failed to load all loot pastes for fleet #%d in FleetLootPastesGetHandler: [%v]", fleetID, err)
+
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to load all loot pastes for fleet")
+		return
+	}
+
+	response["result"] = "success"
+	response["error"] = nil
+	response["lootPastes"] = NewLootPasteResponses(lootPastes)
+
+	SendJSONResponse(w, response)
+}
+
+func FleetLootPastesPutHandler(w http.ResponseWriter, r *http.Request) {
+	response := make(map[string]interface{})
+
+	vars := mux.Vars(r)
+	fleetID, err := strconv.ParseInt(vars["fleetid"], 10, 64)
+	if err != nil {
+		logger.Errorf("Failed to parse fleet ID %q in FleetLootPastesPutHandler: [%v]", vars["fleetid"], err)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Failed to parse fleet ID")
+		return
+	}
+
+	lootPasteID, err := strconv.ParseInt(vars["lootpasteid"], 10, 64)
+	if err != nil {
+		logger.Errorf("Failed to parse loot paste ID %q in FleetLootPastesPutHandler: [%v]", vars["lootpasteid"], err)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Failed to parse loot paste ID")
+		return
+	}
+
+	loggedIn := session.IsLoggedIn(w, r)
+
+	if !loggedIn {
+		SendJSONError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Not logged in")
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		logger.Errorf("Failed to parse form in FleetLootPastesPutHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	command := r.FormValue("command")
+	if len(command) == 0 {
+		logger.Errorf("Received empty command in FleetLootPastesPutHandler...")
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Received empty command")
+		return
+	}
+
+	fleetLocks.Lock(fleetID)
+	defer fleetLocks.Unlock(fleetID)
+
+	fleet, err := database.LoadFleet(fleetID)
+	if err != nil {
+		logger.Errorf("Failed to load fleet in FleetLootPastesPutHandler: [%v]", err)
+
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to load fleet")
+		return
+	}
+
+	corporationID := session.GetCorpID(r)
+
+	if fleet.Corporation.ID != corporationID {
+		SendJSONError(w, http.StatusNotFound, ErrorCodeNotFound, "Fleet not found")
+		return
+	}
+
+	if !IsFleetCommander(r, fleet) && !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
+		logger.Warnf("Received request to FleetLootPastesPutHandler without proper access...")
+
+		SendJSONError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask or fleet role")
+		return
+	}
+
+	if fleet.IsFleetFinished() {
+		logger.Warnf("Received request to FleetLootPastesPutHandler for finished fleet #%d...", fleet.ID)
+
+		SendJSONError(w, http.StatusConflict, ErrorCodeConflict, "Cannot change loot pastes of a finished fleet")
+		return
+	}
+
+	lootPaste, err := database.LoadLootPaste(lootPasteID)
+	if err != nil {
+		logger.Errorf("Failed to load loot paste in FleetLootPastesPutHandler: [%v]", err)
+
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to load loot paste")
+		return
+	}
+
+	if lootPaste.FleetID != fleet.ID {
+		logger.Warnf("Received request to FleetLootPastesPutHandler for loot paste #%d not belonging to fleet #%d...", lootPaste.ID, fleet.ID)
+
+		SendJSONError(w, http.StatusNotFound, ErrorCodeNotFound, "Loot paste does not belong to this fleet")
+		return
+	}
+
+	before := NewLootPasteResponse(lootPaste)
+
+	reappraise := false
+
+	switch strings.ToLower(command) {
+	case "editpaste":
+		rawPaste := r.FormValue("lootPasteRaw")
+		if len(rawPaste) == 0 {
+			logger.Errorf("Content of lootPasteRaw in FleetLootPastesPutHandler was empty...")
+
+			SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Content of lootPasteRaw was empty")
+			return
+		}
+
+		lootPaste.RawPaste = rawPaste
+		reappraise = true
+		break
+	case "reappraisepaste":
+		reappraise = true
+		break
+	case "voidpaste":
+		lootPaste.Voided = true
+		break
+	case "restorepaste":
+		lootPaste.Voided = false
+		break
+	case "assignsite":
+		siteID, err := ParseRequestSiteID(fleet, r.FormValue("lootPasteSiteID"))
+		if err != nil {
+			logger.Errorf("Failed to parse site ID in FleetLootPastesPutHandler: [%v]", err)
+
+			SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+			return
+		}
+
+		lootPaste.SiteID = siteID
+		break
+	default:
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidCommand, "Invalid command")
+		return
+	}
+
+	if reappraise {
+		priceMode, err := ParseRequestPriceMode(r.FormValue("lootPastePriceMode"))
+		if err != nil {
+			logger.Errorf("Failed to parse price mode in FleetLootPastesPutHandler: [%v]", err)
+
+			SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+			return
+		}
+
+		err = AppraiseLootPaste(lootPaste, priceMode)
+		if err != nil {
+			logger.Errorf("Failed to appraise loot paste #%d in FleetLootPastesPutHandler: [%v]", lootPaste.ID, err)
+
+			SendJSONError(w, http.StatusUnprocessableEntity, ErrorCodeUnprocessable, err.Error())
+			return
+		}
+	}
+
+	lootPaste, err = database.SaveLootPaste(lootPaste)
+	if err != nil {
+		logger.Errorf("Failed to save loot paste #%d in FleetLootPastesPutHandler: [%v]", lootPaste.ID, err)
+
+		SendJSONError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to save loot paste")
+		return
+	}
+
+	fleet, err = database.LoadFleet(fleet.ID)
+	if err != nil {
+		logger.Errorf("Failed to reload fleet in FleetLootPastesPutHandler: [%v]", err)
+
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to reload fleet")
 		return
 	}
 
+	RecordAudit(r, fleet.ID, -1, strings.ToLower(command), before, NewLootPasteResponse(lootPaste))
+
 	response["result"] = "success"
 	response["error"] = nil
-	response["fleet"] = fleet
+	response["fleet"] = NewFleetResponse(fleet)
+	response["lootPaste"] = NewLootPasteResponse(lootPaste)
 
 	SendJSONResponse(w, response)
 }
@@ -1544,19 +1837,7 @@ func ReportCreateFormHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		logger.Errorf("Failed to save report in ReportCreateFormHandler: [%v]", err)
 
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	for _, reportPayout := range report.Payouts {
-		reportPayout.ReportID = report.ID
-	}
-
-	report, err = database.SaveReport(report)
-	if err != nil {
-		logger.Errorf("Failed to update report in ReportCreateFormHandler: [%v]", err)
-
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Failed to create report, no fleets or payouts were changed: %v", err), http.StatusInternalServerError)
 		return
 	}
 
@@ -1610,43 +1891,33 @@ func ReportGetHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func ReportPutHandler(w http.ResponseWriter, r *http.Request) {
+func ReportPayoutsGetHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	reportID, err := strconv.ParseInt(vars["reportid"], 10, 64)
 	if err != nil {
-		logger.Errorf("Failed to parse report ID %q in ReportPutHandler: [%v]", vars["reportID"], err)
+		logger.Errorf("Failed to parse report ID %q in ReportPayoutsGetHandler: [%v]", vars["reportid"], err)
 
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	loggedIn := session.IsLoggedIn(w, r)
 
 	if !loggedIn {
-		session.SetLoginRedirect(w, r, fmt.Sprintf("/report/%d", reportID))
+		session.SetLoginRedirect(w, r, fmt.Sprintf("/report/%d/payouts", reportID))
 		http.Redirect(w, r, "/login", http.StatusSeeOther)
 		return
 	}
 
-	err = r.ParseForm()
-	if err != nil {
-		logger.Errorf("Failed to parse form in ReportPutHandler: [%v]", err)
-
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	command := r.FormValue("command")
-	if len(command) == 0 {
-		logger.Errorf("Received empty command in ReportPutHandler...")
+	data := make(map[string]interface{})
 
-		http.Error(w, "Received empty command", http.StatusBadRequest)
-		return
-	}
+	data["PageTitle"] = fmt.Sprintf("Payouts Report #%d", reportID)
+	data["PageType"] = 4
+	data["LoggedIn"] = loggedIn
 
 	report, err := database.LoadReport(reportID)
 	if err != nil {
-		logger.Errorf("Failed to load report in ReportPutHandler: [%v]", err)
+		logger.Errorf("Failed to load details for report #%d in ReportPayoutsGetHandler: [%v]", reportID, err)
 
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -1659,16 +1930,81 @@ func ReportPutHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	switch strings.ToLower(command) {
-	case "finishreport":
-		ReportPutFinishReportHandler(w, r, report)
-		break
-	default:
-		response := make(map[string]interface{})
-		response["result"] = "error"
-		response["error"] = "Invalid command"
+	if !IsReportCreator(r, report) && !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
+		logger.Warnf("Received request to ReportPayoutsGetHandler without proper access...")
 
-		SendJSONResponse(w, response)
+		http.Redirect(w, r, fmt.Sprintf("/report/%d", reportID), http.StatusSeeOther)
+		return
+	}
+
+	data["Report"] = report
+	data["PayoutProgress"] = report.PayoutProgress()
+	data["PayoutBatches"] = report.PayoutBatches()
+
+	err = templates.Funcs(TemplateFunctions(r)).ExecuteTemplate(w, "reportpayouts", data)
+	if err != nil {
+		logger.Errorf("Failed to execute template in ReportPayoutsGetHandler: [%v]", err)
+	}
+}
+
+func ReportPutHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	reportID, err := strconv.ParseInt(vars["reportid"], 10, 64)
+	if err != nil {
+		logger.Errorf("Failed to parse report ID %q in ReportPutHandler: [%v]", vars["reportID"], err)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	loggedIn := session.IsLoggedIn(w, r)
+
+	if !loggedIn {
+		SendJSONError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Not logged in")
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		logger.Errorf("Failed to parse form in ReportPutHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	command := r.FormValue("command")
+	if len(command) == 0 {
+		logger.Errorf("Received empty command in ReportPutHandler...")
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Received empty command")
+		return
+	}
+
+	reportLocks.Lock(reportID)
+	defer reportLocks.Unlock(reportID)
+
+	report, err := database.LoadReport(reportID)
+	if err != nil {
+		logger.Errorf("Failed to load report in ReportPutHandler: [%v]", err)
+
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to load report")
+		return
+	}
+
+	corporationID := session.GetCorpID(r)
+
+	if report.Corporation.ID != corporationID {
+		SendJSONError(w, http.StatusNotFound, ErrorCodeNotFound, "Report not found")
+		return
+	}
+
+	switch strings.ToLower(command) {
+	case "finishreport":
+		ReportPutFinishReportHandler(w, r, report)
+		break
+	default:
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidCommand, "Invalid command")
 	}
 }
 
@@ -1678,15 +2014,22 @@ func ReportPutFinishReportHandler(w http.ResponseWriter, r *http.Request, report
 	if !IsReportCreator(r, report) && !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
 		logger.Warnf("Received request to ReportPutFinishReportHandler without proper access...")
 
-		response["result"] = "error"
-		response["error"] = "Unauthorised access: cannot perform this operation with your current access mask"
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask")
 		return
 	}
 
+	var before, after []*ReportPayoutResponse
+
 	for _, reportPayout := range report.Payouts {
+		if reportPayout.PayoutComplete {
+			continue
+		}
+
+		before = append(before, NewReportPayoutResponse(reportPayout))
+
 		reportPayout.PayoutComplete = true
+
+		after = append(after, NewReportPayoutResponse(reportPayout))
 	}
 
 	report.PayoutComplete = true
@@ -1695,16 +2038,15 @@ func ReportPutFinishReportHandler(w http.ResponseWriter, r *http.Request, report
 	if err != nil {
 		logger.Errorf("Failed to save report in ReportPutFinishReportHandler: [%v]", err)
 
-		response["result"] = "error"
-		response["error"] = err.Error()
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to save report")
 		return
 	}
 
+	RecordAudit(r, -1, report.ID, "finishreport", before, after)
+
 	response["result"] = "success"
 	response["error"] = nil
-	response["report"] = report
+	response["report"] = NewReportResponse(report)
 
 	SendJSONResponse(w, response)
 }
@@ -1715,15 +2057,14 @@ func ReportPlayersPutHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		logger.Errorf("Failed to parse report ID %q in ReportPlayersPutHandler: [%v]", vars["reportID"], err)
 
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
 		return
 	}
 
 	loggedIn := session.IsLoggedIn(w, r)
 
 	if !loggedIn {
-		session.SetLoginRedirect(w, r, fmt.Sprintf("/report/%d", reportID))
-		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		SendJSONError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Not logged in")
 		return
 	}
 
@@ -1731,7 +2072,7 @@ func ReportPlayersPutHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		logger.Errorf("Failed to parse form in ReportPlayersPutHandler: [%v]", err)
 
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -1739,22 +2080,26 @@ func ReportPlayersPutHandler(w http.ResponseWriter, r *http.Request) {
 	if len(command) == 0 {
 		logger.Errorf("Received empty command in ReportPlayersPutHandler...")
 
-		http.Error(w, "Received empty command", http.StatusBadRequest)
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Received empty command")
 		return
 	}
 
+	reportLocks.Lock(reportID)
+	defer reportLocks.Unlock(reportID)
+
 	report, err := database.LoadReport(reportID)
 	if err != nil {
 		logger.Errorf("Failed to load report in ReportPlayersPutHandler: [%v]", err)
 
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to load report")
 		return
 	}
 
 	corporationID := session.GetCorpID(r)
 
 	if report.Corporation.ID != corporationID {
-		http.Redirect(w, r, "/reports", http.StatusSeeOther)
+		SendJSONError(w, http.StatusNotFound, ErrorCodeNotFound, "Report not found")
 		return
 	}
 
@@ -1762,12 +2107,17 @@ func ReportPlayersPutHandler(w http.ResponseWriter, r *http.Request) {
 	case "playerpaid":
 		ReportPlayersPutPlayerPaidHandler(w, r, report)
 		break
+	case "playerspaid":
+		ReportPlayersPutPlayersPaidHandler(w, r, report)
+		break
+	case "claimpayouts":
+		ReportPlayersPutPayerHandler(w, r, report, session.GetPlayerFromRequest(r))
+		break
+	case "releasepayouts":
+		ReportPlayersPutPayerHandler(w, r, report, nil)
+		break
 	default:
-		response := make(map[string]interface{})
-		response["result"] = "error"
-		response["error"] = "Invalid command"
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidCommand, "Invalid command")
 	}
 }
 
@@ -1777,10 +2127,7 @@ func ReportPlayersPutPlayerPaidHandler(w http.ResponseWriter, r *http.Request, r
 	if !IsReportCreator(r, report) && !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
 		logger.Warnf("Received request to ReportPlayersPutPlayerPaidHandler without proper access...")
 
-		response["result"] = "error"
-		response["error"] = "Unauthorised access: cannot perform this operation with your current access mask"
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask")
 		return
 	}
 
@@ -1788,10 +2135,7 @@ func ReportPlayersPutPlayerPaidHandler(w http.ResponseWriter, r *http.Request, r
 	if len(playerName) == 0 {
 		logger.Errorf("Content of playerName in ReportPlayersPutPlayerPaidHandler was empty...")
 
-		response["result"] = "error"
-		response["error"] = fmt.Sprintf("Content of playerName was empty")
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, fmt.Sprintf("Content of playerName was empty"))
 		return
 	}
 
@@ -1799,13 +2143,12 @@ func ReportPlayersPutPlayerPaidHandler(w http.ResponseWriter, r *http.Request, r
 	if !ok {
 		logger.Errorf("Failed to find ReportPayout for player %q in ReportPlayersPutPlayerPaidHandler...", playerName)
 
-		response["result"] = "error"
-		response["error"] = fmt.Sprintf("Failed to find report payout for player")
-
-		SendJSONResponse(w, response)
+		SendJSONError(w, http.StatusNotFound, ErrorCodeNotFound, fmt.Sprintf("Failed to find report payout for player"))
 		return
 	}
 
+	before := NewReportPayoutResponse(reportPayout)
+
 	reportPayout.PayoutComplete = true
 
 	report.Payouts[playerName] = reportPayout
@@ -1814,16 +2157,854 @@ func ReportPlayersPutPlayerPaidHandler(w http.ResponseWriter, r *http.Request, r
 	if err != nil {
 		logger.Errorf("Failed to save report in ReportPlayersPutPlayerPaidHandler: [%v]", err)
 
-		response["result"] = "error"
-		response["error"] = err.Error()
+		SendJSONError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to save report")
+		return
+	}
 
-		SendJSONResponse(w, response)
+	RecordAudit(r, -1, report.ID, "playerpaid", before, NewReportPayoutResponse(report.Payouts[playerName]))
+
+	response["result"] = "success"
+	response["error"] = nil
+	response["report"] = NewReportResponse(report)
+
+	SendJSONResponse(w, response)
+}
+
+func ReportPlayersPutPlayersPaidHandler(w http.ResponseWriter, r *http.Request, report *models.Report) {
+	response := make(map[string]interface{})
+
+	if !IsReportCreator(r, report) && !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
+		logger.Warnf("Received request to ReportPlayersPutPlayersPaidHandler without proper access...")
+
+		SendJSONError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask")
+		return
+	}
+
+	reportPayouts, err := SelectReportPayouts(r, report)
+	if err != nil {
+		logger.Errorf("Failed to select report payouts in ReportPlayersPutPlayersPaidHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	var before, after []*ReportPayoutResponse
+
+	for _, reportPayout := range reportPayouts {
+		if reportPayout.PayoutComplete {
+			continue
+		}
+
+		before = append(before, NewReportPayoutResponse(reportPayout))
+
+		reportPayout.PayoutComplete = true
+
+		after = append(after, NewReportPayoutResponse(reportPayout))
+	}
+
+	report, err = database.SaveReport(report)
+	if err != nil {
+		logger.Errorf("Failed to save report in ReportPlayersPutPlayersPaidHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to save report")
+		return
+	}
+
+	if len(after) > 0 {
+		RecordAudit(r, -1, report.ID, "playerspaid", before, after)
+	}
+
+	response["result"] = "success"
+	response["error"] = nil
+	response["report"] = NewReportResponse(report)
+
+	SendJSONResponse(w, response)
+}
+
+func ReportPlayersPutPayerHandler(w http.ResponseWriter, r *http.Request, report *models.Report, payer *models.Player) {
+	response := make(map[string]interface{})
+
+	if !IsReportCreator(r, report) && !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
+		logger.Warnf("Received request to ReportPlayersPutPayerHandler without proper access...")
+
+		SendJSONError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask")
+		return
+	}
+
+	reportPayouts, err := SelectReportPayouts(r, report)
+	if err != nil {
+		logger.Errorf("Failed to select report payouts in ReportPlayersPutPayerHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	var before, after []*ReportPayoutResponse
+
+	for _, reportPayout := range reportPayouts {
+		before = append(before, NewReportPayoutResponse(reportPayout))
+
+		reportPayout.Payer = payer
+
+		after = append(after, NewReportPayoutResponse(reportPayout))
+	}
+
+	report, err = database.SaveReport(report)
+	if err != nil {
+		logger.Errorf("Failed to save report in ReportPlayersPutPayerHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to save report")
+		return
+	}
+
+	command := "claimpayouts"
+	if payer == nil {
+		command = "releasepayouts"
+	}
+
+	RecordAudit(r, -1, report.ID, command, before, after)
+
+	response["result"] = "success"
+	response["error"] = nil
+	response["report"] = NewReportResponse(report)
+
+	SendJSONResponse(w, response)
+}
+
+func SelectReportPayouts(r *http.Request, report *models.Report) ([]*models.ReportPayout, error) {
+	var reportPayouts []*models.ReportPayout
+
+	if strings.EqualFold(r.FormValue("all"), "true") {
+		for _, reportPayout := range report.Payouts {
+			if !reportPayout.PayoutComplete {
+				reportPayouts = append(reportPayouts, reportPayout)
+			}
+		}
+
+		return reportPayouts, nil
+	}
+
+	playerNames := r.Form["playerName"]
+	if len(playerNames) == 0 {
+		return reportPayouts, fmt.Errorf("No players selected")
+	}
+
+	for _, playerName := range playerNames {
+		reportPayout, ok := report.Payouts[playerName]
+		if !ok {
+			return reportPayouts, fmt.Errorf("Failed to find report payout for player %q", playerName)
+		}
+
+		reportPayouts = append(reportPayouts, reportPayout)
+	}
+
+	return reportPayouts, nil
+}
+
+func CorporationGetHandler(w http.ResponseWriter, r *http.Request) {
+	loggedIn := session.IsLoggedIn(w, r)
+
+	if !loggedIn {
+		session.SetLoginRedirect(w, r, "/corporation")
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	if !HasHigherAccessMask(r, models.AccessMaskDirector) {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	data := make(map[string]interface{})
+
+	data["PageTitle"] = "Corporation Settings"
+	data["PageType"] = 5
+	data["LoggedIn"] = loggedIn
+
+	corporation, err := database.LoadCorporation(session.GetCorpID(r))
+	if err != nil {
+		logger.Errorf("Failed to load corporation in CorporationGetHandler: [%v]", err)
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data["Corporation"] = corporation
+	data["PayoutStrategies"] = models.PayoutStrategyTypes()
+	data["FleetRoleLabelTypes"] = models.FleetRoleLabelTypes()
+
+	err = templates.Funcs(TemplateFunctions(r)).ExecuteTemplate(w, "corporation", data)
+	if err != nil {
+		logger.Errorf("Failed to execute template in CorporationGetHandler: [%v]", err)
+	}
+}
+
+func CorporationPutHandler(w http.ResponseWriter, r *http.Request) {
+	loggedIn := session.IsLoggedIn(w, r)
+
+	if !loggedIn {
+		SendJSONError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Not logged in")
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		logger.Errorf("Failed to parse form in CorporationPutHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	command := r.FormValue("command")
+	if len(command) == 0 {
+		logger.Errorf("Received empty command in CorporationPutHandler...")
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Received empty command")
+		return
+	}
+
+	corporation, err := database.LoadCorporation(session.GetCorpID(r))
+	if err != nil {
+		logger.Errorf("Failed to load corporation in CorporationPutHandler: [%v]", err)
+
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to load corporation")
+		return
+	}
+
+	switch strings.ToLower(command) {
+	case "editsettings":
+		CorporationPutEditSettingsHandler(w, r, corporation)
+		break
+	case "saverole":
+		CorporationPutSaveRoleHandler(w, r, corporation)
+		break
+	case "deleterole":
+		CorporationPutDeleteRoleHandler(w, r, corporation)
+		break
+	default:
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidCommand, "Invalid command")
+	}
+}
+
+func CorporationPutEditSettingsHandler(w http.ResponseWriter, r *http.Request, corporation *models.Corporation) {
+	response := make(map[string]interface{})
+
+	if !HasHigherAccessMask(r, models.AccessMaskDirector) {
+		logger.Warnf("Received request to CorporationPutEditSettingsHandler without proper access...")
+
+		SendJSONError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask")
+		return
+	}
+
+	cut, err := strconv.ParseFloat(r.FormValue("corporationCut"), 64)
+	if err != nil {
+		logger.Errorf("Failed to parse corporation cut in CorporationPutEditSettingsHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	if cut < 0 || cut > 100 {
+		logger.Errorf("Received invalid corporation cut %f in CorporationPutEditSettingsHandler...", cut)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Corporation cut must be between 0 and 100 percent")
+		return
+	}
+
+	strategy, err := strconv.ParseInt(r.FormValue("corporationPayoutStrategy"), 10, 64)
+	if err != nil {
+		logger.Errorf("Failed to parse payout strategy in CorporationPutEditSettingsHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	strategyType := models.PayoutStrategyType(strategy)
+
+	if !strategyType.IsValid() {
+		logger.Errorf("Received invalid payout strategy %d in CorporationPutEditSettingsHandler...", strategy)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Invalid payout strategy")
+		return
+	}
+
+	corporation.CorporationCut = cut
+	corporation.PayoutStrategy = strategyType
+
+	corporation, err = database.SaveCorporation(corporation)
+	if err != nil {
+		logger.Errorf("Failed to save corporation in CorporationPutEditSettingsHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to save corporation")
+		return
+	}
+
+	response["result"] = "success"
+	response["error"] = nil
+
+	SendJSONResponse(w, response)
+}
+
+func CorporationPutSaveRoleHandler(w http.ResponseWriter, r *http.Request, corporation *models.Corporation) {
+	response := make(map[string]interface{})
+
+	if !HasHigherAccessMask(r, models.AccessMaskDirector) {
+		logger.Warnf("Received request to CorporationPutSaveRoleHandler without proper access...")
+
+		SendJSONError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask")
+		return
+	}
+
+	role, err := strconv.ParseInt(r.FormValue("corporationRole"), 10, 64)
+	if err != nil {
+		logger.Errorf("Failed to parse fleet role in CorporationPutSaveRoleHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	fleetRole := models.FleetRole(role)
+
+	if fleetRole == 0 {
+		fleetRole, err = models.NextCustomFleetRole(corporation.Roles)
+		if err != nil {
+			logger.Errorf("Failed to allocate custom fleet role in CorporationPutSaveRoleHandler: [%v]", err)
+
+			SendJSONError(w, http.StatusConflict, ErrorCodeConflict, err.Error())
+			return
+		}
+	} else if !corporation.HasFleetRole(fleetRole) {
+		logger.Errorf("Received invalid fleet role %d in CorporationPutSaveRoleHandler...", role)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Invalid fleet role")
+		return
+	}
+
+	name := strings.TrimSpace(r.FormValue("corporationRoleName"))
+	if len(name) == 0 {
+		logger.Errorf("Received empty role name in CorporationPutSaveRoleHandler...")
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Fleet role name cannot be empty")
+		return
+	}
+
+	paymentRate, err := strconv.ParseFloat(r.FormValue("corporationRolePaymentRate"), 64)
+	if err != nil {
+		logger.Errorf("Failed to parse payment rate in CorporationPutSaveRoleHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	if paymentRate < 0 {
+		logger.Errorf("Received negative payment rate %f in CorporationPutSaveRoleHandler...", paymentRate)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Payment rate cannot be negative")
+		return
+	}
+
+	labelType := r.FormValue("corporationRoleLabelType")
+	if !models.IsValidFleetRoleLabelType(labelType) {
+		logger.Errorf("Received invalid label type %q in CorporationPutSaveRoleHandler...", labelType)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Invalid label type")
+		return
+	}
+
+	definition := models.NewFleetRoleDefinition(-1, corporation.ID, fleetRole, name, paymentRate, labelType)
+
+	definition, err = database.SaveFleetRoleDefinition(definition)
+	if err != nil {
+		logger.Errorf("Failed to save fleet role in CorporationPutSaveRoleHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to save fleet role")
+		return
+	}
+
+	response["result"] = "success"
+	response["error"] = nil
+	response["role"] = NewFleetRoleResponse(definition)
+
+	SendJSONResponse(w, response)
+}
+
+func CorporationPutDeleteRoleHandler(w http.ResponseWriter, r *http.Request, corporation *models.Corporation) {
+	response := make(map[string]interface{})
+
+	if !HasHigherAccessMask(r, models.AccessMaskDirector) {
+		logger.Warnf("Received request to CorporationPutDeleteRoleHandler without proper access...")
+
+		SendJSONError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask")
+		return
+	}
+
+	role, err := strconv.ParseInt(r.FormValue("corporationRole"), 10, 64)
+	if err != nil {
+		logger.Errorf("Failed to parse fleet role in CorporationPutDeleteRoleHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	fleetRole := models.FleetRole(role)
+
+	if _, ok := corporation.Roles[fleetRole]; !ok {
+		logger.Errorf("Received unknown fleet role %d in CorporationPutDeleteRoleHandler...", role)
+
+		SendJSONError(w, http.StatusNotFound, ErrorCodeNotFound, "Fleet role has no stored definition")
+		return
+	}
+
+	if !fleetRole.IsBuiltin() {
+		inUse, err := database.IsFleetRoleInUse(corporation.ID, fleetRole)
+		if err != nil {
+			logger.Errorf("Failed to check fleet role usage in CorporationPutDeleteRoleHandler: [%v]", err)
+
+			SendJSONError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to check fleet role usage")
+			return
+		}
+
+		if inUse {
+			logger.Warnf("Tried to delete fleet role %d still assigned to fleet members in CorporationPutDeleteRoleHandler...", role)
+
+			SendJSONError(w, http.StatusConflict, ErrorCodeConflict, "Cannot delete a fleet role that is still assigned to fleet members")
+			return
+		}
+	}
+
+	err = database.DeleteFleetRoleDefinition(corporation.ID, fleetRole)
+	if err != nil {
+		logger.Errorf("Failed to delete fleet role in CorporationPutDeleteRoleHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to delete fleet role")
+		return
+	}
+
+	response["result"] = "success"
+	response["error"] = nil
+
+	SendJSONResponse(w, response)
+}
+
+func ShipRolesGetHandler(w http.ResponseWriter, r *http.Request) {
+	loggedIn := session.IsLoggedIn(w, r)
+
+	if !loggedIn {
+		session.SetLoginRedirect(w, r, "/shiproles")
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	if !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	data := make(map[string]interface{})
+
+	data["PageTitle"] = "Ship Roles"
+	data["PageType"] = 6
+	data["LoggedIn"] = loggedIn
+
+	shipRoles, err := database.LoadAllShipRoles()
+	if err != nil {
+		logger.Errorf("Failed to load all ship roles in ShipRolesGetHandler: [%v]", err)
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data["ShipRoles"] = shipRoles
+	data["FleetRoles"] = models.BuiltinFleetRoles()
+
+	err = templates.Funcs(TemplateFunctions(r)).ExecuteTemplate(w, "shiproles", data)
+	if err != nil {
+		logger.Errorf("Failed to execute template in ShipRolesGetHandler: [%v]", err)
+	}
+}
+
+func ShipRolesListGetHandler(w http.ResponseWriter, r *http.Request) {
+	response := make(map[string]interface{})
+
+	loggedIn := session.IsLoggedIn(w, r)
+
+	if !loggedIn {
+		SendJSONError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Not logged in")
+		return
+	}
+
+	if !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
+		logger.Warnf("Received request to ShipRolesListGetHandler without proper access...")
+
+		SendJSONError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask")
+		return
+	}
+
+	shipRoles, err := database.LoadAllShipRoles()
+	if err != nil {
+		logger.Errorf("Failed to load all ship roles in ShipRolesListGetHandler: [%v]", err)
+
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to load all ship roles")
+		return
+	}
+
+	response["result"] = "success"
+	response["error"] = nil
+	response["shipRoles"] = NewShipRoleResponses(shipRoles)
+
+	SendJSONResponse(w, response)
+}
+
+func ShipRolesPostHandler(w http.ResponseWriter, r *http.Request) {
+	response := make(map[string]interface{})
+
+	loggedIn := session.IsLoggedIn(w, r)
+
+	if !loggedIn {
+		SendJSONError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Not logged in")
+		return
+	}
+
+	if !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
+		logger.Warnf("Received request to ShipRolesPostHandler without proper access...")
+
+		SendJSONError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask")
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		logger.Errorf("Failed to parse form in ShipRolesPostHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	var shipRoles []*models.ShipRole
+
+	shipRoleImport := r.FormValue("shipRoleImport")
+	if len(shipRoleImport) > 0 {
+		shipRoles, err = ParseShipRoles(shipRoleImport)
+		if err != nil {
+			logger.Errorf("Failed to parse ship role import in ShipRolesPostHandler: [%v]", err)
+
+			SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+			return
+		}
+	} else {
+		ship := strings.TrimSpace(r.FormValue("shipRoleShip"))
+		if len(ship) == 0 {
+			logger.Errorf("Received empty ship in ShipRolesPostHandler...")
+
+			SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Ship cannot be empty")
+			return
+		}
+
+		role, err := ParseShipRoleValue(r.FormValue("shipRoleRole"))
+		if err != nil {
+			logger.Errorf("Failed to parse fleet role in ShipRolesPostHandler: [%v]", err)
+
+			SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+			return
+		}
+
+		shipRoles = append(shipRoles, models.NewShipRole(-1, ship, role))
+	}
+
+	err = database.SaveShipRoles(shipRoles)
+	if err != nil {
+		logger.Errorf("Failed to save ship roles in ShipRolesPostHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to save ship roles")
+		return
+	}
+
+	response["result"] = "success"
+	response["error"] = nil
+	response["shipRoles"] = NewShipRoleResponses(shipRoles)
+
+	if len(r.FormValue("shipRoleFleet")) == 0 {
+		SendJSONResponse(w, response)
+		return
+	}
+
+	fleetID, err := strconv.ParseInt(r.FormValue("shipRoleFleet"), 10, 64)
+	if err != nil {
+		logger.Errorf("Failed to parse fleet ID in ShipRolesPostHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	fleetLocks.Lock(fleetID)
+	defer fleetLocks.Unlock(fleetID)
+
+	fleet, err := database.LoadFleet(fleetID)
+	if err != nil {
+		logger.Errorf("Failed to load fleet in ShipRolesPostHandler: [%v]", err)
+
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to load fleet")
+		return
+	}
+
+	if fleet.Corporation.ID != session.GetCorpID(r) || fleet.IsFleetFinished() {
+		logger.Warnf("Received request to assign ship roles to fleet #%d which cannot be changed in ShipRolesPostHandler...", fleetID)
+
+		SendJSONError(w, http.StatusConflict, ErrorCodeConflict, "Ship roles were saved, but the fleet cannot be changed")
+		return
+	}
+
+	assigned := 0
+
+	for _, shipRole := range shipRoles {
+		assigned += fleet.AssignShipRole(shipRole)
+	}
+
+	if assigned > 0 {
+		fleet, err = database.SaveFleet(fleet)
+		if err != nil {
+			logger.Errorf("Failed to save fleet in ShipRolesPostHandler: [%v]", err)
+
+			SendJSONError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to save fleet")
+			return
+		}
+	}
+
+	response["assignedMembers"] = assigned
+
+	SendJSONResponse(w, response)
+}
+
+func ShipRolePutHandler(w http.ResponseWriter, r *http.Request) {
+	response := make(map[string]interface{})
+
+	vars := mux.Vars(r)
+	shipRoleID, err := strconv.ParseInt(vars["shiproleid"], 10, 64)
+	if err != nil {
+		logger.Errorf("Failed to parse ship role ID %q in ShipRolePutHandler: [%v]", vars["shiproleid"], err)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Failed to parse ship role ID")
+		return
+	}
+
+	loggedIn := session.IsLoggedIn(w, r)
+
+	if !loggedIn {
+		SendJSONError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Not logged in")
+		return
+	}
+
+	if !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
+		logger.Warnf("Received request to ShipRolePutHandler without proper access...")
+
+		SendJSONError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask")
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		logger.Errorf("Failed to parse form in ShipRolePutHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	shipRole, err := database.LoadShipRole(shipRoleID)
+	if err != nil {
+		logger.Errorf("Failed to load ship role in ShipRolePutHandler: [%v]", err)
+
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to load ship role")
+		return
+	}
+
+	ship := strings.TrimSpace(r.FormValue("shipRoleShip"))
+	if len(ship) == 0 {
+		logger.Errorf("Received empty ship in ShipRolePutHandler...")
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Ship cannot be empty")
+		return
+	}
+
+	role, err := ParseShipRoleValue(r.FormValue("shipRoleRole"))
+	if err != nil {
+		logger.Errorf("Failed to parse fleet role in ShipRolePutHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	shipRole.Ship = ship
+	shipRole.Role = role
+
+	shipRole, err = database.SaveShipRole(shipRole)
+	if err != nil {
+		logger.Errorf("Failed to save ship role in ShipRolePutHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to save ship role")
+		return
+	}
+
+	response["result"] = "success"
+	response["error"] = nil
+	response["shipRole"] = NewShipRoleResponse(shipRole)
+
+	SendJSONResponse(w, response)
+}
+
+func ShipRoleDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	response := make(map[string]interface{})
+
+	vars := mux.Vars(r)
+	shipRoleID, err := strconv.ParseInt(vars["shiproleid"], 10, 64)
+	if err != nil {
+		logger.Errorf("Failed to parse ship role ID %q in ShipRoleDeleteHandler: [%v]", vars["shiproleid"], err)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Failed to parse ship role ID")
+		return
+	}
+
+	loggedIn := session.IsLoggedIn(w, r)
+
+	if !loggedIn {
+		SendJSONError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Not logged in")
+		return
+	}
+
+	if !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
+		logger.Warnf("Received request to ShipRoleDeleteHandler without proper access...")
+
+		SendJSONError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask")
+		return
+	}
+
+	err = database.DeleteShipRole(shipRoleID)
+	if err != nil {
+		logger.Errorf("Failed to delete ship role in ShipRoleDeleteHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to delete ship role")
+		return
+	}
+
+	response["result"] = "success"
+	response["error"] = nil
+
+	SendJSONResponse(w, response)
+}
+
+func APITokensGetHandler(w http.ResponseWriter, r *http.Request) {
+	loggedIn := session.IsLoggedIn(w, r)
+
+	if !loggedIn {
+		session.SetLoginRedirect(w, r, "/apitokens")
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	data := make(map[string]interface{})
+
+	data["PageTitle"] = "API Tokens"
+	data["PageType"] = 7
+	data["LoggedIn"] = loggedIn
+
+	player := session.GetPlayerFromRequest(r)
+
+	tokens, err := database.LoadAllAPITokens(player.ID)
+	if err != nil {
+		logger.Errorf("Failed to load all API tokens in APITokensGetHandler: [%v]", err)
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data["APITokens"] = tokens
+
+	err = templates.Funcs(TemplateFunctions(r)).ExecuteTemplate(w, "apitokens", data)
+	if err != nil {
+		logger.Errorf("Failed to execute template in APITokensGetHandler: [%v]", err)
+	}
+}
+
+func APITokensPostHandler(w http.ResponseWriter, r *http.Request) {
+	response := make(map[string]interface{})
+
+	loggedIn := session.IsLoggedIn(w, r)
+
+	if !loggedIn {
+		SendJSONError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Not logged in")
+		return
+	}
+
+	tokenName := strings.TrimSpace(r.FormValue("apiTokenName"))
+	if len(tokenName) == 0 {
+		logger.Errorf("Content of apiTokenName in APITokensPostHandler was empty...")
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Content of apiTokenName was empty")
+		return
+	}
+
+	plainToken, err := GenerateAPIToken()
+	if err != nil {
+		logger.Errorf("Failed to generate API token in APITokensPostHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to generate API token")
+		return
+	}
+
+	player := session.GetPlayerFromRequest(r)
+
+	token := models.NewAPIToken(-1, player.ID, tokenName, HashAPIToken(plainToken), time.Now().UTC(), time.Time{})
+
+	token, err = database.SaveAPIToken(token)
+	if err != nil {
+		logger.Errorf("Failed to save API token in APITokensPostHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to save API token")
+		return
+	}
+
+	response["result"] = "success"
+	response["error"] = nil
+	response["token"] = NewAPITokenResponse(token, plainToken)
+
+	SendJSONResponse(w, response)
+}
+
+func APITokenDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	response := make(map[string]interface{})
+
+	vars := mux.Vars(r)
+	tokenID, err := strconv.ParseInt(vars["tokenid"], 10, 64)
+	if err != nil {
+		logger.Errorf("Failed to parse API token ID %q in APITokenDeleteHandler: [%v]", vars["tokenid"], err)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Failed to parse API token ID")
+		return
+	}
+
+	loggedIn := session.IsLoggedIn(w, r)
+
+	if !loggedIn {
+		SendJSONError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Not logged in")
+		return
+	}
+
+	player := session.GetPlayerFromRequest(r)
+
+	err = database.DeleteAPIToken(player.ID, tokenID)
+	if err != nil {
+		logger.Errorf("Failed to delete API token in APITokenDeleteHandler: [%v]", err)
+
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to delete API token")
 		return
 	}
 
 	response["result"] = "success"
 	response["error"] = nil
-	response["report"] = report
 
 	SendJSONResponse(w, response)
 }
@@ -6,11 +6,13 @@ import (
 	"fmt"
 	"os"
 	"strings"
+
+	"github.com/morpheusxaut/lootsheeter/models"
 )
 
 func main() {
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: lootsheeter [options]\n")
+		fmt.Fprintf(os.Stderr, "Usage: lootsheeter [options] [migrate [up|down|to <version>|status] | prices [import <file>|status] | members import [<corporation ID> <file>] | wallet import [<corporation ID> <file>]]\n")
 		flag.PrintDefaults()
 		os.Exit(2)
 	}
@@ -23,10 +25,106 @@ func main() {
 
 	config = c
 
-	if strings.EqualFold(config.MySqlUser, "") ||
-		strings.EqualFold(config.MySqlPassword, "") ||
-		strings.EqualFold(config.MySqlDatabase, "") ||
-		strings.EqualFold(config.SSOClientID, "") ||
+	if strings.EqualFold(config.DatabaseType, "") {
+		config.DatabaseType = "mysql"
+	}
+
+	if strings.EqualFold(config.DatabaseType, "mysql") &&
+		(strings.EqualFold(config.MySqlUser, "") ||
+			strings.EqualFold(config.MySqlPassword, "") ||
+			strings.EqualFold(config.MySqlDatabase, "")) {
+		flag.Usage()
+	}
+
+	if strings.EqualFold(config.DatabaseType, "sqlite") && strings.EqualFold(config.SQLitePath, "") {
+		flag.Usage()
+	}
+
+	if _, err := models.ParsePriceMode(config.PriceMode); err != nil {
+		flag.Usage()
+	}
+
+	if strings.EqualFold(flag.Arg(0), "migrate") {
+		SetupLogger()
+
+		db, err := ConnectDatabase()
+		if err != nil {
+			fmt.Printf("Failed to connect to database: [%v]\n", err)
+			os.Exit(1)
+		}
+		defer db.Close()
+
+		err = RunMigrateCommand(db, flag.Args()[1:])
+		if err != nil {
+			fmt.Printf("Failed to run migrations: [%v]\n", err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	if strings.EqualFold(flag.Arg(0), "prices") {
+		SetupLogger()
+
+		db, err := ConnectDatabase()
+		if err != nil {
+			fmt.Printf("Failed to connect to database: [%v]\n", err)
+			os.Exit(1)
+		}
+		defer db.Close()
+
+		err = RunPricesCommand(db, flag.Args()[1:])
+		if err != nil {
+			fmt.Printf("Failed to manage prices: [%v]\n", err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	if strings.EqualFold(flag.Arg(0), "members") {
+		SetupLogger()
+
+		db, err := ConnectDatabase()
+		if err != nil {
+			fmt.Printf("Failed to connect to database: [%v]\n", err)
+			os.Exit(1)
+		}
+		defer db.Close()
+
+		InitialiseESI(db)
+
+		err = RunMembersCommand(db, flag.Args()[1:])
+		if err != nil {
+			fmt.Printf("Failed to import members: [%v]\n", err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	if strings.EqualFold(flag.Arg(0), "wallet") {
+		SetupLogger()
+
+		db, err := ConnectDatabase()
+		if err != nil {
+			fmt.Printf("Failed to connect to database: [%v]\n", err)
+			os.Exit(1)
+		}
+		defer db.Close()
+
+		InitialiseESI(db)
+
+		err = RunWalletCommand(db, flag.Args()[1:])
+		if err != nil {
+			fmt.Printf("Failed to import wallet journal: [%v]\n", err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	if strings.EqualFold(config.SSOClientID, "") ||
 		strings.EqualFold(config.SSOClientSecret, "") ||
 		strings.EqualFold(config.SSOCallbackURL, "") {
 		flag.Usage()
@@ -36,6 +134,8 @@ func main() {
 
 	InitialiseDatabase()
 
+	InitialiseESI(database)
+
 	InitialiseScheduler()
 
 	InitialiseSessions()
@@ -43,4 +143,9 @@ func main() {
 	SetupRouter()
 
 	HandleRequests()
+
+	err = database.Close()
+	if err != nil {
+		logger.Errorf("Failed to close database: [%v]", err)
+	}
 }
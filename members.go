@@ -0,0 +1,374 @@
+// members
+package main
+
+import (
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/morpheusxaut/lootsheeter/models"
+)
+
+var (
+	memberTrackingFetcher MemberTrackingFetcher = NewESIMemberTrackingFetcher(noESITokenSource{})
+	memberImportLock      sync.Mutex
+
+	ErrNoESIToken = errors.New("No ESI token available")
+)
+
+type MemberTrackingFetcher interface {
+	FetchMemberTracking(corporation *models.Corporation) (*models.MemberTracking, error)
+}
+
+type ESITokenSource interface {
+	Token(corporation *models.Corporation, feature string) (*models.ESIToken, error)
+}
+
+type PlayerESITokenSource interface {
+	PlayerToken(playerID int64, feature string) (*models.ESIToken, error)
+}
+
+type noESITokenSource struct{}
+
+func (source noESITokenSource) Token(corporation *models.Corporation, feature string) (*models.ESIToken, error) {
+	return nil, ErrNoESIToken
+}
+
+func (source noESITokenSource) PlayerToken(playerID int64, feature string) (*models.ESIToken, error) {
+	return nil, ErrNoESIToken
+}
+
+type ESIMemberTrackingFetcher struct {
+	Tokens ESITokenSource
+}
+
+func NewESIMemberTrackingFetcher(tokens ESITokenSource) *ESIMemberTrackingFetcher {
+	fetcher := &ESIMemberTrackingFetcher{
+		Tokens: tokens,
+	}
+
+	return fetcher
+}
+
+func (fetcher *ESIMemberTrackingFetcher) FetchMemberTracking(corporation *models.Corporation) (*models.MemberTracking, error) {
+	token, err := fetcher.Tokens.Token(corporation, SSOFeatureMemberTracking)
+	if err != nil {
+		return &models.MemberTracking{}, err
+	}
+
+	members, err := esiClient.CorporationMembers(corporation.CorporationID, ESICredentials(token))
+	if err != nil {
+		return &models.MemberTracking{}, err
+	}
+
+	names, err := esiClient.Names(members)
+	if err != nil {
+		return &models.MemberTracking{}, err
+	}
+
+	resolved := make(map[int64]string)
+	for _, name := range names {
+		resolved[name.ID] = name.Name
+	}
+
+	memberTracking := &models.MemberTracking{}
+
+	for _, characterID := range members {
+		memberTracking.Rows = append(memberTracking.Rows, models.MemberTrackingRow{CharacterID: characterID, Name: resolved[characterID]})
+	}
+
+	return memberTracking, nil
+}
+
+type RecordedMemberTrackingFetcher struct {
+	Path string
+}
+
+func NewRecordedMemberTrackingFetcher(path string) *RecordedMemberTrackingFetcher {
+	fetcher := &RecordedMemberTrackingFetcher{
+		Path: path,
+	}
+
+	return fetcher
+}
+
+func (fetcher *RecordedMemberTrackingFetcher) FetchMemberTracking(corporation *models.Corporation) (*models.MemberTracking, error) {
+	xmlContent, err := ioutil.ReadFile(fetcher.Path)
+	if err != nil {
+		return &models.MemberTracking{}, err
+	}
+
+	return ParseMemberTracking(xmlContent)
+}
+
+func ParseMemberTracking(xmlContent []byte) (*models.MemberTracking, error) {
+	var memberTracking models.MemberTracking
+
+	err := xml.Unmarshal(xmlContent, &memberTracking)
+	if err != nil {
+		return &models.MemberTracking{}, err
+	}
+
+	if memberTracking.Error != nil {
+		return &models.MemberTracking{}, fmt.Errorf("EVE API returned error %d: %s", memberTracking.Error.Code, strings.TrimSpace(memberTracking.Error.Message))
+	}
+
+	return &memberTracking, nil
+}
+
+type MemberImportResult struct {
+	Added       int      `json:"added"`
+	Renamed     int      `json:"renamed"`
+	Transferred int      `json:"transferred"`
+	Returned    int      `json:"returned"`
+	Departed    int      `json:"departed"`
+	Unchanged   int      `json:"unchanged"`
+	Failed      []string `json:"failed"`
+}
+
+func (result *MemberImportResult) Fail(format string, args ...interface{}) {
+	result.Failed = append(result.Failed, fmt.Sprintf(format, args...))
+}
+
+func (result *MemberImportResult) String() string {
+	return fmt.Sprintf("%d added, %d renamed, %d transferred, %d returned, %d departed, %d unchanged, %d failed", result.Added, result.Renamed, result.Transferred, result.Returned, result.Departed, result.Unchanged, len(result.Failed))
+}
+
+type MemberImporter struct {
+	store   Store
+	fetcher MemberTrackingFetcher
+}
+
+func NewMemberImporter(store Store, fetcher MemberTrackingFetcher) *MemberImporter {
+	importer := &MemberImporter{
+		store:   store,
+		fetcher: fetcher,
+	}
+
+	return importer
+}
+
+func (importer *MemberImporter) ImportAll() error {
+	corporations, err := importer.store.LoadAllCorporations()
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+
+	// A failing corporation is logged and skipped so the others are still imported
+	for _, corporation := range corporations {
+		result, err := importer.Import(corporation)
+		if errors.Is(err, ErrNoESIToken) {
+			logger.Debugf("Skipping member import for corporation #%d without ESI token...", corporation.ID)
+			continue
+		} else if err != nil {
+			logger.Errorf("Failed to import members for corporation #%d: [%v]", corporation.ID, err)
+
+			failed = append(failed, fmt.Sprintf("#%d: %v", corporation.ID, err))
+			continue
+		}
+
+		logger.Infof("Imported members for corporation #%d: %s", corporation.ID, result)
+
+		for _, failure := range result.Failed {
+			logger.Warnf("Failed to import member for corporation #%d: %s", corporation.ID, failure)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("Failed to import members for %d corporations: %s", len(failed), strings.Join(failed, "; "))
+	}
+
+	return nil
+}
+
+func (importer *MemberImporter) Import(corporation *models.Corporation) (*MemberImportResult, error) {
+	memberImportLock.Lock()
+	defer memberImportLock.Unlock()
+
+	result := &MemberImportResult{}
+
+	memberTracking, err := importer.fetcher.FetchMemberTracking(corporation)
+	if err != nil {
+		return result, err
+	}
+
+	// An empty member list is far more likely to be an API hiccup than everyone leaving at once
+	if len(memberTracking.Rows) == 0 {
+		return result, fmt.Errorf("Member tracking for corporation #%d returned no members", corporation.ID)
+	}
+
+	players, err := importer.store.LoadAllPlayers(corporation.ID)
+	if err != nil {
+		return result, err
+	}
+
+	known := make(map[int64]*models.Player)
+	for _, player := range players {
+		known[player.PlayerID] = player
+	}
+
+	members := make(map[int64]bool)
+
+	for _, row := range memberTracking.Rows {
+		name := strings.TrimSpace(row.Name)
+
+		if row.CharacterID <= 0 || len(name) == 0 {
+			result.Fail("Invalid member row with character ID %d and name %q", row.CharacterID, row.Name)
+			continue
+		}
+
+		members[row.CharacterID] = true
+
+		player, ok := known[row.CharacterID]
+		if ok {
+			importer.updateMember(result, player, name)
+			continue
+		}
+
+		player, err := importer.store.LoadPlayerFromPlayerID(row.CharacterID)
+		if err == sql.ErrNoRows {
+			_, err = importer.store.SavePlayer(models.NewPlayer(-1, row.CharacterID, name, corporation, models.AccessMaskMember, true))
+			if err != nil {
+				result.Fail("Failed to add %q (#%d): %v", name, row.CharacterID, err)
+				continue
+			}
+
+			result.Added++
+			continue
+		} else if err != nil {
+			result.Fail("Failed to load %q (#%d): %v", name, row.CharacterID, err)
+			continue
+		}
+
+		// Characters joining from another corporation keep their history but not their access there
+		joined := player.Copy()
+		joined.Name = name
+		joined.Corp = corporation
+		joined.AccessMask = models.AccessMaskMember
+		joined.Active = true
+
+		_, err = importer.store.SavePlayer(joined)
+		if err != nil {
+			result.Fail("Failed to transfer %q (#%d) from corporation #%d: %v", name, row.CharacterID, player.Corp.ID, err)
+			continue
+		}
+
+		result.Transferred++
+	}
+
+	for _, player := range players {
+		if members[player.PlayerID] || !player.Active {
+			continue
+		}
+
+		departed := player.Copy()
+		departed.Active = false
+
+		_, err = importer.store.SavePlayer(departed)
+		if err != nil {
+			result.Fail("Failed to mark %q (#%d) as departed: %v", player.Name, player.PlayerID, err)
+			continue
+		}
+
+		result.Departed++
+	}
+
+	return result, nil
+}
+
+func (importer *MemberImporter) updateMember(result *MemberImportResult, player *models.Player, name string) {
+	if player.Name == name && player.Active {
+		result.Unchanged++
+		return
+	}
+
+	updated := player.Copy()
+	updated.Name = name
+	updated.Active = true
+
+	_, err := importer.store.SavePlayer(updated)
+	if err != nil {
+		result.Fail("Failed to update %q (#%d): %v", name, player.PlayerID, err)
+		return
+	}
+
+	if player.Name != name {
+		result.Renamed++
+	}
+
+	if !player.Active {
+		result.Returned++
+	}
+}
+
+func RunMembersCommand(db *Database, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("Missing members command, expected import [<corporation ID> <file>]")
+	}
+
+	current, err := db.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	if current < LatestSchemaVersion() {
+		return fmt.Errorf("Database schema is at version %d, run migrate before importing members", current)
+	}
+
+	switch args[0] {
+	case "import":
+		if len(args) == 1 {
+			return NewMemberImporter(db, memberTrackingFetcher).ImportAll()
+		}
+
+		if len(args) < 3 {
+			return fmt.Errorf("Missing file for member import of corporation %s", args[1])
+		}
+
+		corporationID, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("Invalid corporation ID %q: [%v]", args[1], err)
+		}
+
+		corporations, err := db.LoadAllCorporations()
+		if err != nil {
+			return err
+		}
+
+		for _, corporation := range corporations {
+			if corporation.CorporationID != corporationID {
+				continue
+			}
+
+			result, err := NewMemberImporter(db, NewRecordedMemberTrackingFetcher(args[2])).Import(corporation)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Imported members:\n")
+			fmt.Printf("  Added:       %d\n", result.Added)
+			fmt.Printf("  Renamed:     %d\n", result.Renamed)
+			fmt.Printf("  Transferred: %d\n", result.Transferred)
+			fmt.Printf("  Returned:    %d\n", result.Returned)
+			fmt.Printf("  Departed:    %d\n", result.Departed)
+			fmt.Printf("  Unchanged:   %d\n", result.Unchanged)
+
+			for _, failure := range result.Failed {
+				fmt.Printf("  Failed:      %s\n", failure)
+			}
+
+			return nil
+		}
+
+		return fmt.Errorf("Failed to find corporation with ID %d", corporationID)
+	default:
+		return fmt.Errorf("Unknown members command %q, expected import [<corporation ID> <file>]", args[0])
+	}
+}
@@ -0,0 +1,1212 @@
+// migrations
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      map[string][]string
+	Down    map[string][]string
+	// RebuildsTables runs the SQLite statements with foreign keys disabled, as recreating a referenced table would otherwise cascade into its children
+	RebuildsTables bool
+}
+
+var migrations = []Migration{
+	Migration{
+		Version: 1,
+		Name:    "Initial schema",
+		Up: map[string][]string{
+			"mysql": []string{
+				"CREATE TABLE IF NOT EXISTS `corporations` (" +
+					"`id` bigint(20) NOT NULL AUTO_INCREMENT, " +
+					"`corporation_id` bigint(20) NOT NULL, " +
+					"`name` varchar(255) COLLATE utf8_unicode_ci NOT NULL, " +
+					"`ticker` varchar(10) COLLATE utf8_unicode_ci DEFAULT NULL, " +
+					"`corporation_cut` double NOT NULL DEFAULT '0', " +
+					"`api_keyid` int(10) NOT NULL DEFAULT '0', " +
+					"`api_keycode` varchar(64) COLLATE utf8_unicode_ci NOT NULL DEFAULT '', " +
+					"PRIMARY KEY (`id`), " +
+					"UNIQUE KEY `name` (`name`), " +
+					"UNIQUE KEY `corp_id` (`corporation_id`)" +
+					") ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci",
+				"CREATE TABLE IF NOT EXISTS `players` (" +
+					"`id` bigint(20) NOT NULL AUTO_INCREMENT, " +
+					"`player_id` bigint(20) NOT NULL, " +
+					"`name` varchar(255) COLLATE utf8_unicode_ci NOT NULL, " +
+					"`corporation_id` bigint(20) NOT NULL, " +
+					"`accessmask` int(10) NOT NULL DEFAULT '0', " +
+					"PRIMARY KEY (`id`), " +
+					"UNIQUE KEY `player_id` (`player_id`), " +
+					"UNIQUE KEY `name` (`name`), " +
+					"KEY `fk_players_corporation` (`corporation_id`), " +
+					"CONSTRAINT `fk_players_corporation` FOREIGN KEY (`corporation_id`) REFERENCES `corporations` (`id`)" +
+					") ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci",
+				"CREATE TABLE IF NOT EXISTS `reports` (" +
+					"`id` bigint(20) NOT NULL AUTO_INCREMENT, " +
+					"`corporation_id` bigint(20) NOT NULL, " +
+					"`creator` bigint(20) NOT NULL, " +
+					"`total_payout` double NOT NULL DEFAULT '0', " +
+					"`starttime` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP, " +
+					"`endtime` timestamp NOT NULL DEFAULT '0000-00-00 00:00:00', " +
+					"`payout_complete` enum('Y','N') NOT NULL DEFAULT 'N', " +
+					"PRIMARY KEY (`id`), " +
+					"KEY `fk_reports_player` (`creator`), " +
+					"KEY `fk_reports_corporation` (`corporation_id`), " +
+					"CONSTRAINT `fk_reports_corporation` FOREIGN KEY (`corporation_id`) REFERENCES `corporations` (`id`), " +
+					"CONSTRAINT `fk_reports_player` FOREIGN KEY (`creator`) REFERENCES `players` (`id`)" +
+					") ENGINE=InnoDB DEFAULT CHARSET=utf8",
+				"CREATE TABLE IF NOT EXISTS `fleets` (" +
+					"`id` bigint(20) NOT NULL AUTO_INCREMENT, " +
+					"`corporation_id` bigint(20) NOT NULL, " +
+					"`name` varchar(255) COLLATE utf8_unicode_ci DEFAULT NULL, " +
+					"`system` varchar(255) COLLATE utf8_unicode_ci NOT NULL, " +
+					"`system_nickname` varchar(255) COLLATE utf8_unicode_ci DEFAULT NULL, " +
+					"`profit` double NOT NULL DEFAULT '0', " +
+					"`losses` double NOT NULL DEFAULT '0', " +
+					"`sites_finished` int(10) NOT NULL DEFAULT '0', " +
+					"`starttime` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP, " +
+					"`endtime` timestamp NULL DEFAULT NULL, " +
+					"`corporation_payout` double NOT NULL DEFAULT '0', " +
+					"`payout_complete` enum('Y','N') COLLATE utf8_unicode_ci NOT NULL DEFAULT 'N', " +
+					"`notes` text COLLATE utf8_unicode_ci NOT NULL, " +
+					"`report_id` bigint(20) DEFAULT NULL, " +
+					"PRIMARY KEY (`id`), " +
+					"KEY `fk_fleets_report` (`report_id`), " +
+					"KEY `fk_fleets_corporation` (`corporation_id`), " +
+					"CONSTRAINT `fk_fleets_corporation` FOREIGN KEY (`corporation_id`) REFERENCES `corporations` (`id`), " +
+					"CONSTRAINT `fk_fleets_report` FOREIGN KEY (`report_id`) REFERENCES `reports` (`id`)" +
+					") ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci",
+				"CREATE TABLE IF NOT EXISTS `fleetmembers` (" +
+					"`id` bigint(20) NOT NULL AUTO_INCREMENT, " +
+					"`fleet_id` bigint(20) NOT NULL, " +
+					"`player_id` bigint(20) NOT NULL, " +
+					"`role` int(10) NOT NULL DEFAULT '0', " +
+					"`ship` varchar(50) COLLATE utf8_unicode_ci NOT NULL DEFAULT '', " +
+					"`site_modifier` int(10) NOT NULL DEFAULT '0', " +
+					"`payment_modifier` double NOT NULL DEFAULT '1', " +
+					"`payout` double NOT NULL DEFAULT '0', " +
+					"`payout_complete` enum('Y','N') COLLATE utf8_unicode_ci NOT NULL DEFAULT 'N', " +
+					"`report_id` bigint(20) DEFAULT NULL, " +
+					"PRIMARY KEY (`id`), " +
+					"UNIQUE KEY `fleet_id_player_id` (`fleet_id`,`player_id`), " +
+					"KEY `fk_fleetmembers_player` (`player_id`), " +
+					"KEY `fk_fleetmembers_fleet` (`fleet_id`), " +
+					"KEY `fk_fleetmembers_report` (`report_id`), " +
+					"CONSTRAINT `fk_fleetmembers_fleet` FOREIGN KEY (`fleet_id`) REFERENCES `fleets` (`id`), " +
+					"CONSTRAINT `fk_fleetmembers_player` FOREIGN KEY (`player_id`) REFERENCES `players` (`id`), " +
+					"CONSTRAINT `fk_fleetmembers_report` FOREIGN KEY (`report_id`) REFERENCES `reports` (`id`)" +
+					") ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci",
+				"CREATE TABLE IF NOT EXISTS `fleetroles` (" +
+					"`id` bigint(20) NOT NULL AUTO_INCREMENT, " +
+					"`ship` varchar(75) NOT NULL, " +
+					"`fleet_role` int(10) NOT NULL DEFAULT '0', " +
+					"PRIMARY KEY (`id`), " +
+					"UNIQUE KEY `ship` (`ship`)" +
+					") ENGINE=InnoDB DEFAULT CHARSET=utf8",
+				"CREATE TABLE IF NOT EXISTS `lootpastes` (" +
+					"`id` bigint(20) NOT NULL AUTO_INCREMENT, " +
+					"`fleet_id` bigint(20) NOT NULL, " +
+					"`pasted_by` bigint(20) NOT NULL, " +
+					"`raw_paste` text NOT NULL, " +
+					"`value` double NOT NULL DEFAULT '0', " +
+					"`paste_type` int(10) NOT NULL, " +
+					"PRIMARY KEY (`id`), " +
+					"KEY `fk_loot_fleet` (`fleet_id`), " +
+					"KEY `fk_loot_pasted` (`pasted_by`), " +
+					"CONSTRAINT `fk_loot_fleet` FOREIGN KEY (`fleet_id`) REFERENCES `fleets` (`id`), " +
+					"CONSTRAINT `fk_loot_pasted` FOREIGN KEY (`pasted_by`) REFERENCES `players` (`id`)" +
+					") ENGINE=InnoDB DEFAULT CHARSET=utf8",
+				"CREATE TABLE IF NOT EXISTS `reportpayouts` (" +
+					"`id` bigint(20) NOT NULL AUTO_INCREMENT, " +
+					"`report_id` bigint(20) NOT NULL, " +
+					"`player_id` bigint(20) NOT NULL, " +
+					"`payout` double NOT NULL, " +
+					"`payout_complete` enum('Y','N') NOT NULL DEFAULT 'N', " +
+					"PRIMARY KEY (`id`), " +
+					"KEY `fk_reportpayouts_report` (`report_id`), " +
+					"KEY `fk_reportpayouts_player` (`player_id`), " +
+					"CONSTRAINT `fk_reportpayouts_report` FOREIGN KEY (`report_id`) REFERENCES `reports` (`id`), " +
+					"CONSTRAINT `fk_reportpayouts_player` FOREIGN KEY (`player_id`) REFERENCES `players` (`id`)" +
+					") ENGINE=InnoDB DEFAULT CHARSET=utf8",
+			},
+			"sqlite": []string{
+				"CREATE TABLE IF NOT EXISTS corporations (" +
+					"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+					"corporation_id INTEGER NOT NULL UNIQUE, " +
+					"name VARCHAR(255) NOT NULL UNIQUE COLLATE NOCASE, " +
+					"ticker VARCHAR(10) DEFAULT NULL, " +
+					"corporation_cut DOUBLE NOT NULL DEFAULT 0, " +
+					"api_keyid INTEGER NOT NULL DEFAULT 0, " +
+					"api_keycode VARCHAR(64) NOT NULL DEFAULT ''" +
+					")",
+				"CREATE TABLE IF NOT EXISTS players (" +
+					"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+					"player_id INTEGER NOT NULL UNIQUE, " +
+					"name VARCHAR(255) NOT NULL UNIQUE COLLATE NOCASE, " +
+					"corporation_id INTEGER NOT NULL REFERENCES corporations (id), " +
+					"accessmask INTEGER NOT NULL DEFAULT 0" +
+					")",
+				"CREATE TABLE IF NOT EXISTS reports (" +
+					"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+					"corporation_id INTEGER NOT NULL REFERENCES corporations (id), " +
+					"creator INTEGER NOT NULL REFERENCES players (id), " +
+					"total_payout DOUBLE NOT NULL DEFAULT 0, " +
+					"starttime TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, " +
+					"endtime TIMESTAMP NOT NULL, " +
+					"payout_complete CHAR(1) NOT NULL DEFAULT 'N' CHECK (payout_complete IN ('Y', 'N'))" +
+					")",
+				"CREATE TABLE IF NOT EXISTS fleets (" +
+					"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+					"corporation_id INTEGER NOT NULL REFERENCES corporations (id), " +
+					"name VARCHAR(255) DEFAULT NULL, " +
+					"system VARCHAR(255) NOT NULL, " +
+					"system_nickname VARCHAR(255) DEFAULT NULL, " +
+					"profit DOUBLE NOT NULL DEFAULT 0, " +
+					"losses DOUBLE NOT NULL DEFAULT 0, " +
+					"sites_finished INTEGER NOT NULL DEFAULT 0, " +
+					"starttime TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, " +
+					"endtime TIMESTAMP NULL DEFAULT NULL, " +
+					"corporation_payout DOUBLE NOT NULL DEFAULT 0, " +
+					"payout_complete CHAR(1) NOT NULL DEFAULT 'N' CHECK (payout_complete IN ('Y', 'N')), " +
+					"notes TEXT NOT NULL, " +
+					"report_id INTEGER DEFAULT NULL REFERENCES reports (id)" +
+					")",
+				"CREATE TABLE IF NOT EXISTS fleetmembers (" +
+					"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+					"fleet_id INTEGER NOT NULL REFERENCES fleets (id), " +
+					"player_id INTEGER NOT NULL REFERENCES players (id), " +
+					"role INTEGER NOT NULL DEFAULT 0, " +
+					"ship VARCHAR(50) NOT NULL DEFAULT '', " +
+					"site_modifier INTEGER NOT NULL DEFAULT 0, " +
+					"payment_modifier DOUBLE NOT NULL DEFAULT 1, " +
+					"payout DOUBLE NOT NULL DEFAULT 0, " +
+					"payout_complete CHAR(1) NOT NULL DEFAULT 'N' CHECK (payout_complete IN ('Y', 'N')), " +
+					"report_id INTEGER DEFAULT NULL REFERENCES reports (id), " +
+					"UNIQUE (fleet_id, player_id)" +
+					")",
+				"CREATE TABLE IF NOT EXISTS fleetroles (" +
+					"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+					"ship VARCHAR(75) NOT NULL UNIQUE COLLATE NOCASE, " +
+					"fleet_role INTEGER NOT NULL DEFAULT 0" +
+					")",
+				"CREATE TABLE IF NOT EXISTS lootpastes (" +
+					"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+					"fleet_id INTEGER NOT NULL REFERENCES fleets (id), " +
+					"pasted_by INTEGER NOT NULL REFERENCES players (id), " +
+					"raw_paste TEXT NOT NULL, " +
+					"value DOUBLE NOT NULL DEFAULT 0, " +
+					"paste_type INTEGER NOT NULL" +
+					")",
+				"CREATE TABLE IF NOT EXISTS reportpayouts (" +
+					"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+					"report_id INTEGER NOT NULL REFERENCES reports (id), " +
+					"player_id INTEGER NOT NULL REFERENCES players (id), " +
+					"payout DOUBLE NOT NULL, " +
+					"payout_complete CHAR(1) NOT NULL DEFAULT 'N' CHECK (payout_complete IN ('Y', 'N'))" +
+					")",
+			},
+		},
+		Down: map[string][]string{
+			"mysql": []string{
+				"DROP TABLE IF EXISTS `reportpayouts`",
+				"DROP TABLE IF EXISTS `lootpastes`",
+				"DROP TABLE IF EXISTS `fleetroles`",
+				"DROP TABLE IF EXISTS `fleetmembers`",
+				"DROP TABLE IF EXISTS `fleets`",
+				"DROP TABLE IF EXISTS `reports`",
+				"DROP TABLE IF EXISTS `players`",
+				"DROP TABLE IF EXISTS `corporations`",
+			},
+			"sqlite": []string{
+				"DROP TABLE IF EXISTS reportpayouts",
+				"DROP TABLE IF EXISTS lootpastes",
+				"DROP TABLE IF EXISTS fleetroles",
+				"DROP TABLE IF EXISTS fleetmembers",
+				"DROP TABLE IF EXISTS fleets",
+				"DROP TABLE IF EXISTS reports",
+				"DROP TABLE IF EXISTS players",
+				"DROP TABLE IF EXISTS corporations",
+			},
+		},
+	},
+	Migration{
+		Version: 2,
+		Name:    "Default fleet roles",
+		Up: map[string][]string{
+			"mysql": []string{
+				"INSERT IGNORE INTO `fleetroles` (`id`, `ship`, `fleet_role`) VALUES " + defaultFleetRoles,
+			},
+			"sqlite": []string{
+				"INSERT OR IGNORE INTO fleetroles (id, ship, fleet_role) VALUES " + defaultFleetRoles,
+			},
+		},
+		Down: map[string][]string{
+			"mysql": []string{
+				"DELETE FROM `fleetroles` WHERE `id` BETWEEN 1 AND 35",
+			},
+			"sqlite": []string{
+				"DELETE FROM fleetroles WHERE id BETWEEN 1 AND 35",
+			},
+		},
+	},
+	Migration{
+		Version: 3,
+		Name:    "Item types and prices",
+		Up: map[string][]string{
+			"mysql": []string{
+				"CREATE TABLE IF NOT EXISTS `itemtypes` (" +
+					"`id` bigint(20) NOT NULL, " +
+					"`name` varchar(255) COLLATE utf8_unicode_ci NOT NULL, " +
+					"`group_name` varchar(255) COLLATE utf8_unicode_ci NOT NULL DEFAULT '', " +
+					"`volume` double NOT NULL DEFAULT '0', " +
+					"PRIMARY KEY (`id`), " +
+					"UNIQUE KEY `name` (`name`)" +
+					") ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci",
+				"CREATE TABLE IF NOT EXISTS `itemprices` (" +
+					"`type_id` bigint(20) NOT NULL, " +
+					"`buy` double NOT NULL DEFAULT '0', " +
+					"`sell` double NOT NULL DEFAULT '0', " +
+					"`updated` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP, " +
+					"PRIMARY KEY (`type_id`), " +
+					"CONSTRAINT `fk_itemprices_itemtype` FOREIGN KEY (`type_id`) REFERENCES `itemtypes` (`id`) ON DELETE CASCADE" +
+					") ENGINE=InnoDB DEFAULT CHARSET=utf8",
+			},
+			"sqlite": []string{
+				"CREATE TABLE IF NOT EXISTS itemtypes (" +
+					"id INTEGER PRIMARY KEY, " +
+					"name VARCHAR(255) NOT NULL UNIQUE COLLATE NOCASE, " +
+					"group_name VARCHAR(255) NOT NULL DEFAULT '', " +
+					"volume DOUBLE NOT NULL DEFAULT 0" +
+					")",
+				"CREATE TABLE IF NOT EXISTS itemprices (" +
+					"type_id INTEGER PRIMARY KEY REFERENCES itemtypes (id) ON DELETE CASCADE, " +
+					"buy DOUBLE NOT NULL DEFAULT 0, " +
+					"sell DOUBLE NOT NULL DEFAULT 0, " +
+					"updated DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP" +
+					")",
+			},
+		},
+		Down: map[string][]string{
+			"mysql": []string{
+				"DROP TABLE IF EXISTS `itemprices`",
+				"DROP TABLE IF EXISTS `itemtypes`",
+			},
+			"sqlite": []string{
+				"DROP TABLE IF EXISTS itemprices",
+				"DROP TABLE IF EXISTS itemtypes",
+			},
+		},
+	},
+	Migration{
+		Version: 4,
+		Name:    "Loot paste items",
+		Up: map[string][]string{
+			"mysql": []string{
+				"CREATE TABLE IF NOT EXISTS `lootpasteitems` (" +
+					"`id` bigint(20) NOT NULL AUTO_INCREMENT, " +
+					"`lootpaste_id` bigint(20) NOT NULL, " +
+					"`type_id` bigint(20) NOT NULL, " +
+					"`name` varchar(255) COLLATE utf8_unicode_ci NOT NULL, " +
+					"`group_name` varchar(255) COLLATE utf8_unicode_ci NOT NULL DEFAULT '', " +
+					"`quantity` bigint(20) NOT NULL DEFAULT '0', " +
+					"`unit_price` double NOT NULL DEFAULT '0', " +
+					"`total` double NOT NULL DEFAULT '0', " +
+					"`volume` double NOT NULL DEFAULT '0', " +
+					"PRIMARY KEY (`id`), " +
+					"KEY `fk_lootpasteitems_lootpaste` (`lootpaste_id`), " +
+					"CONSTRAINT `fk_lootpasteitems_lootpaste` FOREIGN KEY (`lootpaste_id`) REFERENCES `lootpastes` (`id`) ON DELETE CASCADE" +
+					") ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci",
+			},
+			"sqlite": []string{
+				"CREATE TABLE IF NOT EXISTS lootpasteitems (" +
+					"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+					"lootpaste_id INTEGER NOT NULL REFERENCES lootpastes (id) ON DELETE CASCADE, " +
+					"type_id INTEGER NOT NULL, " +
+					"name VARCHAR(255) NOT NULL, " +
+					"group_name VARCHAR(255) NOT NULL DEFAULT '', " +
+					"quantity INTEGER NOT NULL DEFAULT 0, " +
+					"unit_price DOUBLE NOT NULL DEFAULT 0, " +
+					"total DOUBLE NOT NULL DEFAULT 0, " +
+					"volume DOUBLE NOT NULL DEFAULT 0" +
+					")",
+				"CREATE INDEX IF NOT EXISTS lootpasteitems_lootpaste ON lootpasteitems (lootpaste_id)",
+			},
+		},
+		Down: map[string][]string{
+			"mysql": []string{
+				"DROP TABLE IF EXISTS `lootpasteitems`",
+			},
+			"sqlite": []string{
+				"DROP TABLE IF EXISTS lootpasteitems",
+			},
+		},
+	},
+	Migration{
+		Version: 5,
+		Name:    "Voidable loot pastes, derived fleet totals",
+		Up: map[string][]string{
+			"mysql": []string{
+				"ALTER TABLE `lootpastes` ADD COLUMN `voided` enum('Y','N') NOT NULL DEFAULT 'N'",
+				// Stored totals that were edited by hand or never backed by loot pastes are kept as adjustment pastes
+				"INSERT INTO `lootpastes` (`fleet_id`, `pasted_by`, `raw_paste`, `value`, `paste_type`) " +
+					"SELECT `fleets`.`id`, " + legacyAdjustmentPastedBy["mysql"] + ", " +
+					"'Adjustment for fleet profit recorded before loot pastes were tracked', " +
+					"`fleets`.`profit` - (SELECT COALESCE(SUM(`value`), 0) FROM `lootpastes` WHERE `lootpastes`.`fleet_id` = `fleets`.`id` AND `paste_type` = 2), 2 " +
+					"FROM `fleets` WHERE ABS(`fleets`.`profit` - (SELECT COALESCE(SUM(`value`), 0) FROM `lootpastes` WHERE `lootpastes`.`fleet_id` = `fleets`.`id` AND `paste_type` = 2)) >= 0.01",
+				"INSERT INTO `lootpastes` (`fleet_id`, `pasted_by`, `raw_paste`, `value`, `paste_type`) " +
+					"SELECT `fleets`.`id`, " + legacyAdjustmentPastedBy["mysql"] + ", " +
+					"'Adjustment for fleet losses recorded before loot pastes were tracked', " +
+					"`fleets`.`losses` - (SELECT COALESCE(SUM(`value`), 0) FROM `lootpastes` WHERE `lootpastes`.`fleet_id` = `fleets`.`id` AND `paste_type` = 4), 4 " +
+					"FROM `fleets` WHERE ABS(`fleets`.`losses` - (SELECT COALESCE(SUM(`value`), 0) FROM `lootpastes` WHERE `lootpastes`.`fleet_id` = `fleets`.`id` AND `paste_type` = 4)) >= 0.01",
+				"ALTER TABLE `fleets` DROP COLUMN `profit`, DROP COLUMN `losses`",
+			},
+			"sqlite": []string{
+				"ALTER TABLE lootpastes ADD COLUMN voided CHAR(1) NOT NULL DEFAULT 'N' CHECK (voided IN ('Y', 'N'))",
+				"INSERT INTO lootpastes (fleet_id, pasted_by, raw_paste, value, paste_type) " +
+					"SELECT fleets.id, " + legacyAdjustmentPastedBy["sqlite"] + ", " +
+					"'Adjustment for fleet profit recorded before loot pastes were tracked', " +
+					"fleets.profit - (SELECT COALESCE(SUM(value), 0) FROM lootpastes WHERE lootpastes.fleet_id = fleets.id AND paste_type = 2), 2 " +
+					"FROM fleets WHERE ABS(fleets.profit - (SELECT COALESCE(SUM(value), 0) FROM lootpastes WHERE lootpastes.fleet_id = fleets.id AND paste_type = 2)) >= 0.01",
+				"INSERT INTO lootpastes (fleet_id, pasted_by, raw_paste, value, paste_type) " +
+					"SELECT fleets.id, " + legacyAdjustmentPastedBy["sqlite"] + ", " +
+					"'Adjustment for fleet losses recorded before loot pastes were tracked', " +
+					"fleets.losses - (SELECT COALESCE(SUM(value), 0) FROM lootpastes WHERE lootpastes.fleet_id = fleets.id AND paste_type = 4), 4 " +
+					"FROM fleets WHERE ABS(fleets.losses - (SELECT COALESCE(SUM(value), 0) FROM lootpastes WHERE lootpastes.fleet_id = fleets.id AND paste_type = 4)) >= 0.01",
+				"ALTER TABLE fleets DROP COLUMN profit",
+				"ALTER TABLE fleets DROP COLUMN losses",
+			},
+		},
+		Down: map[string][]string{
+			"mysql": []string{
+				"ALTER TABLE `fleets` ADD COLUMN `profit` double NOT NULL DEFAULT '0' AFTER `system_nickname`, ADD COLUMN `losses` double NOT NULL DEFAULT '0' AFTER `profit`",
+				"UPDATE `fleets` SET " +
+					"`profit` = (SELECT COALESCE(SUM(`value`), 0) FROM `lootpastes` WHERE `lootpastes`.`fleet_id` = `fleets`.`id` AND `paste_type` = 2 AND `voided` = 'N'), " +
+					"`losses` = (SELECT COALESCE(SUM(`value`), 0) FROM `lootpastes` WHERE `lootpastes`.`fleet_id` = `fleets`.`id` AND `paste_type` = 4 AND `voided` = 'N')",
+				"ALTER TABLE `lootpastes` DROP COLUMN `voided`",
+			},
+			"sqlite": []string{
+				"ALTER TABLE fleets ADD COLUMN profit DOUBLE NOT NULL DEFAULT 0",
+				"ALTER TABLE fleets ADD COLUMN losses DOUBLE NOT NULL DEFAULT 0",
+				"UPDATE fleets SET " +
+					"profit = (SELECT COALESCE(SUM(value), 0) FROM lootpastes WHERE lootpastes.fleet_id = fleets.id AND paste_type = 2 AND voided = 'N'), " +
+					"losses = (SELECT COALESCE(SUM(value), 0) FROM lootpastes WHERE lootpastes.fleet_id = fleets.id AND paste_type = 4 AND voided = 'N')",
+				"ALTER TABLE lootpastes DROP COLUMN voided",
+			},
+		},
+	},
+	Migration{
+		Version: 6,
+		Name:    "Payout strategies",
+		Up: map[string][]string{
+			"mysql": []string{
+				"ALTER TABLE `corporations` ADD COLUMN `payout_strategy` int(10) NOT NULL DEFAULT '1'",
+				"ALTER TABLE `fleets` ADD COLUMN `payout_strategy` int(10) NOT NULL DEFAULT '0'",
+				"ALTER TABLE `fleetmembers` ADD COLUMN `join_time` timestamp NULL DEFAULT NULL, ADD COLUMN `leave_time` timestamp NULL DEFAULT NULL",
+			},
+			"sqlite": []string{
+				"ALTER TABLE corporations ADD COLUMN payout_strategy INTEGER NOT NULL DEFAULT 1",
+				"ALTER TABLE fleets ADD COLUMN payout_strategy INTEGER NOT NULL DEFAULT 0",
+				"ALTER TABLE fleetmembers ADD COLUMN join_time DATETIME DEFAULT NULL",
+				"ALTER TABLE fleetmembers ADD COLUMN leave_time DATETIME DEFAULT NULL",
+			},
+		},
+		Down: map[string][]string{
+			"mysql": []string{
+				"ALTER TABLE `fleetmembers` DROP COLUMN `join_time`, DROP COLUMN `leave_time`",
+				"ALTER TABLE `fleets` DROP COLUMN `payout_strategy`",
+				"ALTER TABLE `corporations` DROP COLUMN `payout_strategy`",
+			},
+			"sqlite": []string{
+				"ALTER TABLE fleetmembers DROP COLUMN leave_time",
+				"ALTER TABLE fleetmembers DROP COLUMN join_time",
+				"ALTER TABLE fleets DROP COLUMN payout_strategy",
+				"ALTER TABLE corporations DROP COLUMN payout_strategy",
+			},
+		},
+	},
+	Migration{
+		Version: 7,
+		Name:    "Corporation fleet roles",
+		Up: map[string][]string{
+			"mysql": []string{
+				"CREATE TABLE IF NOT EXISTS `corporationroles` (" +
+					"`id` bigint(20) NOT NULL AUTO_INCREMENT, " +
+					"`corporation_id` bigint(20) NOT NULL, " +
+					"`role` bigint(20) NOT NULL, " +
+					"`name` varchar(64) COLLATE utf8_unicode_ci NOT NULL, " +
+					"`payment_rate` double NOT NULL DEFAULT '1', " +
+					"`label_type` varchar(32) COLLATE utf8_unicode_ci NOT NULL DEFAULT 'label-default', " +
+					"PRIMARY KEY (`id`), " +
+					"UNIQUE KEY `corporation_role` (`corporation_id`, `role`), " +
+					"CONSTRAINT `fk_corporationroles_corporation` FOREIGN KEY (`corporation_id`) REFERENCES `corporations` (`id`) ON DELETE CASCADE" +
+					") ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci",
+			},
+			"sqlite": []string{
+				"CREATE TABLE IF NOT EXISTS corporationroles (" +
+					"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+					"corporation_id INTEGER NOT NULL REFERENCES corporations (id) ON DELETE CASCADE, " +
+					"role INTEGER NOT NULL, " +
+					"name VARCHAR(64) NOT NULL, " +
+					"payment_rate DOUBLE NOT NULL DEFAULT 1, " +
+					"label_type VARCHAR(32) NOT NULL DEFAULT 'label-default', " +
+					"UNIQUE (corporation_id, role)" +
+					")",
+			},
+		},
+		Down: map[string][]string{
+			"mysql": []string{
+				"DROP TABLE IF EXISTS `corporationroles`",
+			},
+			"sqlite": []string{
+				"DROP TABLE IF EXISTS corporationroles",
+			},
+		},
+	},
+	Migration{
+		Version: 8,
+		Name:    "API tokens",
+		Up: map[string][]string{
+			"mysql": []string{
+				"CREATE TABLE IF NOT EXISTS `apitokens` (" +
+					"`id` bigint(20) NOT NULL AUTO_INCREMENT, " +
+					"`player_id` bigint(20) NOT NULL, " +
+					"`name` varchar(64) COLLATE utf8_unicode_ci NOT NULL, " +
+					"`token_hash` char(64) NOT NULL, " +
+					"`created` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP, " +
+					"`last_used` timestamp NULL DEFAULT NULL, " +
+					"PRIMARY KEY (`id`), " +
+					"UNIQUE KEY `token_hash` (`token_hash`), " +
+					"KEY `fk_apitokens_player` (`player_id`), " +
+					"CONSTRAINT `fk_apitokens_player` FOREIGN KEY (`player_id`) REFERENCES `players` (`id`) ON DELETE CASCADE" +
+					") ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci",
+			},
+			"sqlite": []string{
+				"CREATE TABLE IF NOT EXISTS apitokens (" +
+					"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+					"player_id INTEGER NOT NULL REFERENCES players (id) ON DELETE CASCADE, " +
+					"name VARCHAR(64) NOT NULL, " +
+					"token_hash CHAR(64) NOT NULL UNIQUE, " +
+					"created DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP, " +
+					"last_used DATETIME DEFAULT NULL" +
+					")",
+			},
+		},
+		Down: map[string][]string{
+			"mysql": []string{
+				"DROP TABLE IF EXISTS `apitokens`",
+			},
+			"sqlite": []string{
+				"DROP TABLE IF EXISTS apitokens",
+			},
+		},
+	},
+	Migration{
+		Version: 9,
+		Name:    "Audit log",
+		Up: map[string][]string{
+			"mysql": []string{
+				"CREATE TABLE IF NOT EXISTS `auditlog` (" +
+					"`id` bigint(20) NOT NULL AUTO_INCREMENT, " +
+					"`fleet_id` bigint(20) DEFAULT NULL, " +
+					"`report_id` bigint(20) DEFAULT NULL, " +
+					"`player_id` bigint(20) DEFAULT NULL, " +
+					"`actor` varchar(255) COLLATE utf8_unicode_ci NOT NULL, " +
+					"`command` varchar(64) NOT NULL, " +
+					"`before_value` mediumtext COLLATE utf8_unicode_ci NOT NULL, " +
+					"`after_value` mediumtext COLLATE utf8_unicode_ci NOT NULL, " +
+					"`remote_address` varchar(255) NOT NULL, " +
+					"`created` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP, " +
+					"PRIMARY KEY (`id`), " +
+					"KEY `auditlog_fleet` (`fleet_id`, `created`), " +
+					"KEY `auditlog_report` (`report_id`, `created`)" +
+					") ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci",
+			},
+			"sqlite": []string{
+				"CREATE TABLE IF NOT EXISTS auditlog (" +
+					"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+					"fleet_id INTEGER DEFAULT NULL, " +
+					"report_id INTEGER DEFAULT NULL, " +
+					"player_id INTEGER DEFAULT NULL, " +
+					"actor VARCHAR(255) NOT NULL, " +
+					"command VARCHAR(64) NOT NULL, " +
+					"before_value TEXT NOT NULL, " +
+					"after_value TEXT NOT NULL, " +
+					"remote_address VARCHAR(255) NOT NULL, " +
+					"created DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP" +
+					")",
+				"CREATE INDEX IF NOT EXISTS auditlog_fleet ON auditlog (fleet_id, created)",
+				"CREATE INDEX IF NOT EXISTS auditlog_report ON auditlog (report_id, created)",
+			},
+		},
+		Down: map[string][]string{
+			"mysql": []string{
+				"DROP TABLE IF EXISTS `auditlog`",
+			},
+			"sqlite": []string{
+				"DROP TABLE IF EXISTS auditlog",
+			},
+		},
+	},
+	Migration{
+		Version: 10,
+		Name:    "Wallet transfers",
+		Up: map[string][]string{
+			"mysql": []string{
+				"CREATE TABLE IF NOT EXISTS `wallettransfers` (" +
+					"`id` bigint(20) NOT NULL AUTO_INCREMENT, " +
+					"`corporation_id` bigint(20) NOT NULL, " +
+					"`ref_id` bigint(20) NOT NULL, " +
+					"`date` datetime NOT NULL, " +
+					"`recipient_id` bigint(20) NOT NULL, " +
+					"`recipient_name` varchar(255) COLLATE utf8_unicode_ci NOT NULL, " +
+					"`amount` double NOT NULL, " +
+					"`reason` varchar(255) COLLATE utf8_unicode_ci NOT NULL DEFAULT '', " +
+					"`report_id` bigint(20) DEFAULT NULL, " +
+					"`reportpayout_id` bigint(20) DEFAULT NULL, " +
+					"`expected_amount` double NOT NULL DEFAULT '0', " +
+					"`status` int(11) NOT NULL, " +
+					"`resolved` enum('Y','N') NOT NULL DEFAULT 'N', " +
+					"PRIMARY KEY (`id`), " +
+					"UNIQUE KEY `corporation_id_ref_id` (`corporation_id`,`ref_id`)" +
+					") ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci",
+			},
+			"sqlite": []string{
+				"CREATE TABLE IF NOT EXISTS wallettransfers (" +
+					"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+					"corporation_id INTEGER NOT NULL, " +
+					"ref_id INTEGER NOT NULL, " +
+					"date DATETIME NOT NULL, " +
+					"recipient_id INTEGER NOT NULL, " +
+					"recipient_name VARCHAR(255) NOT NULL COLLATE NOCASE, " +
+					"amount DOUBLE NOT NULL, " +
+					"reason VARCHAR(255) NOT NULL DEFAULT '', " +
+					"report_id INTEGER DEFAULT NULL, " +
+					"reportpayout_id INTEGER DEFAULT NULL, " +
+					"expected_amount DOUBLE NOT NULL DEFAULT 0, " +
+					"status INTEGER NOT NULL, " +
+					"resolved CHAR(1) NOT NULL DEFAULT 'N' CHECK (resolved IN ('Y', 'N')), " +
+					"UNIQUE (corporation_id, ref_id)" +
+					")",
+			},
+		},
+		Down: map[string][]string{
+			"mysql": []string{
+				"DROP TABLE IF EXISTS `wallettransfers`",
+			},
+			"sqlite": []string{
+				"DROP TABLE IF EXISTS wallettransfers",
+			},
+		},
+	},
+	Migration{
+		Version: 11,
+		Name:    "Report payout payers",
+		Up: map[string][]string{
+			"mysql": []string{
+				"ALTER TABLE `reportpayouts` ADD COLUMN `payer_id` bigint(20) DEFAULT NULL",
+			},
+			"sqlite": []string{
+				"ALTER TABLE reportpayouts ADD COLUMN payer_id INTEGER DEFAULT NULL",
+			},
+		},
+		Down: map[string][]string{
+			"mysql": []string{
+				"ALTER TABLE `reportpayouts` DROP COLUMN `payer_id`",
+			},
+			"sqlite": []string{
+				"ALTER TABLE reportpayouts DROP COLUMN payer_id",
+			},
+		},
+	},
+	Migration{
+		Version: 12,
+		Name:    "Active players",
+		Up: map[string][]string{
+			"mysql": []string{
+				"ALTER TABLE `players` ADD COLUMN `active` enum('Y','N') COLLATE utf8_unicode_ci NOT NULL DEFAULT 'Y'",
+			},
+			"sqlite": []string{
+				"ALTER TABLE players ADD COLUMN active CHAR(1) NOT NULL DEFAULT 'Y' CHECK (active IN ('Y', 'N'))",
+			},
+		},
+		Down: map[string][]string{
+			"mysql": []string{
+				"ALTER TABLE `players` DROP COLUMN `active`",
+			},
+			"sqlite": []string{
+				"ALTER TABLE players DROP COLUMN active",
+			},
+		},
+	},
+	Migration{
+		Version: 13,
+		Name:    "ESI tokens",
+		Up: map[string][]string{
+			"mysql": []string{
+				"CREATE TABLE IF NOT EXISTS `esitokens` (" +
+					"`id` bigint(20) NOT NULL AUTO_INCREMENT, " +
+					"`player_id` bigint(20) NOT NULL, " +
+					"`character_id` bigint(20) NOT NULL, " +
+					"`scopes` text COLLATE utf8_unicode_ci NOT NULL, " +
+					"`access_token` text NOT NULL, " +
+					"`refresh_token` text NOT NULL, " +
+					"`expiry` timestamp NULL DEFAULT NULL, " +
+					"`valid` enum('Y','N') COLLATE utf8_unicode_ci NOT NULL DEFAULT 'Y', " +
+					"`updated` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP, " +
+					"PRIMARY KEY (`id`), " +
+					"UNIQUE KEY `player_id` (`player_id`), " +
+					"CONSTRAINT `fk_esitokens_player` FOREIGN KEY (`player_id`) REFERENCES `players` (`id`) ON DELETE CASCADE" +
+					") ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci",
+			},
+			"sqlite": []string{
+				"CREATE TABLE IF NOT EXISTS esitokens (" +
+					"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+					"player_id INTEGER NOT NULL UNIQUE REFERENCES players (id) ON DELETE CASCADE, " +
+					"character_id INTEGER NOT NULL, " +
+					"scopes TEXT NOT NULL, " +
+					"access_token TEXT NOT NULL, " +
+					"refresh_token TEXT NOT NULL, " +
+					"expiry DATETIME DEFAULT NULL, " +
+					"valid CHAR(1) NOT NULL DEFAULT 'Y' CHECK (valid IN ('Y', 'N')), " +
+					"updated DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP" +
+					")",
+			},
+		},
+		Down: map[string][]string{
+			"mysql": []string{
+				"DROP TABLE IF EXISTS `esitokens`",
+			},
+			"sqlite": []string{
+				"DROP TABLE IF EXISTS esitokens",
+			},
+		},
+	},
+	Migration{
+		Version: 14,
+		Name:    "Fleet links",
+		Up: map[string][]string{
+			"mysql": []string{
+				"CREATE TABLE IF NOT EXISTS `fleetlinks` (" +
+					"`id` bigint(20) NOT NULL AUTO_INCREMENT, " +
+					"`fleet_id` bigint(20) NOT NULL, " +
+					"`esi_fleet_id` bigint(20) NOT NULL, " +
+					"`player_id` bigint(20) NOT NULL, " +
+					"`members` text COLLATE utf8_unicode_ci NOT NULL, " +
+					"`last_sync` timestamp NULL DEFAULT NULL, " +
+					"`last_error` text COLLATE utf8_unicode_ci NOT NULL, " +
+					"`created` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP, " +
+					"PRIMARY KEY (`id`), " +
+					"UNIQUE KEY `fleet_id` (`fleet_id`), " +
+					"CONSTRAINT `fk_fleetlinks_fleet` FOREIGN KEY (`fleet_id`) REFERENCES `fleets` (`id`) ON DELETE CASCADE, " +
+					"CONSTRAINT `fk_fleetlinks_player` FOREIGN KEY (`player_id`) REFERENCES `players` (`id`) ON DELETE CASCADE" +
+					") ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci",
+			},
+			"sqlite": []string{
+				"CREATE TABLE IF NOT EXISTS fleetlinks (" +
+					"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+					"fleet_id INTEGER NOT NULL UNIQUE REFERENCES fleets (id) ON DELETE CASCADE, " +
+					"esi_fleet_id INTEGER NOT NULL, " +
+					"player_id INTEGER NOT NULL REFERENCES players (id) ON DELETE CASCADE, " +
+					"members TEXT NOT NULL, " +
+					"last_sync DATETIME DEFAULT NULL, " +
+					"last_error TEXT NOT NULL, " +
+					"created DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP" +
+					")",
+			},
+		},
+		Down: map[string][]string{
+			"mysql": []string{
+				"DROP TABLE IF EXISTS `fleetlinks`",
+			},
+			"sqlite": []string{
+				"DROP TABLE IF EXISTS fleetlinks",
+			},
+		},
+	},
+	Migration{
+		Version: 15,
+		Name:    "Fleet member intervals",
+		Up: map[string][]string{
+			"mysql": []string{
+				"CREATE TABLE IF NOT EXISTS `fleetmemberintervals` (" +
+					"`id` bigint(20) NOT NULL AUTO_INCREMENT, " +
+					"`fleetmember_id` bigint(20) NOT NULL, " +
+					"`join_time` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP, " +
+					"`leave_time` timestamp NULL DEFAULT NULL, " +
+					"PRIMARY KEY (`id`), " +
+					"KEY `fk_fleetmemberintervals_fleetmember` (`fleetmember_id`), " +
+					"CONSTRAINT `fk_fleetmemberintervals_fleetmember` FOREIGN KEY (`fleetmember_id`) REFERENCES `fleetmembers` (`id`) ON DELETE CASCADE" +
+					") ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci",
+				"INSERT INTO `fleetmemberintervals` (`fleetmember_id`, `join_time`, `leave_time`) " +
+					"SELECT m.`id`, COALESCE(m.`join_time`, f.`starttime`), m.`leave_time` FROM `fleetmembers` AS m INNER JOIN `fleets` AS f ON f.`id` = m.`fleet_id`",
+			},
+			"sqlite": []string{
+				"CREATE TABLE IF NOT EXISTS fleetmemberintervals (" +
+					"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+					"fleetmember_id INTEGER NOT NULL REFERENCES fleetmembers (id) ON DELETE CASCADE, " +
+					"join_time DATETIME NOT NULL, " +
+					"leave_time DATETIME DEFAULT NULL" +
+					")",
+				"CREATE INDEX IF NOT EXISTS fleetmemberintervals_fleetmember ON fleetmemberintervals (fleetmember_id)",
+				"INSERT INTO fleetmemberintervals (fleetmember_id, join_time, leave_time) " +
+					"SELECT m.id, COALESCE(m.join_time, f.starttime), m.leave_time FROM fleetmembers AS m INNER JOIN fleets AS f ON f.id = m.fleet_id",
+			},
+		},
+		Down: map[string][]string{
+			"mysql": []string{
+				"DROP TABLE IF EXISTS `fleetmemberintervals`",
+			},
+			"sqlite": []string{
+				"DROP TABLE IF EXISTS fleetmemberintervals",
+			},
+		},
+	},
+	Migration{
+		Version: 16,
+		Name:    "Fleet site log",
+		Up: map[string][]string{
+			"mysql": []string{
+				"CREATE TABLE IF NOT EXISTS `fleetsites` (" +
+					"`id` bigint(20) NOT NULL AUTO_INCREMENT, " +
+					"`fleet_id` bigint(20) NOT NULL, " +
+					"`name` varchar(255) COLLATE utf8_unicode_ci NOT NULL DEFAULT '', " +
+					"`finished` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP, " +
+					"`escalation` enum('Y','N') COLLATE utf8_unicode_ci NOT NULL DEFAULT 'N', " +
+					"`voided` enum('Y','N') COLLATE utf8_unicode_ci NOT NULL DEFAULT 'N', " +
+					"`ticked_by` bigint(20) DEFAULT NULL, " +
+					"PRIMARY KEY (`id`), " +
+					"KEY `fk_fleetsites_fleet` (`fleet_id`), " +
+					"CONSTRAINT `fk_fleetsites_fleet` FOREIGN KEY (`fleet_id`) REFERENCES `fleets` (`id`) ON DELETE CASCADE" +
+					") ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci",
+				"CREATE TABLE IF NOT EXISTS `fleetsitemembers` (" +
+					"`fleetsite_id` bigint(20) NOT NULL, " +
+					"`fleetmember_id` bigint(20) NOT NULL, " +
+					"PRIMARY KEY (`fleetsite_id`, `fleetmember_id`), " +
+					"KEY `fk_fleetsitemembers_fleetmember` (`fleetmember_id`), " +
+					"CONSTRAINT `fk_fleetsitemembers_fleetsite` FOREIGN KEY (`fleetsite_id`) REFERENCES `fleetsites` (`id`) ON DELETE CASCADE, " +
+					"CONSTRAINT `fk_fleetsitemembers_fleetmember` FOREIGN KEY (`fleetmember_id`) REFERENCES `fleetmembers` (`id`) ON DELETE CASCADE" +
+					") ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci",
+				"ALTER TABLE `lootpastes` ADD COLUMN `site_id` bigint(20) DEFAULT NULL",
+				"INSERT INTO `fleetsites` (`fleet_id`, `name`, `finished`, `escalation`, `voided`) " +
+					"WITH RECURSIVE `seq` (`n`) AS (SELECT 1 UNION ALL SELECT `n` + 1 FROM `seq` WHERE `n` < (SELECT COALESCE(MAX(`sites_finished`), 0) FROM `fleets`)) " +
+					"SELECT f.`id`, '', f.`starttime`, 'N', 'N' FROM `fleets` AS f INNER JOIN `seq` ON `seq`.`n` <= f.`sites_finished`",
+				"INSERT INTO `fleetsitemembers` (`fleetsite_id`, `fleetmember_id`) " +
+					"SELECT s.`id`, m.`id` FROM `fleetsites` AS s INNER JOIN `fleetmembers` AS m ON m.`fleet_id` = s.`fleet_id`",
+				"ALTER TABLE `fleets` DROP COLUMN `sites_finished`",
+			},
+			"sqlite": []string{
+				"CREATE TABLE IF NOT EXISTS fleetsites (" +
+					"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+					"fleet_id INTEGER NOT NULL REFERENCES fleets (id) ON DELETE CASCADE, " +
+					"name VARCHAR(255) NOT NULL DEFAULT '', " +
+					"finished DATETIME NOT NULL, " +
+					"escalation CHAR(1) NOT NULL DEFAULT 'N' CHECK (escalation IN ('Y', 'N')), " +
+					"voided CHAR(1) NOT NULL DEFAULT 'N' CHECK (voided IN ('Y', 'N')), " +
+					"ticked_by INTEGER DEFAULT NULL" +
+					")",
+				"CREATE INDEX IF NOT EXISTS fleetsites_fleet ON fleetsites (fleet_id)",
+				"CREATE TABLE IF NOT EXISTS fleetsitemembers (" +
+					"fleetsite_id INTEGER NOT NULL REFERENCES fleetsites (id) ON DELETE CASCADE, " +
+					"fleetmember_id INTEGER NOT NULL REFERENCES fleetmembers (id) ON DELETE CASCADE, " +
+					"PRIMARY KEY (fleetsite_id, fleetmember_id)" +
+					")",
+				"ALTER TABLE lootpastes ADD COLUMN site_id INTEGER DEFAULT NULL",
+				"INSERT INTO fleetsites (fleet_id, name, finished, escalation, voided) " +
+					"WITH RECURSIVE seq (n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM seq WHERE n < (SELECT COALESCE(MAX(sites_finished), 0) FROM fleets)) " +
+					"SELECT f.id, '', f.starttime, 'N', 'N' FROM fleets AS f INNER JOIN seq ON seq.n <= f.sites_finished",
+				"INSERT INTO fleetsitemembers (fleetsite_id, fleetmember_id) " +
+					"SELECT s.id, m.id FROM fleetsites AS s INNER JOIN fleetmembers AS m ON m.fleet_id = s.fleet_id",
+				"ALTER TABLE fleets DROP COLUMN sites_finished",
+			},
+		},
+		Down: map[string][]string{
+			"mysql": []string{
+				"ALTER TABLE `fleets` ADD COLUMN `sites_finished` int(10) NOT NULL DEFAULT '0' AFTER `system_nickname`",
+				"UPDATE `fleets` SET `sites_finished` = (SELECT COUNT(*) FROM `fleetsites` WHERE `fleetsites`.`fleet_id` = `fleets`.`id` AND `voided` = 'N')",
+				"ALTER TABLE `lootpastes` DROP COLUMN `site_id`",
+				"DROP TABLE IF EXISTS `fleetsitemembers`",
+				"DROP TABLE IF EXISTS `fleetsites`",
+			},
+			"sqlite": []string{
+				"ALTER TABLE fleets ADD COLUMN sites_finished INTEGER NOT NULL DEFAULT 0",
+				"UPDATE fleets SET sites_finished = (SELECT COUNT(*) FROM fleetsites WHERE fleetsites.fleet_id = fleets.id AND voided = 'N')",
+				"ALTER TABLE lootpastes DROP COLUMN site_id",
+				"DROP TABLE IF EXISTS fleetsitemembers",
+				"DROP TABLE IF EXISTS fleetsites",
+			},
+		},
+	},
+	Migration{
+		Version:        17,
+		Name:           "Nullable report end time",
+		RebuildsTables: true,
+		Up: map[string][]string{
+			"mysql": []string{
+				"ALTER TABLE `reports` MODIFY `endtime` timestamp NULL DEFAULT NULL",
+				"UPDATE `reports` SET `endtime` = NULL WHERE CAST(`endtime` AS CHAR(19)) = '0000-00-00 00:00:00'",
+			},
+			"sqlite": []string{
+				"CREATE TABLE reports_new (" +
+					"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+					"corporation_id INTEGER NOT NULL REFERENCES corporations (id), " +
+					"creator INTEGER NOT NULL REFERENCES players (id), " +
+					"total_payout DOUBLE NOT NULL DEFAULT 0, " +
+					"starttime TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, " +
+					"endtime TIMESTAMP DEFAULT NULL, " +
+					"payout_complete CHAR(1) NOT NULL DEFAULT 'N' CHECK (payout_complete IN ('Y', 'N'))" +
+					")",
+				"INSERT INTO reports_new (id, corporation_id, creator, total_payout, starttime, endtime, payout_complete) " +
+					"SELECT id, corporation_id, creator, total_payout, starttime, " +
+					"CASE WHEN endtime LIKE '0000-00-00%' OR endtime LIKE '0001-01-01%' THEN NULL ELSE endtime END, " +
+					"payout_complete FROM reports",
+				"DROP TABLE reports",
+				"ALTER TABLE reports_new RENAME TO reports",
+			},
+		},
+		Down: map[string][]string{
+			"mysql": []string{
+				"UPDATE `reports` SET `endtime` = `starttime` WHERE `endtime` IS NULL",
+				"ALTER TABLE `reports` MODIFY `endtime` timestamp NOT NULL DEFAULT '0000-00-00 00:00:00'",
+			},
+			"sqlite": []string{
+				"CREATE TABLE reports_new (" +
+					"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+					"corporation_id INTEGER NOT NULL REFERENCES corporations (id), " +
+					"creator INTEGER NOT NULL REFERENCES players (id), " +
+					"total_payout DOUBLE NOT NULL DEFAULT 0, " +
+					"starttime TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, " +
+					"endtime TIMESTAMP NOT NULL, " +
+					"payout_complete CHAR(1) NOT NULL DEFAULT 'N' CHECK (payout_complete IN ('Y', 'N'))" +
+					")",
+				"INSERT INTO reports_new (id, corporation_id, creator, total_payout, starttime, endtime, payout_complete) " +
+					"SELECT id, corporation_id, creator, total_payout, starttime, COALESCE(endtime, starttime), payout_complete FROM reports",
+				"DROP TABLE reports",
+				"ALTER TABLE reports_new RENAME TO reports",
+			},
+		},
+	},
+	Migration{
+		Version: 18,
+		Name:    "Member times from intervals",
+		Up: map[string][]string{
+			"mysql": []string{
+				"ALTER TABLE `fleetmembers` DROP COLUMN `join_time`, DROP COLUMN `leave_time`",
+			},
+			"sqlite": []string{
+				"ALTER TABLE fleetmembers DROP COLUMN leave_time",
+				"ALTER TABLE fleetmembers DROP COLUMN join_time",
+			},
+		},
+		Down: map[string][]string{
+			"mysql": []string{
+				"ALTER TABLE `fleetmembers` ADD COLUMN `join_time` timestamp NULL DEFAULT NULL, ADD COLUMN `leave_time` timestamp NULL DEFAULT NULL",
+				"UPDATE `fleetmembers` SET " +
+					"`join_time` = (SELECT MIN(i.`join_time`) FROM `fleetmemberintervals` AS i WHERE i.`fleetmember_id` = `fleetmembers`.`id`), " +
+					"`leave_time` = (SELECT CASE WHEN COUNT(*) = COUNT(i.`leave_time`) THEN MAX(i.`leave_time`) END FROM `fleetmemberintervals` AS i WHERE i.`fleetmember_id` = `fleetmembers`.`id`)",
+			},
+			"sqlite": []string{
+				"ALTER TABLE fleetmembers ADD COLUMN join_time DATETIME DEFAULT NULL",
+				"ALTER TABLE fleetmembers ADD COLUMN leave_time DATETIME DEFAULT NULL",
+				"UPDATE fleetmembers SET " +
+					"join_time = (SELECT MIN(i.join_time) FROM fleetmemberintervals AS i WHERE i.fleetmember_id = fleetmembers.id), " +
+					"leave_time = (SELECT CASE WHEN COUNT(*) = COUNT(i.leave_time) THEN MAX(i.leave_time) END FROM fleetmemberintervals AS i WHERE i.fleetmember_id = fleetmembers.id)",
+			},
+		},
+	},
+}
+
+const defaultFleetRoles = "(1, 'vexor navy issue', 32), (2, 'tengu', 32), (3, 'loki', 32), (4, 'legion', 32), (5, 'proteus', 32), " +
+	"(6, 'huggin', 32), (7, 'rapier', 32), (8, 'moa', 32), (9, 'ferox', 32), (10, 'ishtar', 32), " +
+	"(11, 'drake', 32), (12, 'dominix', 32), (13, 'pilgrim', 32), (14, 'orthrus', 32), (15, 'gila', 32), " +
+	"(16, 'bellicose', 64), (17, 'scimitar', 16), (18, 'scythe', 16), (19, 'noctis', 8), (20, 'catalyst', 8), " +
+	"(21, 'cormorant', 8), (22, 'heron', 4), (23, 'magnate', 4), (24, 'anathema', 4), (25, 'probe', 4), " +
+	"(26, 'buzzard', 4), (27, 'imicus', 4), (28, 'helios', 4), (29, 'cheetah', 4), (30, 'purifier', 4), " +
+	"(31, 'manticore', 4), (32, 'nemesis', 4), (33, 'hound', 4), (34, 'thrasher', 8), (35, 'vexor', 32)"
+
+// Adjustment pastes are attributed to the fleet commander, any other member or a player of the corporation, in that order
+var legacyAdjustmentPastedBy = map[string]string{
+	"mysql": "COALESCE(" +
+		"(SELECT `fleetmembers`.`player_id` FROM `fleetmembers` WHERE `fleetmembers`.`fleet_id` = `fleets`.`id` ORDER BY CASE WHEN `fleetmembers`.`role` = 64 THEN 0 ELSE 1 END, `fleetmembers`.`id` LIMIT 1), " +
+		"(SELECT MIN(`players`.`id`) FROM `players` WHERE `players`.`corporation_id` = `fleets`.`corporation_id`), " +
+		"(SELECT MIN(`players`.`id`) FROM `players`))",
+	"sqlite": "COALESCE(" +
+		"(SELECT fleetmembers.player_id FROM fleetmembers WHERE fleetmembers.fleet_id = fleets.id ORDER BY CASE WHEN fleetmembers.role = 64 THEN 0 ELSE 1 END, fleetmembers.id LIMIT 1), " +
+		"(SELECT MIN(players.id) FROM players WHERE players.corporation_id = fleets.corporation_id), " +
+		"(SELECT MIN(players.id) FROM players))",
+}
+
+func LatestSchemaVersion() int {
+	if len(migrations) == 0 {
+		return 0
+	}
+
+	return migrations[len(migrations)-1].Version
+}
+
+func (db *Database) ensureSchemaVersionTable() error {
+	var err error
+
+	switch db.driver {
+	case "mysql":
+		_, err = db.db.Exec("CREATE TABLE IF NOT EXISTS `schemaversion` (`version` int(10) NOT NULL, `name` varchar(255) NOT NULL, `applied` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP, PRIMARY KEY (`version`)) ENGINE=InnoDB DEFAULT CHARSET=utf8")
+	default:
+		_, err = db.db.Exec("CREATE TABLE IF NOT EXISTS schemaversion (version INTEGER PRIMARY KEY, name VARCHAR(255) NOT NULL, applied TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP)")
+	}
+
+	return err
+}
+
+func (db *Database) SchemaVersion() (int, error) {
+	err := db.ensureSchemaVersionTable()
+	if err != nil {
+		return 0, err
+	}
+
+	var version sql.NullInt64
+
+	err = db.db.QueryRow("SELECT MAX(version) FROM schemaversion").Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+
+	if !version.Valid {
+		return 0, nil
+	}
+
+	return int(version.Int64), nil
+}
+
+func (db *Database) CheckSchemaVersion() error {
+	current, err := db.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	if current > LatestSchemaVersion() {
+		return fmt.Errorf("Database schema version %d is newer than the latest version %d supported by this binary, refusing to start", current, LatestSchemaVersion())
+	}
+
+	return nil
+}
+
+func (db *Database) MigrateTo(target int) error {
+	if target < 0 || target > LatestSchemaVersion() {
+		return fmt.Errorf("Invalid target schema version %d, must be between 0 and %d", target, LatestSchemaVersion())
+	}
+
+	err := db.CheckSchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	current, err := db.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	if target >= current {
+		for _, migration := range migrations {
+			if migration.Version <= current || migration.Version > target {
+				continue
+			}
+
+			logger.Infof("Applying migration #%d (%s)...", migration.Version, migration.Name)
+
+			err = db.applyMigration(migration, true)
+			if err != nil {
+				return fmt.Errorf("Failed to apply migration #%d (%s): [%v]", migration.Version, migration.Name, err)
+			}
+		}
+
+		return nil
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		migration := migrations[i]
+
+		if migration.Version > current || migration.Version <= target {
+			continue
+		}
+
+		logger.Infof("Reverting migration #%d (%s)...", migration.Version, migration.Name)
+
+		err = db.applyMigration(migration, false)
+		if err != nil {
+			return fmt.Errorf("Failed to revert migration #%d (%s): [%v]", migration.Version, migration.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (db *Database) MigrateUp() error {
+	return db.MigrateTo(LatestSchemaVersion())
+}
+
+func (db *Database) applyMigration(migration Migration, up bool) error {
+	statements := migration.Down
+	if up {
+		statements = migration.Up
+	}
+
+	queries, ok := statements[db.driver]
+	if !ok {
+		return fmt.Errorf("No statements for database type %q", db.driver)
+	}
+
+	if db.driver == "sqlite" && migration.RebuildsTables {
+		return db.applySQLiteRebuild(migration, queries, up)
+	}
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	// MySQL commits DDL implicitly, so the rollback only undoes statements of a failed migration on SQLite
+	for i, query := range queries {
+		_, err = tx.Exec(query)
+		if err != nil {
+			tx.Rollback()
+
+			if db.driver == "mysql" && i > 0 {
+				return fmt.Errorf("%v; MySQL does not roll back schema changes, the %d statement(s) before the failing one stay applied and must be repaired by hand", err, i)
+			}
+
+			return err
+		}
+	}
+
+	err = recordMigration(tx, migration, up)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// applySQLiteRebuild follows SQLite's procedure for recreating tables: foreign keys can only be toggled outside of a transaction, so the migration runs on a dedicated connection and is checked for violations before committing
+func (db *Database) applySQLiteRebuild(migration Migration, queries []string, up bool) error {
+	conn, err := db.db.Conn(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(context.Background(), "PRAGMA foreign_keys = OFF")
+	if err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), "PRAGMA foreign_keys = ON")
+
+	tx, err := conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+
+	for _, query := range queries {
+		_, err = tx.Exec(query)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	rows, err := tx.Query("PRAGMA foreign_key_check")
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	violated := rows.Next()
+	rows.Close()
+
+	if violated {
+		tx.Rollback()
+		return fmt.Errorf("Rebuilt tables violate foreign key constraints")
+	}
+
+	err = recordMigration(tx, migration, up)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func recordMigration(tx *sql.Tx, migration Migration, up bool) error {
+	var err error
+
+	if up {
+		_, err = tx.Exec("INSERT INTO schemaversion(version, name, applied) VALUES (?, ?, ?)", migration.Version, migration.Name, time.Now())
+	} else {
+		_, err = tx.Exec("DELETE FROM schemaversion WHERE version = ?", migration.Version)
+	}
+
+	return err
+}
+
+func RunMigrateCommand(db *Database, args []string) error {
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		return db.MigrateUp()
+	case "down":
+		current, err := db.SchemaVersion()
+		if err != nil {
+			return err
+		}
+
+		if current == 0 {
+			return fmt.Errorf("No migrations applied, cannot migrate down")
+		}
+
+		return db.MigrateTo(current - 1)
+	case "to":
+		if len(args) < 2 {
+			return fmt.Errorf("Missing target version for migrate to")
+		}
+
+		var target int
+
+		_, err := fmt.Sscanf(args[1], "%d", &target)
+		if err != nil {
+			return fmt.Errorf("Invalid target version %q: [%v]", args[1], err)
+		}
+
+		return db.MigrateTo(target)
+	case "status":
+		current, err := db.SchemaVersion()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Database schema version: %d\n", current)
+		fmt.Printf("Latest schema version:   %d\n", LatestSchemaVersion())
+
+		for _, migration := range migrations {
+			state := "pending"
+			if migration.Version <= current {
+				state = "applied"
+			}
+
+			fmt.Printf("  #%d %-40s %s\n", migration.Version, migration.Name, state)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("Unknown migrate command %q, expected up, down, to <version> or status", command)
+	}
+}
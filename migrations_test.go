@@ -0,0 +1,148 @@
+// migrations_test
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMigrateKeepsLegacyFleetTotals(t *testing.T) {
+	db := openTestDatabase(t)
+
+	err := db.MigrateTo(4)
+	if err != nil {
+		t.Fatalf("Failed to migrate down to version 4: [%v]", err)
+	}
+
+	statements := []string{
+		"INSERT INTO corporations (id, corporation_id, name, ticker) VALUES (1, 98000001, 'Test Corporation', 'TEST')",
+		"INSERT INTO players (id, player_id, name, corporation_id) VALUES (1, 90000001, 'Alice', 1), (2, 90000002, 'Bob', 1)",
+		// Fleet 1 has pastes covering its totals, fleet 2 was corrected by hand and fleet 3 never had any pastes
+		"INSERT INTO fleets (id, corporation_id, name, system, system_nickname, profit, losses, notes) VALUES " +
+			"(1, 1, 'Covered', 'J100001', '', 1000, 50, ''), (2, 1, 'Corrected', 'J100002', '', 1500, 0, ''), (3, 1, 'Manual', 'J100003', '', 700, 25, '')",
+		"INSERT INTO fleetmembers (fleet_id, player_id, role) VALUES (2, 1, 32), (2, 2, 64)",
+		"INSERT INTO lootpastes (fleet_id, pasted_by, raw_paste, value, paste_type) VALUES " +
+			"(1, 1, 'Tritanium 1000', 1000, 2), (1, 1, 'Tritanium 50', 50, 4), (2, 1, 'Tritanium 1800', 1800, 2)",
+	}
+
+	for _, statement := range statements {
+		_, err = db.db.Exec(statement)
+		if err != nil {
+			t.Fatalf("Failed to seed legacy data: [%v]", err)
+		}
+	}
+
+	err = db.MigrateUp()
+	if err != nil {
+		t.Fatalf("Failed to migrate up: [%v]", err)
+	}
+
+	tests := []struct {
+		fleetID int64
+		profit  float64
+		losses  float64
+		pastes  int
+	}{
+		{1, 1000, 50, 2},
+		{2, 1500, 0, 2},
+		{3, 700, 25, 2},
+	}
+
+	for _, test := range tests {
+		fleet, err := db.LoadFleet(test.fleetID)
+		if err != nil {
+			t.Fatalf("Failed to load fleet #%d: [%v]", test.fleetID, err)
+		}
+
+		if fleet.Profit != test.profit || fleet.Losses != test.losses {
+			t.Errorf("Expected fleet #%d to keep profit %.2f and losses %.2f, got %.2f and %.2f", test.fleetID, test.profit, test.losses, fleet.Profit, fleet.Losses)
+		}
+
+		pastes, err := db.LoadAllLootPastes(test.fleetID)
+		if err != nil {
+			t.Fatalf("Failed to load loot pastes of fleet #%d: [%v]", test.fleetID, err)
+		}
+
+		if len(pastes) != test.pastes {
+			t.Errorf("Expected %d loot pastes for fleet #%d, got %d", test.pastes, test.fleetID, len(pastes))
+		}
+	}
+
+	var pastedBy int64
+
+	err = db.db.QueryRow("SELECT pasted_by FROM lootpastes WHERE fleet_id = 2 AND value < 0").Scan(&pastedBy)
+	if err != nil {
+		t.Fatalf("Failed to query adjustment paste of fleet #2: [%v]", err)
+	}
+
+	if pastedBy != 2 {
+		t.Errorf("Expected adjustment paste to be attributed to the fleet commander #2, got #%d", pastedBy)
+	}
+}
+
+func TestMigrateNullsLegacyReportEndTimes(t *testing.T) {
+	db := openTestDatabase(t)
+
+	err := db.MigrateTo(16)
+	if err != nil {
+		t.Fatalf("Failed to migrate down to version 16: [%v]", err)
+	}
+
+	corporation, players := createTestPlayers(t, db, "Alice")
+
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 10, 8, 0, 0, 0, 0, time.UTC)
+
+	// Report 1 was saved without an end time before the column became nullable
+	_, err = db.db.Exec("INSERT INTO reports (id, corporation_id, creator, total_payout, starttime, endtime, payout_complete) VALUES "+
+		"(1, ?, ?, 1000, ?, '0001-01-01 00:00:00+00:00', 'N'), (2, ?, ?, 2000, ?, ?, 'Y')", corporation.ID, players[0].ID, start, corporation.ID, players[0].ID, start, end)
+	if err != nil {
+		t.Fatalf("Failed to seed legacy reports: [%v]", err)
+	}
+
+	_, err = db.db.Exec("INSERT INTO reportpayouts (report_id, player_id, payout, payout_complete) VALUES (1, ?, 1000, 'N')", players[0].ID)
+	if err != nil {
+		t.Fatalf("Failed to seed legacy report payout: [%v]", err)
+	}
+
+	err = db.MigrateUp()
+	if err != nil {
+		t.Fatalf("Failed to migrate up: [%v]", err)
+	}
+
+	var nulls int
+
+	err = db.db.QueryRow("SELECT COUNT(*) FROM reports WHERE endtime IS NULL").Scan(&nulls)
+	if err != nil {
+		t.Fatalf("Failed to count reports without end time: [%v]", err)
+	}
+
+	if nulls != 1 {
+		t.Errorf("Expected 1 report without end time, got %d", nulls)
+	}
+
+	tests := []struct {
+		reportID int64
+		end      time.Time
+		payouts  int
+	}{
+		{1, time.Time{}, 1},
+		{2, end, 0},
+	}
+
+	for _, test := range tests {
+		report, err := reopenTestDatabase(t, db).LoadReport(test.reportID)
+		if err != nil {
+			t.Fatalf("Failed to load report #%d: [%v]", test.reportID, err)
+		}
+
+		if !report.EndRange.Equal(test.end) || !report.StartRange.Equal(start) || len(report.Payouts) != test.payouts {
+			t.Errorf("Expected report #%d to end %v with %d payouts, got %v with %d", test.reportID, test.end, test.payouts, report.EndRange, len(report.Payouts))
+		}
+	}
+
+	_, err = db.db.Exec("INSERT INTO reportpayouts (report_id, player_id, payout, payout_complete) VALUES (999, ?, 0, 'N')", players[0].ID)
+	if err == nil {
+		t.Errorf("Expected foreign keys to be enforced again after rebuilding the reports table")
+	}
+}
@@ -0,0 +1,32 @@
+// apitoken
+package models
+
+import (
+	"time"
+)
+
+type APIToken struct {
+	ID        int64
+	PlayerID  int64
+	Name      string
+	TokenHash string
+	Created   time.Time
+	LastUsed  time.Time
+}
+
+func NewAPIToken(id int64, player int64, name string, hash string, created time.Time, used time.Time) *APIToken {
+	token := &APIToken{
+		ID:        id,
+		PlayerID:  player,
+		Name:      name,
+		TokenHash: hash,
+		Created:   created,
+		LastUsed:  used,
+	}
+
+	return token
+}
+
+func (token *APIToken) HasBeenUsed() bool {
+	return !token.LastUsed.IsZero()
+}
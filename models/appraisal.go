@@ -0,0 +1,125 @@
+// appraisal
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type PriceMode int
+
+const (
+	PriceModeBuy PriceMode = 1 << iota
+	PriceModeSell
+	PriceModeSplit
+)
+
+func (mode PriceMode) String() string {
+	switch mode {
+	case PriceModeBuy:
+		return "buy"
+	case PriceModeSell:
+		return "sell"
+	case PriceModeSplit:
+		return "split"
+	default:
+		return "buy"
+	}
+}
+
+func ParsePriceMode(mode string) (PriceMode, error) {
+	switch strings.ToLower(mode) {
+	case "buy":
+		return PriceModeBuy, nil
+	case "sell":
+		return PriceModeSell, nil
+	case "split":
+		return PriceModeSplit, nil
+	default:
+		return PriceModeBuy, fmt.Errorf("Invalid price mode %q, expected buy, sell or split", mode)
+	}
+}
+
+type ItemType struct {
+	ID           int64
+	Name         string
+	GroupName    string
+	Volume       float64
+	BuyPrice     float64
+	SellPrice    float64
+	PriceUpdated time.Time
+}
+
+func NewItemType(id int64, name string, group string, volume float64, buy float64, sell float64, updated time.Time) *ItemType {
+	itemType := &ItemType{
+		ID:           id,
+		Name:         name,
+		GroupName:    group,
+		Volume:       volume,
+		BuyPrice:     buy,
+		SellPrice:    sell,
+		PriceUpdated: updated,
+	}
+
+	return itemType
+}
+
+func (itemType *ItemType) Price(mode PriceMode) float64 {
+	switch mode {
+	case PriceModeSell:
+		return itemType.SellPrice
+	case PriceModeSplit:
+		return (itemType.BuyPrice + itemType.SellPrice) / 2
+	default:
+		return itemType.BuyPrice
+	}
+}
+
+type AppraisalItem struct {
+	Type      *ItemType
+	Name      string
+	Quantity  int64
+	UnitPrice float64
+	Total     float64
+}
+
+type Appraisal struct {
+	Mode    PriceMode
+	Items   []*AppraisalItem
+	Unknown []string
+	Total   float64
+	Volume  float64
+}
+
+func NewAppraisal(mode PriceMode) *Appraisal {
+	appraisal := &Appraisal{
+		Mode:    mode,
+		Items:   make([]*AppraisalItem, 0),
+		Unknown: make([]string, 0),
+		Total:   0,
+		Volume:  0,
+	}
+
+	return appraisal
+}
+
+func (appraisal *Appraisal) AddItem(itemType *ItemType, quantity int64) {
+	unitPrice := itemType.Price(appraisal.Mode)
+
+	item := &AppraisalItem{
+		Type:      itemType,
+		Name:      itemType.Name,
+		Quantity:  quantity,
+		UnitPrice: unitPrice,
+		Total:     unitPrice * float64(quantity),
+	}
+
+	appraisal.Items = append(appraisal.Items, item)
+	appraisal.Total += item.Total
+	appraisal.Volume += itemType.Volume * float64(quantity)
+}
+
+func (appraisal *Appraisal) AddUnknown(name string) {
+	appraisal.Unknown = append(appraisal.Unknown, name)
+}
@@ -0,0 +1,134 @@
+// auditentry
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+type AuditEntry struct {
+	ID            int64
+	FleetID       int64
+	ReportID      int64
+	PlayerID      int64
+	Actor         string
+	Command       string
+	Before        string
+	After         string
+	RemoteAddress string
+	Created       time.Time
+}
+
+func NewAuditEntry(id int64, fleet int64, report int64, player int64, actor string, command string, before string, after string, remote string, created time.Time) *AuditEntry {
+	entry := &AuditEntry{
+		ID:            id,
+		FleetID:       fleet,
+		ReportID:      report,
+		PlayerID:      player,
+		Actor:         actor,
+		Command:       command,
+		Before:        before,
+		After:         after,
+		RemoteAddress: remote,
+		Created:       created,
+	}
+
+	return entry
+}
+
+type AuditChange struct {
+	Field  string
+	Before string
+	After  string
+}
+
+func (entry *AuditEntry) Changes() []*AuditChange {
+	before := make(map[string]string)
+	after := make(map[string]string)
+
+	flattenAuditValue(entry.Before, before)
+	flattenAuditValue(entry.After, after)
+
+	fields := make(map[string]bool)
+	for field := range before {
+		fields[field] = true
+	}
+	for field := range after {
+		fields[field] = true
+	}
+
+	var changes []*AuditChange
+
+	for field := range fields {
+		if before[field] != after[field] {
+			changes = append(changes, &AuditChange{Field: field, Before: before[field], After: after[field]})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+
+	return changes
+}
+
+func flattenAuditValue(raw string, values map[string]string) {
+	if len(raw) == 0 {
+		return
+	}
+
+	var value interface{}
+
+	err := json.Unmarshal([]byte(raw), &value)
+	if err != nil {
+		values[""] = raw
+		return
+	}
+
+	flattenAuditField("", value, values)
+}
+
+func flattenAuditField(prefix string, value interface{}, values map[string]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			flattenAuditField(joinAuditField(prefix, key), child, values)
+		}
+	case []interface{}:
+		for index, child := range v {
+			key := fmt.Sprintf("[%d]", index)
+
+			if object, ok := child.(map[string]interface{}); ok {
+				if name, ok := object["name"].(string); ok && len(name) > 0 {
+					key = fmt.Sprintf("[%s]", name)
+				}
+			}
+
+			flattenAuditField(prefix+key, child, values)
+		}
+	case nil:
+		values[prefix] = ""
+	default:
+		encoded, _ := json.Marshal(v)
+		values[prefix] = strings.Trim(string(encoded), "\"")
+	}
+}
+
+func joinAuditField(prefix string, key string) string {
+	if len(prefix) == 0 {
+		return key
+	}
+
+	return prefix + "." + key
+}
+
+type AuditFilter struct {
+	FleetID  int64
+	ReportID int64
+	Actor    string
+	Command  string
+	From     time.Time
+	To       time.Time
+	Limit    int
+}
@@ -1,6 +1,10 @@
 // corporation
 package models
 
+import (
+	"sort"
+)
+
 type Corporation struct {
 	ID             int64
 	CorporationID  int64
@@ -8,10 +12,12 @@ type Corporation struct {
 	Ticker         string
 	CorporationCut float64
 	APIID          int64
-	APICode        string
+	APICode        string `json:"-"`
+	PayoutStrategy PayoutStrategyType
+	Roles          map[FleetRole]*FleetRoleDefinition
 }
 
-func NewCorporation(id int64, corpID int64, name string, ticker string, cut float64, apiID int64, code string) *Corporation {
+func NewCorporation(id int64, corpID int64, name string, ticker string, cut float64, apiID int64, code string, strategy PayoutStrategyType) *Corporation {
 	corp := &Corporation{
 		ID:             id,
 		CorporationID:  corpID,
@@ -20,7 +26,60 @@ func NewCorporation(id int64, corpID int64, name string, ticker string, cut floa
 		CorporationCut: cut,
 		APIID:          apiID,
 		APICode:        code,
+		PayoutStrategy: strategy,
+		Roles:          make(map[FleetRole]*FleetRoleDefinition),
 	}
 
 	return corp
 }
+
+func (corp *Corporation) Copy() *Corporation {
+	c := *corp
+
+	c.Roles = make(map[FleetRole]*FleetRoleDefinition)
+	for role, definition := range corp.Roles {
+		c.Roles[role] = definition.Copy()
+	}
+
+	return &c
+}
+
+func (corp *Corporation) FleetRole(role FleetRole) *FleetRoleDefinition {
+	if definition, ok := corp.Roles[role]; ok {
+		return definition
+	}
+
+	return role.Definition()
+}
+
+func (corp *Corporation) FleetRoles() []*FleetRoleDefinition {
+	var definitions []*FleetRoleDefinition
+
+	for _, role := range BuiltinFleetRoles() {
+		definitions = append(definitions, corp.FleetRole(role))
+	}
+
+	for role, definition := range corp.Roles {
+		if !role.IsBuiltin() {
+			definitions = append(definitions, definition)
+		}
+	}
+
+	sort.Sort(FleetRoleDefinitionsByRole(definitions))
+
+	return definitions
+}
+
+func (corp *Corporation) HasFleetRole(role FleetRole) bool {
+	if !role.IsSelectable() {
+		return false
+	}
+
+	if role.IsBuiltin() {
+		return true
+	}
+
+	_, ok := corp.Roles[role]
+
+	return ok
+}
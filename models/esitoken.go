@@ -0,0 +1,58 @@
+// esitoken
+package models
+
+import (
+	"time"
+)
+
+type ESIToken struct {
+	ID           int64
+	PlayerID     int64
+	CharacterID  int64
+	Scopes       []string
+	AccessToken  string
+	RefreshToken string
+	Expiry       time.Time
+	Valid        bool
+	Updated      time.Time
+}
+
+func NewESIToken(id int64, player int64, characterID int64, scopes []string, access string, refresh string, expiry time.Time, valid bool, updated time.Time) *ESIToken {
+	token := &ESIToken{
+		ID:           id,
+		PlayerID:     player,
+		CharacterID:  characterID,
+		Scopes:       scopes,
+		AccessToken:  access,
+		RefreshToken: refresh,
+		Expiry:       expiry,
+		Valid:        valid,
+		Updated:      updated,
+	}
+
+	return token
+}
+
+func (token *ESIToken) HasScope(scope string) bool {
+	for _, s := range token.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (token *ESIToken) HasScopes(scopes []string) bool {
+	for _, scope := range scopes {
+		if !token.HasScope(scope) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (token *ESIToken) ExpiresWithin(d time.Duration) bool {
+	return time.Now().UTC().Add(d).After(token.Expiry)
+}
@@ -3,6 +3,8 @@ package models
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -18,13 +20,15 @@ type Fleet struct {
 	Profit            float64
 	Losses            float64
 	SitesFinished     int
+	Sites             []*FleetSite
 	CorporationPayout float64
 	PayoutComplete    bool
 	Notes             string
 	ReportID          int64
+	PayoutStrategy    PayoutStrategyType
 }
 
-func NewFleet(id int64, corp *Corporation, name string, system string, systemNick string, profit float64, losses float64, sites int, start time.Time, end time.Time, payout float64, complete bool, notes string, report int64) *Fleet {
+func NewFleet(id int64, corp *Corporation, name string, system string, systemNick string, profit float64, losses float64, sites int, start time.Time, end time.Time, payout float64, complete bool, notes string, report int64, strategy PayoutStrategyType) *Fleet {
 	fleet := &Fleet{
 		ID:                id,
 		Corporation:       corp,
@@ -35,17 +39,37 @@ func NewFleet(id int64, corp *Corporation, name string, system string, systemNic
 		Profit:            profit,
 		Losses:            losses,
 		SitesFinished:     sites,
+		Sites:             make([]*FleetSite, 0),
 		StartTime:         start,
 		EndTime:           end,
 		CorporationPayout: payout,
 		PayoutComplete:    complete,
 		Notes:             notes,
 		ReportID:          report,
+		PayoutStrategy:    strategy,
 	}
 
 	return fleet
 }
 
+func (fleet *Fleet) Copy() *Fleet {
+	f := *fleet
+
+	f.Members = make(map[string]*FleetMember)
+
+	for name, member := range fleet.Members {
+		f.Members[name] = member.Copy()
+	}
+
+	f.Sites = make([]*FleetSite, len(fleet.Sites))
+	for i, site := range fleet.Sites {
+		st := *site
+		f.Sites[i] = &st
+	}
+
+	return &f
+}
+
 func (fleet *Fleet) IsFleetFinished() bool {
 	return !fleet.EndTime.IsZero()
 }
@@ -55,12 +79,29 @@ func (fleet *Fleet) FinishFleet() {
 	fleet.CalculatePayouts()
 }
 
-func (fleet *Fleet) AddProfit(profit float64) {
-	fleet.Profit += profit
+func (fleet *Fleet) AllPayoutsComplete() bool {
+	if fleet.PayoutComplete {
+		return true
+	}
+
+	fleet.PayoutComplete = true
+
+	for _, member := range fleet.Members {
+		if !member.PayoutComplete {
+			fleet.PayoutComplete = false
+		}
+	}
+
+	return fleet.PayoutComplete
 }
 
-func (fleet *Fleet) AddLoss(loss float64) {
-	fleet.Losses += loss
+func (fleet *Fleet) Duration() time.Duration {
+	end := fleet.EndTime
+	if end.IsZero() {
+		end = time.Now()
+	}
+
+	return end.Sub(fleet.StartTime)
 }
 
 func (fleet *Fleet) GetSurplus() float64 {
@@ -85,6 +126,101 @@ func (fleet *Fleet) FleetCommanders() []*FleetMember {
 	return fleetCommanders
 }
 
+func (fleet *Fleet) MembersPresentAt(t time.Time) []int64 {
+	var members []int64
+
+	for _, member := range fleet.Members {
+		if member.WasPresentAt(t) {
+			members = append(members, member.ID)
+		}
+	}
+
+	sort.Slice(members, func(i, j int) bool { return members[i] < members[j] })
+
+	return members
+}
+
+func (fleet *Fleet) HasMemberID(memberID int64) bool {
+	for _, member := range fleet.Members {
+		if member.ID == memberID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (fleet *Fleet) Site(siteID int64) *FleetSite {
+	for _, site := range fleet.Sites {
+		if site.ID == siteID {
+			return site
+		}
+	}
+
+	return nil
+}
+
+func (fleet *Fleet) LastSite() *FleetSite {
+	for i := len(fleet.Sites) - 1; i >= 0; i-- {
+		if !fleet.Sites[i].Voided {
+			return fleet.Sites[i]
+		}
+	}
+
+	return nil
+}
+
+func (fleet *Fleet) UnmappedShips() []string {
+	var ships []string
+
+	seen := make(map[string]bool)
+
+	for _, member := range fleet.Members {
+		if member.Role != FleetRoleNone || len(member.Ship) == 0 {
+			continue
+		}
+
+		key := strings.ToLower(member.Ship)
+		if seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		ships = append(ships, member.Ship)
+	}
+
+	sort.Strings(ships)
+
+	return ships
+}
+
+func (fleet *Fleet) MembersWithoutRole() []string {
+	var names []string
+
+	for name, member := range fleet.Members {
+		if member.Role == FleetRoleNone {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func (fleet *Fleet) AssignShipRole(shipRole *ShipRole) int {
+	assigned := 0
+
+	for _, member := range fleet.Members {
+		if member.Role == FleetRoleNone && strings.EqualFold(member.Ship, shipRole.Ship) {
+			member.Role = shipRole.Role
+			assigned++
+		}
+	}
+
+	return assigned
+}
+
 func (fleet *Fleet) AddMember(member *FleetMember) error {
 	if fleet.HasMember(member.Player.Name) {
 		return fmt.Errorf("Member %q already exists in fleet, cannot add twice", member.Player.Name)
@@ -140,12 +276,7 @@ func (fleet *Fleet) GetMemberSitesFinished(player string) (int, error) {
 		return 0, fmt.Errorf("Member %q does not exists in fleet, cannot get sites finished", player)
 	}
 
-	modifier, err := fleet.GetMemberSiteModifier(player)
-	if err != nil {
-		return 0, err
-	}
-
-	return (fleet.SitesFinished - modifier), nil
+	return fleet.Members[player].SitesCredited(fleet), nil
 }
 
 func (fleet *Fleet) GetMemberPaymentModifier(player string) (float64, error) {
@@ -156,13 +287,25 @@ func (fleet *Fleet) GetMemberPaymentModifier(player string) (float64, error) {
 	return fleet.Members[player].PaymentModifier, nil
 }
 
-func (fleet *Fleet) CalculatePayouts() {
-	var totalPoints float64
+func (fleet *Fleet) EffectivePayoutStrategy() PayoutStrategyType {
+	if fleet.PayoutStrategy.IsValid() {
+		return fleet.PayoutStrategy
+	}
+
+	if fleet.Corporation != nil && fleet.Corporation.PayoutStrategy.IsValid() {
+		return fleet.Corporation.PayoutStrategy
+	}
+
+	return PayoutStrategyTypeSitePoints
+}
+
+func (fleet *Fleet) PreviewPayouts(strategyType PayoutStrategyType) (float64, map[string]float64) {
 	var corpPayment float64
 	var payout float64
+	var totalWeight float64
 
-	totalPoints = 0
 	corpPayment = 0
+	totalWeight = 0
 
 	if fleet.Corporation.CorporationCut > 0 {
 		corpPayment = (fleet.Profit - fleet.Losses) * (fleet.Corporation.CorporationCut / 100)
@@ -170,35 +313,31 @@ func (fleet *Fleet) CalculatePayouts() {
 
 	payout = fleet.Profit - corpPayment - fleet.Losses
 
-	fleet.CorporationPayout = corpPayment
+	weights := strategyType.Strategy().Weights(fleet)
 
-	for _, member := range fleet.Members {
-		var points float64
-		if member.PaymentModifier != 1 {
-			points = float64((fleet.SitesFinished + member.SiteModifier)) * member.PaymentModifier
-		} else {
-			points = float64((fleet.SitesFinished + member.SiteModifier)) * member.Role.PaymentRate()
-		}
-
-		totalPoints += points
+	for _, weight := range weights {
+		totalWeight += weight
 	}
 
-	for _, member := range fleet.Members {
-		var points float64
-		var isk float64
+	payouts := make(map[string]float64)
 
-		if member.PaymentModifier != 1 {
-			points = float64((fleet.SitesFinished + member.SiteModifier)) * member.PaymentModifier
+	for name, weight := range weights {
+		if totalWeight > 0 {
+			payouts[name] = payout * (weight / totalWeight)
 		} else {
-			points = float64((fleet.SitesFinished + member.SiteModifier)) * member.Role.PaymentRate()
+			payouts[name] = 0
 		}
+	}
 
-		if totalPoints > 0 {
-			isk = payout * (points / totalPoints)
-		} else {
-			isk = 0
-		}
+	return corpPayment, payouts
+}
+
+func (fleet *Fleet) CalculatePayouts() {
+	corpPayment, payouts := fleet.PreviewPayouts(fleet.EffectivePayoutStrategy())
+
+	fleet.CorporationPayout = corpPayment
 
-		member.Payout = isk
+	for name, member := range fleet.Members {
+		member.Payout = payouts[name]
 	}
 }
@@ -0,0 +1,56 @@
+// fleetlink
+package models
+
+import (
+	"time"
+)
+
+type FleetLink struct {
+	ID           int64
+	FleetID      int64
+	ESIFleetID   int64
+	PlayerID     int64
+	CharacterIDs []int64
+	LastSync     time.Time
+	LastError    string
+	Created      time.Time
+}
+
+func NewFleetLink(id int64, fleetID int64, esiFleetID int64, player int64, characterIDs []int64, lastSync time.Time, lastError string, created time.Time) *FleetLink {
+	link := &FleetLink{
+		ID:           id,
+		FleetID:      fleetID,
+		ESIFleetID:   esiFleetID,
+		PlayerID:     player,
+		CharacterIDs: characterIDs,
+		LastSync:     lastSync,
+		LastError:    lastError,
+		Created:      created,
+	}
+
+	return link
+}
+
+func (link *FleetLink) HasCharacter(characterID int64) bool {
+	for _, id := range link.CharacterIDs {
+		if id == characterID {
+			return true
+		}
+	}
+
+	return false
+}
+
+type InGameFleet struct {
+	ESIFleetID int64
+	BossID     int64
+	ReadBy     int64
+	Members    []InGameFleetMember
+}
+
+type InGameFleetMember struct {
+	CharacterID int64
+	Name        string
+	Ship        string
+	JoinTime    time.Time
+}
@@ -3,7 +3,9 @@ package models
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 )
 
 type FleetMember struct {
@@ -17,9 +19,32 @@ type FleetMember struct {
 	Payout          float64
 	PayoutComplete  bool
 	ReportID        int64
+	JoinTime        time.Time
+	LeaveTime       time.Time
+	Intervals       []*FleetMemberInterval
 }
 
-func NewFleetMember(id int64, fleetID int64, player *Player, role FleetRole, ship string, site int, payment float64, payout float64, complete bool, report int64) *FleetMember {
+type FleetMemberInterval struct {
+	ID    int64
+	Join  time.Time
+	Leave time.Time
+}
+
+func NewFleetMemberInterval(id int64, join time.Time, leave time.Time) *FleetMemberInterval {
+	interval := &FleetMemberInterval{
+		ID:    id,
+		Join:  join,
+		Leave: leave,
+	}
+
+	return interval
+}
+
+func (interval *FleetMemberInterval) IsOpen() bool {
+	return interval.Leave.IsZero()
+}
+
+func NewFleetMember(id int64, fleetID int64, player *Player, role FleetRole, ship string, site int, payment float64, payout float64, complete bool, report int64, join time.Time, leave time.Time) *FleetMember {
 	member := &FleetMember{
 		ID:              id,
 		FleetID:         fleetID,
@@ -31,6 +56,12 @@ func NewFleetMember(id int64, fleetID int64, player *Player, role FleetRole, shi
 		Payout:          payout,
 		PayoutComplete:  complete,
 		ReportID:        report,
+		JoinTime:        join,
+		LeaveTime:       leave,
+	}
+
+	if !join.IsZero() {
+		member.Intervals = []*FleetMemberInterval{NewFleetMemberInterval(-1, join, leave)}
 	}
 
 	return member
@@ -39,3 +70,185 @@ func NewFleetMember(id int64, fleetID int64, player *Player, role FleetRole, shi
 func (member *FleetMember) HasRole(role string) bool {
 	return strings.EqualFold(role, fmt.Sprintf("%s", member.Role))
 }
+
+func (member *FleetMember) Copy() *FleetMember {
+	m := *member
+
+	m.Intervals = make([]*FleetMemberInterval, len(member.Intervals))
+	for i, interval := range member.Intervals {
+		in := *interval
+		m.Intervals[i] = &in
+	}
+
+	return &m
+}
+
+func (member *FleetMember) IsPresent() bool {
+	if len(member.Intervals) == 0 {
+		return member.LeaveTime.IsZero()
+	}
+
+	return member.Intervals[len(member.Intervals)-1].IsOpen()
+}
+
+func (member *FleetMember) WasPresentAt(t time.Time) bool {
+	if len(member.Intervals) == 0 {
+		return member.LeaveTime.IsZero() || !t.After(member.LeaveTime)
+	}
+
+	for _, interval := range member.Intervals {
+		if !t.Before(interval.Join) && (interval.IsOpen() || !t.After(interval.Leave)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Join starts a new interval unless the member is still in fleet and reports whether the member rejoined
+func (member *FleetMember) Join(t time.Time) bool {
+	if len(member.Intervals) > 0 && member.IsPresent() {
+		return false
+	}
+
+	member.Intervals = append(member.Intervals, NewFleetMemberInterval(-1, t, time.Time{}))
+	member.updateTimes()
+
+	return true
+}
+
+// Leave closes the open interval and reports whether the member was in fleet
+func (member *FleetMember) Leave(t time.Time) bool {
+	if len(member.Intervals) == 0 || !member.IsPresent() {
+		return false
+	}
+
+	interval := member.Intervals[len(member.Intervals)-1]
+	if t.Before(interval.Join) {
+		t = interval.Join
+	}
+
+	interval.Leave = t
+	member.updateTimes()
+
+	return true
+}
+
+func (member *FleetMember) SetIntervals(intervals []*FleetMemberInterval) error {
+	sorted := make([]*FleetMemberInterval, len(intervals))
+	copy(sorted, intervals)
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Join.Before(sorted[j].Join) })
+
+	for i, interval := range sorted {
+		if interval.Join.IsZero() {
+			return fmt.Errorf("Interval #%d has no join time", i+1)
+		}
+
+		if !interval.IsOpen() && interval.Leave.Before(interval.Join) {
+			return fmt.Errorf("Interval #%d ends before it starts", i+1)
+		}
+
+		if interval.IsOpen() && i < len(sorted)-1 {
+			return fmt.Errorf("Only the last interval can be open")
+		}
+
+		if i > 0 && sorted[i].Join.Before(sorted[i-1].Leave) {
+			return fmt.Errorf("Interval #%d overlaps the previous one", i+1)
+		}
+	}
+
+	member.Intervals = sorted
+	member.updateTimes()
+
+	return nil
+}
+
+// RestoreIntervals sets the stored intervals of a loaded member and derives its join and leave time from them
+func (member *FleetMember) RestoreIntervals(intervals []*FleetMemberInterval) {
+	if len(intervals) == 0 {
+		return
+	}
+
+	member.Intervals = intervals
+	member.updateTimes()
+}
+
+// updateTimes keeps the first join and last leave time in sync with the intervals
+func (member *FleetMember) updateTimes() {
+	if len(member.Intervals) == 0 {
+		return
+	}
+
+	member.JoinTime = member.Intervals[0].Join
+	member.LeaveTime = member.Intervals[len(member.Intervals)-1].Leave
+}
+
+func (member *FleetMember) PaymentRate(corp *Corporation) float64 {
+	if member.PaymentModifier != 1 {
+		return member.PaymentModifier
+	}
+
+	if corp == nil {
+		return member.Role.PaymentRate()
+	}
+
+	return corp.FleetRole(member.Role).PaymentRate
+}
+
+func (member *FleetMember) TimeInFleet(fleet *Fleet) time.Duration {
+	end := fleet.EndTime
+	if end.IsZero() {
+		end = time.Now()
+	}
+
+	intervals := member.Intervals
+	if len(intervals) == 0 {
+		intervals = []*FleetMemberInterval{NewFleetMemberInterval(-1, member.JoinTime, member.LeaveTime)}
+	}
+
+	var total time.Duration
+	var counted time.Time
+
+	for _, interval := range intervals {
+		join := interval.Join
+		if join.IsZero() || join.Before(fleet.StartTime) {
+			join = fleet.StartTime
+		}
+
+		// Overlapping intervals only count once
+		if join.Before(counted) {
+			join = counted
+		}
+
+		leave := interval.Leave
+		if leave.IsZero() || leave.After(end) {
+			leave = end
+		}
+
+		if leave.After(join) {
+			total += leave.Sub(join)
+			counted = leave
+		}
+	}
+
+	return total
+}
+
+// SitesCredited returns the logged sites the member was present for. Presence does not show whether a member took part in a site,
+// so the site modifier remains to deduct sites by hand and is never positive
+func (member *FleetMember) SitesCredited(fleet *Fleet) int {
+	sites := member.SiteModifier
+
+	for _, site := range fleet.Sites {
+		if !site.Voided && site.HasMember(member.ID) {
+			sites++
+		}
+	}
+
+	if sites < 0 {
+		return 0
+	}
+
+	return sites
+}
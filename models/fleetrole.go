@@ -1,6 +1,11 @@
 // fleetrole
 package models
 
+import (
+	"fmt"
+	"strings"
+)
+
 type FleetRole int
 
 const (
@@ -11,6 +16,7 @@ const (
 	FleetRoleLogistics
 	FleetRoleDPS
 	FleetRoleFleetCommander
+	FleetRoleCustom
 )
 
 func (role FleetRole) String() string {
@@ -75,3 +81,116 @@ func (role FleetRole) PaymentRate() float64 {
 		return 0
 	}
 }
+
+func (role FleetRole) IsBuiltin() bool {
+	return role >= FleetRoleUnknown && role < FleetRoleCustom
+}
+
+func (role FleetRole) IsSelectable() bool {
+	return role > FleetRoleNone
+}
+
+func (role FleetRole) Definition() *FleetRoleDefinition {
+	return NewFleetRoleDefinition(-1, -1, role, role.String(), role.PaymentRate(), role.LabelType())
+}
+
+func (role FleetRole) IsMappable() bool {
+	return role.IsBuiltin() && role.IsSelectable()
+}
+
+func ParseFleetRoleName(name string) (FleetRole, error) {
+	for _, role := range BuiltinFleetRoles() {
+		if strings.EqualFold(strings.TrimSpace(name), role.String()) {
+			return role, nil
+		}
+	}
+
+	return FleetRoleUnknown, fmt.Errorf("Invalid fleet role %q", name)
+}
+
+func BuiltinFleetRoles() []FleetRole {
+	return []FleetRole{
+		FleetRoleScout,
+		FleetRoleSalvage,
+		FleetRoleLogistics,
+		FleetRoleDPS,
+		FleetRoleFleetCommander,
+	}
+}
+
+func FleetRoleLabelTypes() []string {
+	return []string{
+		"label-default",
+		"label-primary",
+		"label-success",
+		"label-info",
+		"label-warning",
+		"label-danger",
+	}
+}
+
+func IsValidFleetRoleLabelType(label string) bool {
+	for _, labelType := range FleetRoleLabelTypes() {
+		if label == labelType {
+			return true
+		}
+	}
+
+	return false
+}
+
+type FleetRoleDefinition struct {
+	ID            int64
+	CorporationID int64
+	Role          FleetRole
+	Name          string
+	PaymentRate   float64
+	LabelType     string
+}
+
+func NewFleetRoleDefinition(id int64, corpID int64, role FleetRole, name string, rate float64, label string) *FleetRoleDefinition {
+	definition := &FleetRoleDefinition{
+		ID:            id,
+		CorporationID: corpID,
+		Role:          role,
+		Name:          name,
+		PaymentRate:   rate,
+		LabelType:     label,
+	}
+
+	return definition
+}
+
+func (definition *FleetRoleDefinition) IsCustomised() bool {
+	return definition.ID > 0
+}
+
+func (definition *FleetRoleDefinition) Copy() *FleetRoleDefinition {
+	d := *definition
+
+	return &d
+}
+
+type FleetRoleDefinitionsByRole []*FleetRoleDefinition
+
+func (definitions FleetRoleDefinitionsByRole) Len() int {
+	return len(definitions)
+}
+
+func (definitions FleetRoleDefinitionsByRole) Less(i, j int) bool {
+	return definitions[i].Role < definitions[j].Role
+}
+
+func (definitions FleetRoleDefinitionsByRole) Swap(i, j int) {
+	definitions[i], definitions[j] = definitions[j], definitions[i]
+}
+
+func NextCustomFleetRole(definitions map[FleetRole]*FleetRoleDefinition) (FleetRole, error) {
+	for role := FleetRoleCustom; role > 0; role <<= 1 {
+		if _, ok := definitions[role]; !ok {
+			return role, nil
+		}
+	}
+
+	return FleetRoleUnknown, fmt.Errorf("No free fleet role available, delete an unused custom role first")
+}
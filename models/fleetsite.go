@@ -0,0 +1,50 @@
+// fleetsite
+package models
+
+import (
+	"time"
+)
+
+type FleetSite struct {
+	ID         int64
+	FleetID    int64
+	Name       string
+	Finished   time.Time
+	Escalation bool
+	Voided     bool
+	TickedBy   int64
+	MemberIDs  []int64
+}
+
+func NewFleetSite(id int64, fleet int64, name string, finished time.Time, escalation bool, ticked int64, members []int64) *FleetSite {
+	site := &FleetSite{
+		ID:         id,
+		FleetID:    fleet,
+		Name:       name,
+		Finished:   finished,
+		Escalation: escalation,
+		Voided:     false,
+		TickedBy:   ticked,
+		MemberIDs:  members,
+	}
+
+	return site
+}
+
+func (site *FleetSite) HasMember(memberID int64) bool {
+	for _, id := range site.MemberIDs {
+		if id == memberID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (site *FleetSite) DisplayName() string {
+	if len(site.Name) == 0 {
+		return "Unnamed site"
+	}
+
+	return site.Name
+}
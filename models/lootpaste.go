@@ -1,6 +1,10 @@
 // lootpaste
 package models
 
+import (
+	"sort"
+)
+
 type LootPaste struct {
 	ID        int64
 	FleetID   int64
@@ -8,6 +12,9 @@ type LootPaste struct {
 	RawPaste  string
 	Value     float64
 	PasteType LootPasteType
+	Voided    bool
+	SiteID    int64
+	Items     []*LootPasteItem
 }
 
 func NewLootPaste(id int64, fleet int64, pasted int64, raw string, value float64, pasteType LootPasteType) *LootPaste {
@@ -18,11 +25,24 @@ func NewLootPaste(id int64, fleet int64, pasted int64, raw string, value float64
 		RawPaste:  raw,
 		Value:     value,
 		PasteType: pasteType,
+		Voided:    false,
+		SiteID:    -1,
+		Items:     make([]*LootPasteItem, 0),
 	}
 
 	return paste
 }
 
+func (paste *LootPaste) SetAppraisal(appraisal *Appraisal) {
+	paste.Items = make([]*LootPasteItem, 0)
+
+	for _, item := range appraisal.Items {
+		paste.Items = append(paste.Items, NewLootPasteItem(-1, paste.ID, item.Type.ID, item.Name, item.Type.GroupName, item.Quantity, item.UnitPrice, item.Total, item.Type.Volume*float64(item.Quantity)))
+	}
+
+	paste.Value = appraisal.Total
+}
+
 type LootPasteType int
 
 const (
@@ -43,3 +63,103 @@ func (t LootPasteType) String() string {
 		return "U"
 	}
 }
+
+func (t LootPasteType) Name() string {
+	switch t {
+	case LootPasteTypeProfit:
+		return "Profit"
+	case LootPasteTypeLoss:
+		return "Loss"
+	default:
+		return "Unknown"
+	}
+}
+
+func (t LootPasteType) LabelType() string {
+	switch t {
+	case LootPasteTypeProfit:
+		return "label-success"
+	case LootPasteTypeLoss:
+		return "label-warning"
+	default:
+		return "label-danger"
+	}
+}
+
+type LootPasteItem struct {
+	ID          int64
+	LootPasteID int64
+	TypeID      int64
+	Name        string
+	GroupName   string
+	Quantity    int64
+	UnitPrice   float64
+	Total       float64
+	Volume      float64
+}
+
+func NewLootPasteItem(id int64, paste int64, typeID int64, name string, group string, quantity int64, unitPrice float64, total float64, volume float64) *LootPasteItem {
+	item := &LootPasteItem{
+		ID:          id,
+		LootPasteID: paste,
+		TypeID:      typeID,
+		Name:        name,
+		GroupName:   group,
+		Quantity:    quantity,
+		UnitPrice:   unitPrice,
+		Total:       total,
+		Volume:      volume,
+	}
+
+	return item
+}
+
+type LootPasteGroup struct {
+	Name     string
+	Quantity int64
+	Total    float64
+	Volume   float64
+}
+
+func GroupLootPasteItems(pastes []*LootPaste, pasteType LootPasteType) []*LootPasteGroup {
+	var groups []*LootPasteGroup
+
+	index := make(map[string]*LootPasteGroup)
+
+	for _, paste := range pastes {
+		if paste.Voided || paste.PasteType != pasteType {
+			continue
+		}
+
+		for _, item := range paste.Items {
+			group, ok := index[item.GroupName]
+			if !ok {
+				group = &LootPasteGroup{Name: item.GroupName}
+				index[item.GroupName] = group
+				groups = append(groups, group)
+			}
+
+			group.Quantity += item.Quantity
+			group.Total += item.Total
+			group.Volume += item.Volume
+		}
+	}
+
+	sort.Stable(LootPasteGroupsByTotal(groups))
+
+	return groups
+}
+
+type LootPasteGroupsByTotal []*LootPasteGroup
+
+func (groups LootPasteGroupsByTotal) Len() int {
+	return len(groups)
+}
+
+func (groups LootPasteGroupsByTotal) Less(i, j int) bool {
+	return groups[i].Total > groups[j].Total
+}
+
+func (groups LootPasteGroupsByTotal) Swap(i, j int) {
+	groups[i], groups[j] = groups[j], groups[i]
+}
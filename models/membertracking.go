@@ -6,9 +6,15 @@ import (
 )
 
 type MemberTracking struct {
-	XMLName     xml.Name            `xml:"eveapi"`
-	Rows        []MemberTrackingRow `xml:"result>rowset>row"`
-	CachedUntil string              `xml:"cachedUntil"`
+	XMLName     xml.Name             `xml:"eveapi"`
+	Rows        []MemberTrackingRow  `xml:"result>rowset>row"`
+	Error       *MemberTrackingError `xml:"error"`
+	CachedUntil string               `xml:"cachedUntil"`
+}
+
+type MemberTrackingError struct {
+	Code    int    `xml:"code,attr"`
+	Message string `xml:",chardata"`
 }
 
 type MemberTrackingRow struct {
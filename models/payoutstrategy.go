@@ -0,0 +1,142 @@
+// payoutstrategy
+package models
+
+type PayoutStrategy interface {
+	Type() PayoutStrategyType
+	Weights(fleet *Fleet) map[string]float64
+}
+
+type PayoutStrategyType int
+
+const (
+	PayoutStrategyTypeSitePoints PayoutStrategyType = 1 << iota
+	PayoutStrategyTypeEqualShares
+	PayoutStrategyTypeTimeInFleet
+	PayoutStrategyTypeRoleShares
+)
+
+func PayoutStrategyTypes() []PayoutStrategyType {
+	return []PayoutStrategyType{
+		PayoutStrategyTypeSitePoints,
+		PayoutStrategyTypeEqualShares,
+		PayoutStrategyTypeTimeInFleet,
+		PayoutStrategyTypeRoleShares,
+	}
+}
+
+func (t PayoutStrategyType) String() string {
+	switch t {
+	case PayoutStrategyTypeSitePoints:
+		return "Site points"
+	case PayoutStrategyTypeEqualShares:
+		return "Equal shares"
+	case PayoutStrategyTypeTimeInFleet:
+		return "Time in fleet"
+	case PayoutStrategyTypeRoleShares:
+		return "Role shares"
+	default:
+		return "Corporation default"
+	}
+}
+
+func (t PayoutStrategyType) Description() string {
+	switch t {
+	case PayoutStrategyTypeSitePoints:
+		return "Logged sites the member was present for plus site modifier, weighted by payment modifier or role payment rate"
+	case PayoutStrategyTypeEqualShares:
+		return "Every member receives the same share"
+	case PayoutStrategyTypeTimeInFleet:
+		return "Shares are weighted by the time each member spent in fleet"
+	case PayoutStrategyTypeRoleShares:
+		return "Flat share per member, weighted by payment modifier or role payment rate"
+	default:
+		return "Uses the payout strategy configured for the corporation"
+	}
+}
+
+func (t PayoutStrategyType) IsValid() bool {
+	for _, strategyType := range PayoutStrategyTypes() {
+		if t == strategyType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (t PayoutStrategyType) Strategy() PayoutStrategy {
+	switch t {
+	case PayoutStrategyTypeEqualShares:
+		return EqualSharesPayoutStrategy{}
+	case PayoutStrategyTypeTimeInFleet:
+		return TimeInFleetPayoutStrategy{}
+	case PayoutStrategyTypeRoleShares:
+		return RoleSharesPayoutStrategy{}
+	default:
+		return SitePointsPayoutStrategy{}
+	}
+}
+
+type SitePointsPayoutStrategy struct{}
+
+func (s SitePointsPayoutStrategy) Type() PayoutStrategyType {
+	return PayoutStrategyTypeSitePoints
+}
+
+func (s SitePointsPayoutStrategy) Weights(fleet *Fleet) map[string]float64 {
+	weights := make(map[string]float64)
+
+	for name, member := range fleet.Members {
+		weights[name] = float64(member.SitesCredited(fleet)) * member.PaymentRate(fleet.Corporation)
+	}
+
+	return weights
+}
+
+type EqualSharesPayoutStrategy struct{}
+
+func (s EqualSharesPayoutStrategy) Type() PayoutStrategyType {
+	return PayoutStrategyTypeEqualShares
+}
+
+func (s EqualSharesPayoutStrategy) Weights(fleet *Fleet) map[string]float64 {
+	weights := make(map[string]float64)
+
+	for name := range fleet.Members {
+		weights[name] = 1
+	}
+
+	return weights
+}
+
+type TimeInFleetPayoutStrategy struct{}
+
+func (s TimeInFleetPayoutStrategy) Type() PayoutStrategyType {
+	return PayoutStrategyTypeTimeInFleet
+}
+
+func (s TimeInFleetPayoutStrategy) Weights(fleet *Fleet) map[string]float64 {
+	weights := make(map[string]float64)
+
+	for name, member := range fleet.Members {
+		weights[name] = member.TimeInFleet(fleet).Minutes()
+	}
+
+	return weights
+}
+
+type RoleSharesPayoutStrategy struct{}
+
+func (s RoleSharesPayoutStrategy) Type() PayoutStrategyType {
+	return PayoutStrategyTypeRoleShares
+}
+
+func (s RoleSharesPayoutStrategy) Weights(fleet *Fleet) map[string]float64 {
+	weights := make(map[string]float64)
+
+	for name, member := range fleet.Members {
+		weights[name] = member.PaymentRate(fleet.Corporation)
+	}
+
+	return weights
+}
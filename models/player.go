@@ -6,17 +6,25 @@ type Player struct {
 	PlayerID int64
 	Name     string
 	Corp     *Corporation
+	Active   bool
 	AccessMask
 }
 
-func NewPlayer(id int64, playerID int64, name string, corp *Corporation, access AccessMask) *Player {
+func NewPlayer(id int64, playerID int64, name string, corp *Corporation, access AccessMask, active bool) *Player {
 	player := &Player{
 		ID:         id,
 		PlayerID:   playerID,
 		Name:       name,
 		Corp:       corp,
+		Active:     active,
 		AccessMask: access,
 	}
 
 	return player
 }
+
+func (player *Player) Copy() *Player {
+	p := *player
+
+	return &p
+}
@@ -2,6 +2,8 @@
 package models
 
 import (
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -33,6 +35,24 @@ func NewReport(id int64, payout float64, start time.Time, end time.Time, complet
 	return report
 }
 
+func (report *Report) Copy() *Report {
+	r := *report
+
+	r.Fleets = make([]*Fleet, len(report.Fleets))
+
+	for i, fleet := range report.Fleets {
+		r.Fleets[i] = fleet.Copy()
+	}
+
+	r.Payouts = make(map[string]*ReportPayout)
+
+	for name, payout := range report.Payouts {
+		r.Payouts[name] = payout.Copy()
+	}
+
+	return &r
+}
+
 func (report *Report) CalculatePayouts() {
 	report.TotalPayout = 0
 
@@ -42,7 +62,7 @@ func (report *Report) CalculatePayouts() {
 		for _, member := range fleet.Members {
 			_, ok := report.Payouts[member.Name]
 			if !ok {
-				report.Payouts[member.Name] = NewReportPayout(-1, report.ID, member.Player, 0, false)
+				report.Payouts[member.Name] = NewReportPayout(-1, report.ID, member.Player, 0, false, nil)
 			}
 
 			if !member.PayoutComplete {
@@ -70,3 +90,96 @@ func (report *Report) AllPayoutsComplete() bool {
 
 	return report.PayoutComplete
 }
+
+func (report *Report) PayoutFleets(player string) []*Fleet {
+	var fleets []*Fleet
+
+	for _, fleet := range report.Fleets {
+		if fleet.HasMember(player) {
+			fleets = append(fleets, fleet)
+		}
+	}
+
+	return fleets
+}
+
+type ReportPayoutProgress struct {
+	Paid        int
+	Total       int
+	PaidPayout  float64
+	TotalPayout float64
+}
+
+func (progress *ReportPayoutProgress) Percentage() int {
+	if progress.Total == 0 {
+		return 100
+	}
+
+	return progress.Paid * 100 / progress.Total
+}
+
+func (report *Report) PayoutProgress() *ReportPayoutProgress {
+	progress := &ReportPayoutProgress{}
+
+	for _, payout := range report.Payouts {
+		progress.Total++
+		progress.TotalPayout += payout.Payout
+
+		if payout.PayoutComplete || report.PayoutComplete {
+			progress.Paid++
+			progress.PaidPayout += payout.Payout
+		}
+	}
+
+	return progress
+}
+
+type ReportPayoutBatch struct {
+	Payer       *Player
+	Payouts     []*ReportPayout
+	Outstanding float64
+}
+
+func (report *Report) PayoutBatches() []*ReportPayoutBatch {
+	batches := make(map[int64]*ReportPayoutBatch)
+
+	for _, payout := range report.Payouts {
+		payerID := int64(-1)
+		if payout.HasPayer() {
+			payerID = payout.Payer.ID
+		}
+
+		batch, ok := batches[payerID]
+		if !ok {
+			batch = &ReportPayoutBatch{Payer: payout.Payer}
+			batches[payerID] = batch
+		}
+
+		batch.Payouts = append(batch.Payouts, payout)
+
+		if !payout.PayoutComplete && !report.PayoutComplete {
+			batch.Outstanding += payout.Payout
+		}
+	}
+
+	var result []*ReportPayoutBatch
+
+	for _, batch := range batches {
+		sort.Slice(batch.Payouts, func(i, j int) bool {
+			return strings.ToLower(batch.Payouts[i].Player.Name) < strings.ToLower(batch.Payouts[j].Player.Name)
+		})
+
+		result = append(result, batch)
+	}
+
+	// Payouts nobody has claimed yet are listed last
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Payer == nil || result[j].Payer == nil {
+			return result[j].Payer == nil && result[i].Payer != nil
+		}
+
+		return strings.ToLower(result[i].Payer.Name) < strings.ToLower(result[j].Payer.Name)
+	})
+
+	return result
+}
@@ -7,16 +7,28 @@ type ReportPayout struct {
 	Player         *Player
 	Payout         float64
 	PayoutComplete bool
+	Payer          *Player
 }
 
-func NewReportPayout(id int64, report int64, player *Player, total float64, complete bool) *ReportPayout {
+func NewReportPayout(id int64, report int64, player *Player, total float64, complete bool, payer *Player) *ReportPayout {
 	payout := &ReportPayout{
 		ID:             id,
 		ReportID:       report,
 		Player:         player,
 		Payout:         total,
 		PayoutComplete: complete,
+		Payer:          payer,
 	}
 
 	return payout
 }
+
+func (payout *ReportPayout) Copy() *ReportPayout {
+	p := *payout
+
+	return &p
+}
+
+func (payout *ReportPayout) HasPayer() bool {
+	return payout.Payer != nil
+}
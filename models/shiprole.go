@@ -0,0 +1,18 @@
+// shiprole
+package models
+
+type ShipRole struct {
+	ID   int64
+	Ship string
+	Role FleetRole
+}
+
+func NewShipRole(id int64, ship string, role FleetRole) *ShipRole {
+	shipRole := &ShipRole{
+		ID:   id,
+		Ship: ship,
+		Role: role,
+	}
+
+	return shipRole
+}
@@ -0,0 +1,138 @@
+// walletjournal
+package models
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+const (
+	WalletJournalRefTypePlayerDonation = 10
+	WalletJournalTimeLayout            = "2006-01-02 15:04:05"
+)
+
+type WalletJournal struct {
+	XMLName     xml.Name            `xml:"eveapi"`
+	Rows        []WalletJournalRow  `xml:"result>rowset>row"`
+	Error       *WalletJournalError `xml:"error"`
+	CachedUntil string              `xml:"cachedUntil"`
+}
+
+type WalletJournalError struct {
+	Code    int    `xml:"code,attr"`
+	Message string `xml:",chardata"`
+}
+
+type WalletJournalRow struct {
+	Date       string  `xml:"date,attr"`
+	RefID      int64   `xml:"refID,attr"`
+	RefTypeID  int     `xml:"refTypeID,attr"`
+	OwnerName1 string  `xml:"ownerName1,attr"`
+	OwnerID1   int64   `xml:"ownerID1,attr"`
+	OwnerName2 string  `xml:"ownerName2,attr"`
+	OwnerID2   int64   `xml:"ownerID2,attr"`
+	Amount     float64 `xml:"amount,attr"`
+	Balance    float64 `xml:"balance,attr"`
+	Reason     string  `xml:"reason,attr"`
+}
+
+func (row WalletJournalRow) IsOutgoingDonation() bool {
+	return row.RefTypeID == WalletJournalRefTypePlayerDonation && row.Amount < 0
+}
+
+func (row WalletJournalRow) Time() time.Time {
+	date, err := time.Parse(WalletJournalTimeLayout, row.Date)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return date
+}
+
+type WalletTransfer struct {
+	ID             int64
+	CorporationID  int64
+	RefID          int64
+	Date           time.Time
+	RecipientID    int64
+	RecipientName  string
+	Amount         float64
+	Reason         string
+	ReportID       int64
+	ReportPayoutID int64
+	ExpectedAmount float64
+	Status         WalletTransferStatus
+	Resolved       bool
+}
+
+func NewWalletTransfer(id int64, corp int64, ref int64, date time.Time, recipientID int64, recipientName string, amount float64, reason string, report int64, payout int64, expected float64, status WalletTransferStatus, resolved bool) *WalletTransfer {
+	transfer := &WalletTransfer{
+		ID:             id,
+		CorporationID:  corp,
+		RefID:          ref,
+		Date:           date,
+		RecipientID:    recipientID,
+		RecipientName:  recipientName,
+		Amount:         amount,
+		Reason:         reason,
+		ReportID:       report,
+		ReportPayoutID: payout,
+		ExpectedAmount: expected,
+		Status:         status,
+		Resolved:       resolved,
+	}
+
+	return transfer
+}
+
+func (transfer *WalletTransfer) Difference() float64 {
+	return transfer.Amount - transfer.ExpectedAmount
+}
+
+func (transfer *WalletTransfer) NeedsReview() bool {
+	return transfer.Status != WalletTransferStatusMatched && !transfer.Resolved
+}
+
+type WalletTransferStatus int
+
+const (
+	WalletTransferStatusMatched WalletTransferStatus = 1 << iota
+	WalletTransferStatusOverpaid
+	WalletTransferStatusUnderpaid
+	WalletTransferStatusUnmatched
+	WalletTransferStatusAmbiguous
+)
+
+func (status WalletTransferStatus) String() string {
+	switch status {
+	case WalletTransferStatusMatched:
+		return "Matched"
+	case WalletTransferStatusOverpaid:
+		return "Overpaid"
+	case WalletTransferStatusUnderpaid:
+		return "Underpaid"
+	case WalletTransferStatusUnmatched:
+		return "Unmatched"
+	case WalletTransferStatusAmbiguous:
+		return "Ambiguous"
+	default:
+		return "Unknown"
+	}
+}
+
+func (status WalletTransferStatus) Description() string {
+	switch status {
+	case WalletTransferStatusMatched:
+		return "Transfer matched an open payout and marked it as paid"
+	case WalletTransferStatusOverpaid:
+		return "Transfer exceeded the open payout, the payout is still outstanding until the difference is reviewed"
+	case WalletTransferStatusUnderpaid:
+		return "Transfer was lower than the open payout, the payout is still outstanding"
+	case WalletTransferStatusUnmatched:
+		return "No open payout was found for the recipient"
+	case WalletTransferStatusAmbiguous:
+		return "Recipient has several open payouts and none matches the amount"
+	default:
+		return "Unknown transfer status"
+	}
+}
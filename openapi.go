@@ -0,0 +1,282 @@
+// openapi
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	openAPIVersion     = "3.0.3"
+	openAPIAPIPrefix   = "/api/v1/"
+	openAPIContentType = "application/json"
+)
+
+var (
+	openAPIPathParameterRegex = regexp.MustCompile(`\{(\w+)(?::([^}]+))?\}`)
+	openAPITimeType           = reflect.TypeOf(time.Time{})
+
+	openAPIDocument map[string]interface{}
+)
+
+type openAPISchemas map[string]interface{}
+
+func BuildOpenAPIDocument() map[string]interface{} {
+	schemas := make(openAPISchemas)
+	paths := make(map[string]map[string]interface{})
+
+	schemas["ErrorBody"] = schemas.schemaFor(reflect.TypeOf(ErrorBody{}))
+
+	for _, route := range routes {
+		if len(route.Summary) == 0 {
+			continue
+		}
+
+		path, parameters := openAPIPathParameters(route.Pattern)
+
+		for _, query := range route.Query {
+			parameters = append(parameters, map[string]interface{}{
+				"name":     query,
+				"in":       "query",
+				"required": false,
+				"schema":   map[string]interface{}{"type": "string"},
+			})
+		}
+
+		isAPIRoute := strings.HasPrefix(route.Pattern, openAPIAPIPrefix)
+
+		operation := map[string]interface{}{
+			"operationId": route.Name,
+			"summary":     route.Summary,
+			"tags":        []string{openAPITag(route.Pattern)},
+			"responses":   schemas.responses(route, isAPIRoute),
+		}
+
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+
+		if route.Request != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					openAPIContentType: map[string]interface{}{"schema": schemas.schemaFor(reflect.TypeOf(route.Request))},
+				},
+			}
+		}
+
+		if isAPIRoute {
+			operation["security"] = []map[string][]string{{"bearerAuth": {}}}
+		}
+
+		if _, ok := paths[path]; !ok {
+			paths[path] = make(map[string]interface{})
+		}
+
+		for _, method := range route.Methods {
+			paths[path][strings.ToLower(method)] = operation
+		}
+	}
+
+	return map[string]interface{}{
+		"openapi": openAPIVersion,
+		"info": map[string]interface{}{
+			"title":       "lootsheeter",
+			"description": "JSON API for fleets, members, loot pastes, reports and payouts. Authenticate with a personal API token created on the API Tokens page.",
+			"version":     "v1",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{
+					"type":   "http",
+					"scheme": "bearer",
+				},
+			},
+		},
+	}
+}
+
+func (schemas openAPISchemas) responses(route Route, isAPIRoute bool) map[string]interface{} {
+	responses := make(map[string]interface{})
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	if route.Response == nil {
+		responses[strconv.Itoa(status)] = map[string]interface{}{"description": http.StatusText(status)}
+	} else {
+		schema := schemas.schemaFor(reflect.TypeOf(route.Response))
+		if isAPIRoute {
+			schema = map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"data": schema},
+			}
+		}
+
+		responses[strconv.Itoa(status)] = map[string]interface{}{
+			"description": http.StatusText(status),
+			"content": map[string]interface{}{
+				openAPIContentType: map[string]interface{}{"schema": schema},
+			},
+		}
+	}
+
+	errorSchema := map[string]interface{}{"$ref": "#/components/schemas/ErrorBody"}
+	if isAPIRoute {
+		errorSchema = map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"error": errorSchema},
+		}
+	}
+
+	responses["default"] = map[string]interface{}{
+		"description": "Error",
+		"content": map[string]interface{}{
+			openAPIContentType: map[string]interface{}{"schema": errorSchema},
+		},
+	}
+
+	return responses
+}
+
+func (schemas openAPISchemas) schemaFor(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == openAPITimeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number", "format": "double"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemas.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemas.schemaFor(t.Elem())}
+	case reflect.Struct:
+		name := t.Name()
+
+		if _, ok := schemas[name]; !ok {
+			// Reserve the name before descending so self-referencing types terminate
+			schemas[name] = nil
+
+			properties := make(map[string]interface{})
+
+			for i := 0; i < t.NumField(); i++ {
+				field := t.Field(i)
+				if len(field.PkgPath) > 0 {
+					continue
+				}
+
+				fieldName, omitEmpty, skip := openAPIFieldName(field)
+				if skip {
+					continue
+				}
+
+				schema := schemas.schemaFor(field.Type)
+				if omitEmpty || field.Type.Kind() == reflect.Ptr {
+					if _, ok := schema["$ref"]; ok {
+						schema = map[string]interface{}{"allOf": []interface{}{schema}}
+					}
+
+					schema["nullable"] = true
+				}
+
+				properties[fieldName] = schema
+			}
+
+			schemas[name] = map[string]interface{}{
+				"type":       "object",
+				"properties": properties,
+			}
+		}
+
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+
+	return map[string]interface{}{}
+}
+
+func openAPIFieldName(field reflect.StructField) (string, bool, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	name := field.Name
+	omitEmpty := false
+
+	parts := strings.Split(tag, ",")
+	if len(parts[0]) > 0 {
+		name = parts[0]
+	}
+
+	for _, option := range parts[1:] {
+		if option == "omitempty" {
+			omitEmpty = true
+		}
+	}
+
+	return name, omitEmpty, false
+}
+
+func openAPIPathParameters(pattern string) (string, []map[string]interface{}) {
+	var parameters []map[string]interface{}
+
+	for _, match := range openAPIPathParameterRegex.FindAllStringSubmatch(pattern, -1) {
+		schema := map[string]interface{}{"type": "string"}
+		if match[2] == "[0-9]+" {
+			schema = map[string]interface{}{"type": "integer", "format": "int64"}
+		}
+
+		parameters = append(parameters, map[string]interface{}{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   schema,
+		})
+	}
+
+	return openAPIPathParameterRegex.ReplaceAllString(pattern, "{$1}"), parameters
+}
+
+func openAPITag(pattern string) string {
+	segments := strings.Split(strings.TrimPrefix(strings.TrimPrefix(pattern, openAPIAPIPrefix), "/"), "/")
+
+	return segments[0]
+}
+
+func OpenAPIGetHandler(w http.ResponseWriter, r *http.Request) {
+	SendJSONResponseWithStatus(w, http.StatusOK, openAPIDocument)
+}
+
+func APIExplorerGetHandler(w http.ResponseWriter, r *http.Request) {
+	data := make(map[string]interface{})
+
+	data["PageTitle"] = "API Explorer"
+	data["PageType"] = 8
+	data["LoggedIn"] = session.IsLoggedIn(w, r)
+
+	err := templates.Funcs(TemplateFunctions(r)).ExecuteTemplate(w, "apiexplorer", data)
+	if err != nil {
+		logger.Errorf("Failed to execute template in APIExplorerGetHandler: [%v]", err)
+	}
+}
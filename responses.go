@@ -0,0 +1,596 @@
+// responses
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/morpheusxaut/lootsheeter/models"
+)
+
+type ErrorCode string
+
+const (
+	ErrorCodeInvalidRequest ErrorCode = "invalid_request"
+	ErrorCodeInvalidCommand ErrorCode = "invalid_command"
+	ErrorCodeUnauthorised   ErrorCode = "unauthorised"
+	ErrorCodeForbidden      ErrorCode = "forbidden"
+	ErrorCodeNotFound       ErrorCode = "not_found"
+	ErrorCodeConflict       ErrorCode = "conflict"
+	ErrorCodeUnprocessable  ErrorCode = "unprocessable"
+	ErrorCodeInternal       ErrorCode = "internal_error"
+)
+
+type ErrorBody struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+}
+
+func ErrorStatus(err error) (int, ErrorCode) {
+	if err == sql.ErrNoRows {
+		return http.StatusNotFound, ErrorCodeNotFound
+	}
+
+	return http.StatusInternalServerError, ErrorCodeInternal
+}
+
+type CorporationResponse struct {
+	ID             int64   `json:"id"`
+	CorporationID  int64   `json:"corporationID"`
+	Name           string  `json:"name"`
+	Ticker         string  `json:"ticker"`
+	CorporationCut float64 `json:"corporationCut"`
+	PayoutStrategy string  `json:"payoutStrategy"`
+}
+
+func NewCorporationResponse(corp *models.Corporation) *CorporationResponse {
+	if corp == nil {
+		return nil
+	}
+
+	return &CorporationResponse{
+		ID:             corp.ID,
+		CorporationID:  corp.CorporationID,
+		Name:           corp.Name,
+		Ticker:         corp.Ticker,
+		CorporationCut: corp.CorporationCut,
+		PayoutStrategy: corp.PayoutStrategy.String(),
+	}
+}
+
+type PlayerResponse struct {
+	ID          int64                `json:"id"`
+	CharacterID int64                `json:"characterID"`
+	Name        string               `json:"name"`
+	AccessMask  int                  `json:"accessMask"`
+	Corporation *CorporationResponse `json:"corporation,omitempty"`
+}
+
+func NewPlayerResponse(player *models.Player) *PlayerResponse {
+	if player == nil {
+		return nil
+	}
+
+	return &PlayerResponse{
+		ID:          player.ID,
+		CharacterID: player.PlayerID,
+		Name:        player.Name,
+		AccessMask:  int(player.AccessMask),
+		Corporation: NewCorporationResponse(player.Corp),
+	}
+}
+
+type FleetRoleResponse struct {
+	Role        int     `json:"role"`
+	Name        string  `json:"name"`
+	PaymentRate float64 `json:"paymentRate"`
+	LabelType   string  `json:"labelType"`
+	Builtin     bool    `json:"builtin"`
+}
+
+func NewFleetRoleResponse(definition *models.FleetRoleDefinition) *FleetRoleResponse {
+	return &FleetRoleResponse{
+		Role:        int(definition.Role),
+		Name:        definition.Name,
+		PaymentRate: definition.PaymentRate,
+		LabelType:   definition.LabelType,
+		Builtin:     definition.Role.IsBuiltin(),
+	}
+}
+
+type FleetMemberResponse struct {
+	ID              int64                          `json:"id"`
+	FleetID         int64                          `json:"fleetID"`
+	PlayerID        int64                          `json:"playerID"`
+	CharacterID     int64                          `json:"characterID"`
+	Name            string                         `json:"name"`
+	Role            int                            `json:"role"`
+	RoleName        string                         `json:"roleName"`
+	Ship            string                         `json:"ship"`
+	SiteModifier    int                            `json:"siteModifier"`
+	PaymentModifier float64                        `json:"paymentModifier"`
+	Payout          float64                        `json:"payout"`
+	PayoutComplete  bool                           `json:"payoutComplete"`
+	ReportID        int64                          `json:"reportID"`
+	JoinTime        *time.Time                     `json:"joinTime,omitempty"`
+	LeaveTime       *time.Time                     `json:"leaveTime,omitempty"`
+	Present         bool                           `json:"present"`
+	Intervals       []*FleetMemberIntervalResponse `json:"intervals"`
+}
+
+type FleetMemberIntervalResponse struct {
+	Join  time.Time  `json:"join"`
+	Leave *time.Time `json:"leave"`
+}
+
+func NewFleetMemberResponse(member *models.FleetMember, corp *models.Corporation) *FleetMemberResponse {
+	roleName := member.Role.String()
+	if corp != nil {
+		roleName = corp.FleetRole(member.Role).Name
+	}
+
+	intervals := make([]*FleetMemberIntervalResponse, 0, len(member.Intervals))
+	for _, interval := range member.Intervals {
+		intervals = append(intervals, &FleetMemberIntervalResponse{Join: interval.Join, Leave: optionalTime(interval.Leave)})
+	}
+
+	return &FleetMemberResponse{
+		ID:              member.ID,
+		FleetID:         member.FleetID,
+		PlayerID:        member.Player.ID,
+		CharacterID:     member.PlayerID,
+		Name:            member.Name,
+		Role:            int(member.Role),
+		RoleName:        roleName,
+		Ship:            member.Ship,
+		SiteModifier:    member.SiteModifier,
+		PaymentModifier: member.PaymentModifier,
+		Payout:          member.Payout,
+		PayoutComplete:  member.PayoutComplete,
+		ReportID:        member.ReportID,
+		JoinTime:        optionalTime(member.JoinTime),
+		LeaveTime:       optionalTime(member.LeaveTime),
+		Present:         member.IsPresent(),
+		Intervals:       intervals,
+	}
+}
+
+func NewFleetMemberResponses(fleet *models.Fleet) []*FleetMemberResponse {
+	members := make([]*FleetMemberResponse, 0, len(fleet.Members))
+
+	for _, member := range fleet.Members {
+		members = append(members, NewFleetMemberResponse(member, fleet.Corporation))
+	}
+
+	sort.Slice(members, func(i, j int) bool { return members[i].ID < members[j].ID })
+
+	return members
+}
+
+type MemberPayoutResponse struct {
+	PlayerID int64   `json:"playerID"`
+	Name     string  `json:"name"`
+	Payout   float64 `json:"payout"`
+}
+
+func NewMemberPayoutResponses(fleet *models.Fleet, payouts map[string]float64) []*MemberPayoutResponse {
+	previews := make([]*MemberPayoutResponse, 0, len(payouts))
+
+	for name, payout := range payouts {
+		preview := &MemberPayoutResponse{
+			Name:   name,
+			Payout: payout,
+		}
+
+		if member, ok := fleet.Members[name]; ok {
+			preview.PlayerID = member.Player.ID
+		}
+
+		previews = append(previews, preview)
+	}
+
+	sort.Slice(previews, func(i, j int) bool { return previews[i].Name < previews[j].Name })
+
+	return previews
+}
+
+type FleetResponse struct {
+	ID                int64                  `json:"id"`
+	Corporation       *CorporationResponse   `json:"corporation"`
+	Name              string                 `json:"name"`
+	System            string                 `json:"system"`
+	SystemNickname    string                 `json:"systemNickname"`
+	StartTime         *time.Time             `json:"startTime,omitempty"`
+	EndTime           *time.Time             `json:"endTime,omitempty"`
+	Profit            float64                `json:"profit"`
+	Losses            float64                `json:"losses"`
+	SitesFinished     int                    `json:"sitesFinished"`
+	CorporationPayout float64                `json:"corporationPayout"`
+	PayoutComplete    bool                   `json:"payoutComplete"`
+	Finished          bool                   `json:"finished"`
+	Notes             string                 `json:"notes"`
+	ReportID          int64                  `json:"reportID"`
+	PayoutStrategy    string                 `json:"payoutStrategy"`
+	Members           []*FleetMemberResponse `json:"members"`
+	Sites             []*FleetSiteResponse   `json:"sites"`
+}
+
+func NewFleetResponse(fleet *models.Fleet) *FleetResponse {
+	return &FleetResponse{
+		ID:                fleet.ID,
+		Corporation:       NewCorporationResponse(fleet.Corporation),
+		Name:              fleet.Name,
+		System:            fleet.System,
+		SystemNickname:    fleet.SystemNickname,
+		StartTime:         optionalTime(fleet.StartTime),
+		EndTime:           optionalTime(fleet.EndTime),
+		Profit:            fleet.Profit,
+		Losses:            fleet.Losses,
+		SitesFinished:     fleet.SitesFinished,
+		CorporationPayout: fleet.CorporationPayout,
+		PayoutComplete:    fleet.PayoutComplete,
+		Finished:          fleet.IsFleetFinished(),
+		Notes:             fleet.Notes,
+		ReportID:          fleet.ReportID,
+		PayoutStrategy:    fleet.EffectivePayoutStrategy().String(),
+		Members:           NewFleetMemberResponses(fleet),
+		Sites:             NewFleetSiteResponses(fleet.Sites),
+	}
+}
+
+func NewFleetResponses(fleets []*models.Fleet) []*FleetResponse {
+	responses := make([]*FleetResponse, 0, len(fleets))
+
+	for _, fleet := range fleets {
+		responses = append(responses, NewFleetResponse(fleet))
+	}
+
+	return responses
+}
+
+type FleetSiteResponse struct {
+	ID         int64     `json:"id"`
+	FleetID    int64     `json:"fleetID"`
+	Name       string    `json:"name"`
+	Finished   time.Time `json:"finished"`
+	Escalation bool      `json:"escalation"`
+	Voided     bool      `json:"voided"`
+	TickedBy   int64     `json:"tickedBy,omitempty"`
+	Members    []int64   `json:"members"`
+}
+
+func NewFleetSiteResponse(site *models.FleetSite) *FleetSiteResponse {
+	response := &FleetSiteResponse{
+		ID:         site.ID,
+		FleetID:    site.FleetID,
+		Name:       site.Name,
+		Finished:   site.Finished,
+		Escalation: site.Escalation,
+		Voided:     site.Voided,
+		Members:    site.MemberIDs,
+	}
+
+	if site.TickedBy > 0 {
+		response.TickedBy = site.TickedBy
+	}
+
+	if response.Members == nil {
+		response.Members = make([]int64, 0)
+	}
+
+	return response
+}
+
+func NewFleetSiteResponses(sites []*models.FleetSite) []*FleetSiteResponse {
+	responses := make([]*FleetSiteResponse, 0, len(sites))
+
+	for _, site := range sites {
+		responses = append(responses, NewFleetSiteResponse(site))
+	}
+
+	return responses
+}
+
+type PayoutPreviewResponse struct {
+	Strategy          string             `json:"strategy"`
+	Final             bool               `json:"final"`
+	CorporationPayout float64            `json:"corporationPayout"`
+	Payouts           map[string]float64 `json:"payouts"`
+}
+
+type LootPasteItemResponse struct {
+	TypeID    int64   `json:"typeID"`
+	Name      string  `json:"name"`
+	GroupName string  `json:"groupName"`
+	Quantity  int64   `json:"quantity"`
+	UnitPrice float64 `json:"unitPrice"`
+	Total     float64 `json:"total"`
+	Volume    float64 `json:"volume"`
+}
+
+type LootPasteResponse struct {
+	ID       int64                    `json:"id"`
+	FleetID  int64                    `json:"fleetID"`
+	PastedBy int64                    `json:"pastedBy"`
+	Type     string                   `json:"type"`
+	Value    float64                  `json:"value"`
+	Voided   bool                     `json:"voided"`
+	SiteID   int64                    `json:"siteID,omitempty"`
+	RawPaste string                   `json:"rawPaste"`
+	Items    []*LootPasteItemResponse `json:"items"`
+}
+
+func NewLootPasteResponse(paste *models.LootPaste) *LootPasteResponse {
+	items := make([]*LootPasteItemResponse, 0, len(paste.Items))
+
+	for _, item := range paste.Items {
+		items = append(items, &LootPasteItemResponse{
+			TypeID:    item.TypeID,
+			Name:      item.Name,
+			GroupName: item.GroupName,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			Total:     item.Total,
+			Volume:    item.Volume,
+		})
+	}
+
+	response := &LootPasteResponse{
+		ID:       paste.ID,
+		FleetID:  paste.FleetID,
+		PastedBy: paste.PastedBy,
+		Type:     paste.PasteType.Name(),
+		Value:    paste.Value,
+		Voided:   paste.Voided,
+		RawPaste: paste.RawPaste,
+		Items:    items,
+	}
+
+	if paste.SiteID > 0 {
+		response.SiteID = paste.SiteID
+	}
+
+	return response
+}
+
+func NewLootPasteResponses(pastes []*models.LootPaste) []*LootPasteResponse {
+	responses := make([]*LootPasteResponse, 0, len(pastes))
+
+	for _, paste := range pastes {
+		responses = append(responses, NewLootPasteResponse(paste))
+	}
+
+	return responses
+}
+
+type ReportPayoutResponse struct {
+	ID             int64   `json:"id"`
+	ReportID       int64   `json:"reportID"`
+	PlayerID       int64   `json:"playerID"`
+	Name           string  `json:"name"`
+	Payout         float64 `json:"payout"`
+	PayoutComplete bool    `json:"payoutComplete"`
+	PayerID        int64   `json:"payerID,omitempty"`
+	Payer          string  `json:"payer,omitempty"`
+}
+
+func NewReportPayoutResponse(payout *models.ReportPayout) *ReportPayoutResponse {
+	response := &ReportPayoutResponse{
+		ID:             payout.ID,
+		ReportID:       payout.ReportID,
+		PlayerID:       payout.Player.ID,
+		Name:           payout.Player.Name,
+		Payout:         payout.Payout,
+		PayoutComplete: payout.PayoutComplete,
+	}
+
+	if payout.HasPayer() {
+		response.PayerID = payout.Payer.ID
+		response.Payer = payout.Payer.Name
+	}
+
+	return response
+}
+
+func NewReportPayoutResponses(report *models.Report) []*ReportPayoutResponse {
+	payouts := make([]*ReportPayoutResponse, 0, len(report.Payouts))
+
+	for _, payout := range report.Payouts {
+		payouts = append(payouts, NewReportPayoutResponse(payout))
+	}
+
+	sort.Slice(payouts, func(i, j int) bool { return payouts[i].ID < payouts[j].ID })
+
+	return payouts
+}
+
+type ReportResponse struct {
+	ID             int64                   `json:"id"`
+	TotalPayout    float64                 `json:"totalPayout"`
+	StartRange     *time.Time              `json:"startRange,omitempty"`
+	EndRange       *time.Time              `json:"endRange,omitempty"`
+	PayoutComplete bool                    `json:"payoutComplete"`
+	Corporation    *CorporationResponse    `json:"corporation"`
+	Creator        *PlayerResponse         `json:"creator"`
+	FleetIDs       []int64                 `json:"fleetIDs"`
+	Payouts        []*ReportPayoutResponse `json:"payouts"`
+}
+
+func NewReportResponse(report *models.Report) *ReportResponse {
+	fleetIDs := make([]int64, 0, len(report.Fleets))
+
+	for _, fleet := range report.Fleets {
+		fleetIDs = append(fleetIDs, fleet.ID)
+	}
+
+	creator := NewPlayerResponse(report.Creator)
+	if creator != nil {
+		creator.Corporation = nil
+	}
+
+	return &ReportResponse{
+		ID:             report.ID,
+		TotalPayout:    report.TotalPayout,
+		StartRange:     optionalTime(report.StartRange),
+		EndRange:       optionalTime(report.EndRange),
+		PayoutComplete: report.PayoutComplete,
+		Corporation:    NewCorporationResponse(report.Corporation),
+		Creator:        creator,
+		FleetIDs:       fleetIDs,
+		Payouts:        NewReportPayoutResponses(report),
+	}
+}
+
+func NewReportResponses(reports []*models.Report) []*ReportResponse {
+	responses := make([]*ReportResponse, 0, len(reports))
+
+	for _, report := range reports {
+		responses = append(responses, NewReportResponse(report))
+	}
+
+	return responses
+}
+
+type ShipRoleResponse struct {
+	ID       int64  `json:"id"`
+	Ship     string `json:"ship"`
+	Role     int    `json:"role"`
+	RoleName string `json:"roleName"`
+}
+
+func NewShipRoleResponse(shipRole *models.ShipRole) *ShipRoleResponse {
+	return &ShipRoleResponse{
+		ID:       shipRole.ID,
+		Ship:     shipRole.Ship,
+		Role:     int(shipRole.Role),
+		RoleName: shipRole.Role.String(),
+	}
+}
+
+func NewShipRoleResponses(shipRoles []*models.ShipRole) []*ShipRoleResponse {
+	responses := make([]*ShipRoleResponse, 0, len(shipRoles))
+
+	for _, shipRole := range shipRoles {
+		responses = append(responses, NewShipRoleResponse(shipRole))
+	}
+
+	return responses
+}
+
+type APITokenResponse struct {
+	ID       int64      `json:"id"`
+	Name     string     `json:"name"`
+	Created  time.Time  `json:"created"`
+	LastUsed *time.Time `json:"lastUsed,omitempty"`
+	Token    string     `json:"token,omitempty"`
+}
+
+func NewAPITokenResponse(token *models.APIToken, plainToken string) *APITokenResponse {
+	return &APITokenResponse{
+		ID:       token.ID,
+		Name:     token.Name,
+		Created:  token.Created,
+		LastUsed: optionalTime(token.LastUsed),
+		Token:    plainToken,
+	}
+}
+
+type WalletTransferResponse struct {
+	ID             int64     `json:"id"`
+	RefID          int64     `json:"refID"`
+	Date           time.Time `json:"date"`
+	RecipientID    int64     `json:"recipientID"`
+	RecipientName  string    `json:"recipientName"`
+	Amount         float64   `json:"amount"`
+	Reason         string    `json:"reason"`
+	ReportID       int64     `json:"reportID"`
+	ReportPayoutID int64     `json:"reportPayoutID"`
+	ExpectedAmount float64   `json:"expectedAmount"`
+	Status         string    `json:"status"`
+	Resolved       bool      `json:"resolved"`
+}
+
+func NewWalletTransferResponse(transfer *models.WalletTransfer) *WalletTransferResponse {
+	return &WalletTransferResponse{
+		ID:             transfer.ID,
+		RefID:          transfer.RefID,
+		Date:           transfer.Date,
+		RecipientID:    transfer.RecipientID,
+		RecipientName:  transfer.RecipientName,
+		Amount:         transfer.Amount,
+		Reason:         transfer.Reason,
+		ReportID:       transfer.ReportID,
+		ReportPayoutID: transfer.ReportPayoutID,
+		ExpectedAmount: transfer.ExpectedAmount,
+		Status:         transfer.Status.String(),
+		Resolved:       transfer.Resolved,
+	}
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
+
+type JobStatusResponse struct {
+	Name         string     `json:"name"`
+	Description  string     `json:"description"`
+	Schedule     string     `json:"schedule"`
+	Enabled      bool       `json:"enabled"`
+	Running      bool       `json:"running"`
+	LastRun      *time.Time `json:"lastRun"`
+	LastDuration string     `json:"lastDuration"`
+	LastError    string     `json:"lastError"`
+	NextRun      *time.Time `json:"nextRun"`
+}
+
+func NewJobStatusResponse(status *JobStatus) *JobStatusResponse {
+	response := &JobStatusResponse{
+		Name:        status.Name,
+		Description: status.Description,
+		Schedule:    status.Schedule,
+		Enabled:     status.Enabled,
+		Running:     status.Running,
+		LastError:   status.LastError,
+	}
+
+	if !status.LastRun.IsZero() {
+		response.LastRun = &status.LastRun
+		response.LastDuration = status.LastDuration.String()
+	}
+
+	if !status.NextRun.IsZero() {
+		response.NextRun = &status.NextRun
+	}
+
+	return response
+}
+
+type FleetLinkResponse struct {
+	FleetID    int64      `json:"fleetID"`
+	ESIFleetID int64      `json:"esiFleetID"`
+	PlayerID   int64      `json:"playerID"`
+	Members    int        `json:"members"`
+	LastSync   *time.Time `json:"lastSync"`
+	LastError  string     `json:"lastError"`
+	Created    time.Time  `json:"created"`
+}
+
+func NewFleetLinkResponse(link *models.FleetLink) *FleetLinkResponse {
+	response := &FleetLinkResponse{
+		FleetID:    link.FleetID,
+		ESIFleetID: link.ESIFleetID,
+		PlayerID:   link.PlayerID,
+		Members:    len(link.CharacterIDs),
+		LastSync:   optionalTime(link.LastSync),
+		LastError:  link.LastError,
+		Created:    link.Created,
+	}
+
+	return response
+}
@@ -0,0 +1,30 @@
+// responses_test
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/morpheusxaut/lootsheeter/models"
+)
+
+func TestNewMemberPayoutResponses(t *testing.T) {
+	alice := models.NewPlayer(1, 90000001, "Alice", nil, models.AccessMaskMember, true)
+	bob := models.NewPlayer(2, 90000002, "Bob", nil, models.AccessMaskMember, true)
+
+	fleet := models.NewFleet(1, nil, "Test Fleet", "J123456", "", 0, 0, 0, time.Now(), time.Time{}, 0, false, "", -1, models.PayoutStrategyTypeSitePoints)
+	fleet.AddMember(models.NewFleetMember(1, fleet.ID, bob, models.FleetRoleDPS, "Loki", 0, 1, 0, false, -1, time.Now(), time.Time{}))
+	fleet.AddMember(models.NewFleetMember(2, fleet.ID, alice, models.FleetRoleFleetCommander, "Tengu", 0, 1, 0, false, -1, time.Now(), time.Time{}))
+
+	content, err := json.Marshal(NewMemberPayoutResponses(fleet, map[string]float64{"Bob": 1000000, "Alice": 1250000}))
+	if err != nil {
+		t.Fatalf("Failed to encode payout preview: [%v]", err)
+	}
+
+	expected := `[{"playerID":1,"name":"Alice","payout":1250000},{"playerID":2,"name":"Bob","payout":1000000}]`
+
+	if string(content) != expected {
+		t.Errorf("Expected payout preview %s, got %s", expected, content)
+	}
+}
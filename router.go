@@ -2,9 +2,14 @@
 package main
 
 import (
+	"context"
 	"net"
 	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -27,16 +32,55 @@ func SetupRouter() {
 		router.Methods(route.Methods...).Path(route.Pattern).Name(route.Name).Handler(handler)
 	}
 
+	openAPIDocument = BuildOpenAPIDocument()
+
 	router.PathPrefix("/").Handler(http.FileServer(http.Dir("./web/assets")))
 
 	logger.Infof("Successfully set up new router!")
 }
 
 func HandleRequests() {
-	logger.Infof("Listening for requests on %q...", net.JoinHostPort(config.HTTPHost, strconv.Itoa(config.HTTPPort)))
+	address := net.JoinHostPort(config.HTTPHost, strconv.Itoa(config.HTTPPort))
+
+	server := &http.Server{
+		Addr:    address,
+		Handler: router,
+	}
+
+	shutdown := make(chan struct{})
+
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+		received := <-signals
+
+		logger.Infof("Received signal %q, shutting down...", received)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.ShutdownTimeout)*time.Second)
+		defer cancel()
+
+		err := server.Shutdown(ctx)
+		if err != nil {
+			logger.Errorf("Failed to gracefully shut down HTTP server: [%v]", err)
+		}
+
+		err = scheduler.Stop(ctx)
+		if err != nil {
+			logger.Errorf("Failed to gracefully stop scheduler: [%v]", err)
+		}
+
+		close(shutdown)
+	}()
+
+	logger.Infof("Listening for requests on %q...", address)
+
+	err := server.ListenAndServe()
+	if err != http.ErrServerClosed {
+		logger.Fatalf("Received error while listening for requests: [%v]", err)
+	}
 
-	http.Handle("/", router)
-	err := http.ListenAndServe(net.JoinHostPort(config.HTTPHost, strconv.Itoa(config.HTTPPort)), nil)
+	<-shutdown
 
-	logger.Fatalf("Received error while listening for requests: [%v]", err)
+	logger.Infof("Successfully shut down!")
 }
@@ -10,6 +10,11 @@ type Route struct {
 	Methods     []string
 	Pattern     string
 	HandlerFunc http.HandlerFunc
+	Summary     string
+	Query       []string
+	Request     interface{}
+	Response    interface{}
+	Status      int
 }
 
 var routes = []Route{
@@ -97,6 +102,48 @@ var routes = []Route{
 		Pattern:     "/fleet/{fleetid:[0-9]+}/members/{memberid:[0-9]+}",
 		HandlerFunc: FleetMembersDeleteHandler,
 	},
+	Route{
+		Name:        "FleetLootPastesGet",
+		Methods:     []string{"GET"},
+		Pattern:     "/fleet/{fleetid:[0-9]+}/lootpastes",
+		HandlerFunc: FleetLootPastesGetHandler,
+	},
+	Route{
+		Name:        "FleetLootPastesPut",
+		Methods:     []string{"PUT"},
+		Pattern:     "/fleet/{fleetid:[0-9]+}/lootpastes/{lootpasteid:[0-9]+}",
+		HandlerFunc: FleetLootPastesPutHandler,
+	},
+	Route{
+		Name:        "FleetSitesGet",
+		Methods:     []string{"GET"},
+		Pattern:     "/fleet/{fleetid:[0-9]+}/sites",
+		HandlerFunc: FleetSitesGetHandler,
+	},
+	Route{
+		Name:        "FleetSitesPut",
+		Methods:     []string{"PUT"},
+		Pattern:     "/fleet/{fleetid:[0-9]+}/sites/{siteid:[0-9]+}",
+		HandlerFunc: FleetSitesPutHandler,
+	},
+	Route{
+		Name:        "FleetAuditGet",
+		Methods:     []string{"GET"},
+		Pattern:     "/fleet/{fleetid:[0-9]+}/audit",
+		HandlerFunc: FleetAuditGetHandler,
+	},
+	Route{
+		Name:        "FleetLinkPost",
+		Methods:     []string{"POST"},
+		Pattern:     "/fleet/{fleetid:[0-9]+}/link",
+		HandlerFunc: FleetLinkPostHandler,
+	},
+	Route{
+		Name:        "FleetLinkDelete",
+		Methods:     []string{"DELETE"},
+		Pattern:     "/fleet/{fleetid:[0-9]+}/link",
+		HandlerFunc: FleetLinkDeleteHandler,
+	},
 	Route{
 		Name:        "ReportListGet",
 		Methods:     []string{"GET"},
@@ -115,12 +162,72 @@ var routes = []Route{
 		Pattern:     "/reports/create",
 		HandlerFunc: ReportCreateFormHandler,
 	},
+	Route{
+		Name:        "ReconciliationGet",
+		Methods:     []string{"GET"},
+		Pattern:     "/reports/reconciliation",
+		HandlerFunc: ReconciliationGetHandler,
+	},
+	Route{
+		Name:        "ReconciliationPost",
+		Methods:     []string{"POST"},
+		Pattern:     "/reports/reconciliation",
+		HandlerFunc: ReconciliationPostHandler,
+	},
+	Route{
+		Name:        "ReconciliationPut",
+		Methods:     []string{"PUT"},
+		Pattern:     "/reports/reconciliation/{transferid:[0-9]+}",
+		HandlerFunc: ReconciliationPutHandler,
+	},
+	Route{
+		Name:        "ESIAccessGet",
+		Methods:     []string{"GET"},
+		Pattern:     "/sso",
+		HandlerFunc: ESIAccessGetHandler,
+	},
+	Route{
+		Name:        "SSOAuthorize",
+		Methods:     []string{"GET"},
+		Pattern:     "/sso/authorize",
+		HandlerFunc: SSOAuthorizeHandler,
+	},
+	Route{
+		Name:        "ESITokenDelete",
+		Methods:     []string{"DELETE"},
+		Pattern:     "/sso/token",
+		HandlerFunc: ESITokenDeleteHandler,
+	},
+	Route{
+		Name:        "SchedulerGet",
+		Methods:     []string{"GET"},
+		Pattern:     "/scheduler",
+		HandlerFunc: SchedulerGetHandler,
+	},
+	Route{
+		Name:        "SchedulerJobsGet",
+		Methods:     []string{"GET"},
+		Pattern:     "/scheduler/jobs",
+		HandlerFunc: SchedulerJobsGetHandler,
+	},
+	Route{
+		Name:        "SchedulerJobRunPost",
+		Methods:     []string{"POST"},
+		Pattern:     "/scheduler/jobs/{job}/run",
+		HandlerFunc: SchedulerJobRunPostHandler,
+	},
 	Route{
 		Name:        "ReportGet",
 		Methods:     []string{"GET"},
 		Pattern:     "/report/{reportid:[0-9]+}",
 		HandlerFunc: ReportGetHandler,
 	},
+	Route{
+		Name:        "ReportPayoutsGet",
+		Methods:     []string{"GET"},
+		Pattern:     "/report/{reportid:[0-9]+}/payouts",
+		HandlerFunc: ReportPayoutsGetHandler,
+	},
 	Route{
 		Name:        "ReportPut",
 		Methods:     []string{"PUT"},
@@ -133,4 +240,235 @@ var routes = []Route{
 		Pattern:     "/report/{reportid:[0-9]+}/players",
 		HandlerFunc: ReportPlayersPutHandler,
 	},
+	Route{
+		Name:        "ReportAuditGet",
+		Methods:     []string{"GET"},
+		Pattern:     "/report/{reportid:[0-9]+}/audit",
+		HandlerFunc: ReportAuditGetHandler,
+	},
+	Route{
+		Name:        "ReportExportGet",
+		Methods:     []string{"GET"},
+		Pattern:     "/report/{reportid:[0-9]+}/export",
+		HandlerFunc: ReportExportGetHandler,
+	},
+	Route{
+		Name:        "CorporationGet",
+		Methods:     []string{"GET"},
+		Pattern:     "/corporation",
+		HandlerFunc: CorporationGetHandler,
+	},
+	Route{
+		Name:        "CorporationPut",
+		Methods:     []string{"PUT"},
+		Pattern:     "/corporation",
+		HandlerFunc: CorporationPutHandler,
+	},
+	Route{
+		Name:        "ShipRolesGet",
+		Methods:     []string{"GET"},
+		Pattern:     "/shiproles",
+		HandlerFunc: ShipRolesGetHandler,
+	},
+	Route{
+		Name:        "ShipRolesListGet",
+		Methods:     []string{"GET"},
+		Pattern:     "/shiproles/list",
+		HandlerFunc: ShipRolesListGetHandler,
+	},
+	Route{
+		Name:        "ShipRolesPost",
+		Methods:     []string{"POST"},
+		Pattern:     "/shiproles",
+		HandlerFunc: ShipRolesPostHandler,
+	},
+	Route{
+		Name:        "ShipRolePut",
+		Methods:     []string{"PUT"},
+		Pattern:     "/shiproles/{shiproleid:[0-9]+}",
+		HandlerFunc: ShipRolePutHandler,
+	},
+	Route{
+		Name:        "ShipRoleDelete",
+		Methods:     []string{"DELETE"},
+		Pattern:     "/shiproles/{shiproleid:[0-9]+}",
+		HandlerFunc: ShipRoleDeleteHandler,
+	},
+	Route{
+		Name:        "APITokensGet",
+		Methods:     []string{"GET"},
+		Pattern:     "/apitokens",
+		HandlerFunc: APITokensGetHandler,
+	},
+	Route{
+		Name:        "APITokensPost",
+		Methods:     []string{"POST"},
+		Pattern:     "/apitokens",
+		HandlerFunc: APITokensPostHandler,
+	},
+	Route{
+		Name:        "APITokenDelete",
+		Methods:     []string{"DELETE"},
+		Pattern:     "/apitokens/{tokenid:[0-9]+}",
+		HandlerFunc: APITokenDeleteHandler,
+	},
+	Route{
+		Name:        "APIMeGet",
+		Methods:     []string{"GET"},
+		Pattern:     "/api/v1/me",
+		HandlerFunc: APIAuthenticated(APIMeGetHandler),
+		Summary:     "Get the player owning the API token",
+		Response:    &PlayerResponse{},
+	},
+	Route{
+		Name:        "APIFleetsGet",
+		Methods:     []string{"GET"},
+		Pattern:     "/api/v1/fleets",
+		HandlerFunc: APIAuthenticated(APIFleetsGetHandler),
+		Summary:     "List open fleets of the corporation, or all fleets with all=true",
+		Query:       []string{"all"},
+		Response:    []*FleetResponse{},
+	},
+	Route{
+		Name:        "APIFleetGet",
+		Methods:     []string{"GET"},
+		Pattern:     "/api/v1/fleets/{fleetid:[0-9]+}",
+		HandlerFunc: APIAuthenticated(APIFleetGetHandler),
+		Summary:     "Get a fleet",
+		Response:    &FleetResponse{},
+	},
+	Route{
+		Name:        "APIFleetMembersGet",
+		Methods:     []string{"GET"},
+		Pattern:     "/api/v1/fleets/{fleetid:[0-9]+}/members",
+		HandlerFunc: APIAuthenticated(APIFleetMembersGetHandler),
+		Summary:     "List the members of a fleet",
+		Response:    []*FleetMemberResponse{},
+	},
+	Route{
+		Name:        "APIFleetMembersPost",
+		Methods:     []string{"POST"},
+		Pattern:     "/api/v1/fleets/{fleetid:[0-9]+}/members",
+		HandlerFunc: APIAuthenticated(APIFleetMembersPostHandler),
+		Summary:     "Add a member to a fleet",
+		Request:     &APIFleetMemberRequest{},
+		Response:    &FleetMemberResponse{},
+		Status:      http.StatusCreated,
+	},
+	Route{
+		Name:        "APIFleetMemberPut",
+		Methods:     []string{"PUT"},
+		Pattern:     "/api/v1/fleets/{fleetid:[0-9]+}/members/{memberid:[0-9]+}",
+		HandlerFunc: APIAuthenticated(APIFleetMemberPutHandler),
+		Summary:     "Update a fleet member, omitted fields are left unchanged",
+		Request:     &APIFleetMemberRequest{},
+		Response:    &FleetMemberResponse{},
+	},
+	Route{
+		Name:        "APIFleetMemberDelete",
+		Methods:     []string{"DELETE"},
+		Pattern:     "/api/v1/fleets/{fleetid:[0-9]+}/members/{memberid:[0-9]+}",
+		HandlerFunc: APIAuthenticated(APIFleetMemberDeleteHandler),
+		Summary:     "Remove a member from a fleet",
+		Status:      http.StatusNoContent,
+	},
+	Route{
+		Name:        "APIFleetLootPastesGet",
+		Methods:     []string{"GET"},
+		Pattern:     "/api/v1/fleets/{fleetid:[0-9]+}/lootpastes",
+		HandlerFunc: APIAuthenticated(APIFleetLootPastesGetHandler),
+		Summary:     "List the loot pastes of a fleet",
+		Response:    []*LootPasteResponse{},
+	},
+	Route{
+		Name:        "APIFleetLootPastesPost",
+		Methods:     []string{"POST"},
+		Pattern:     "/api/v1/fleets/{fleetid:[0-9]+}/lootpastes",
+		HandlerFunc: APIAuthenticated(APIFleetLootPastesPostHandler),
+		Summary:     "Appraise and add a loot paste to a fleet",
+		Request:     &APILootPasteRequest{},
+		Response:    &LootPasteResponse{},
+		Status:      http.StatusCreated,
+	},
+	Route{
+		Name:        "APIFleetSitesGet",
+		Methods:     []string{"GET"},
+		Pattern:     "/api/v1/fleets/{fleetid:[0-9]+}/sites",
+		HandlerFunc: APIAuthenticated(APIFleetSitesGetHandler),
+		Summary:     "List the site log of a fleet",
+		Response:    []*FleetSiteResponse{},
+	},
+	Route{
+		Name:        "APIFleetSitesPost",
+		Methods:     []string{"POST"},
+		Pattern:     "/api/v1/fleets/{fleetid:[0-9]+}/sites",
+		HandlerFunc: APIAuthenticated(APIFleetSitesPostHandler),
+		Summary:     "Log a finished site for a fleet",
+		Request:     &APIFleetSiteRequest{},
+		Response:    &FleetSiteResponse{},
+		Status:      http.StatusCreated,
+	},
+	Route{
+		Name:        "APIFleetSitePut",
+		Methods:     []string{"PUT"},
+		Pattern:     "/api/v1/fleets/{fleetid:[0-9]+}/sites/{siteid:[0-9]+}",
+		HandlerFunc: APIAuthenticated(APIFleetSitePutHandler),
+		Summary:     "Edit, void or restore a logged site",
+		Request:     &APIFleetSiteRequest{},
+		Response:    &FleetSiteResponse{},
+	},
+	Route{
+		Name:        "APIFleetPayoutsGet",
+		Methods:     []string{"GET"},
+		Pattern:     "/api/v1/fleets/{fleetid:[0-9]+}/payouts",
+		HandlerFunc: APIAuthenticated(APIFleetPayoutsGetHandler),
+		Summary:     "Get the payouts of a fleet, previewed until the fleet is finished",
+		Response:    &PayoutPreviewResponse{},
+	},
+	Route{
+		Name:        "APIReportsGet",
+		Methods:     []string{"GET"},
+		Pattern:     "/api/v1/reports",
+		HandlerFunc: APIAuthenticated(APIReportsGetHandler),
+		Summary:     "List open reports of the corporation, or all reports with all=true",
+		Query:       []string{"all"},
+		Response:    []*ReportResponse{},
+	},
+	Route{
+		Name:        "APIReportGet",
+		Methods:     []string{"GET"},
+		Pattern:     "/api/v1/reports/{reportid:[0-9]+}",
+		HandlerFunc: APIAuthenticated(APIReportGetHandler),
+		Summary:     "Get a report",
+		Response:    &ReportResponse{},
+	},
+	Route{
+		Name:        "APIReportPayoutsGet",
+		Methods:     []string{"GET"},
+		Pattern:     "/api/v1/reports/{reportid:[0-9]+}/payouts",
+		HandlerFunc: APIAuthenticated(APIReportPayoutsGetHandler),
+		Summary:     "List the payouts of a report",
+		Response:    []*ReportPayoutResponse{},
+	},
+	Route{
+		Name:        "APIReportPayoutPut",
+		Methods:     []string{"PUT"},
+		Pattern:     "/api/v1/reports/{reportid:[0-9]+}/payouts/{payoutid:[0-9]+}",
+		HandlerFunc: APIAuthenticated(APIReportPayoutPutHandler),
+		Summary:     "Mark a report payout as paid or unpaid",
+		Request:     &APIReportPayoutRequest{},
+		Response:    &ReportPayoutResponse{},
+	},
+	Route{
+		Name:        "OpenAPIGet",
+		Methods:     []string{"GET"},
+		Pattern:     "/api/openapi.json",
+		HandlerFunc: OpenAPIGetHandler,
+	},
+	Route{
+		Name:        "APIExplorerGet",
+		Methods:     []string{"GET"},
+		Pattern:     "/api/explorer",
+		HandlerFunc: APIExplorerGetHandler,
+	},
 }
@@ -0,0 +1,210 @@
+// schedule
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Schedule interface {
+	Next(after time.Time) time.Time
+	String() string
+}
+
+type IntervalSchedule struct {
+	Interval time.Duration
+}
+
+func (schedule *IntervalSchedule) Next(after time.Time) time.Time {
+	return after.Add(schedule.Interval)
+}
+
+func (schedule *IntervalSchedule) String() string {
+	return fmt.Sprintf("@every %s", schedule.Interval)
+}
+
+type CronSchedule struct {
+	spec       string
+	minutes    uint64
+	hours      uint64
+	days       uint64
+	months     uint64
+	weekdays   uint64
+	anyDay     bool
+	anyWeekday bool
+}
+
+var cronScheduleAliases = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+}
+
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	if strings.HasPrefix(spec, "@every ") {
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid interval in schedule %q: [%v]", spec, err)
+		}
+
+		if interval < time.Minute {
+			return nil, fmt.Errorf("Interval in schedule %q must be at least one minute", spec)
+		}
+
+		return &IntervalSchedule{Interval: interval}, nil
+	}
+
+	expression := spec
+	if alias, ok := cronScheduleAliases[spec]; ok {
+		expression = alias
+	}
+
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("Invalid schedule %q, expected five fields (minute hour day month weekday) or @every <interval>", spec)
+	}
+
+	schedule := &CronSchedule{
+		spec:       spec,
+		anyDay:     fields[2] == "*",
+		anyWeekday: fields[4] == "*",
+	}
+
+	var err error
+
+	schedule.minutes, err = parseCronField(fields[0], 0, 59)
+	if err == nil {
+		schedule.hours, err = parseCronField(fields[1], 0, 23)
+	}
+	if err == nil {
+		schedule.days, err = parseCronField(fields[2], 1, 31)
+	}
+	if err == nil {
+		schedule.months, err = parseCronField(fields[3], 1, 12)
+	}
+	if err == nil {
+		schedule.weekdays, err = parseCronField(fields[4], 0, 7)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Invalid schedule %q: [%v]", spec, err)
+	}
+
+	// Both 0 and 7 refer to Sunday
+	if schedule.weekdays&(1<<7) != 0 {
+		schedule.weekdays |= 1
+	}
+
+	// Day and month combinations such as the 31st of February never fire and would leave the job idle forever
+	if schedule.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("Schedule %q never matches any date", spec)
+	}
+
+	return schedule, nil
+}
+
+func parseCronField(field string, min int, max int) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+
+		if index := strings.Index(part, "/"); index >= 0 {
+			var err error
+
+			step, err = strconv.Atoi(part[index+1:])
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("Invalid step in %q", part)
+			}
+
+			part = part[:index]
+		}
+
+		start, end := min, max
+
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+
+			var err error
+
+			start, err = strconv.Atoi(bounds[0])
+			if err != nil {
+				return 0, fmt.Errorf("Invalid value %q", part)
+			}
+
+			end = start
+			if len(bounds) == 2 {
+				end, err = strconv.Atoi(bounds[1])
+				if err != nil {
+					return 0, fmt.Errorf("Invalid value %q", part)
+				}
+			} else if step > 1 {
+				end = max
+			}
+		}
+
+		if start < min || end > max || start > end {
+			return 0, fmt.Errorf("Value %q out of range %d-%d", part, min, max)
+		}
+
+		for value := start; value <= end; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+
+	return bits, nil
+}
+
+func (schedule *CronSchedule) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+
+	// Every valid expression matches at least once within a few years, give up afterwards
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if schedule.months&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if !schedule.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if schedule.hours&(1<<uint(t.Hour())) == 0 {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+
+		if schedule.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (schedule *CronSchedule) matchesDay(t time.Time) bool {
+	day := schedule.days&(1<<uint(t.Day())) != 0
+	weekday := schedule.weekdays&(1<<uint(t.Weekday())) != 0
+
+	// As in cron, a restricted day of month and day of week match if either of them does
+	if !schedule.anyDay && !schedule.anyWeekday {
+		return day || weekday
+	}
+
+	return day && weekday
+}
+
+func (schedule *CronSchedule) String() string {
+	return schedule.spec
+}
@@ -0,0 +1,123 @@
+// schedule_test
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronField(t *testing.T) {
+	tests := []struct {
+		field  string
+		min    int
+		max    int
+		values []int
+	}{
+		{"*", 0, 6, []int{0, 1, 2, 3, 4, 5, 6}},
+		{"5", 0, 59, []int{5}},
+		{"1,3-5", 0, 59, []int{1, 3, 4, 5}},
+		{"*/20", 0, 59, []int{0, 20, 40}},
+		{"10/20", 0, 59, []int{10, 30, 50}},
+		{"9-17/4", 0, 23, []int{9, 13, 17}},
+		{"1-31/10", 1, 31, []int{1, 11, 21, 31}},
+	}
+
+	for _, test := range tests {
+		bits, err := parseCronField(test.field, test.min, test.max)
+		if err != nil {
+			t.Errorf("Failed to parse field %q: [%v]", test.field, err)
+			continue
+		}
+
+		var expected uint64
+		for _, value := range test.values {
+			expected |= 1 << uint(value)
+		}
+
+		if bits != expected {
+			t.Errorf("Expected field %q to match %v, got %b", test.field, test.values, bits)
+		}
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"* * *",
+		"* * * * * *",
+		"@yearly",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 0 *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"@every soon",
+		"@every 30s",
+		"@every 59s",
+		"0 0 31 2 *",
+		"0 0 30,31 2 *",
+		"0 0 31 4,6,9,11 *",
+	}
+
+	for _, spec := range tests {
+		schedule, err := ParseSchedule(spec)
+		if err == nil {
+			t.Errorf("Expected schedule %q to be rejected, got %v", spec, schedule)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	// Thursday
+	after := time.Date(2026, 10, 1, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		next time.Time
+	}{
+		{"@every 1m0s", time.Date(2026, 10, 1, 10, 31, 0, 0, time.UTC)},
+		{"@every 1h30m0s", time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 10, 1, 10, 45, 0, 0, time.UTC)},
+		{"0 */6 * * *", time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)},
+		{"30 9-17/4 * * *", time.Date(2026, 10, 1, 13, 30, 0, 0, time.UTC)},
+		{"5 4 * 1-3 *", time.Date(2027, 1, 1, 4, 5, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 10, 1, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)},
+		{"@midnight", time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2026, 10, 4, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		// Sunday may be written as 0 or 7
+		{"0 0 * * 7", time.Date(2026, 10, 4, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 2", time.Date(2026, 10, 6, 0, 0, 0, 0, time.UTC)},
+		// Restricting both day of month and day of week matches either of them
+		{"0 0 13 * 5", time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * 2", time.Date(2026, 10, 6, 0, 0, 0, 0, time.UTC)},
+		// A restricted day of month with any weekday and the other way round only match the restricted field
+		{"0 0 13 * *", time.Date(2026, 10, 13, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * 10 5", time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		schedule, err := ParseSchedule(test.spec)
+		if err != nil {
+			t.Errorf("Failed to parse schedule %q: [%v]", test.spec, err)
+			continue
+		}
+
+		if schedule.String() != test.spec {
+			t.Errorf("Expected schedule %q to be printed as itself, got %q", test.spec, schedule.String())
+		}
+
+		next := schedule.Next(after)
+		if !next.Equal(test.next) {
+			t.Errorf("Expected schedule %q to run next at %v, got %v", test.spec, test.next, next)
+		}
+	}
+}
@@ -2,13 +2,15 @@
 package main
 
 import (
-	"encoding/xml"
+	"context"
 	"fmt"
-	"io/ioutil"
 	"net/http"
+	"runtime/debug"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/gorilla/mux"
 	"github.com/morpheusxaut/lootsheeter/models"
 )
 
@@ -16,13 +18,133 @@ var (
 	scheduler *Scheduler
 )
 
+type Job struct {
+	Name        string
+	Description string
+	Schedule    Schedule
+	Run         func() error
+
+	lock         sync.Mutex
+	running      bool
+	lastRun      time.Time
+	lastDuration time.Duration
+	lastError    error
+	nextRun      time.Time
+	trigger      chan struct{}
+}
+
+func NewJob(name string, description string, schedule Schedule, run func() error) *Job {
+	job := &Job{
+		Name:        name,
+		Description: description,
+		Schedule:    schedule,
+		Run:         run,
+		trigger:     make(chan struct{}, 1),
+	}
+
+	return job
+}
+
+func (job *Job) IsEnabled() bool {
+	return job.Schedule != nil
+}
+
+func (job *Job) Status() *JobStatus {
+	job.lock.Lock()
+	defer job.lock.Unlock()
+
+	status := &JobStatus{
+		Name:         job.Name,
+		Description:  job.Description,
+		Enabled:      job.IsEnabled(),
+		Running:      job.running,
+		LastRun:      job.lastRun,
+		LastDuration: job.lastDuration,
+		NextRun:      job.nextRun,
+	}
+
+	if job.IsEnabled() {
+		status.Schedule = job.Schedule.String()
+	}
+
+	if job.lastError != nil {
+		status.LastError = job.lastError.Error()
+	}
+
+	return status
+}
+
+func (job *Job) scheduleNext(now time.Time) time.Time {
+	job.lock.Lock()
+	defer job.lock.Unlock()
+
+	job.nextRun = time.Time{}
+	if job.IsEnabled() {
+		job.nextRun = job.Schedule.Next(now)
+	}
+
+	return job.nextRun
+}
+
+func (job *Job) execute() {
+	job.lock.Lock()
+	job.running = true
+	job.lock.Unlock()
+
+	logger.Debugf("Running scheduled job %q...", job.Name)
+
+	start := time.Now().UTC()
+	err := job.run()
+	duration := time.Since(start)
+
+	if err != nil {
+		logger.Errorf("Scheduled job %q failed after %s: [%v]", job.Name, duration, err)
+	} else {
+		logger.Debugf("Finished scheduled job %q after %s...", job.Name, duration)
+	}
+
+	job.lock.Lock()
+	job.running = false
+	job.lastRun = start
+	job.lastDuration = duration
+	job.lastError = err
+	job.lock.Unlock()
+}
+
+// run recovers from a panicking job so it is reported as failed instead of taking down the scheduler with it
+func (job *Job) run() (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("Scheduled job panicked: %v\n%s", recovered, debug.Stack())
+		}
+	}()
+
+	return job.Run()
+}
+
+type JobStatus struct {
+	Name         string
+	Description  string
+	Schedule     string
+	Enabled      bool
+	Running      bool
+	LastRun      time.Time
+	LastDuration time.Duration
+	LastError    string
+	NextRun      time.Time
+}
+
 type Scheduler struct {
-	memberImportStop   chan struct{}
-	memberImportTicker *time.Ticker
+	jobs     []*Job
+	stop     chan struct{}
+	stopOnce sync.Once
+	wait     sync.WaitGroup
 }
 
 func NewScheduler() *Scheduler {
-	scheduler := &Scheduler{}
+	scheduler := &Scheduler{
+		stop: make(chan struct{}),
+	}
 
 	return scheduler
 }
@@ -30,75 +152,252 @@ func NewScheduler() *Scheduler {
 func InitialiseScheduler() {
 	scheduler = NewScheduler()
 
-	if config.SchedulerMemberTracking {
-		scheduler.StartMemberImport(4 * time.Hour)
+	memberTrackingSchedule, err := ParseSchedule(config.SchedulerMemberTrackingSchedule)
+	if err != nil {
+		logger.Fatalf("Failed to parse member tracking schedule: [%v]", err)
+		return
 	}
-}
 
-func (s *Scheduler) StartMemberImport(interval time.Duration) {
-	logger.Debugf("Starting member import scheduling...")
+	if !config.SchedulerMemberTracking {
+		memberTrackingSchedule = nil
+	}
+
+	walletJournalSchedule, err := ParseSchedule(config.SchedulerWalletJournalSchedule)
+	if err != nil {
+		logger.Fatalf("Failed to parse wallet journal schedule: [%v]", err)
+		return
+	}
 
-	s.memberImportStop = make(chan struct{})
-	s.memberImportTicker = time.NewTicker(interval)
+	if !config.SchedulerWalletJournal {
+		walletJournalSchedule = nil
+	}
 
-	err := s.ImportMembers()
+	fleetSyncSchedule, err := ParseSchedule(config.SchedulerFleetSyncSchedule)
 	if err != nil {
-		logger.Errorf("Failed to import members: [%v]", err)
+		logger.Fatalf("Failed to parse fleet sync schedule: [%v]", err)
+		return
 	}
 
-	go func() {
-		for {
-			select {
-			case <-s.memberImportTicker.C:
-				err = s.ImportMembers()
-				if err != nil {
-					logger.Errorf("Failed to import members: [%v]", err)
-				}
-			case <-s.memberImportStop:
-				s.memberImportTicker.Stop()
-				return
+	if !config.SchedulerFleetSync {
+		fleetSyncSchedule = nil
+	}
+
+	scheduler.Register(NewJob("membertracking", "Imports corporation members via the EVE API member tracking", memberTrackingSchedule, func() error {
+		return NewMemberImporter(database, memberTrackingFetcher).ImportAll()
+	}))
+	scheduler.Register(NewJob("walletjournal", "Verifies report payouts against the corporation wallet journal", walletJournalSchedule, func() error {
+		return NewWalletJournalImporter(database, walletJournalFetcher).ImportAll()
+	}))
+	scheduler.Register(NewJob("fleetsync", "Syncs members of linked in-game fleets via ESI", fleetSyncSchedule, func() error {
+		return NewFleetSyncer(database, inGameFleetFetcher).SyncAll()
+	}))
+
+	scheduler.Start()
+}
+
+func (s *Scheduler) Register(job *Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+func (s *Scheduler) Jobs() []*Job {
+	return s.jobs
+}
+
+func (s *Scheduler) Job(name string) (*Job, error) {
+	for _, job := range s.jobs {
+		if strings.EqualFold(job.Name, name) {
+			return job, nil
+		}
+	}
+
+	return nil, fmt.Errorf("Failed to find job %q", name)
+}
+
+func (s *Scheduler) Start() {
+	logger.Debugf("Starting scheduler with %d jobs...", len(s.jobs))
+
+	for _, job := range s.jobs {
+		s.wait.Add(1)
+
+		go s.runJob(job)
+	}
+}
+
+func (s *Scheduler) runJob(job *Job) {
+	defer s.wait.Done()
+
+	for {
+		var timer *time.Timer
+		var timerChannel <-chan time.Time
+
+		now := time.Now().UTC()
+
+		next := job.scheduleNext(now)
+		if !next.IsZero() {
+			timer = time.NewTimer(next.Sub(now))
+			timerChannel = timer.C
+		}
+
+		select {
+		case <-timerChannel:
+			job.execute()
+		case <-job.trigger:
+			if timer != nil {
+				timer.Stop()
+			}
+
+			job.execute()
+		case <-s.stop:
+			if timer != nil {
+				timer.Stop()
 			}
+
+			return
 		}
+	}
+}
+
+func (s *Scheduler) Trigger(name string) (*Job, error) {
+	job, err := s.Job(name)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case job.trigger <- struct{}{}:
+		return job, nil
+	default:
+		return job, fmt.Errorf("Job %q has already been triggered", job.Name)
+	}
+}
+
+func (s *Scheduler) Stop(ctx context.Context) error {
+	logger.Debugf("Stopping scheduler...")
+
+	s.stopOnce.Do(func() { close(s.stop) })
+
+	done := make(chan struct{})
+
+	go func() {
+		s.wait.Wait()
+		close(done)
 	}()
 
-	logger.Debugf("Finished member import scheduling...")
+	select {
+	case <-done:
+		logger.Debugf("Stopped scheduler...")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("Timed out waiting for running jobs to finish: [%v]", ctx.Err())
+	}
 }
 
-func (s *Scheduler) ImportMembers() error {
-	corporations, err := database.LoadAllCorporations()
+func SchedulerGetHandler(w http.ResponseWriter, r *http.Request) {
+	loggedIn := session.IsLoggedIn(w, r)
+
+	if !loggedIn {
+		session.SetLoginRedirect(w, r, "/scheduler")
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	if !HasHigherAccessMask(r, models.AccessMaskDirector) {
+		logger.Warnf("Received request to SchedulerGetHandler without proper access...")
+
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	data := make(map[string]interface{})
+
+	data["PageTitle"] = "Scheduler"
+	data["PageType"] = 9
+	data["LoggedIn"] = loggedIn
+
+	var jobs []*JobStatus
+
+	for _, job := range scheduler.Jobs() {
+		jobs = append(jobs, job.Status())
+	}
+
+	data["Jobs"] = jobs
+
+	err := templates.Funcs(TemplateFunctions(r)).ExecuteTemplate(w, "scheduler", data)
 	if err != nil {
-		return err
+		logger.Errorf("Failed to execute template in SchedulerGetHandler: [%v]", err)
 	}
+}
 
-	for _, corporation := range corporations {
-		apiURL := fmt.Sprintf("https://api.eveonline.com/corp/MemberTracking.xml.aspx?KeyID=%d&vCode=%s", corporation.APIID, corporation.APICode)
+func SchedulerJobsGetHandler(w http.ResponseWriter, r *http.Request) {
+	response := make(map[string]interface{})
 
-		resp, err := http.Get(apiURL)
-		if err != nil {
-			return err
-		}
+	loggedIn := session.IsLoggedIn(w, r)
+
+	if !loggedIn {
+		SendJSONError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Not logged in")
+		return
+	}
 
-		defer resp.Body.Close()
+	if !HasHigherAccessMask(r, models.AccessMaskDirector) {
+		logger.Warnf("Received request to SchedulerJobsGetHandler without proper access...")
 
-		xmlContent, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return err
-		}
+		SendJSONError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask")
+		return
+	}
 
-		var memberTracking models.MemberTracking
+	jobs := make([]*JobStatusResponse, 0)
 
-		err = xml.Unmarshal(xmlContent, &memberTracking)
-		if err != nil {
-			return err
-		}
+	for _, job := range scheduler.Jobs() {
+		jobs = append(jobs, NewJobStatusResponse(job.Status()))
+	}
 
-		for _, row := range memberTracking.Rows {
-			_, err := database.SavePlayer(models.NewPlayer(-1, row.CharacterID, row.Name, corporation, models.AccessMaskMember))
-			if !strings.Contains(err.Error(), "Duplicate entry") {
-				return err
-			}
-		}
+	response["result"] = "success"
+	response["error"] = nil
+	response["jobs"] = jobs
+
+	SendJSONResponse(w, response)
+}
+
+func SchedulerJobRunPostHandler(w http.ResponseWriter, r *http.Request) {
+	response := make(map[string]interface{})
+
+	vars := mux.Vars(r)
+
+	loggedIn := session.IsLoggedIn(w, r)
+
+	if !loggedIn {
+		SendJSONError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Not logged in")
+		return
+	}
+
+	if !HasHigherAccessMask(r, models.AccessMaskDirector) {
+		logger.Warnf("Received request to SchedulerJobRunPostHandler without proper access...")
+
+		SendJSONError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask")
+		return
+	}
+
+	job, err := scheduler.Job(vars["job"])
+	if err != nil {
+		logger.Errorf("Failed to find job in SchedulerJobRunPostHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusNotFound, ErrorCodeNotFound, fmt.Sprintf("Unknown job %q", vars["job"]))
+		return
+	}
+
+	_, err = scheduler.Trigger(job.Name)
+	if err != nil {
+		logger.Warnf("Failed to trigger job in SchedulerJobRunPostHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusConflict, ErrorCodeConflict, fmt.Sprintf("Job %q is already queued to run", job.Name))
+		return
 	}
 
-	return nil
+	logger.Infof("Player #%d triggered scheduled job %q...", session.GetPlayerID(r), job.Name)
+
+	response["result"] = "success"
+	response["error"] = nil
+	response["job"] = NewJobStatusResponse(job.Status())
+
+	SendJSONResponse(w, response)
 }
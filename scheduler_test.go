@@ -0,0 +1,29 @@
+// scheduler_test
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJobExecuteRecoversPanic(t *testing.T) {
+	job := NewJob("panic", "Panics while running", nil, func() error {
+		panic("wallet journal missing")
+	})
+
+	job.execute()
+
+	status := job.Status()
+
+	if status.Running {
+		t.Errorf("Expected panicked job to no longer be running")
+	}
+
+	if !strings.Contains(status.LastError, "wallet journal missing") {
+		t.Errorf("Expected panic to be recorded as last error, got %q", status.LastError)
+	}
+
+	if status.LastRun.IsZero() {
+		t.Errorf("Expected panicked job to record its last run")
+	}
+}
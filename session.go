@@ -74,6 +74,10 @@ func (s *Session) DestroySession(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Session) GetPlayerFromRequest(r *http.Request) *models.Player {
+	if player := APIPlayer(r); player != nil {
+		return player
+	}
+
 	session, _ := s.store.Get(r, "player")
 	if session.IsNew {
 		return nil
@@ -161,14 +165,23 @@ func (s *Session) SetIdentity(w http.ResponseWriter, r *http.Request, a models.C
 		}
 	}
 
-	player, err := database.LoadPlayerFromName(a.GetCharacterName())
-	if err != nil {
+	player, err := database.LoadPlayerFromPlayerID(a.GetCharacterID())
+	if err == nil && player.Name != a.GetCharacterName() && len(a.GetCharacterName()) > 0 {
+		renamed := player.Copy()
+		renamed.Name = a.GetCharacterName()
+
+		player, err = database.SavePlayer(renamed)
+		if err != nil {
+			return fmt.Errorf("Failed to save renamed player in session: [%v]", err)
+		}
+	} else if err != nil {
 		if len(a.GetCharacterName()) > 0 && a.GetCharacterID() > 0 {
-			_, err = database.SavePlayer(&models.Player{
+			player, err = database.SavePlayer(&models.Player{
 				ID:         -1,
 				Name:       a.GetCharacterName(),
 				PlayerID:   a.GetCharacterID(),
 				Corp:       corp,
+				Active:     true,
 				AccessMask: models.AccessMaskMember,
 			})
 			if err != nil {
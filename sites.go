@@ -0,0 +1,263 @@
+// sites
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/morpheusxaut/lootsheeter/models"
+)
+
+func ParseRequestSiteID(fleet *models.Fleet, raw string) (int64, error) {
+	raw = strings.TrimSpace(raw)
+	if len(raw) == 0 {
+		return -1, nil
+	}
+
+	siteID, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return -1, err
+	}
+
+	if siteID <= 0 {
+		return -1, nil
+	}
+
+	if fleet.Site(siteID) == nil {
+		return -1, fmt.Errorf("Site #%d does not belong to fleet #%d", siteID, fleet.ID)
+	}
+
+	return siteID, nil
+}
+
+func ParseRequestSiteMembers(fleet *models.Fleet, raw []string) ([]int64, error) {
+	memberIDs := make([]int64, 0, len(raw))
+
+	for _, value := range raw {
+		memberID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		memberIDs = append(memberIDs, memberID)
+	}
+
+	return ValidateSiteMembers(fleet, memberIDs)
+}
+
+func ValidateSiteMembers(fleet *models.Fleet, memberIDs []int64) ([]int64, error) {
+	members := make([]int64, 0, len(memberIDs))
+	seen := make(map[int64]bool)
+
+	for _, memberID := range memberIDs {
+		if !fleet.HasMemberID(memberID) {
+			return nil, fmt.Errorf("Member #%d does not belong to fleet #%d", memberID, fleet.ID)
+		}
+
+		if seen[memberID] {
+			continue
+		}
+
+		seen[memberID] = true
+		members = append(members, memberID)
+	}
+
+	return members, nil
+}
+
+func FleetSitesGetHandler(w http.ResponseWriter, r *http.Request) {
+	response := make(map[string]interface{})
+
+	vars := mux.Vars(r)
+	fleetID, err := strconv.ParseInt(vars["fleetid"], 10, 64)
+	if err != nil {
+		logger.Errorf("Failed to parse fleet ID %q in FleetSitesGetHandler: [%v]", vars["fleetid"], err)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Failed to parse fleet ID")
+		return
+	}
+
+	loggedIn := session.IsLoggedIn(w, r)
+
+	if !loggedIn {
+		SendJSONError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Not logged in")
+		return
+	}
+
+	fleet, err := database.LoadFleet(fleetID)
+	if err != nil {
+		logger.Errorf("Failed to load fleet in FleetSitesGetHandler: [%v]", err)
+
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to load fleet")
+		return
+	}
+
+	if fleet.Corporation.ID != session.GetCorpID(r) {
+		SendJSONError(w, http.StatusNotFound, ErrorCodeNotFound, "Fleet not found")
+		return
+	}
+
+	response["result"] = "success"
+	response["error"] = nil
+	response["sites"] = NewFleetSiteResponses(fleet.Sites)
+
+	SendJSONResponse(w, response)
+}
+
+func FleetSitesPutHandler(w http.ResponseWriter, r *http.Request) {
+	response := make(map[string]interface{})
+
+	vars := mux.Vars(r)
+	fleetID, err := strconv.ParseInt(vars["fleetid"], 10, 64)
+	if err != nil {
+		logger.Errorf("Failed to parse fleet ID %q in FleetSitesPutHandler: [%v]", vars["fleetid"], err)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Failed to parse fleet ID")
+		return
+	}
+
+	siteID, err := strconv.ParseInt(vars["siteid"], 10, 64)
+	if err != nil {
+		logger.Errorf("Failed to parse site ID %q in FleetSitesPutHandler: [%v]", vars["siteid"], err)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Failed to parse site ID")
+		return
+	}
+
+	loggedIn := session.IsLoggedIn(w, r)
+
+	if !loggedIn {
+		SendJSONError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Not logged in")
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		logger.Errorf("Failed to parse form in FleetSitesPutHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	command := r.FormValue("command")
+	if len(command) == 0 {
+		logger.Errorf("Received empty command in FleetSitesPutHandler...")
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Received empty command")
+		return
+	}
+
+	fleetLocks.Lock(fleetID)
+	defer fleetLocks.Unlock(fleetID)
+
+	fleet, err := database.LoadFleet(fleetID)
+	if err != nil {
+		logger.Errorf("Failed to load fleet in FleetSitesPutHandler: [%v]", err)
+
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to load fleet")
+		return
+	}
+
+	if fleet.Corporation.ID != session.GetCorpID(r) {
+		SendJSONError(w, http.StatusNotFound, ErrorCodeNotFound, "Fleet not found")
+		return
+	}
+
+	if !IsFleetCommander(r, fleet) && !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
+		logger.Warnf("Received request to FleetSitesPutHandler without proper access...")
+
+		SendJSONError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask or fleet role")
+		return
+	}
+
+	if fleet.IsFleetFinished() {
+		logger.Warnf("Received request to FleetSitesPutHandler for finished fleet #%d...", fleet.ID)
+
+		SendJSONError(w, http.StatusConflict, ErrorCodeConflict, "Cannot change sites of a finished fleet")
+		return
+	}
+
+	site, err := database.LoadFleetSite(siteID)
+	if err != nil {
+		logger.Errorf("Failed to load fleet site in FleetSitesPutHandler: [%v]", err)
+
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to load fleet site")
+		return
+	}
+
+	if site.FleetID != fleet.ID {
+		logger.Warnf("Received request to FleetSitesPutHandler for site #%d not belonging to fleet #%d...", site.ID, fleet.ID)
+
+		SendJSONError(w, http.StatusNotFound, ErrorCodeNotFound, "Site does not belong to this fleet")
+		return
+	}
+
+	before := NewFleetSiteResponse(site)
+
+	switch strings.ToLower(command) {
+	case "editsite":
+		finished, err := time.Parse("2006-01-02 15:04:05 +0000 UTC", r.FormValue("fleetSiteFinishedEdit"))
+		if err != nil {
+			logger.Errorf("Failed to parse finished in FleetSitesPutHandler: [%v]", err)
+
+			SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+			return
+		}
+
+		members, err := ParseRequestSiteMembers(fleet, r.Form["fleetSiteMembersEdit"])
+		if err != nil {
+			logger.Errorf("Failed to parse members in FleetSitesPutHandler: [%v]", err)
+
+			SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+			return
+		}
+
+		site.Name = strings.TrimSpace(r.FormValue("fleetSiteNameEdit"))
+		site.Finished = finished
+		site.Escalation = strings.EqualFold(r.FormValue("fleetSiteEscalationEdit"), "true")
+		site.MemberIDs = members
+		break
+	case "voidsite":
+		site.Voided = true
+		break
+	case "restoresite":
+		site.Voided = false
+		break
+	default:
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidCommand, "Invalid command")
+		return
+	}
+
+	site, err = database.SaveFleetSite(site)
+	if err != nil {
+		logger.Errorf("Failed to save fleet site #%d in FleetSitesPutHandler: [%v]", site.ID, err)
+
+		SendJSONError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to save fleet site")
+		return
+	}
+
+	fleet, err = database.LoadFleet(fleet.ID)
+	if err != nil {
+		logger.Errorf("Failed to reload fleet in FleetSitesPutHandler: [%v]", err)
+
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to reload fleet")
+		return
+	}
+
+	RecordAudit(r, fleet.ID, -1, strings.ToLower(command), before, NewFleetSiteResponse(site))
+
+	response["result"] = "success"
+	response["error"] = nil
+	response["fleet"] = NewFleetResponse(fleet)
+	response["site"] = NewFleetSiteResponse(site)
+
+	SendJSONResponse(w, response)
+}
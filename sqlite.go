@@ -0,0 +1,20 @@
+// sqlite
+package main
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func OpenSQLiteDatabase(path string) (*Database, error) {
+	logger.Infof("Trying to open SQLite database at %q...", path)
+
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_foreign_keys=1&_busy_timeout=5000&_journal_mode=WAL", path))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewDatabase("sqlite", db), nil
+}
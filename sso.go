@@ -10,20 +10,63 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/morpheusxaut/lootsheeter/models"
 )
 
-func FetchSSOToken(authorizationCode string) (models.SSOToken, error) {
-	auth := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", config.SSOClientID, config.SSOClientSecret)))
+type SSOError struct {
+	StatusCode  int
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (err *SSOError) Error() string {
+	return fmt.Sprintf("SSO returned status %d: %s %s", err.StatusCode, err.Code, err.Description)
+}
+
+// IsInvalidGrant reports whether the refresh token has been revoked or expired and the player has to consent again
+func (err *SSOError) IsInvalidGrant() bool {
+	return err.Code == "invalid_grant" || err.Code == "invalid_token"
+}
+
+func SSOAuthorizeURL(state string, scopes []string) string {
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("redirect_uri", config.SSOCallbackURL)
+	query.Set("client_id", config.SSOClientID)
+	query.Set("scope", strings.Join(scopes, " "))
+	query.Set("state", state)
 
+	return fmt.Sprintf("%s/oauth/authorize/?%s", strings.TrimRight(config.SSOURL, "/"), query.Encode())
+}
+
+func FetchSSOToken(authorizationCode string) (models.SSOToken, error) {
 	verifyData := url.Values{}
 	verifyData.Set("grant_type", "authorization_code")
 	verifyData.Set("code", authorizationCode)
 
-	verifyReq, err := http.NewRequest("POST", "https://login.eveonline.com/oauth/token", bytes.NewBufferString(verifyData.Encode()))
+	return requestSSOToken(verifyData)
+}
+
+func FetchSSORefreshToken(refreshToken string) (models.SSOToken, error) {
+	refreshData := url.Values{}
+	refreshData.Set("grant_type", "refresh_token")
+	refreshData.Set("refresh_token", refreshToken)
+
+	return requestSSOToken(refreshData)
+}
+
+func requestSSOToken(data url.Values) (models.SSOToken, error) {
+	auth := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", config.SSOClientID, config.SSOClientSecret)))
+
+	verifyReq, err := http.NewRequest("POST", strings.TrimRight(config.SSOURL, "/")+"/oauth/token", bytes.NewBufferString(data.Encode()))
+	if err != nil {
+		return models.SSOToken{}, err
+	}
+
 	verifyReq.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	verifyReq.Header.Add("Content-Length", strconv.Itoa(len(verifyData.Encode())))
+	verifyReq.Header.Add("Content-Length", strconv.Itoa(len(data.Encode())))
 	verifyReq.Header.Add("Authorization", fmt.Sprintf("Basic %s", auth))
 
 	client := &http.Client{}
@@ -38,6 +81,13 @@ func FetchSSOToken(authorizationCode string) (models.SSOToken, error) {
 		return models.SSOToken{}, err
 	}
 
+	if verifyResp.StatusCode != http.StatusOK {
+		ssoErr := &SSOError{StatusCode: verifyResp.StatusCode}
+		json.Unmarshal(verifyBody, ssoErr)
+
+		return models.SSOToken{}, ssoErr
+	}
+
 	var t models.SSOToken
 
 	err = json.Unmarshal(verifyBody, &t)
@@ -49,7 +99,11 @@ func FetchSSOToken(authorizationCode string) (models.SSOToken, error) {
 }
 
 func FetchSSOVerification(t models.SSOToken) (models.SSOVerification, error) {
-	charReq, err := http.NewRequest("GET", "https://login.eveonline.com/oauth/verify", nil)
+	charReq, err := http.NewRequest("GET", strings.TrimRight(config.SSOURL, "/")+"/oauth/verify", nil)
+	if err != nil {
+		return models.SSOVerification{}, err
+	}
+
 	charReq.Header.Add("Authorization", fmt.Sprintf("Bearer %s", t.AccessToken))
 
 	client := &http.Client{}
@@ -64,6 +118,13 @@ func FetchSSOVerification(t models.SSOToken) (models.SSOVerification, error) {
 		return models.SSOVerification{}, err
 	}
 
+	if charResp.StatusCode != http.StatusOK {
+		ssoErr := &SSOError{StatusCode: charResp.StatusCode}
+		json.Unmarshal(charBody, ssoErr)
+
+		return models.SSOVerification{}, ssoErr
+	}
+
 	var v models.SSOVerification
 
 	err = json.Unmarshal(charBody, &v)
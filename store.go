@@ -0,0 +1,95 @@
+// store
+package main
+
+import (
+	"github.com/morpheusxaut/lootsheeter/models"
+)
+
+type Store interface {
+	LoadCorporation(id int64) (*models.Corporation, error)
+	LoadCorporationFromName(name string) (*models.Corporation, error)
+	LoadAllCorporations() ([]*models.Corporation, error)
+	SaveCorporation(corporation *models.Corporation) (*models.Corporation, error)
+
+	LoadPlayer(id int64) (*models.Player, error)
+	LoadPlayerFromName(name string) (*models.Player, error)
+	LoadPlayerFromPlayerID(playerID int64) (*models.Player, error)
+	LoadAllPlayers(corporationID int64) ([]*models.Player, error)
+	LoadActivePlayers(corporationID int64) ([]*models.Player, error)
+	LoadAvailablePlayers(fleetID int64, corporationID int64) ([]*models.Player, error)
+	SavePlayer(player *models.Player) (*models.Player, error)
+
+	LoadFleetMember(fleetID int64, id int64) (*models.FleetMember, error)
+	LoadAllFleetMembers(fleetID int64) ([]*models.FleetMember, error)
+	LoadAllFleetMembersForReportPlayer(reportID int64, playerID int64) ([]*models.FleetMember, error)
+	SaveFleetMember(fleetID int64, member *models.FleetMember) (*models.FleetMember, error)
+	DeleteFleetMember(fleetID int64, memberID int64) error
+
+	LoadFleet(id int64) (*models.Fleet, error)
+	LoadAllFleets(corporationID int64) ([]*models.Fleet, error)
+	LoadAllFleetsForReport(reportID int64) ([]*models.Fleet, error)
+	LoadAllFleetsWithoutReports(corporationID int64) ([]*models.Fleet, error)
+	SaveFleet(fleet *models.Fleet) (*models.Fleet, error)
+
+	LoadReportPayout(reportPayoutID int64) (*models.ReportPayout, error)
+	LoadAllReportPayouts(reportID int64) ([]*models.ReportPayout, error)
+	SaveReportPayout(reportPayout *models.ReportPayout) (*models.ReportPayout, error)
+	CompleteReportPayout(reportPayout *models.ReportPayout, transfer *models.WalletTransfer) error
+
+	LoadReport(id int64) (*models.Report, error)
+	LoadAllReports(corporationID int64) ([]*models.Report, error)
+	SaveReport(report *models.Report) (*models.Report, error)
+
+	QueryShipRole(ship string) (models.FleetRole, error)
+	LoadShipRole(id int64) (*models.ShipRole, error)
+	LoadAllShipRoles() ([]*models.ShipRole, error)
+	SaveShipRole(shipRole *models.ShipRole) (*models.ShipRole, error)
+	SaveShipRoles(shipRoles []*models.ShipRole) error
+	DeleteShipRole(id int64) error
+
+	LoadAllFleetRoleDefinitions(corporationID int64) ([]*models.FleetRoleDefinition, error)
+	SaveFleetRoleDefinition(definition *models.FleetRoleDefinition) (*models.FleetRoleDefinition, error)
+	DeleteFleetRoleDefinition(corporationID int64, role models.FleetRole) error
+	IsFleetRoleInUse(corporationID int64, role models.FleetRole) (bool, error)
+
+	LoadLootPaste(id int64) (*models.LootPaste, error)
+	LoadAllLootPastes(fleetID int64) ([]*models.LootPaste, error)
+	LoadAllLootPasteItems(lootPasteID int64) ([]*models.LootPasteItem, error)
+	SaveLootPaste(paste *models.LootPaste) (*models.LootPaste, error)
+
+	LoadFleetSite(id int64) (*models.FleetSite, error)
+	LoadAllFleetSites(fleetID int64) ([]*models.FleetSite, error)
+	SaveFleetSite(site *models.FleetSite) (*models.FleetSite, error)
+
+	LoadItemTypeFromName(name string) (*models.ItemType, error)
+	SaveItemType(itemType *models.ItemType) (*models.ItemType, error)
+
+	LoadAPITokenFromHash(hash string) (*models.APIToken, error)
+	LoadAllAPITokens(playerID int64) ([]*models.APIToken, error)
+	SaveAPIToken(token *models.APIToken) (*models.APIToken, error)
+	DeleteAPIToken(playerID int64, tokenID int64) error
+
+	LoadESIToken(playerID int64) (*models.ESIToken, error)
+	LoadCorporationESITokens(corporationID int64) ([]*models.ESIToken, error)
+	SaveESIToken(token *models.ESIToken) (*models.ESIToken, error)
+	DeleteESIToken(playerID int64) error
+
+	LoadFleetLink(fleetID int64) (*models.FleetLink, error)
+	LoadAllFleetLinks() ([]*models.FleetLink, error)
+	SaveFleetLink(link *models.FleetLink) (*models.FleetLink, error)
+	DeleteFleetLink(fleetID int64) error
+
+	LoadAuditEntries(filter *models.AuditFilter) ([]*models.AuditEntry, error)
+	SaveAuditEntry(entry *models.AuditEntry) (*models.AuditEntry, error)
+
+	LoadWalletTransfer(corporationID int64, id int64) (*models.WalletTransfer, error)
+	LoadAllWalletTransfers(corporationID int64, limit int) ([]*models.WalletTransfer, error)
+	LoadUnresolvedWalletTransfers(corporationID int64) ([]*models.WalletTransfer, error)
+	IsWalletTransferImported(corporationID int64, refID int64) (bool, error)
+	SaveWalletTransfer(transfer *models.WalletTransfer) (*models.WalletTransfer, error)
+
+	RemovePlayerFromCache(id int64)
+
+	Ping() error
+	Close() error
+}
@@ -0,0 +1,251 @@
+// store_test
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/morpheusxaut/lootsheeter/models"
+)
+
+// openTestDatabase migrates a throwaway SQLite file and installs it as the global store for the duration of the test
+func openTestDatabase(t *testing.T) *Database {
+	t.Helper()
+
+	if config == nil {
+		config = &Config{}
+	}
+
+	path := filepath.Join(t.TempDir(), "lootsheeter.db")
+
+	db, err := OpenSQLiteDatabase(path)
+	if err != nil {
+		t.Fatalf("Failed to open SQLite database: [%v]", err)
+	}
+
+	err = db.MigrateUp()
+	if err != nil {
+		t.Fatalf("Failed to migrate SQLite database: [%v]", err)
+	}
+
+	previous := database
+	database = db
+
+	t.Cleanup(func() {
+		database = previous
+		db.Close()
+	})
+
+	return db
+}
+
+// reopenTestDatabase opens a second connection to the same file so loads bypass the caches of db
+func reopenTestDatabase(t *testing.T, db *Database) *Database {
+	t.Helper()
+
+	var path string
+
+	err := db.db.QueryRow("SELECT file FROM pragma_database_list WHERE name = 'main'").Scan(&path)
+	if err != nil {
+		t.Fatalf("Failed to query SQLite database path: [%v]", err)
+	}
+
+	reopened, err := OpenSQLiteDatabase(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen SQLite database: [%v]", err)
+	}
+
+	t.Cleanup(func() {
+		reopened.Close()
+	})
+
+	return reopened
+}
+
+func createTestPlayers(t *testing.T, db *Database, names ...string) (*models.Corporation, []*models.Player) {
+	t.Helper()
+
+	corporation, err := db.SaveCorporation(models.NewCorporation(-1, 98000001, "Test Corporation", "TEST", 10, 0, "", models.PayoutStrategyTypeSitePoints))
+	if err != nil {
+		t.Fatalf("Failed to save corporation: [%v]", err)
+	}
+
+	var players []*models.Player
+
+	for i, name := range names {
+		player, err := db.SavePlayer(models.NewPlayer(-1, int64(90000001+i), name, corporation, models.AccessMaskMember, true))
+		if err != nil {
+			t.Fatalf("Failed to save player %q: [%v]", name, err)
+		}
+
+		players = append(players, player)
+	}
+
+	return corporation, players
+}
+
+func TestMigrateRoundTrip(t *testing.T) {
+	db := openTestDatabase(t)
+
+	version, err := db.SchemaVersion()
+	if err != nil {
+		t.Fatalf("Failed to query schema version: [%v]", err)
+	}
+
+	if version != LatestSchemaVersion() {
+		t.Fatalf("Expected schema version %d after migrating up, got %d", LatestSchemaVersion(), version)
+	}
+
+	err = db.MigrateTo(0)
+	if err != nil {
+		t.Fatalf("Failed to migrate down: [%v]", err)
+	}
+
+	err = db.MigrateUp()
+	if err != nil {
+		t.Fatalf("Failed to migrate up again: [%v]", err)
+	}
+}
+
+func TestStoreFleetRoundTrip(t *testing.T) {
+	db := openTestDatabase(t)
+
+	corporation, players := createTestPlayers(t, db, "Alice", "Bob")
+
+	start := time.Date(2026, 10, 1, 18, 0, 0, 0, time.UTC)
+
+	fleet, err := db.SaveFleet(models.NewFleet(-1, corporation, "Test Fleet", "J123456", "Home", 0, 0, 0, start, time.Time{}, 0, false, "Some notes", -1, models.PayoutStrategyTypeSitePoints))
+	if err != nil {
+		t.Fatalf("Failed to save fleet: [%v]", err)
+	}
+
+	fleet.AddMember(models.NewFleetMember(-1, fleet.ID, players[0], models.FleetRoleFleetCommander, "Tengu", 0, 1, 0, false, -1, start, time.Time{}))
+	fleet.AddMember(models.NewFleetMember(-1, fleet.ID, players[1], models.FleetRoleDPS, "Noctis", 2, 0.5, 0, false, -1, start, time.Time{}))
+
+	fleet, err = db.SaveFleet(fleet)
+	if err != nil {
+		t.Fatalf("Failed to save fleet members: [%v]", err)
+	}
+
+	loaded, err := reopenTestDatabase(t, db).LoadFleet(fleet.ID)
+	if err != nil {
+		t.Fatalf("Failed to load fleet: [%v]", err)
+	}
+
+	if loaded.Name != "Test Fleet" || loaded.System != "J123456" || loaded.SystemNickname != "Home" || loaded.Notes != "Some notes" {
+		t.Errorf("Loaded fleet does not match saved fleet: %+v", loaded)
+	}
+
+	if !loaded.StartTime.Equal(start) || !loaded.EndTime.IsZero() {
+		t.Errorf("Expected start %v and no end, got %v and %v", start, loaded.StartTime, loaded.EndTime)
+	}
+
+	if loaded.Corporation.ID != corporation.ID {
+		t.Errorf("Expected corporation #%d, got #%d", corporation.ID, loaded.Corporation.ID)
+	}
+
+	if len(loaded.Members) != 2 {
+		t.Fatalf("Expected 2 members, got %d", len(loaded.Members))
+	}
+
+	bob, ok := loaded.Members["Bob"]
+	if !ok {
+		t.Fatalf("Expected member Bob in loaded fleet")
+	}
+
+	if bob.Role != models.FleetRoleDPS || bob.Ship != "Noctis" || bob.SiteModifier != 2 || bob.PaymentModifier != 0.5 {
+		t.Errorf("Loaded member does not match saved member: %+v", bob)
+	}
+
+	if len(bob.Intervals) != 1 || !bob.JoinTime.Equal(start) || !bob.LeaveTime.IsZero() || !bob.IsPresent() {
+		t.Errorf("Expected member to have joined at %v and still be present, got %v and %v with %d intervals", start, bob.JoinTime, bob.LeaveTime, len(bob.Intervals))
+	}
+}
+
+func TestStoreReportRoundTrip(t *testing.T) {
+	db := openTestDatabase(t)
+
+	corporation, players := createTestPlayers(t, db, "Alice", "Bob")
+
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 10, 8, 0, 0, 0, 0, time.UTC)
+
+	fleet, err := db.SaveFleet(models.NewFleet(-1, corporation, "Test Fleet", "J123456", "", 0, 0, 0, start, end, 0, true, "", -1, models.PayoutStrategyTypeSitePoints))
+	if err != nil {
+		t.Fatalf("Failed to save fleet: [%v]", err)
+	}
+
+	report, err := db.SaveReport(models.NewReport(-1, 4000000, start, end, false, corporation, players[0], []*models.Fleet{fleet}))
+	if err != nil {
+		t.Fatalf("Failed to save report: [%v]", err)
+	}
+
+	report.Payouts["Alice"] = models.NewReportPayout(-1, report.ID, players[0], 1500000, false, nil)
+	report.Payouts["Bob"] = models.NewReportPayout(-1, report.ID, players[1], 2500000, true, players[0])
+
+	report, err = db.SaveReport(report)
+	if err != nil {
+		t.Fatalf("Failed to save report payouts: [%v]", err)
+	}
+
+	reopened := reopenTestDatabase(t, db)
+
+	loaded, err := reopened.LoadReport(report.ID)
+	if err != nil {
+		t.Fatalf("Failed to load report: [%v]", err)
+	}
+
+	if loaded.TotalPayout != 4000000 || loaded.PayoutComplete || !loaded.StartRange.Equal(start) || !loaded.EndRange.Equal(end) {
+		t.Errorf("Loaded report does not match saved report: %+v", loaded)
+	}
+
+	if loaded.Creator == nil || loaded.Creator.ID != players[0].ID {
+		t.Errorf("Expected creator #%d, got %+v", players[0].ID, loaded.Creator)
+	}
+
+	if len(loaded.Payouts) != 2 {
+		t.Fatalf("Expected 2 payouts, got %d", len(loaded.Payouts))
+	}
+
+	alice := loaded.Payouts["Alice"]
+	if alice == nil || alice.Payout != 1500000 || alice.PayoutComplete || alice.HasPayer() {
+		t.Errorf("Loaded payout for Alice does not match: %+v", alice)
+	}
+
+	bob := loaded.Payouts["Bob"]
+	if bob == nil || bob.Payout != 2500000 || !bob.PayoutComplete || !bob.HasPayer() || bob.Payer.ID != players[0].ID {
+		t.Errorf("Loaded payout for Bob does not match: %+v", bob)
+	}
+
+	fleet, err = reopened.LoadFleet(fleet.ID)
+	if err != nil {
+		t.Fatalf("Failed to load fleet: [%v]", err)
+	}
+
+	if fleet.ReportID != report.ID {
+		t.Errorf("Expected fleet to belong to report #%d, got #%d", report.ID, fleet.ReportID)
+	}
+}
+
+func TestStoreReportWithoutEndTime(t *testing.T) {
+	db := openTestDatabase(t)
+
+	corporation, players := createTestPlayers(t, db, "Alice")
+
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	report, err := db.SaveReport(models.NewReport(-1, 0, start, time.Time{}, false, corporation, players[0], nil))
+	if err != nil {
+		t.Fatalf("Failed to save report without end time: [%v]", err)
+	}
+
+	loaded, err := reopenTestDatabase(t, db).LoadReport(report.ID)
+	if err != nil {
+		t.Fatalf("Failed to load report: [%v]", err)
+	}
+
+	if !loaded.StartRange.Equal(start) || !loaded.EndRange.IsZero() {
+		t.Errorf("Expected start %v and no end, got %v and %v", start, loaded.StartRange, loaded.EndRange)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/dustin/go-humanize"
 	"github.com/morpheusxaut/lootsheeter/models"
@@ -28,10 +29,31 @@ func TemplateFunctions(r *http.Request) template.FuncMap {
 		"IsPlayerName":                func(name string) bool { return IsPlayerName(r, name) },
 		"HasAccessMask":               func(accessMask models.AccessMask) bool { return HasAccessMask(r, accessMask) },
 		"HasHigherAccessMask":         func(accessMask models.AccessMask) bool { return HasHigherAccessMask(r, accessMask) },
-		"GetFleetRolePaymentModifier": func(role *models.FleetRole) float64 { return GetFleetRolePaymentModifier(role) },
+		"GetFleetRolePaymentModifier": GetFleetRolePaymentModifier,
+		"FormatISKAmount":             FormatISKAmount,
+		"CharacterInfoLink":           CharacterInfoLink,
+		"FormatDuration":              FormatDuration,
 	}
 }
 
+func FormatDuration(d time.Duration) string {
+	minutes := int64(d.Minutes())
+
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+
+	return fmt.Sprintf("%dh %02dm", minutes/60, minutes%60)
+}
+
+func FormatISKAmount(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
+
+func CharacterInfoLink(characterID int64) template.URL {
+	return template.URL(fmt.Sprintf("showinfo:1377//%d", characterID))
+}
+
 func FormatFloat(f float64) string {
 	fString := humanize.Ftoa(f)
 
@@ -135,7 +157,7 @@ func IsPlayerName(r *http.Request, name string) bool {
 
 func HasAccessMask(r *http.Request, accessMask models.AccessMask) bool {
 	player := session.GetPlayerFromRequest(r)
-	if player == nil {
+	if player == nil || !player.Active {
 		return false
 	}
 
@@ -144,13 +166,17 @@ func HasAccessMask(r *http.Request, accessMask models.AccessMask) bool {
 
 func HasHigherAccessMask(r *http.Request, accessMask models.AccessMask) bool {
 	player := session.GetPlayerFromRequest(r)
-	if player == nil {
+	if player == nil || !player.Active {
 		return false
 	}
 
 	return player.AccessMask >= accessMask
 }
 
-func GetFleetRolePaymentModifier(role *models.FleetRole) float64 {
-	return role.PaymentRate()
+func GetFleetRolePaymentModifier(corp *models.Corporation, role models.FleetRole) float64 {
+	if corp == nil {
+		return role.PaymentRate()
+	}
+
+	return corp.FleetRole(role).PaymentRate
 }
@@ -0,0 +1,426 @@
+// tokens
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/morpheusxaut/lootsheeter/models"
+)
+
+const (
+	// Access tokens are refreshed slightly before they expire to account for slow requests
+	esiTokenRefreshMargin = time.Minute
+
+	SSOFeatureMemberTracking = "membertracking"
+	SSOFeatureWallet         = "wallet"
+	SSOFeatureFleet          = "fleet"
+)
+
+var (
+	tokenCipher   cipher.AEAD
+	esiTokenLocks = NewKeyedMutex()
+
+	ErrTokenStorageDisabled = errors.New("Storing ESI tokens is disabled, set -ssotokenkey to enable it")
+)
+
+type SSOFeature struct {
+	Name        string
+	Title       string
+	Description string
+	Scopes      []string
+}
+
+func SSOFeatures() []*SSOFeature {
+	features := []*SSOFeature{
+		&SSOFeature{
+			Name:        SSOFeatureMemberTracking,
+			Title:       "Member Tracking",
+			Description: "Imports the corporation member list",
+			Scopes:      strings.Fields(config.SSOScopesMemberTracking),
+		},
+		&SSOFeature{
+			Name:        SSOFeatureWallet,
+			Title:       "Wallet Journal",
+			Description: "Verifies report payouts against the corporation wallet journal",
+			Scopes:      strings.Fields(config.SSOScopesWallet),
+		},
+		&SSOFeature{
+			Name:        SSOFeatureFleet,
+			Title:       "In-Game Fleets",
+			Description: "Imports members of your in-game fleet",
+			Scopes:      strings.Fields(config.SSOScopesFleet),
+		},
+	}
+
+	return features
+}
+
+func SSOFeatureScopes(name string) ([]string, error) {
+	for _, feature := range SSOFeatures() {
+		if feature.Name == name {
+			return feature.Scopes, nil
+		}
+	}
+
+	return nil, fmt.Errorf("Unknown SSO feature %q", name)
+}
+
+func InitialiseTokenCipher(secret string) error {
+	if len(secret) == 0 {
+		tokenCipher = nil
+		return nil
+	}
+
+	key := sha256.Sum256([]byte(secret))
+
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+
+	tokenCipher = gcm
+
+	return nil
+}
+
+func IsTokenStorageEnabled() bool {
+	return tokenCipher != nil
+}
+
+func EncryptSecret(plain string) (string, error) {
+	if tokenCipher == nil {
+		return "", ErrTokenStorageDisabled
+	}
+
+	nonce := make([]byte, tokenCipher.NonceSize())
+
+	_, err := io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(tokenCipher.Seal(nonce, nonce, []byte(plain), nil)), nil
+}
+
+func DecryptSecret(encrypted string) (string, error) {
+	if tokenCipher == nil {
+		return "", ErrTokenStorageDisabled
+	}
+
+	content, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", err
+	}
+
+	if len(content) < tokenCipher.NonceSize() {
+		return "", fmt.Errorf("Encrypted secret is too short")
+	}
+
+	plain, err := tokenCipher.Open(nil, content[:tokenCipher.NonceSize()], content[tokenCipher.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("Failed to decrypt secret, was -ssotokenkey changed? [%v]", err)
+	}
+
+	return string(plain), nil
+}
+
+func SaveSSOToken(store Store, player *models.Player, t models.SSOToken, v models.SSOVerification) (*models.ESIToken, error) {
+	token := models.NewESIToken(-1, player.ID, v.CharacterID, strings.Fields(v.Scopes), t.AccessToken, t.RefreshToken, time.Now().UTC().Add(time.Duration(t.Expiry)*time.Second), true, time.Now().UTC())
+
+	return store.SaveESIToken(token)
+}
+
+func RefreshESIToken(store Store, token *models.ESIToken) (*models.ESIToken, error) {
+	esiTokenLocks.Lock(token.PlayerID)
+	defer esiTokenLocks.Unlock(token.PlayerID)
+
+	// Another job might have refreshed the token while we were waiting for the lock
+	current, err := store.LoadESIToken(token.PlayerID)
+	if err != nil {
+		return token, err
+	}
+
+	if !current.Valid {
+		return current, fmt.Errorf("ESI token of player #%d needs to be authorised again", token.PlayerID)
+	}
+
+	if !current.ExpiresWithin(esiTokenRefreshMargin) {
+		return current, nil
+	}
+
+	t, err := FetchSSORefreshToken(current.RefreshToken)
+	if err != nil {
+		if ssoErr, ok := err.(*SSOError); ok && ssoErr.IsInvalidGrant() {
+			current.Valid = false
+
+			_, saveErr := store.SaveESIToken(current)
+			if saveErr != nil {
+				logger.Errorf("Failed to invalidate ESI token of player #%d: [%v]", current.PlayerID, saveErr)
+			}
+		}
+
+		return current, err
+	}
+
+	current.AccessToken = t.AccessToken
+	if len(t.RefreshToken) > 0 {
+		current.RefreshToken = t.RefreshToken
+	}
+	current.Expiry = time.Now().UTC().Add(time.Duration(t.Expiry) * time.Second)
+
+	return store.SaveESIToken(current)
+}
+
+// LoadPlayerESIToken returns a refreshed token of the player if it grants the scopes of the feature
+func LoadPlayerESIToken(store Store, playerID int64, feature string) (*models.ESIToken, error) {
+	if !IsTokenStorageEnabled() {
+		return nil, ErrNoESIToken
+	}
+
+	scopes, err := SSOFeatureScopes(feature)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := store.LoadESIToken(playerID)
+	if err == sql.ErrNoRows {
+		return nil, ErrNoESIToken
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid || !token.HasScopes(scopes) {
+		return nil, ErrNoESIToken
+	}
+
+	if token.ExpiresWithin(esiTokenRefreshMargin) {
+		return RefreshESIToken(store, token)
+	}
+
+	return token, nil
+}
+
+type StoredESITokenSource struct {
+	store Store
+}
+
+func NewStoredESITokenSource(store Store) *StoredESITokenSource {
+	source := &StoredESITokenSource{
+		store: store,
+	}
+
+	return source
+}
+
+func (source *StoredESITokenSource) Token(corporation *models.Corporation, feature string) (*models.ESIToken, error) {
+	if !IsTokenStorageEnabled() {
+		return nil, ErrNoESIToken
+	}
+
+	scopes, err := SSOFeatureScopes(feature)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := source.store.LoadCorporationESITokens(corporation.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, token := range tokens {
+		if !token.HasScopes(scopes) {
+			continue
+		}
+
+		if token.ExpiresWithin(esiTokenRefreshMargin) {
+			token, err = RefreshESIToken(source.store, token)
+			if err != nil {
+				logger.Warnf("Failed to refresh ESI token of player #%d for %s: [%v]", token.PlayerID, feature, err)
+				continue
+			}
+		}
+
+		return token, nil
+	}
+
+	return nil, ErrNoESIToken
+}
+
+func (source *StoredESITokenSource) PlayerToken(playerID int64, feature string) (*models.ESIToken, error) {
+	return LoadPlayerESIToken(source.store, playerID, feature)
+}
+
+func HasCorporationESIToken(store Store, corporation *models.Corporation, feature string) (bool, error) {
+	if !IsTokenStorageEnabled() {
+		return false, nil
+	}
+
+	scopes, err := SSOFeatureScopes(feature)
+	if err != nil {
+		return false, err
+	}
+
+	tokens, err := store.LoadCorporationESITokens(corporation.ID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, token := range tokens {
+		if token.HasScopes(scopes) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func ESIAccessGetHandler(w http.ResponseWriter, r *http.Request) {
+	loggedIn := session.IsLoggedIn(w, r)
+
+	if !loggedIn {
+		session.SetLoginRedirect(w, r, "/sso")
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	data := make(map[string]interface{})
+
+	data["PageTitle"] = "ESI Access"
+	data["PageType"] = 10
+	data["LoggedIn"] = loggedIn
+
+	data["TokenStorageEnabled"] = IsTokenStorageEnabled()
+	data["Features"] = SSOFeatures()
+
+	if IsTokenStorageEnabled() {
+		token, err := database.LoadESIToken(session.GetPlayerID(r))
+		if err == nil {
+			data["Token"] = token
+		} else if err != sql.ErrNoRows {
+			logger.Errorf("Failed to load ESI token in ESIAccessGetHandler: [%v]", err)
+
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	err := templates.Funcs(TemplateFunctions(r)).ExecuteTemplate(w, "esiaccess", data)
+	if err != nil {
+		logger.Errorf("Failed to execute template in ESIAccessGetHandler: [%v]", err)
+	}
+}
+
+func SSOAuthorizeHandler(w http.ResponseWriter, r *http.Request) {
+	loggedIn := session.IsLoggedIn(w, r)
+
+	if !loggedIn {
+		session.SetLoginRedirect(w, r, "/sso")
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	if !IsTokenStorageEnabled() {
+		http.Redirect(w, r, "/sso", http.StatusSeeOther)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		logger.Errorf("Failed to parse form in SSOAuthorizeHandler: [%v]", err)
+
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	requested := r.Form["feature"]
+	if len(requested) == 0 {
+		for _, feature := range SSOFeatures() {
+			requested = append(requested, feature.Name)
+		}
+	}
+
+	var scopes []string
+
+	// Scopes granted earlier are requested again, otherwise the new token would lose them
+	token, err := database.LoadESIToken(session.GetPlayerID(r))
+	if err == nil && token.Valid {
+		scopes = append(scopes, token.Scopes...)
+	}
+
+	for _, name := range requested {
+		featureScopes, err := SSOFeatureScopes(name)
+		if err != nil {
+			logger.Warnf("Received unknown feature %q in SSOAuthorizeHandler...", name)
+
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		scopes = append(scopes, featureScopes...)
+	}
+
+	state := GenerateRandomString(32)
+
+	session.SetSSOState(w, r, state)
+	session.SetLoginRedirect(w, r, "/sso")
+
+	http.Redirect(w, r, SSOAuthorizeURL(state, uniqueStrings(scopes)), http.StatusSeeOther)
+}
+
+func ESITokenDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	response := make(map[string]interface{})
+
+	loggedIn := session.IsLoggedIn(w, r)
+
+	if !loggedIn {
+		SendJSONError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Not logged in")
+		return
+	}
+
+	err := database.DeleteESIToken(session.GetPlayerID(r))
+	if err != nil {
+		logger.Errorf("Failed to delete ESI token in ESITokenDeleteHandler: [%v]", err)
+
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to delete ESI token")
+		return
+	}
+
+	response["result"] = "success"
+	response["error"] = nil
+
+	SendJSONResponse(w, response)
+}
+
+func uniqueStrings(values []string) []string {
+	var unique []string
+
+	seen := make(map[string]bool)
+
+	for _, value := range values {
+		if seen[value] {
+			continue
+		}
+
+		seen[value] = true
+		unique = append(unique, value)
+	}
+
+	return unique
+}
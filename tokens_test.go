@@ -0,0 +1,216 @@
+// tokens_test
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/morpheusxaut/lootsheeter/models"
+)
+
+const testWalletScope = "esi-wallet.read_corporation_wallets.v1"
+
+// setupTestSSO points the SSO configuration at a mock server and enables token storage for the duration of the test
+func setupTestSSO(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+
+	if config == nil {
+		config = &Config{}
+	}
+
+	server := httptest.NewServer(handler)
+
+	previousConfig := *config
+	previousCipher := tokenCipher
+
+	t.Cleanup(func() {
+		server.Close()
+		*config = previousConfig
+		tokenCipher = previousCipher
+	})
+
+	config.SSOURL = server.URL
+	config.SSOClientID = "client"
+	config.SSOClientSecret = "secret"
+	config.SSOScopesWallet = testWalletScope
+
+	err := InitialiseTokenCipher("test key")
+	if err != nil {
+		t.Fatalf("Failed to initialise token cipher: [%v]", err)
+	}
+}
+
+func TestRefreshESIToken(t *testing.T) {
+	db := openTestDatabase(t)
+
+	corporation, players := createTestPlayers(t, db, "Alice")
+
+	tests := []struct {
+		name     string
+		scopes   []string
+		status   int
+		response string
+		err      error
+		requests int
+		valid    bool
+		access   string
+		refresh  string
+	}{
+		{"refresh success", []string{testWalletScope}, http.StatusOK, `{"access_token": "new-access", "token_type": "Bearer", "expires_in": 1199, "refresh_token": "new-refresh"}`, nil, 1, true, "new-access", "new-refresh"},
+		{"revoked refresh token", []string{testWalletScope}, http.StatusBadRequest, `{"error": "invalid_grant", "error_description": "Invalid refresh token. Token missing/expired."}`, ErrNoESIToken, 1, false, "old-access", "old-refresh"},
+		{"missing scope", []string{"esi-fleets.read_fleet.v1"}, http.StatusOK, `{}`, ErrNoESIToken, 0, true, "old-access", "old-refresh"},
+	}
+
+	for _, test := range tests {
+		requests := 0
+
+		setupTestSSO(t, func(w http.ResponseWriter, r *http.Request) {
+			requests++
+
+			r.ParseForm()
+
+			if r.URL.Path != "/oauth/token" || r.Form.Get("grant_type") != "refresh_token" || r.Form.Get("refresh_token") != "old-refresh" {
+				t.Errorf("%s: Unexpected SSO request %s %v", test.name, r.URL.Path, r.Form)
+			}
+
+			if r.Header.Get("Authorization") != "Basic "+base64.StdEncoding.EncodeToString([]byte("client:secret")) {
+				t.Errorf("%s: Expected client credentials, got %q", test.name, r.Header.Get("Authorization"))
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(test.status)
+			fmt.Fprint(w, test.response)
+		})
+
+		_, err := db.SaveESIToken(models.NewESIToken(-1, players[0].ID, 90000001, test.scopes, "old-access", "old-refresh", time.Now().UTC().Add(-time.Minute), true, time.Now().UTC()))
+		if err != nil {
+			t.Fatalf("%s: Failed to save ESI token: [%v]", test.name, err)
+		}
+
+		source := NewStoredESITokenSource(db)
+
+		token, err := source.Token(corporation, SSOFeatureWallet)
+		if err != test.err {
+			t.Errorf("%s: Expected error [%v], got [%v]", test.name, test.err, err)
+		} else if err == nil && token.AccessToken != test.access {
+			t.Errorf("%s: Expected access token %q, got %q", test.name, test.access, token.AccessToken)
+		}
+
+		if requests != test.requests {
+			t.Errorf("%s: Expected %d SSO requests, got %d", test.name, test.requests, requests)
+		}
+
+		stored, err := reopenTestDatabase(t, db).LoadESIToken(players[0].ID)
+		if err != nil {
+			t.Fatalf("%s: Failed to load stored ESI token: [%v]", test.name, err)
+		}
+
+		if stored.Valid != test.valid || stored.AccessToken != test.access || stored.RefreshToken != test.refresh {
+			t.Errorf("%s: Expected stored token valid %v with %q and %q, got %v with %q and %q", test.name, test.valid, test.access, test.refresh, stored.Valid, stored.AccessToken, stored.RefreshToken)
+		}
+
+		// A revoked token is not refreshed again until the player consents anew
+		if !test.valid {
+			requests = 0
+
+			_, err = RefreshESIToken(db, stored)
+			if err == nil || requests != 0 {
+				t.Errorf("%s: Expected invalidated token to require consent, got [%v] after %d requests", test.name, err, requests)
+			}
+		}
+	}
+}
+
+func TestRefreshESITokenInvalidGrant(t *testing.T) {
+	db := openTestDatabase(t)
+
+	_, players := createTestPlayers(t, db, "Alice")
+
+	setupTestSSO(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error": "invalid_grant", "error_description": "Invalid refresh token. Token missing/expired."}`)
+	})
+
+	token, err := db.SaveESIToken(models.NewESIToken(-1, players[0].ID, 90000001, []string{testWalletScope}, "old-access", "old-refresh", time.Now().UTC().Add(-time.Minute), true, time.Now().UTC()))
+	if err != nil {
+		t.Fatalf("Failed to save ESI token: [%v]", err)
+	}
+
+	token, err = RefreshESIToken(db, token)
+	if ssoErr, ok := err.(*SSOError); !ok || !ssoErr.IsInvalidGrant() || ssoErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected invalid grant error, got [%v]", err)
+	}
+
+	if token.Valid {
+		t.Errorf("Expected token with revoked refresh token to be invalidated")
+	}
+}
+
+func TestTokenCipherRoundTrip(t *testing.T) {
+	setupTestSSO(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	encrypted, err := EncryptSecret("refresh-token")
+	if err != nil {
+		t.Fatalf("Failed to encrypt secret: [%v]", err)
+	}
+
+	again, err := EncryptSecret("refresh-token")
+	if err != nil {
+		t.Fatalf("Failed to encrypt secret: [%v]", err)
+	}
+
+	if encrypted == again {
+		t.Errorf("Expected encrypting the same secret twice to use different nonces")
+	}
+
+	plain, err := DecryptSecret(encrypted)
+	if err != nil || plain != "refresh-token" {
+		t.Fatalf("Expected secret to round-trip, got %q: [%v]", plain, err)
+	}
+
+	content, _ := base64.StdEncoding.DecodeString(encrypted)
+
+	tampered := make([]byte, len(content))
+	copy(tampered, content)
+	tampered[len(tampered)-1] ^= 0x01
+
+	tests := []struct {
+		name      string
+		encrypted string
+	}{
+		{"tampered ciphertext", base64.StdEncoding.EncodeToString(tampered)},
+		{"truncated ciphertext", base64.StdEncoding.EncodeToString(content[:tokenCipher.NonceSize()-1])},
+		{"invalid encoding", "not base64!"},
+	}
+
+	for _, test := range tests {
+		plain, err := DecryptSecret(test.encrypted)
+		if err == nil {
+			t.Errorf("%s: Expected decryption to fail, got %q", test.name, plain)
+		}
+	}
+
+	err = InitialiseTokenCipher("other key")
+	if err != nil {
+		t.Fatalf("Failed to initialise token cipher: [%v]", err)
+	}
+
+	_, err = DecryptSecret(encrypted)
+	if err == nil {
+		t.Errorf("Expected decryption with a different key to fail")
+	}
+
+	err = InitialiseTokenCipher("")
+	if err != nil {
+		t.Fatalf("Failed to disable token cipher: [%v]", err)
+	}
+
+	_, err = EncryptSecret("refresh-token")
+	if err != ErrTokenStorageDisabled {
+		t.Errorf("Expected disabled token storage error, got [%v]", err)
+	}
+}
@@ -2,53 +2,20 @@
 package main
 
 import (
-	"bytes"
+	"database/sql"
 	"encoding/json"
-	"encoding/xml"
 	"fmt"
 	"io/ioutil"
 	"math/rand"
 	"net/http"
-	"net/url"
-	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/morpheusxaut/lootsheeter/composition"
 	"github.com/morpheusxaut/lootsheeter/models"
 )
 
-func GetEvepraisalValue(raw string) (float64, error) {
-	url := strings.ToLower(raw)
-
-	if strings.HasPrefix(url, "http://evepraisal.com/e/") || strings.HasPrefix(url, "http://evepraisal.com/estimate/") {
-		if !strings.HasSuffix(url, ".json") {
-			url += ".json"
-		}
-
-		resp, err := http.Get(url)
-		if err != nil {
-			return 0, err
-		}
-
-		defer resp.Body.Close()
-
-		jsonContent, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return 0, err
-		}
-
-		var evePraisal models.EvePraisal
-		err = json.Unmarshal(jsonContent, &evePraisal)
-		if err != nil {
-			return 0, err
-		}
-
-		return evePraisal.GetTotalBuyValue(), nil
-	}
-
-	return 0, fmt.Errorf("Invalid evepraisal link, cannot parse")
-}
-
 func GetZKillboardValue(raw string) (float64, error) {
 	url := strings.TrimRight(strings.ToLower(raw), "/")
 
@@ -79,37 +46,6 @@ func GetZKillboardValue(raw string) (float64, error) {
 	return 0, fmt.Errorf("Invalid zKillboard link, cannot parse")
 }
 
-func GetPasteValue(raw string) (float64, error) {
-	data := url.Values{}
-	data.Set("raw_paste", raw)
-
-	req, err := http.NewRequest("POST", "http://evepraisal.com/estimate", bytes.NewBufferString(data.Encode()))
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Add("Content-Length", strconv.Itoa(len(data.Encode())))
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return 0, err
-	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return 0, err
-	}
-
-	reg := regexp.MustCompile("Result #([0-9]+)")
-	resultID := reg.FindStringSubmatch(string(body))
-
-	value, err := GetEvepraisalValue(fmt.Sprintf("http://evepraisal.com/e/%s.json", resultID[1]))
-	if err != nil {
-		return 0, err
-	}
-
-	return value, nil
-}
-
 func GenerateRandomString(length int) string {
 	chars := []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
 
@@ -122,57 +58,19 @@ func GenerateRandomString(length int) string {
 	return string(b)
 }
 
-func FetchCharacterAffiliation(v models.SSOVerification) (models.CharacterAffiliation, error) {
-	assocReq, err := http.NewRequest("GET", fmt.Sprintf("https://api.eveonline.com/eve/CharacterAffiliation.xml.aspx?ids=%d", v.CharacterID), nil)
-
-	client := &http.Client{}
-	assocResp, err := client.Do(assocReq)
-	if err != nil {
-		return models.CharacterAffiliation{}, err
-	}
-	defer assocResp.Body.Close()
-
-	assocBody, err := ioutil.ReadAll(assocResp.Body)
-	if err != nil {
-		return models.CharacterAffiliation{}, err
-	}
-
-	var a models.CharacterAffiliation
-
-	err = xml.Unmarshal(assocBody, &a)
-	if err != nil {
-		return models.CharacterAffiliation{}, err
-	}
-
-	return a, nil
+func SendJSONResponse(w http.ResponseWriter, response map[string]interface{}) {
+	SendJSONResponseWithStatus(w, http.StatusOK, response)
 }
 
-func FetchCorporationSheet(a models.CharacterAffiliation) (models.CorporationSheet, error) {
-	sheetReq, err := http.NewRequest("GET", fmt.Sprintf("https://api.eveonline.com/corp/CorporationSheet.xml.aspx?corporationID=%d", a.GetCorporationID()), nil)
-
-	client := &http.Client{}
-	sheetResp, err := client.Do(sheetReq)
-	if err != nil {
-		return models.CorporationSheet{}, err
-	}
-	defer sheetResp.Body.Close()
-
-	sheetBody, err := ioutil.ReadAll(sheetResp.Body)
-	if err != nil {
-		return models.CorporationSheet{}, err
-	}
-
-	var s models.CorporationSheet
+func SendJSONError(w http.ResponseWriter, status int, code ErrorCode, message string) {
+	response := make(map[string]interface{})
+	response["result"] = "error"
+	response["error"] = ErrorBody{Code: code, Message: message}
 
-	err = xml.Unmarshal(sheetBody, &s)
-	if err != nil {
-		return models.CorporationSheet{}, err
-	}
-
-	return s, nil
+	SendJSONResponseWithStatus(w, status, response)
 }
 
-func SendJSONResponse(w http.ResponseWriter, response map[string]interface{}) {
+func SendJSONResponseWithStatus(w http.ResponseWriter, status int, response interface{}) {
 	jsonResponse, err := json.Marshal(response)
 	if err != nil {
 		logger.Errorf("Failed to encode response to JSON: [%v]", err)
@@ -184,44 +82,52 @@ func SendJSONResponse(w http.ResponseWriter, response map[string]interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Content-Length", strconv.Itoa(len(jsonResponse)))
 
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(status)
 
 	w.Write(jsonResponse)
 }
 
-func ParseFleetCompositionRows(fleetID int64, rows []string) ([]*models.FleetMember, error) {
+func ParseFleetComposition(fleetID int64, raw string) ([]*models.FleetMember, []string, []string, error) {
 	var members []*models.FleetMember
+	var unmappedShips []string
+	var warnings []string
 
-	for _, row := range rows {
-		splitRow := strings.Split(row, "\t")
-		if len(splitRow) != 7 {
-			return members, fmt.Errorf("Invalid fleet composition row: %q", row)
-		}
+	result := composition.Parse(raw)
 
-		name := splitRow[0]
-		ship := splitRow[2]
-		fleetBoss := false
+	for _, warning := range result.Warnings {
+		warnings = append(warnings, warning.String())
+	}
+
+	for _, row := range result.Rows {
+		player, err := database.LoadPlayerFromName(row.Name)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				warnings = append(warnings, fmt.Sprintf("Line %d: Unknown player %q", row.Line, row.Name))
+				continue
+			}
 
-		if strings.Contains(splitRow[4], "(Boss)") {
-			fleetBoss = true
+			return members, unmappedShips, warnings, err
 		}
 
-		player, err := database.LoadPlayerFromName(name)
+		role, err := ParseFleetRole(row.Ship, row.Boss)
 		if err != nil {
-			return members, err
+			return members, unmappedShips, warnings, err
 		}
 
-		role, err := ParseFleetRole(ship, fleetBoss)
-		if err != nil {
-			return members, err
+		if role == models.FleetRoleNone {
+			if len(row.Ship) > 0 {
+				unmappedShips = append(unmappedShips, row.Ship)
+			} else {
+				warnings = append(warnings, fmt.Sprintf("Line %d: No ship listed for %q, pick a fleet role before finishing the fleet", row.Line, row.Name))
+			}
 		}
 
-		member := models.NewFleetMember(-1, fleetID, player, role, ship, 0, 1, 0, false, -1)
+		member := models.NewFleetMember(-1, fleetID, player, role, row.Ship, 0, 1, 0, false, -1, time.Now(), time.Time{})
 
 		members = append(members, member)
 	}
 
-	return members, nil
+	return members, unmappedShips, warnings, nil
 }
 
 func ParseFleetRole(ship string, fleetBoss bool) (models.FleetRole, error) {
@@ -240,3 +146,57 @@ func ParseFleetRole(ship string, fleetBoss bool) (models.FleetRole, error) {
 
 	return role, nil
 }
+
+func ParseShipRoles(raw string) ([]*models.ShipRole, error) {
+	var shipRoles []*models.ShipRole
+
+	for i, row := range strings.Split(strings.Replace(raw, "\r\n", "\n", -1), "\n") {
+		row = strings.TrimSpace(row)
+		if len(row) == 0 {
+			continue
+		}
+
+		separator := strings.LastIndexAny(row, "\t,;")
+		if separator < 0 {
+			return shipRoles, fmt.Errorf("Invalid ship role row %d, expected ship and role: %q", i+1, row)
+		}
+
+		ship := strings.TrimSpace(row[:separator])
+		if len(ship) == 0 {
+			return shipRoles, fmt.Errorf("Invalid ship role row %d, missing ship: %q", i+1, row)
+		}
+
+		role, err := ParseShipRoleValue(row[separator+1:])
+		if err != nil {
+			return shipRoles, fmt.Errorf("Invalid ship role row %d: [%v]", i+1, err)
+		}
+
+		shipRoles = append(shipRoles, models.NewShipRole(-1, ship, role))
+	}
+
+	if len(shipRoles) == 0 {
+		return shipRoles, fmt.Errorf("Import did not contain any ship roles")
+	}
+
+	return shipRoles, nil
+}
+
+func ParseShipRoleValue(raw string) (models.FleetRole, error) {
+	var role models.FleetRole
+
+	value, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+	if err == nil {
+		role = models.FleetRole(value)
+	} else {
+		role, err = models.ParseFleetRoleName(raw)
+		if err != nil {
+			return role, err
+		}
+	}
+
+	if !role.IsMappable() {
+		return role, fmt.Errorf("Fleet role %q cannot be mapped to ships", strings.TrimSpace(raw))
+	}
+
+	return role, nil
+}
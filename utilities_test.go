@@ -0,0 +1,55 @@
+// utilities_test
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/morpheusxaut/lootsheeter/models"
+)
+
+func TestParseFleetCompositionFlagsMembersWithoutRole(t *testing.T) {
+	db := openTestDatabase(t)
+
+	createTestPlayers(t, db, "Alice Adama", "Bob Bobsen", "Eve Evans")
+
+	members, unmappedShips, warnings, err := ParseFleetComposition(1, "Alice Adama\tTengu\nBob Bobsen\tHurricane\nEve Evans\n")
+	if err != nil {
+		t.Fatalf("Failed to parse fleet composition: [%v]", err)
+	}
+
+	roles := make(map[string]models.FleetRole)
+	for _, member := range members {
+		roles[member.Name] = member.Role
+	}
+
+	expectedRoles := map[string]models.FleetRole{
+		"Alice Adama": models.FleetRoleDPS,
+		"Bob Bobsen":  models.FleetRoleNone,
+		"Eve Evans":   models.FleetRoleNone,
+	}
+
+	if !reflect.DeepEqual(roles, expectedRoles) {
+		t.Errorf("Expected roles %v, got %v", expectedRoles, roles)
+	}
+
+	if !reflect.DeepEqual(unmappedShips, []string{"Hurricane"}) {
+		t.Errorf("Expected unmapped ship Hurricane, got %v", unmappedShips)
+	}
+
+	expectedWarnings := []string{`Line 3: No ship listed for "Eve Evans", pick a fleet role before finishing the fleet`}
+
+	if !reflect.DeepEqual(warnings, expectedWarnings) {
+		t.Errorf("Expected warnings %q, got %q", expectedWarnings, warnings)
+	}
+
+	fleet := models.NewFleet(-1, nil, "Test Fleet", "J123456", "", 0, 0, 0, time.Now(), time.Time{}, 0, false, "", -1, models.PayoutStrategyTypeSitePoints)
+	for _, member := range members {
+		fleet.AddMember(member)
+	}
+
+	if names := fleet.MembersWithoutRole(); !reflect.DeepEqual(names, []string{"Bob Bobsen", "Eve Evans"}) {
+		t.Errorf("Expected Bob Bobsen and Eve Evans without role, got %v", names)
+	}
+}
@@ -0,0 +1,632 @@
+// wallet
+package main
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/morpheusxaut/lootsheeter/models"
+)
+
+const (
+	walletJournalDivision  = 1
+	walletJournalTolerance = 1.0
+	walletJournalActor     = "Wallet journal import"
+	walletTransferLimit    = 50
+)
+
+var (
+	walletJournalFetcher    WalletJournalFetcher = NewESIWalletJournalFetcher(noESITokenSource{})
+	walletJournalImportLock sync.Mutex
+)
+
+type WalletJournalFetcher interface {
+	FetchWalletJournal(corporation *models.Corporation) (*models.WalletJournal, error)
+}
+
+type ESIWalletJournalFetcher struct {
+	Tokens ESITokenSource
+}
+
+func NewESIWalletJournalFetcher(tokens ESITokenSource) *ESIWalletJournalFetcher {
+	fetcher := &ESIWalletJournalFetcher{
+		Tokens: tokens,
+	}
+
+	return fetcher
+}
+
+func (fetcher *ESIWalletJournalFetcher) FetchWalletJournal(corporation *models.Corporation) (*models.WalletJournal, error) {
+	token, err := fetcher.Tokens.Token(corporation, SSOFeatureWallet)
+	if err != nil {
+		return &models.WalletJournal{}, err
+	}
+
+	entries, err := esiClient.CorporationWalletJournal(corporation.CorporationID, walletJournalDivision, ESICredentials(token))
+	if err != nil {
+		return &models.WalletJournal{}, err
+	}
+
+	var ids []int64
+
+	for _, entry := range entries {
+		if entry.RefType == "player_donation" && entry.SecondPartyID > 0 {
+			ids = append(ids, entry.SecondPartyID)
+		}
+	}
+
+	names, err := esiClient.Names(uniqueIDs(ids))
+	if err != nil {
+		return &models.WalletJournal{}, err
+	}
+
+	resolved := make(map[int64]string)
+	for _, name := range names {
+		resolved[name.ID] = name.Name
+	}
+
+	walletJournal := &models.WalletJournal{}
+
+	// The journal is converted to the rows of the legacy API so recorded responses can still be imported
+	for _, entry := range entries {
+		row := models.WalletJournalRow{
+			Date:       entry.Date.UTC().Format(models.WalletJournalTimeLayout),
+			RefID:      entry.ID,
+			OwnerID1:   entry.FirstPartyID,
+			OwnerName1: resolved[entry.FirstPartyID],
+			OwnerID2:   entry.SecondPartyID,
+			OwnerName2: resolved[entry.SecondPartyID],
+			Amount:     entry.Amount,
+			Balance:    entry.Balance,
+			Reason:     entry.Reason,
+		}
+
+		if entry.RefType == "player_donation" {
+			row.RefTypeID = models.WalletJournalRefTypePlayerDonation
+		}
+
+		walletJournal.Rows = append(walletJournal.Rows, row)
+	}
+
+	return walletJournal, nil
+}
+
+func uniqueIDs(ids []int64) []int64 {
+	var unique []int64
+
+	seen := make(map[int64]bool)
+
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+
+		seen[id] = true
+		unique = append(unique, id)
+	}
+
+	return unique
+}
+
+type RecordedWalletJournalFetcher struct {
+	Path string
+}
+
+func NewRecordedWalletJournalFetcher(path string) *RecordedWalletJournalFetcher {
+	fetcher := &RecordedWalletJournalFetcher{
+		Path: path,
+	}
+
+	return fetcher
+}
+
+func (fetcher *RecordedWalletJournalFetcher) FetchWalletJournal(corporation *models.Corporation) (*models.WalletJournal, error) {
+	xmlContent, err := ioutil.ReadFile(fetcher.Path)
+	if err != nil {
+		return &models.WalletJournal{}, err
+	}
+
+	return ParseWalletJournal(xmlContent)
+}
+
+func ParseWalletJournal(xmlContent []byte) (*models.WalletJournal, error) {
+	var walletJournal models.WalletJournal
+
+	err := xml.Unmarshal(xmlContent, &walletJournal)
+	if err != nil {
+		return &models.WalletJournal{}, err
+	}
+
+	if walletJournal.Error != nil {
+		return &models.WalletJournal{}, fmt.Errorf("EVE API returned error %d: %s", walletJournal.Error.Code, strings.TrimSpace(walletJournal.Error.Message))
+	}
+
+	return &walletJournal, nil
+}
+
+type WalletJournalImportResult struct {
+	Imported  int `json:"imported"`
+	Matched   int `json:"matched"`
+	Overpaid  int `json:"overpaid"`
+	Underpaid int `json:"underpaid"`
+	Unmatched int `json:"unmatched"`
+	Ambiguous int `json:"ambiguous"`
+}
+
+func (result *WalletJournalImportResult) Add(status models.WalletTransferStatus) {
+	result.Imported++
+
+	switch status {
+	case models.WalletTransferStatusMatched:
+		result.Matched++
+	case models.WalletTransferStatusOverpaid:
+		result.Overpaid++
+	case models.WalletTransferStatusUnderpaid:
+		result.Underpaid++
+	case models.WalletTransferStatusUnmatched:
+		result.Unmatched++
+	case models.WalletTransferStatusAmbiguous:
+		result.Ambiguous++
+	}
+}
+
+type WalletJournalImporter struct {
+	store   Store
+	fetcher WalletJournalFetcher
+}
+
+func NewWalletJournalImporter(store Store, fetcher WalletJournalFetcher) *WalletJournalImporter {
+	importer := &WalletJournalImporter{
+		store:   store,
+		fetcher: fetcher,
+	}
+
+	return importer
+}
+
+func (importer *WalletJournalImporter) ImportAll() error {
+	corporations, err := importer.store.LoadAllCorporations()
+	if err != nil {
+		return err
+	}
+
+	for _, corporation := range corporations {
+		result, err := importer.Import(corporation)
+		if errors.Is(err, ErrNoESIToken) {
+			logger.Debugf("Skipping wallet journal import for corporation #%d without ESI token...", corporation.ID)
+			continue
+		} else if err != nil {
+			logger.Errorf("Failed to import wallet journal for corporation #%d: [%v]", corporation.ID, err)
+			continue
+		}
+
+		logger.Infof("Imported %d wallet transfers for corporation #%d, %d matched, %d overpaid, %d underpaid, %d unmatched, %d ambiguous", result.Imported, corporation.ID, result.Matched, result.Overpaid, result.Underpaid, result.Unmatched, result.Ambiguous)
+	}
+
+	return nil
+}
+
+func (importer *WalletJournalImporter) Import(corporation *models.Corporation) (*WalletJournalImportResult, error) {
+	walletJournalImportLock.Lock()
+	defer walletJournalImportLock.Unlock()
+
+	result := &WalletJournalImportResult{}
+
+	walletJournal, err := importer.fetcher.FetchWalletJournal(corporation)
+	if err != nil {
+		return result, err
+	}
+
+	reports, err := importer.store.LoadAllReports(corporation.ID)
+	if err != nil {
+		return result, err
+	}
+
+	// Older reports are paid first when a player has several outstanding payouts
+	sort.Slice(reports, func(i, j int) bool { return reports[i].ID < reports[j].ID })
+
+	rows := walletJournal.Rows
+
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].RefID < rows[j].RefID })
+
+	for _, row := range rows {
+		if !row.IsOutgoingDonation() {
+			continue
+		}
+
+		imported, err := importer.store.IsWalletTransferImported(corporation.ID, row.RefID)
+		if err != nil {
+			return result, err
+		}
+
+		if imported {
+			continue
+		}
+
+		transfer := models.NewWalletTransfer(-1, corporation.ID, row.RefID, row.Time(), row.OwnerID2, row.OwnerName2, -row.Amount, row.Reason, -1, -1, 0, models.WalletTransferStatusUnmatched, false)
+
+		report, reportPayout := MatchWalletTransfer(reports, transfer)
+
+		// Only exact matches pay a payout, every other transfer is left for reconciliation
+		if reportPayout != nil && transfer.Status == models.WalletTransferStatusMatched {
+			completed, err := importer.completePayout(report.ID, reportPayout.ID, transfer)
+			if err != nil {
+				return result, err
+			}
+
+			reportPayout.PayoutComplete = true
+
+			if completed {
+				result.Add(transfer.Status)
+				continue
+			}
+
+			// The payout was paid by hand since the reports were loaded, so this transfer pays it twice
+			transfer.Status = models.WalletTransferStatusUnmatched
+		}
+
+		_, err = importer.store.SaveWalletTransfer(transfer)
+		if err != nil {
+			return result, err
+		}
+
+		result.Add(transfer.Status)
+	}
+
+	return result, nil
+}
+
+// completePayout marks the payout as paid together with saving the transfer, so a failed import never leaves a paid payout without the transfer that paid it
+func (importer *WalletJournalImporter) completePayout(reportID int64, reportPayoutID int64, transfer *models.WalletTransfer) (bool, error) {
+	reportLocks.Lock(reportID)
+	defer reportLocks.Unlock(reportID)
+
+	// The report is reloaded under the lock so payouts marked as paid or claimed in the meantime are kept
+	report, err := importer.store.LoadReport(reportID)
+	if err != nil {
+		return false, err
+	}
+
+	var reportPayout *models.ReportPayout
+
+	for _, payout := range report.Payouts {
+		if payout.ID == reportPayoutID {
+			reportPayout = payout
+			break
+		}
+	}
+
+	if reportPayout == nil {
+		return false, fmt.Errorf("Failed to find payout #%d in report #%d", reportPayoutID, reportID)
+	}
+
+	if reportPayout.PayoutComplete || report.PayoutComplete {
+		return false, nil
+	}
+
+	before := NewReportPayoutResponse(reportPayout)
+
+	reportPayout.PayoutComplete = true
+
+	err = importer.store.CompleteReportPayout(reportPayout, transfer)
+	if err != nil {
+		return false, err
+	}
+
+	RecordSystemAudit(importer.store, walletJournalActor, -1, report.ID, "playerpaid", before, NewReportPayoutResponse(reportPayout))
+
+	return true, nil
+}
+
+func MatchWalletTransfer(reports []*models.Report, transfer *models.WalletTransfer) (*models.Report, *models.ReportPayout) {
+	var candidateReport *models.Report
+	var candidate *models.ReportPayout
+
+	candidates := 0
+
+	for _, report := range reports {
+		if report.PayoutComplete {
+			continue
+		}
+
+		for _, reportPayout := range report.Payouts {
+			if reportPayout.PayoutComplete || reportPayout.Payout <= 0 || reportPayout.Player.PlayerID != transfer.RecipientID {
+				continue
+			}
+
+			if math.Abs(reportPayout.Payout-transfer.Amount) <= walletJournalTolerance {
+				linkWalletTransfer(transfer, report, reportPayout, models.WalletTransferStatusMatched)
+
+				return report, reportPayout
+			}
+
+			candidates++
+
+			if candidate == nil {
+				candidateReport = report
+				candidate = reportPayout
+			}
+		}
+	}
+
+	if candidate == nil {
+		transfer.Status = models.WalletTransferStatusUnmatched
+
+		return nil, nil
+	}
+
+	// With several open payouts it cannot be told which one the transfer was meant for
+	if candidates > 1 {
+		transfer.Status = models.WalletTransferStatusAmbiguous
+
+		return nil, nil
+	}
+
+	if transfer.Amount > candidate.Payout {
+		linkWalletTransfer(transfer, candidateReport, candidate, models.WalletTransferStatusOverpaid)
+	} else {
+		linkWalletTransfer(transfer, candidateReport, candidate, models.WalletTransferStatusUnderpaid)
+	}
+
+	return candidateReport, candidate
+}
+
+func linkWalletTransfer(transfer *models.WalletTransfer, report *models.Report, reportPayout *models.ReportPayout, status models.WalletTransferStatus) {
+	transfer.ReportID = report.ID
+	transfer.ReportPayoutID = reportPayout.ID
+	transfer.ExpectedAmount = reportPayout.Payout
+	transfer.Status = status
+}
+
+func RunWalletCommand(db *Database, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("Missing wallet command, expected import [<corporation ID> <file>]")
+	}
+
+	current, err := db.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	if current < LatestSchemaVersion() {
+		return fmt.Errorf("Database schema is at version %d, run migrate before importing wallet journals", current)
+	}
+
+	switch args[0] {
+	case "import":
+		if len(args) == 1 {
+			return NewWalletJournalImporter(db, walletJournalFetcher).ImportAll()
+		}
+
+		if len(args) < 3 {
+			return fmt.Errorf("Missing file for wallet import of corporation %s", args[1])
+		}
+
+		corporationID, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("Invalid corporation ID %q: [%v]", args[1], err)
+		}
+
+		corporations, err := db.LoadAllCorporations()
+		if err != nil {
+			return err
+		}
+
+		for _, corporation := range corporations {
+			if corporation.CorporationID != corporationID {
+				continue
+			}
+
+			result, err := NewWalletJournalImporter(db, NewRecordedWalletJournalFetcher(args[2])).Import(corporation)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Imported wallet transfers: %d\n", result.Imported)
+			fmt.Printf("  Matched:   %d\n", result.Matched)
+			fmt.Printf("  Overpaid:  %d\n", result.Overpaid)
+			fmt.Printf("  Underpaid: %d\n", result.Underpaid)
+			fmt.Printf("  Unmatched: %d\n", result.Unmatched)
+			fmt.Printf("  Ambiguous: %d\n", result.Ambiguous)
+
+			return nil
+		}
+
+		return fmt.Errorf("Failed to find corporation with ID %d", corporationID)
+	default:
+		return fmt.Errorf("Unknown wallet command %q, expected import [<corporation ID> <file>]", args[0])
+	}
+}
+
+func ReconciliationGetHandler(w http.ResponseWriter, r *http.Request) {
+	loggedIn := session.IsLoggedIn(w, r)
+
+	if !loggedIn {
+		session.SetLoginRedirect(w, r, "/reports/reconciliation")
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	if !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
+		http.Redirect(w, r, "/reports", http.StatusSeeOther)
+		return
+	}
+
+	data := make(map[string]interface{})
+
+	data["PageTitle"] = "Payout Reconciliation"
+	data["PageType"] = 4
+	data["LoggedIn"] = loggedIn
+
+	corporation, err := database.LoadCorporation(session.GetCorpID(r))
+	if err != nil {
+		logger.Errorf("Failed to load corporation in ReconciliationGetHandler: [%v]", err)
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	unresolved, err := database.LoadUnresolvedWalletTransfers(corporation.ID)
+	if err != nil {
+		logger.Errorf("Failed to load unresolved wallet transfers in ReconciliationGetHandler: [%v]", err)
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	transfers, err := database.LoadAllWalletTransfers(corporation.ID, walletTransferLimit)
+	if err != nil {
+		logger.Errorf("Failed to load wallet transfers in ReconciliationGetHandler: [%v]", err)
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	hasESIToken, err := HasCorporationESIToken(database, corporation, SSOFeatureWallet)
+	if err != nil {
+		logger.Errorf("Failed to check ESI tokens in ReconciliationGetHandler: [%v]", err)
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data["HasESIToken"] = hasESIToken
+	data["UnresolvedTransfers"] = unresolved
+	data["WalletTransfers"] = transfers
+
+	err = templates.Funcs(TemplateFunctions(r)).ExecuteTemplate(w, "reconciliation", data)
+	if err != nil {
+		logger.Errorf("Failed to execute template in ReconciliationGetHandler: [%v]", err)
+	}
+}
+
+func ReconciliationPostHandler(w http.ResponseWriter, r *http.Request) {
+	response := make(map[string]interface{})
+
+	loggedIn := session.IsLoggedIn(w, r)
+
+	if !loggedIn {
+		SendJSONError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Not logged in")
+		return
+	}
+
+	if !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
+		logger.Warnf("Received request to ReconciliationPostHandler without proper access...")
+
+		SendJSONError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask")
+		return
+	}
+
+	corporation, err := database.LoadCorporation(session.GetCorpID(r))
+	if err != nil {
+		logger.Errorf("Failed to load corporation in ReconciliationPostHandler: [%v]", err)
+
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to load corporation")
+		return
+	}
+
+	result, err := NewWalletJournalImporter(database, walletJournalFetcher).Import(corporation)
+	if errors.Is(err, ErrNoESIToken) {
+		SendJSONError(w, http.StatusConflict, ErrorCodeConflict, "No officer has authorised access to the corporation wallet via ESI")
+		return
+	} else if err != nil {
+		logger.Errorf("Failed to import wallet journal in ReconciliationPostHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusBadGateway, ErrorCodeInternal, fmt.Sprintf("Failed to import wallet journal: %v", err))
+		return
+	}
+
+	response["result"] = "success"
+	response["error"] = nil
+	response["import"] = result
+
+	SendJSONResponse(w, response)
+}
+
+func ReconciliationPutHandler(w http.ResponseWriter, r *http.Request) {
+	response := make(map[string]interface{})
+
+	vars := mux.Vars(r)
+	transferID, err := strconv.ParseInt(vars["transferid"], 10, 64)
+	if err != nil {
+		logger.Errorf("Failed to parse wallet transfer ID %q in ReconciliationPutHandler: [%v]", vars["transferid"], err)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "Failed to parse wallet transfer ID")
+		return
+	}
+
+	loggedIn := session.IsLoggedIn(w, r)
+
+	if !loggedIn {
+		SendJSONError(w, http.StatusUnauthorized, ErrorCodeUnauthorised, "Not logged in")
+		return
+	}
+
+	if !HasHigherAccessMask(r, models.AccessMaskPayoutOfficer) {
+		logger.Warnf("Received request to ReconciliationPutHandler without proper access...")
+
+		SendJSONError(w, http.StatusForbidden, ErrorCodeForbidden, "Unauthorised access: cannot perform this operation with your current access mask")
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		logger.Errorf("Failed to parse form in ReconciliationPutHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error())
+		return
+	}
+
+	transfer, err := database.LoadWalletTransfer(session.GetCorpID(r), transferID)
+	if err != nil {
+		logger.Errorf("Failed to load wallet transfer in ReconciliationPutHandler: [%v]", err)
+
+		status, code := ErrorStatus(err)
+		SendJSONError(w, status, code, "Failed to load wallet transfer")
+		return
+	}
+
+	before := NewWalletTransferResponse(transfer)
+
+	command := r.FormValue("command")
+
+	switch strings.ToLower(command) {
+	case "resolve":
+		transfer.Resolved = true
+	case "reopen":
+		transfer.Resolved = false
+	default:
+		logger.Errorf("Received unknown command %q in ReconciliationPutHandler...", command)
+
+		SendJSONError(w, http.StatusBadRequest, ErrorCodeInvalidCommand, fmt.Sprintf("Unknown command %q", command))
+		return
+	}
+
+	transfer, err = database.SaveWalletTransfer(transfer)
+	if err != nil {
+		logger.Errorf("Failed to save wallet transfer in ReconciliationPutHandler: [%v]", err)
+
+		SendJSONError(w, http.StatusInternalServerError, ErrorCodeInternal, "Failed to save wallet transfer")
+		return
+	}
+
+	if transfer.ReportID > 0 {
+		RecordAudit(r, -1, transfer.ReportID, strings.ToLower(command)+"transfer", before, NewWalletTransferResponse(transfer))
+	}
+
+	response["result"] = "success"
+	response["error"] = nil
+	response["walletTransfer"] = NewWalletTransferResponse(transfer)
+
+	SendJSONResponse(w, response)
+}
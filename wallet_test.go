@@ -0,0 +1,505 @@
+// wallet_test
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/morpheusxaut/lootsheeter/esi"
+	"github.com/morpheusxaut/lootsheeter/models"
+)
+
+type staticWalletJournalFetcher struct {
+	journal *models.WalletJournal
+}
+
+func (fetcher *staticWalletJournalFetcher) FetchWalletJournal(corporation *models.Corporation) (*models.WalletJournal, error) {
+	return fetcher.journal, nil
+}
+
+// racingStore runs a callback after the importer loaded the reports, standing in for an officer editing a report concurrently
+type racingStore struct {
+	*Database
+	afterLoadAllReports func()
+}
+
+func (store *racingStore) LoadAllReports(corporationID int64) ([]*models.Report, error) {
+	reports, err := store.Database.LoadAllReports(corporationID)
+
+	if store.afterLoadAllReports != nil {
+		store.afterLoadAllReports()
+	}
+
+	return reports, err
+}
+
+// forgetfulStore claims no transfer was imported yet, so saving an already imported transfer fails on its reference ID
+type forgetfulStore struct {
+	*Database
+}
+
+func (store *forgetfulStore) IsWalletTransferImported(corporationID int64, refID int64) (bool, error) {
+	return false, nil
+}
+
+type staticESITokenSource struct {
+	token string
+}
+
+func (source staticESITokenSource) Token(corporation *models.Corporation, feature string) (*models.ESIToken, error) {
+	return models.NewESIToken(-1, -1, 90000001, nil, source.token, "", time.Now().Add(time.Hour), true, time.Now()), nil
+}
+
+// setupRecordedESI serves recorded ESI responses from testdata/esi and points the ESI client at them for the duration of the test
+func setupRecordedESI(t *testing.T, token string, responses map[string]string) {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, ok := responses[r.URL.Path]
+		if !ok {
+			t.Errorf("Unexpected ESI request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+			return
+		}
+
+		if r.Method == http.MethodGet && r.Header.Get("Authorization") != "Bearer "+token {
+			t.Errorf("Expected ESI request %s to be authorised with %q, got %q", r.URL.Path, token, r.Header.Get("Authorization"))
+		}
+
+		content, err := ioutil.ReadFile(filepath.Join("testdata", "esi", file))
+		if err != nil {
+			t.Errorf("Failed to read recorded ESI response %q: [%v]", file, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Pages", "1")
+		w.Write(content)
+	}))
+
+	previous := esiClient
+	esiClient = esi.NewClient(server.URL, "lootsheeter test")
+
+	t.Cleanup(func() {
+		esiClient = previous
+		server.Close()
+	})
+}
+
+func walletDonation(refID int64, recipient *models.Player, amount float64) models.WalletJournalRow {
+	return models.WalletJournalRow{
+		Date:       "2026-10-10 20:00:00",
+		RefID:      refID,
+		RefTypeID:  models.WalletJournalRefTypePlayerDonation,
+		OwnerID1:   98000001,
+		OwnerName1: "Test Corporation",
+		OwnerID2:   recipient.PlayerID,
+		OwnerName2: recipient.Name,
+		Amount:     -amount,
+	}
+}
+
+func TestWalletImportKeepsConcurrentPayoutChanges(t *testing.T) {
+	db := openTestDatabase(t)
+
+	corporation, players := createTestPlayers(t, db, "Alice", "Bob", "Carol")
+
+	report := models.NewReport(-1, 0, time.Now().Add(-time.Hour), time.Now(), false, corporation, players[0], nil)
+	report.Payouts["Alice"] = models.NewReportPayout(-1, -1, players[0], 1000000, false, nil)
+	report.Payouts["Bob"] = models.NewReportPayout(-1, -1, players[1], 2000000, false, nil)
+	report.Payouts["Carol"] = models.NewReportPayout(-1, -1, players[2], 3000000, false, nil)
+
+	report, err := db.SaveReport(report)
+	if err != nil {
+		t.Fatalf("Failed to save report: [%v]", err)
+	}
+
+	store := &racingStore{Database: db}
+	store.afterLoadAllReports = func() {
+		concurrent, err := db.LoadReport(report.ID)
+		if err != nil {
+			t.Fatalf("Failed to load report concurrently: [%v]", err)
+		}
+
+		// Bob is marked as paid by hand and Carol's payout is corrected and claimed by a payer after the import loaded the reports
+		concurrent.Payouts["Bob"].PayoutComplete = true
+		concurrent.Payouts["Carol"].Payout = 3500000
+		concurrent.Payouts["Carol"].Payer = players[0]
+
+		_, err = db.SaveReport(concurrent)
+		if err != nil {
+			t.Fatalf("Failed to save report concurrently: [%v]", err)
+		}
+	}
+
+	journal := &models.WalletJournal{
+		Rows: []models.WalletJournalRow{
+			walletDonation(1, players[0], 1000000),
+			walletDonation(2, players[1], 2000000),
+		},
+	}
+
+	result, err := NewWalletJournalImporter(store, &staticWalletJournalFetcher{journal: journal}).Import(corporation)
+	if err != nil {
+		t.Fatalf("Failed to import wallet journal: [%v]", err)
+	}
+
+	if result.Imported != 2 || result.Matched != 1 || result.Unmatched != 1 {
+		t.Errorf("Expected 2 imported transfers, 1 matched and 1 unmatched, got %+v", result)
+	}
+
+	loaded, err := reopenTestDatabase(t, db).LoadReport(report.ID)
+	if err != nil {
+		t.Fatalf("Failed to load report: [%v]", err)
+	}
+
+	if !loaded.Payouts["Alice"].PayoutComplete {
+		t.Errorf("Expected payout of Alice to be completed by the import")
+	}
+
+	if !loaded.Payouts["Bob"].PayoutComplete {
+		t.Errorf("Expected payout of Bob marked as paid by hand to stay complete")
+	}
+
+	carol := loaded.Payouts["Carol"]
+	if carol.PayoutComplete || carol.Payout != 3500000 || !carol.HasPayer() || carol.Payer.ID != players[0].ID {
+		t.Errorf("Expected corrected payout of Carol to stay open with its amount and payer, got %+v", carol)
+	}
+
+	unresolved, err := db.LoadUnresolvedWalletTransfers(corporation.ID)
+	if err != nil {
+		t.Fatalf("Failed to load unresolved wallet transfers: [%v]", err)
+	}
+
+	if len(unresolved) != 1 || unresolved[0].RecipientID != players[1].PlayerID {
+		t.Errorf("Expected the second payment to Bob to need review, got %d unresolved transfers", len(unresolved))
+	}
+}
+
+func TestWalletImportKeepsPayoutOpenWhenTransferFails(t *testing.T) {
+	db := openTestDatabase(t)
+
+	corporation, players := createTestPlayers(t, db, "Alice")
+
+	report := models.NewReport(-1, 0, time.Now().Add(-time.Hour), time.Now(), false, corporation, players[0], nil)
+	report.Payouts["Alice"] = models.NewReportPayout(-1, -1, players[0], 1000000, false, nil)
+
+	report, err := db.SaveReport(report)
+	if err != nil {
+		t.Fatalf("Failed to save report: [%v]", err)
+	}
+
+	_, err = db.SaveWalletTransfer(models.NewWalletTransfer(-1, corporation.ID, 1, time.Now(), players[0].PlayerID, players[0].Name, 500000, "", -1, -1, 0, models.WalletTransferStatusUnmatched, true))
+	if err != nil {
+		t.Fatalf("Failed to save wallet transfer: [%v]", err)
+	}
+
+	journal := &models.WalletJournal{
+		Rows: []models.WalletJournalRow{
+			walletDonation(1, players[0], 1000000),
+		},
+	}
+
+	_, err = NewWalletJournalImporter(&forgetfulStore{Database: db}, &staticWalletJournalFetcher{journal: journal}).Import(corporation)
+	if err == nil {
+		t.Fatalf("Expected saving the duplicate wallet transfer to fail")
+	}
+
+	loaded, err := reopenTestDatabase(t, db).LoadReport(report.ID)
+	if err != nil {
+		t.Fatalf("Failed to load report: [%v]", err)
+	}
+
+	if loaded.Payouts["Alice"].PayoutComplete {
+		t.Errorf("Expected payout of Alice to stay open when its transfer could not be saved")
+	}
+}
+
+func TestESIWalletJournalFetcher(t *testing.T) {
+	db := openTestDatabase(t)
+
+	corporation, players := createTestPlayers(t, db, "Alice", "Bob")
+
+	setupRecordedESI(t, "esi-access", map[string]string{
+		"/corporations/98000001/wallets/1/journal/": "corporation_walletjournal.json",
+		"/universe/names/":                          "universe_names.json",
+	})
+
+	fetcher := NewESIWalletJournalFetcher(staticESITokenSource{token: "esi-access"})
+
+	walletJournal, err := fetcher.FetchWalletJournal(corporation)
+	if err != nil {
+		t.Fatalf("Failed to fetch wallet journal: [%v]", err)
+	}
+
+	if len(walletJournal.Rows) != 4 {
+		t.Fatalf("Expected 4 wallet journal rows, got %d", len(walletJournal.Rows))
+	}
+
+	expected := models.WalletJournalRow{
+		Date:       "2026-10-10 20:10:14",
+		RefID:      13700000001,
+		RefTypeID:  models.WalletJournalRefTypePlayerDonation,
+		OwnerID1:   98000001,
+		OwnerName1: "Test Corporation",
+		OwnerID2:   90000001,
+		OwnerName2: "Alice",
+		Amount:     -1000000,
+		Balance:    82549999.5,
+		Reason:     "Payout report #1",
+	}
+
+	if !reflect.DeepEqual(walletJournal.Rows[2], expected) {
+		t.Errorf("Expected wallet journal row %+v, got %+v", expected, walletJournal.Rows[2])
+	}
+
+	var outgoing []string
+
+	for _, row := range walletJournal.Rows {
+		if row.IsOutgoingDonation() {
+			outgoing = append(outgoing, row.OwnerName2)
+		}
+	}
+
+	sort.Strings(outgoing)
+
+	if !reflect.DeepEqual(outgoing, []string{"Alice", "Bob"}) {
+		t.Errorf("Expected outgoing donations to Alice and Bob, got %v", outgoing)
+	}
+
+	report := models.NewReport(-1, 0, time.Now().Add(-time.Hour), time.Now(), false, corporation, players[0], nil)
+	report.Payouts["Alice"] = models.NewReportPayout(-1, -1, players[0], 1000000, false, nil)
+	report.Payouts["Bob"] = models.NewReportPayout(-1, -1, players[1], 2000000, false, nil)
+
+	_, err = db.SaveReport(report)
+	if err != nil {
+		t.Fatalf("Failed to save report: [%v]", err)
+	}
+
+	result, err := NewWalletJournalImporter(db, fetcher).Import(corporation)
+	if err != nil {
+		t.Fatalf("Failed to import wallet journal: [%v]", err)
+	}
+
+	if result.Imported != 2 || result.Matched != 2 {
+		t.Errorf("Expected 2 imported and matched transfers, got %+v", result)
+	}
+}
+
+func TestParseWalletJournal(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		content   string
+		rows      int
+		donations int
+		err       bool
+	}{
+		{name: "recorded journal", path: "walletjournal.xml", rows: 8, donations: 6},
+		{name: "empty rowset", content: `<eveapi version="2"><result><rowset name="entries" key="refID"></rowset></result></eveapi>`},
+		{name: "api error", content: `<eveapi version="2"><error code="200">Current security level not high enough.</error></eveapi>`, err: true},
+		{name: "malformed", content: `<eveapi version="2"><result>`, err: true},
+	}
+
+	for _, test := range tests {
+		content := []byte(test.content)
+
+		if len(test.path) > 0 {
+			recorded, err := ioutil.ReadFile(filepath.Join("testdata", test.path))
+			if err != nil {
+				t.Fatalf("%s: Failed to read recorded wallet journal: [%v]", test.name, err)
+			}
+
+			content = recorded
+		}
+
+		journal, err := ParseWalletJournal(content)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: Expected error, got %d rows", test.name, len(journal.Rows))
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("%s: Failed to parse wallet journal: [%v]", test.name, err)
+		}
+
+		checkWalletJournal(t, test.name, journal, test.rows, test.donations)
+	}
+}
+
+func checkWalletJournal(t *testing.T, name string, journal *models.WalletJournal, rows int, donations int) {
+	t.Helper()
+
+	if len(journal.Rows) != rows {
+		t.Errorf("%s: Expected %d rows, got %d", name, rows, len(journal.Rows))
+	}
+
+	outgoing := 0
+
+	for _, row := range journal.Rows {
+		if row.IsOutgoingDonation() {
+			outgoing++
+		}
+	}
+
+	if outgoing != donations {
+		t.Errorf("%s: Expected %d outgoing donations, got %d", name, donations, outgoing)
+	}
+
+	if rows == 0 {
+		return
+	}
+
+	last := journal.Rows[len(journal.Rows)-1]
+	if last.RefID != 13700000001 || last.OwnerID2 != 90000001 || last.OwnerName2 != "Alice" || last.Amount != -1000000 {
+		t.Errorf("%s: Unexpected last row %+v", name, last)
+	}
+
+	if !last.Time().Equal(time.Date(2026, 10, 10, 20, 10, 14, 0, time.UTC)) {
+		t.Errorf("%s: Unexpected time of last row %v", name, last.Time())
+	}
+}
+
+func TestMatchWalletTransfer(t *testing.T) {
+	alice := models.NewPlayer(1, 90000001, "Alice", nil, models.AccessMaskMember, true)
+	bob := models.NewPlayer(2, 90000002, "Bob", nil, models.AccessMaskMember, true)
+	carol := models.NewPlayer(3, 90000003, "Carol", nil, models.AccessMaskMember, true)
+
+	newReports := func() []*models.Report {
+		older := models.NewReport(1, 0, time.Time{}, time.Time{}, false, nil, alice, nil)
+		older.Payouts["Alice"] = models.NewReportPayout(11, 1, alice, 1000000, false, nil)
+		older.Payouts["Bob"] = models.NewReportPayout(12, 1, bob, 2000000, false, nil)
+		older.Payouts["Carol"] = models.NewReportPayout(13, 1, carol, 500000, true, nil)
+
+		newer := models.NewReport(2, 0, time.Time{}, time.Time{}, false, nil, alice, nil)
+		newer.Payouts["Alice"] = models.NewReportPayout(21, 2, alice, 1000000, false, nil)
+		newer.Payouts["Bob"] = models.NewReportPayout(22, 2, bob, 3000000, false, nil)
+		newer.Payouts["Carol"] = models.NewReportPayout(23, 2, carol, 750000, false, nil)
+
+		closed := models.NewReport(3, 0, time.Time{}, time.Time{}, true, nil, alice, nil)
+		closed.Payouts["Carol"] = models.NewReportPayout(31, 3, carol, 900000, false, nil)
+
+		return []*models.Report{older, newer, closed}
+	}
+
+	tests := []struct {
+		name      string
+		recipient *models.Player
+		amount    float64
+		status    models.WalletTransferStatus
+		payoutID  int64
+	}{
+		{"exact match pays oldest report", alice, 1000000, models.WalletTransferStatusMatched, 11},
+		{"within tolerance below", alice, 999999, models.WalletTransferStatusMatched, 11},
+		{"within tolerance above", bob, 3000001, models.WalletTransferStatusMatched, 22},
+		{"outside tolerance below", carol, 749998.99, models.WalletTransferStatusUnderpaid, 23},
+		{"outside tolerance above", carol, 750001.01, models.WalletTransferStatusOverpaid, 23},
+		{"overpaid", carol, 1000000, models.WalletTransferStatusOverpaid, 23},
+		{"underpaid", carol, 100000, models.WalletTransferStatusUnderpaid, 23},
+		{"several open payouts", bob, 2500000, models.WalletTransferStatusAmbiguous, -1},
+		{"no open payout", models.NewPlayer(4, 90000004, "Dave", nil, models.AccessMaskMember, true), 1000000, models.WalletTransferStatusUnmatched, -1},
+	}
+
+	for _, test := range tests {
+		transfer := models.NewWalletTransfer(-1, 1, 1, time.Time{}, test.recipient.PlayerID, test.recipient.Name, test.amount, "", -1, -1, 0, models.WalletTransferStatusUnmatched, false)
+
+		_, reportPayout := MatchWalletTransfer(newReports(), transfer)
+
+		if transfer.Status != test.status {
+			t.Errorf("%s: Expected status %v, got %v", test.name, test.status, transfer.Status)
+		}
+
+		if test.payoutID < 0 {
+			if reportPayout != nil || transfer.ReportPayoutID != -1 {
+				t.Errorf("%s: Expected no payout, got #%d", test.name, transfer.ReportPayoutID)
+			}
+
+			continue
+		}
+
+		if reportPayout == nil || reportPayout.ID != test.payoutID || transfer.ReportPayoutID != test.payoutID {
+			t.Errorf("%s: Expected payout #%d, got #%d", test.name, test.payoutID, transfer.ReportPayoutID)
+			continue
+		}
+
+		if transfer.ExpectedAmount != reportPayout.Payout || transfer.ReportID != reportPayout.ReportID {
+			t.Errorf("%s: Expected transfer linked to payout #%d of report #%d, got %+v", test.name, reportPayout.ID, reportPayout.ReportID, transfer)
+		}
+	}
+}
+
+func TestWalletImportRecordedJournal(t *testing.T) {
+	db := openTestDatabase(t)
+
+	corporation, players := createTestPlayers(t, db, "Alice", "Bob", "Carol", "Dave", "Eve", "Frank")
+
+	older := models.NewReport(-1, 0, time.Now().Add(-48*time.Hour), time.Now().Add(-24*time.Hour), false, corporation, players[0], nil)
+	older.Payouts["Alice"] = models.NewReportPayout(-1, -1, players[0], 1000000, false, nil)
+	older.Payouts["Bob"] = models.NewReportPayout(-1, -1, players[1], 2000000, false, nil)
+	older.Payouts["Carol"] = models.NewReportPayout(-1, -1, players[2], 3000000, false, nil)
+	older.Payouts["Dave"] = models.NewReportPayout(-1, -1, players[3], 1000000, false, nil)
+	older.Payouts["Frank"] = models.NewReportPayout(-1, -1, players[5], 1000000, false, nil)
+
+	newer := models.NewReport(-1, 0, time.Now().Add(-24*time.Hour), time.Now(), false, corporation, players[0], nil)
+	newer.Payouts["Frank"] = models.NewReportPayout(-1, -1, players[5], 2000000, false, nil)
+
+	for _, report := range []*models.Report{older, newer} {
+		_, err := db.SaveReport(report)
+		if err != nil {
+			t.Fatalf("Failed to save report: [%v]", err)
+		}
+	}
+
+	importer := NewWalletJournalImporter(db, NewRecordedWalletJournalFetcher(filepath.Join("testdata", "walletjournal.xml")))
+
+	result, err := importer.Import(corporation)
+	if err != nil {
+		t.Fatalf("Failed to import wallet journal: [%v]", err)
+	}
+
+	expected := WalletJournalImportResult{Imported: 6, Matched: 2, Overpaid: 1, Underpaid: 1, Unmatched: 1, Ambiguous: 1}
+	if *result != expected {
+		t.Errorf("Expected import result %+v, got %+v", expected, *result)
+	}
+
+	result, err = importer.Import(corporation)
+	if err != nil {
+		t.Fatalf("Failed to import wallet journal again: [%v]", err)
+	}
+
+	if result.Imported != 0 {
+		t.Errorf("Expected already imported transfers to be skipped, got %d imported", result.Imported)
+	}
+
+	loaded, err := reopenTestDatabase(t, db).LoadReport(older.ID)
+	if err != nil {
+		t.Fatalf("Failed to load report: [%v]", err)
+	}
+
+	paid := map[string]bool{"Alice": true, "Bob": true, "Carol": false, "Dave": false, "Frank": false}
+
+	for name, complete := range paid {
+		if loaded.Payouts[name].PayoutComplete != complete {
+			t.Errorf("Expected payout of %s to be complete %v, got %v", name, complete, loaded.Payouts[name].PayoutComplete)
+		}
+	}
+
+	unresolved, err := db.LoadUnresolvedWalletTransfers(corporation.ID)
+	if err != nil {
+		t.Fatalf("Failed to load unresolved wallet transfers: [%v]", err)
+	}
+
+	if len(unresolved) != 4 {
+		t.Errorf("Expected 4 transfers to need review, got %d", len(unresolved))
+	}
+}
@@ -0,0 +1,144 @@
+var apiExplorerMethodLabels = {
+	"get": "label-primary",
+	"post": "label-success",
+	"put": "label-warning",
+	"delete": "label-danger"
+};
+
+function apiExplorerResolve(spec, schema) {
+	if (schema && schema.$ref) {
+		return spec.components.schemas[schema.$ref.replace('#/components/schemas/', '')];
+	}
+	if (schema && schema.allOf) {
+		return apiExplorerResolve(spec, schema.allOf[0]);
+	}
+	return schema;
+}
+
+function apiExplorerExample(spec, schema, depth) {
+	schema = apiExplorerResolve(spec, schema);
+	if (!schema || depth > 4) {
+		return null;
+	}
+
+	switch (schema.type) {
+		case "object":
+			var example = {};
+			$.each(schema.properties || {}, function(name, property) {
+				example[name] = apiExplorerExample(spec, property, depth + 1);
+			});
+			return example;
+		case "array":
+			return [apiExplorerExample(spec, schema.items, depth + 1)];
+		case "integer":
+		case "number":
+			return 0;
+		case "boolean":
+			return false;
+		case "string":
+			return schema.format === "date-time" ? new Date().toISOString() : "";
+		default:
+			return null;
+	}
+}
+
+function apiExplorerRender(spec) {
+	var container = $('#apiExplorerOperations');
+	var index = 0;
+
+	$.each(Object.keys(spec.paths).sort(), function(i, path) {
+		$.each(spec.paths[path], function(method, operation) {
+			var id = 'apiExplorerOperation' + (index++);
+			var panel = $('<div class="panel panel-default"></div>');
+			var heading = $('<div class="panel-heading"></div>');
+			var body = $('<div class="panel-body collapse"></div>').attr('id', id);
+
+			heading.append($('<span class="label"></span>').addClass(apiExplorerMethodLabels[method] || 'label-default').text(method.toUpperCase()));
+			heading.append(' ');
+			heading.append($('<a data-toggle="collapse"></a>').attr('href', '#' + id).append($('<code></code>').text(path)));
+			heading.append(' ');
+			heading.append($('<span class="text-muted"></span>').text(operation.summary));
+
+			$.each(operation.parameters || [], function(j, parameter) {
+				var group = $('<div class="form-group"></div>');
+				group.append($('<label class="control-label"></label>').text(parameter.name + ' (' + parameter.in + ')'));
+				group.append($('<input type="text" class="form-control">').attr('api-parameter', parameter.name).attr('api-parameter-in', parameter.in));
+				body.append(group);
+			});
+
+			if (operation.requestBody) {
+				var schema = operation.requestBody.content["application/json"].schema;
+				var group = $('<div class="form-group"></div>');
+				group.append($('<label class="control-label">Request Body</label>'));
+				group.append($('<textarea class="form-control" rows="8" api-body></textarea>').val(JSON.stringify(apiExplorerExample(spec, schema, 0), null, 2)));
+				body.append(group);
+			}
+
+			var send = $('<a class="btn btn-success">Send</a>');
+			var result = $('<pre class="collapse"></pre>');
+
+			send.click(function() {
+				var url = path;
+				var query = [];
+
+				body.find('input[api-parameter]').each(function() {
+					var value = $.trim($(this).val());
+					if ($(this).attr('api-parameter-in') === 'path') {
+						url = url.replace('{' + $(this).attr('api-parameter') + '}', encodeURIComponent(value));
+					} else if (value.length > 0) {
+						query.push(encodeURIComponent($(this).attr('api-parameter')) + '=' + encodeURIComponent(value));
+					}
+				});
+
+				if (query.length > 0) {
+					url += '?' + query.join('&');
+				}
+
+				$.ajax({
+					cache: false,
+					contentType: "application/json",
+					data: body.find('textarea[api-body]').val(),
+					headers: { "Authorization": "Bearer " + $.trim($('#apiExplorerToken').val()) },
+					complete: function(jqXHR) {
+						var text = jqXHR.responseText;
+						try {
+							text = JSON.stringify(JSON.parse(text), null, 2);
+						} catch (e) {
+						}
+						result.text(jqXHR.status + ' ' + jqXHR.statusText + '\n\n' + (text || '')).collapse('show');
+					},
+					timeout: 10000,
+					type: method.toUpperCase(),
+					url: url
+				});
+			});
+
+			body.append($('<p></p>').append(send));
+			body.append(result);
+
+			panel.append(heading);
+			panel.append(body);
+			container.append(panel);
+		});
+	});
+}
+
+$(document).ready(function(e) {
+	$('#apiExplorerToken').val(sessionStorage.getItem('apiExplorerToken') || '');
+	$('#apiExplorerToken').change(function() {
+		sessionStorage.setItem('apiExplorerToken', $.trim($(this).val()));
+	});
+
+	$.ajax({
+		accepts: "application/json",
+		cache: false,
+		dataType: "json",
+		error: displayAjaxError,
+		success: function(reply) {
+			apiExplorerRender(reply);
+		},
+		timeout: 10000,
+		type: "GET",
+		url: '/api/openapi.json'
+	});
+});
@@ -0,0 +1,42 @@
+$(document).ready(function(e) {
+	$('a.create-api-token-submit').click(function() {
+		$.ajax({
+			accepts: "application/json",
+			cache: false,
+			data: $('#createAPITokenForm').serialize(),
+			dataType: "json",
+			error: displayAjaxError,
+			success: function(reply) {
+				if (reply.result === "success" && reply.error === null) {
+					$('#apiTokenValue').val(reply.token.token);
+					$('#apiTokenCreated').collapse('show');
+					$('#apiTokenValue').select();
+				} else {
+					displayError(reply.error);
+				}
+			},
+			timeout: 10000,
+			type: "POST",
+			url: '/apitokens'
+		});
+	});
+
+	$('a.api-token-delete').click(function() {
+		$.ajax({
+			accepts: "application/json",
+			cache: false,
+			dataType: "json",
+			error: displayAjaxError,
+			success: function(reply) {
+				if (reply.result === "success" && reply.error === null) {
+					location.reload(true);
+				} else {
+					displayError(reply.error);
+				}
+			},
+			timeout: 10000,
+			type: "DELETE",
+			url: '/apitokens/'+$(this).attr('api-token')
+		});
+	});
+});
@@ -0,0 +1,64 @@
+$(document).ready(function(e) {
+	$('a.corporation-settings-submit').click(function() {
+		$.ajax({
+			accepts: "application/json",
+			cache: false,
+			data: "command=editSettings&"+$('#corporationSettingsForm').serialize(),
+			dataType: "json",
+			error: displayAjaxError,
+			success: function(reply) {
+				if (reply.result === "success" && reply.error === null) {
+					location.reload(true);
+				} else {
+					displayError(reply.error);
+				}
+			},
+			timeout: 10000,
+			type: "PUT",
+			url: '/corporation'
+		});
+	});
+
+	$('a.corporation-role-save').click(function() {
+		var role = $(this).attr('role-id');
+		var fields = $('[role-id="'+role+'"]').filter('input, select');
+
+		$.ajax({
+			accepts: "application/json",
+			cache: false,
+			data: "command=saveRole&corporationRole="+role+"&"+fields.serialize(),
+			dataType: "json",
+			error: displayAjaxError,
+			success: function(reply) {
+				if (reply.result === "success" && reply.error === null) {
+					location.reload(true);
+				} else {
+					displayError(reply.error);
+				}
+			},
+			timeout: 10000,
+			type: "PUT",
+			url: '/corporation'
+		});
+	});
+
+	$('a.corporation-role-delete').click(function() {
+		$.ajax({
+			accepts: "application/json",
+			cache: false,
+			data: "command=deleteRole&corporationRole="+$(this).attr('role-id'),
+			dataType: "json",
+			error: displayAjaxError,
+			success: function(reply) {
+				if (reply.result === "success" && reply.error === null) {
+					location.reload(true);
+				} else {
+					displayError(reply.error);
+				}
+			},
+			timeout: 10000,
+			type: "PUT",
+			url: '/corporation'
+		});
+	});
+});
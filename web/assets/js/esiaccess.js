@@ -0,0 +1,20 @@
+$(document).ready(function(e) {
+	$('a.esi-token-delete').click(function() {
+		$.ajax({
+			accepts: "application/json",
+			cache: false,
+			dataType: "json",
+			error: displayAjaxError,
+			success: function(reply) {
+				if (reply.result === "success" && reply.error === null) {
+					location.reload(true);
+				} else {
+					displayError(reply.error);
+				}
+			},
+			timeout: 10000,
+			type: "DELETE",
+			url: '/sso/token'
+		});
+	});
+});
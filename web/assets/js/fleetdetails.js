@@ -8,10 +8,13 @@ $(document).ready(function(e) {
 	});
 	
 	$('a.fleet-details-tick-sites').click(function() {
+		var formData = $('#tickSiteForm').serializeArray();
+		formData.push({ name: "command", value: "tickSitesFinished" });
+		
 		$.ajax({
 			accepts: "application/json",
 			cache: false,
-			data: "command=tickSitesFinished",
+			data: formData,
 			dataType: "json",
 			error: displayAjaxError,
 			success: function(reply) {
@@ -140,6 +143,172 @@ $(document).ready(function(e) {
 		});
 	});
 	
+	$('a.fleet-payout-strategy-save').click(function() {
+		var formData = $('#fleetPayoutStrategyForm').serializeArray();
+		formData.push({ name: "command", value: "setPayoutStrategy" });
+		
+		$.ajax({
+			accepts: "application/json",
+			cache: false,
+			data: formData,
+			dataType: "json",
+			error: displayAjaxError,
+			success: function(reply) {
+				if (reply.result === "success" && reply.error === null) {
+					location.reload(true);
+				} else {
+					displayError(reply.error);
+				}
+			},
+			timeout: 10000,
+			type: "PUT",
+			url: '/fleet/'+$(this).attr('fleet')
+		});
+	});
+	
+	$('a.fleet-payout-strategy-preview').click(function() {
+		var formData = $('#fleetPayoutStrategyForm').serializeArray();
+		formData.push({ name: "command", value: "previewPayouts" });
+		
+		$.ajax({
+			accepts: "application/json",
+			cache: false,
+			data: formData,
+			dataType: "json",
+			error: displayAjaxError,
+			success: function(reply) {
+				if (reply.result === "success" && reply.error === null) {
+					var rows = $('#fleetPayoutPreviewRows');
+					rows.empty();
+					rows.append($('<tr>').append($('<td>').text('Corporation')).append($('<td class="text-right">').text(reply.corporationPayout.toFixed(2) + ' ISK')));
+					$.each(reply.payouts, function(i, preview) {
+						rows.append($('<tr>').append($('<td>').text(preview.name)).append($('<td class="text-right">').text(preview.payout.toFixed(2) + ' ISK')));
+					});
+					$('#fleetPayoutPreviewStrategy').text(reply.strategy);
+					$('#fleetPayoutPreview').show();
+				} else {
+					displayError(reply.error);
+				}
+			},
+			timeout: 10000,
+			type: "PUT",
+			url: '/fleet/'+$(this).attr('fleet')
+		});
+	});
+	
+	$('a.loot-paste-command').click(function() {
+		var formData = [{ name: "command", value: $(this).attr('command') }];
+		
+		$.ajax({
+			accepts: "application/json",
+			cache: false,
+			data: formData,
+			dataType: "json",
+			error: displayAjaxError,
+			success: function(reply) {
+				if (reply.result === "success" && reply.error === null) {
+					location.reload(true);
+				} else {
+					displayError(reply.error);
+				}
+			},
+			timeout: 10000,
+			type: "PUT",
+			url: '/fleet/'+$(this).attr('fleet')+'/lootpastes/'+$(this).attr('paste')
+		});
+	});
+	
+	$('a.loot-paste-edit-submit').click(function() {
+		var formData = $('form.loot-paste-edit-form[paste='+$(this).attr('paste')+']').serializeArray();
+		formData.push({ name: "command", value: "editPaste" });
+		
+		$.ajax({
+			accepts: "application/json",
+			cache: false,
+			data: formData,
+			dataType: "json",
+			error: displayAjaxError,
+			success: function(reply) {
+				if (reply.result === "success" && reply.error === null) {
+					location.reload(true);
+				} else {
+					displayError(reply.error);
+				}
+			},
+			timeout: 10000,
+			type: "PUT",
+			url: '/fleet/'+$(this).attr('fleet')+'/lootpastes/'+$(this).attr('paste')
+		});
+	});
+	
+	$('a.loot-paste-site-submit').click(function() {
+		var formData = $('form.loot-paste-site-form[paste='+$(this).attr('paste')+']').serializeArray();
+		formData.push({ name: "command", value: "assignSite" });
+		
+		$.ajax({
+			accepts: "application/json",
+			cache: false,
+			data: formData,
+			dataType: "json",
+			error: displayAjaxError,
+			success: function(reply) {
+				if (reply.result === "success" && reply.error === null) {
+					location.reload(true);
+				} else {
+					displayError(reply.error);
+				}
+			},
+			timeout: 10000,
+			type: "PUT",
+			url: '/fleet/'+$(this).attr('fleet')+'/lootpastes/'+$(this).attr('paste')
+		});
+	});
+	
+	$('a.fleet-site-command').click(function() {
+		var formData = [{ name: "command", value: $(this).attr('command') }];
+		
+		$.ajax({
+			accepts: "application/json",
+			cache: false,
+			data: formData,
+			dataType: "json",
+			error: displayAjaxError,
+			success: function(reply) {
+				if (reply.result === "success" && reply.error === null) {
+					location.reload(true);
+				} else {
+					displayError(reply.error);
+				}
+			},
+			timeout: 10000,
+			type: "PUT",
+			url: '/fleet/'+$(this).attr('fleet')+'/sites/'+$(this).attr('site')
+		});
+	});
+	
+	$('a.fleet-site-edit-submit').click(function() {
+		var formData = $('form.fleet-site-edit-form[site='+$(this).attr('site')+']').serializeArray();
+		formData.push({ name: "command", value: "editSite" });
+		
+		$.ajax({
+			accepts: "application/json",
+			cache: false,
+			data: formData,
+			dataType: "json",
+			error: displayAjaxError,
+			success: function(reply) {
+				if (reply.result === "success" && reply.error === null) {
+					location.reload(true);
+				} else {
+					displayError(reply.error);
+				}
+			},
+			timeout: 10000,
+			type: "PUT",
+			url: '/fleet/'+$(this).attr('fleet')+'/sites/'+$(this).attr('site')
+		});
+	});
+	
 	$('a.fleet-member-list-toggle').click(function() {
 		$('div.fleet-member-list[member='+$(this).attr('member')+']').toggle();
 	});
@@ -173,6 +342,14 @@ $(document).ready(function(e) {
 			error: displayAjaxError,
 			success: function(reply) {
 				if (reply.result === "success" && reply.error === null) {
+					if (reply.warnings && reply.warnings.length > 0) {
+						displayWarning('Some rows of the fleet composition were skipped, close this message to reload the fleet:<ul><li>' + $.map(reply.warnings, function(warning) { return $('<div/>').text(warning).html(); }).join('</li><li>') + '</li></ul>');
+						$('div.alert-warning').first().on('closed.bs.alert', function() {
+							location.reload(true);
+						});
+						return;
+					}
+
 					location.reload(true);
 				} else {
 					displayError(reply.error);
@@ -184,6 +361,44 @@ $(document).ready(function(e) {
 		});
 	});
 	
+	$('a.fleet-link-submit').click(function() {
+		$.ajax({
+			accepts: "application/json",
+			cache: false,
+			dataType: "json",
+			error: displayAjaxError,
+			success: function(reply) {
+				if (reply.result === "success" && reply.error === null) {
+					location.reload(true);
+				} else {
+					displayError(reply.error);
+				}
+			},
+			timeout: 30000,
+			type: "POST",
+			url: '/fleet/'+$(this).attr('fleet') + '/link'
+		});
+	});
+
+	$('a.fleet-link-remove').click(function() {
+		$.ajax({
+			accepts: "application/json",
+			cache: false,
+			dataType: "json",
+			error: displayAjaxError,
+			success: function(reply) {
+				if (reply.result === "success" && reply.error === null) {
+					location.reload(true);
+				} else {
+					displayError(reply.error);
+				}
+			},
+			timeout: 10000,
+			type: "DELETE",
+			url: '/fleet/'+$(this).attr('fleet') + '/link'
+		});
+	});
+
 	$('a.fleet-member-list-remove').click(function() {		
 		$.ajax({
 			accepts: "application/json",
@@ -202,4 +417,27 @@ $(document).ready(function(e) {
 			url: '/fleet/'+$(this).attr('fleet')+'/members/'+$(this).attr('member')
 		});
 	});
+
+	$('a.fleet-unmapped-ship-assign').click(function() {
+		var ship = $(this).attr('ship');
+		var role = $('select[name="shipRoleRole"]').filter(function() { return $(this).attr('ship') === ship; }).val();
+
+		$.ajax({
+			accepts: "application/json",
+			cache: false,
+			data: {shipRoleShip: ship, shipRoleRole: role, shipRoleFleet: $(this).attr('fleet')},
+			dataType: "json",
+			error: displayAjaxError,
+			success: function(reply) {
+				if (reply.result === "success" && reply.error === null) {
+					location.reload(true);
+				} else {
+					displayError(reply.error);
+				}
+			},
+			timeout: 10000,
+			type: "POST",
+			url: '/shiproles'
+		});
+	});
 });
\ No newline at end of file
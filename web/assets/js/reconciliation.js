@@ -0,0 +1,40 @@
+$(document).ready(function(e) {
+	$('a.reconciliation-import').click(function() {
+		$.ajax({
+			accepts: "application/json",
+			cache: false,
+			dataType: "json",
+			error: displayAjaxError,
+			success: function(reply) {
+				if (reply.result === "success" && reply.error === null) {
+					location.reload(true);
+				} else {
+					displayError(reply.error);
+				}
+			},
+			timeout: 60000,
+			type: "POST",
+			url: '/reports/reconciliation'
+		});
+	});
+
+	$('a.reconciliation-transfer').click(function() {
+		$.ajax({
+			accepts: "application/json",
+			cache: false,
+			data: { command: $(this).attr('command') },
+			dataType: "json",
+			error: displayAjaxError,
+			success: function(reply) {
+				if (reply.result === "success" && reply.error === null) {
+					location.reload(true);
+				} else {
+					displayError(reply.error);
+				}
+			},
+			timeout: 10000,
+			type: "PUT",
+			url: '/reports/reconciliation/'+$(this).attr('transfer')
+		});
+	});
+});
@@ -0,0 +1,55 @@
+$(document).ready(function(e) {
+	$('input.report-payout-select-all').change(function() {
+		$(this).closest('table').find('input.report-payout-select').prop('checked', $(this).prop('checked'));
+	});
+
+	$('a.report-payout-copy').click(function() {
+		var amount = $(this).closest('.input-group').find('input.report-payout-amount');
+
+		amount.select();
+		document.execCommand('copy');
+	});
+
+	$('a.report-payouts-command').click(function() {
+		var data = { command: $(this).attr('command'), playerName: [] };
+
+		$('input.report-payout-select:checked').each(function() {
+			data.playerName.push($(this).attr('player'));
+		});
+
+		if (data.playerName.length === 0) {
+			displayError('No players selected');
+			return;
+		}
+
+		updateReportPayouts($(this).attr('report'), $.param(data, true));
+	});
+
+	$('a.report-payouts-all-paid').click(function() {
+		if (!confirm('Mark all outstanding payouts of this report as paid?')) {
+			return;
+		}
+
+		updateReportPayouts($(this).attr('report'), "command=playersPaid&all=true");
+	});
+});
+
+function updateReportPayouts(report, data) {
+	$.ajax({
+		accepts: "application/json",
+		cache: false,
+		data: data,
+		dataType: "json",
+		error: displayAjaxError,
+		success: function(reply) {
+			if (reply.result === "success" && reply.error === null) {
+				location.reload(true);
+			} else {
+				displayError(reply.error);
+			}
+		},
+		timeout: 10000,
+		type: "PUT",
+		url: '/report/'+report+'/players'
+	});
+}
@@ -0,0 +1,20 @@
+$(document).ready(function(e) {
+	$('a.scheduler-run').click(function() {
+		$.ajax({
+			accepts: "application/json",
+			cache: false,
+			dataType: "json",
+			error: displayAjaxError,
+			success: function(reply) {
+				if (reply.result === "success" && reply.error === null) {
+					location.reload(true);
+				} else {
+					displayError(reply.error);
+				}
+			},
+			timeout: 10000,
+			type: "POST",
+			url: '/scheduler/jobs/'+$(this).attr('job')+'/run'
+		});
+	});
+});
@@ -3,7 +3,17 @@ function displayError(error) {
 	$('html, body').animate({ scrollTop: '0px' });
 }
 
+function displayWarning(warning) {
+	$('div.col-md').prepend('<div class="alert alert-warning alert-dismissible fade in" role="alert"><button type="button" class="close" data-dismiss="alert"><span aria-hidden="true">&times;</span><span class="sr-only">Close</span></button><strong>Heads up!</strong> '+warning+'</div>');
+	$('html, body').animate({ scrollTop: '0px' });
+}
+
 function displayAjaxError(jqXHR, textStatus, errorThrown) {
+	if (jqXHR.responseJSON && jqXHR.responseJSON.error && jqXHR.responseJSON.error.message) {
+		displayError(jqXHR.responseJSON.error.message);
+		return;
+	}
+
 	switch (textStatus) {
 		case null:
 			displayError('Received unknown error while performing AJAX request');
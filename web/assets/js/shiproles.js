@@ -0,0 +1,82 @@
+$(document).ready(function(e) {
+	$('a.ship-role-save').click(function() {
+		var shipRole = $(this).attr('ship-role');
+
+		$.ajax({
+			accepts: "application/json",
+			cache: false,
+			data: $('[ship-role="'+shipRole+'"]').filter('input, select').serialize(),
+			dataType: "json",
+			error: displayAjaxError,
+			success: function(reply) {
+				if (reply.result === "success" && reply.error === null) {
+					location.reload(true);
+				} else {
+					displayError(reply.error);
+				}
+			},
+			timeout: 10000,
+			type: "PUT",
+			url: '/shiproles/'+shipRole
+		});
+	});
+
+	$('a.ship-role-delete').click(function() {
+		$.ajax({
+			accepts: "application/json",
+			cache: false,
+			dataType: "json",
+			error: displayAjaxError,
+			success: function(reply) {
+				if (reply.result === "success" && reply.error === null) {
+					location.reload(true);
+				} else {
+					displayError(reply.error);
+				}
+			},
+			timeout: 10000,
+			type: "DELETE",
+			url: '/shiproles/'+$(this).attr('ship-role')
+		});
+	});
+
+	$('a.add-ship-role-submit').click(function() {
+		$.ajax({
+			accepts: "application/json",
+			cache: false,
+			data: $('#addShipRoleForm').serialize(),
+			dataType: "json",
+			error: displayAjaxError,
+			success: function(reply) {
+				if (reply.result === "success" && reply.error === null) {
+					location.reload(true);
+				} else {
+					displayError(reply.error);
+				}
+			},
+			timeout: 10000,
+			type: "POST",
+			url: '/shiproles'
+		});
+	});
+
+	$('a.import-ship-roles-submit').click(function() {
+		$.ajax({
+			accepts: "application/json",
+			cache: false,
+			data: $('#importShipRolesForm').serialize(),
+			dataType: "json",
+			error: displayAjaxError,
+			success: function(reply) {
+				if (reply.result === "success" && reply.error === null) {
+					location.reload(true);
+				} else {
+					displayError(reply.error);
+				}
+			},
+			timeout: 10000,
+			type: "POST",
+			url: '/shiproles'
+		});
+	});
+});